@@ -3,21 +3,31 @@
 package main
 
 import (
+	"crypto/rand"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/pozitronik/tucha/internal/application/port"
 	"github.com/pozitronik/tucha/internal/application/service"
 	"github.com/pozitronik/tucha/internal/cli"
 	"github.com/pozitronik/tucha/internal/config"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 	"github.com/pozitronik/tucha/internal/infrastructure/contentstore"
+	"github.com/pozitronik/tucha/internal/infrastructure/exif"
 	"github.com/pozitronik/tucha/internal/infrastructure/hasher"
 	"github.com/pozitronik/tucha/internal/infrastructure/logger"
 	"github.com/pozitronik/tucha/internal/infrastructure/sqlite"
+	"github.com/pozitronik/tucha/internal/infrastructure/sshkey"
+	"github.com/pozitronik/tucha/internal/infrastructure/textextract"
 	"github.com/pozitronik/tucha/internal/infrastructure/thumbnail"
+	"github.com/pozitronik/tucha/internal/infrastructure/totp"
 	"github.com/pozitronik/tucha/internal/transport/httpapi"
+	"github.com/pozitronik/tucha/internal/transport/sftpd"
 )
 
 func main() {
@@ -45,7 +55,10 @@ func main() {
 	case cli.CmdConfigCheck:
 		runConfigCheck(parsed.ConfigPath)
 
-	case cli.CmdUserList, cli.CmdUserAdd, cli.CmdUserRemove, cli.CmdUserPwd, cli.CmdUserQuota, cli.CmdUserSizeLimit, cli.CmdUserHistory, cli.CmdUserInfo:
+	case cli.CmdTOTPSecret:
+		runTOTPSecret()
+
+	case cli.CmdUserList, cli.CmdUserAdd, cli.CmdUserRemove, cli.CmdUserPwd, cli.CmdUserQuota, cli.CmdUserSizeLimit, cli.CmdUserHistory, cli.CmdUserInfo, cli.CmdUserTwoFactorReset, cli.CmdUserImpersonate, cli.CmdUserS3Key:
 		runUserCommand(parsed)
 
 	case cli.CmdRun, cli.CmdBackground:
@@ -87,6 +100,18 @@ func runConfigCheck(configPath string) {
 	fmt.Println("Configuration is valid")
 }
 
+func runTOTPSecret() {
+	gen := totp.NewGenerator()
+	secret, err := gen.GenerateSecret()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitError)
+	}
+	fmt.Printf("Secret: %s\n", secret)
+	fmt.Printf("URI:    %s\n", gen.ProvisioningURI("Tucha", "admin", secret))
+	fmt.Println("Add the secret to an authenticator app and set admin.totp_secret in the config.")
+}
+
 func runUserCommand(parsed *cli.CLI) {
 	cfg, err := config.Load(parsed.ConfigPath)
 	if err != nil {
@@ -101,10 +126,21 @@ func runUserCommand(parsed *cli.CLI) {
 	}
 	defer db.Close()
 
+	// Support tokens issued from the CLI are recorded in the server log.
+	auditLogger, err := logger.New(cfg.Logging.Level, cfg.Logging.Output, cfg.Logging.File)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating logger: %v\n", err)
+		os.Exit(cli.ExitError)
+	}
+	defer auditLogger.Close()
+
 	userRepo := sqlite.NewUserRepository(db)
 	nodeRepo := sqlite.NewNodeRepository(db)
+	tokenRepo := sqlite.NewTokenRepository(db)
 	userSvc := service.NewUserService(userRepo, nodeRepo, cfg.Storage.QuotaBytes)
-	cmds := cli.NewUserCommands(userSvc, userRepo)
+	impersonationSvc := service.NewImpersonationService(tokenRepo, userRepo, auditLogger)
+	accessKeySvc := service.NewAccessKeyService(sqlite.NewAccessKeyRepository(db), userRepo)
+	cmds := cli.NewUserCommands(userSvc, userRepo, impersonationSvc, accessKeySvc)
 
 	var cmdErr error
 	switch parsed.Command {
@@ -139,6 +175,23 @@ func runUserCommand(parsed *cli.CLI) {
 
 	case cli.CmdUserInfo:
 		cmdErr = cmds.Info(os.Stdout, parsed.Args[0])
+
+	case cli.CmdUserTwoFactorReset:
+		cmdErr = cmds.ResetTwoFactor(os.Stdout, parsed.Args[0])
+
+	case cli.CmdUserImpersonate:
+		mode := ""
+		if len(parsed.Args) > 1 {
+			mode = parsed.Args[1]
+		}
+		cmdErr = cmds.Impersonate(os.Stdout, parsed.Args[0], mode)
+
+	case cli.CmdUserS3Key:
+		name := ""
+		if len(parsed.Args) > 1 {
+			name = parsed.Args[1]
+		}
+		cmdErr = cmds.S3Key(os.Stdout, parsed.Args[0], name)
 	}
 
 	if cmdErr != nil {
@@ -192,6 +245,7 @@ func runServer(parsed *cli.CLI) {
 	appLogger.Info("  Thumbnail dir: %s", cfg.Storage.ThumbnailDir)
 	appLogger.Info("  Quota: %d bytes", cfg.Storage.QuotaBytes)
 	appLogger.Info("  Token TTL: %d seconds", cfg.Auth.TokenTTLSeconds)
+	appLogger.Info("  OAuth clients: %d", len(cfg.Auth.Clients))
 	appLogger.Debug("  Log level: %s", cfg.Logging.Level)
 	appLogger.Debug("  Log output: %s", cfg.Logging.Output)
 
@@ -227,48 +281,136 @@ func runServer(parsed *cli.CLI) {
 	trashRepo := sqlite.NewTrashRepository(db)
 	shareRepo := sqlite.NewShareRepository(db)
 	fileVersionRepo := sqlite.NewFileVersionRepository(db)
+	appPasswordRepo := sqlite.NewAppPasswordRepository(db)
+	accessKeyRepo := sqlite.NewAccessKeyRepository(db)
+	multipartRepo := sqlite.NewMultipartRepository(db)
+	sshKeyRepo := sqlite.NewSSHKeyRepository(db)
+	changeRepo := sqlite.NewChangeRepository(db)
+	nodeSearchRepo := sqlite.NewNodeSearchRepository(db)
+	xattrRepo := sqlite.NewXAttrRepository(db)
+	mediaRepo := sqlite.NewMediaRepository(db)
 
 	// --- Application services ---
 
+	totpGen := totp.NewGenerator()
 	adminAuthSvc := service.NewAdminAuthService(cfg.Admin.Login, cfg.Admin.Password)
-	authSvc := service.NewAuthService(tokenRepo, userRepo)
-	tokenSvc := service.NewTokenService(tokenRepo, userRepo)
-	quotaSvc := service.NewQuotaService(nodeRepo, userRepo)
+	if cfg.Admin.TOTPSecret != "" {
+		adminAuthSvc.WithTOTP(cfg.Admin.TOTPSecret, totpGen)
+	}
+	authSvc := service.NewAuthService(tokenRepo, userRepo).WithAuditLog(appLogger)
+	tokenSvc := service.NewTokenService(tokenRepo, userRepo, appPasswordRepo)
+	clientRegistry := service.NewClientRegistry(oauthClients(cfg.Auth.Clients))
+	urlSigner := service.NewURLSigner(urlSigningKey(cfg.Auth.URLSigningKey), time.Duration(cfg.Auth.SignedURLTTLSeconds)*time.Second)
+	changeSvc := service.NewChangeService(changeRepo)
+	quotaSvc := service.NewQuotaService(nodeRepo, userRepo).WithVersions(fileVersionRepo).WithTrash(trashRepo, cfg.Trash.CountInQuota)
 	userSvc := service.NewUserService(userRepo, nodeRepo, cfg.Storage.QuotaBytes)
-	folderSvc := service.NewFolderService(nodeRepo)
-	fileSvc := service.NewFileService(nodeRepo, contentRepo, diskStore, quotaSvc, fileVersionRepo)
+	folderSvc := service.NewFolderService(nodeRepo).WithChanges(changeSvc)
+	fileSvc := service.NewFileService(nodeRepo, contentRepo, diskStore, quotaSvc, fileVersionRepo).WithChanges(changeSvc)
 	uploadSvc := service.NewUploadService(mrCloudHasher, diskStore, contentRepo)
 	downloadSvc := service.NewDownloadService(nodeRepo, diskStore)
+	archiveSvc := service.NewArchiveService(nodeRepo, diskStore).WithXAttrs(xattrRepo)
 	thumbnailSvc := service.NewThumbnailService(nodeRepo, diskStore, thumbGen)
-	trashSvc := service.NewTrashService(nodeRepo, trashRepo, contentRepo, diskStore, shareRepo)
-	publishSvc := service.NewPublishService(nodeRepo, contentRepo)
-	shareSvc := service.NewShareService(shareRepo, nodeRepo, contentRepo, userRepo)
+	trashSvc := service.NewTrashService(nodeRepo, trashRepo, contentRepo, diskStore, shareRepo).WithChanges(changeSvc).WithXAttrs(xattrRepo)
+	publishSvc := service.NewPublishService(nodeRepo, contentRepo).WithChanges(changeSvc)
+	shareSvc := service.NewShareService(shareRepo, nodeRepo, contentRepo, userRepo).WithChanges(changeSvc).WithXAttrs(xattrRepo)
+	changeSvc.WithShares(shareSvc)
+	twoFactorSvc := service.NewTwoFactorService(userRepo, appPasswordRepo, totpGen, "Tucha")
+	impersonationSvc := service.NewImpersonationService(tokenRepo, userRepo, appLogger)
+	accessKeySvc := service.NewAccessKeyService(accessKeyRepo, userRepo)
+	multipartSvc := service.NewMultipartService(multipartRepo, contentRepo, diskStore, uploadSvc, fileSvc)
+	sshKeySvc := service.NewSSHKeyService(sshKeyRepo, userRepo, sshkey.NewParser())
+	jobRegistry := service.NewJobRegistry()
+	searchSvc := service.NewSearchService(nodeSearchRepo, shareSvc)
+	tagSvc := service.NewTagService(nodeRepo)
+	xattrSvc := service.NewXAttrService(nodeRepo, xattrRepo)
+	mediaSvc := service.NewMediaService(nodeRepo, mediaRepo)
+	extractSvc := service.NewExtractService(nodeRepo, contentRepo, diskStore, mrCloudHasher, fileSvc, quotaSvc, jobRegistry, appLogger).WithChanges(changeSvc)
 
 	// --- Transport (HTTP handlers) ---
 
 	presenter := httpapi.NewPresenter()
 
-	tokenH := httpapi.NewTokenHandler(tokenSvc, cfg.Auth.TokenTTLSeconds, appLogger)
+	tokenH := httpapi.NewTokenHandler(tokenSvc, clientRegistry, appLogger)
 	csrfH := httpapi.NewCSRFHandler(authSvc)
-	dispatchH := httpapi.NewDispatchHandler(authSvc, cfg.Server.ExternalURL)
+	dispatchH := httpapi.NewDispatchHandler(authSvc, urlSigner, cfg.Server.ExternalURL)
 	folderH := httpapi.NewFolderHandler(authSvc, folderSvc, shareSvc, publishSvc, presenter)
 	fileH := httpapi.NewFileHandler(authSvc, fileSvc, trashSvc, shareSvc, presenter)
 	uploadH := httpapi.NewUploadHandler(authSvc, uploadSvc, fileSvc, shareSvc)
-	downloadH := httpapi.NewDownloadHandler(authSvc, downloadSvc, shareSvc)
+	downloadH := httpapi.NewDownloadHandler(authSvc, downloadSvc, archiveSvc, shareSvc, urlSigner)
 	spaceH := httpapi.NewSpaceHandler(authSvc, quotaSvc)
 	selfConfigH := httpapi.NewSelfConfigureHandler(cfg.Endpoints)
 	userH := httpapi.NewUserHandler(adminAuthSvc, userSvc)
 	adminH := httpapi.NewAdminHandler(adminAuthSvc)
 	trashH := httpapi.NewTrashHandler(authSvc, trashSvc, presenter)
 	publishH := httpapi.NewPublishHandler(authSvc, publishSvc, presenter)
-	weblinkH := httpapi.NewWeblinkDownloadHandler(publishSvc, downloadSvc, folderSvc, presenter, cfg.Server.ExternalURL)
+	weblinkH := httpapi.NewWeblinkDownloadHandler(publishSvc, downloadSvc, archiveSvc, folderSvc, presenter, cfg.Server.ExternalURL)
 	shareH := httpapi.NewShareHandler(authSvc, shareSvc, presenter)
-	thumbnailH := httpapi.NewThumbnailHandler(authSvc, thumbnailSvc)
+	thumbnailH := httpapi.NewThumbnailHandler(authSvc, thumbnailSvc, urlSigner)
 	publicThumbH := httpapi.NewPublicThumbnailHandler(publishSvc, thumbnailSvc)
 	videoH := httpapi.NewVideoHandler(publishSvc, downloadSvc, cfg.Server.ExternalURL)
+	personalTokenH := httpapi.NewPersonalTokenHandler(authSvc, adminAuthSvc, tokenSvc)
+	sessionH := httpapi.NewSessionHandler(adminAuthSvc, tokenSvc, clientRegistry)
+	twoFactorH := httpapi.NewTwoFactorHandler(authSvc, twoFactorSvc)
+	impersonationH := httpapi.NewImpersonationHandler(adminAuthSvc, impersonationSvc)
+	webdavH := httpapi.NewWebDAVHandler(authSvc, tokenSvc, folderSvc, fileSvc, uploadSvc, downloadSvc, trashSvc, shareSvc, appLogger)
+	s3H := httpapi.NewS3Handler(authSvc, accessKeySvc, folderSvc, fileSvc, uploadSvc, downloadSvc, trashSvc, multipartSvc, appLogger)
+	accessKeyH := httpapi.NewAccessKeyHandler(authSvc, accessKeySvc)
+	sshKeyH := httpapi.NewSSHKeyHandler(authSvc, sshKeySvc)
+	webH := httpapi.NewWebHandler(authSvc, tokenSvc, twoFactorSvc, cfg.Auth.TokenTTLSeconds, !cfg.Auth.InsecureCookies)
+	extractH := httpapi.NewExtractHandler(authSvc, extractSvc, jobRegistry, shareSvc)
+	changeH := httpapi.NewChangeHandler(authSvc, changeSvc)
+	searchH := httpapi.NewSearchHandler(authSvc, searchSvc, presenter)
+	tagH := httpapi.NewTagHandler(authSvc, tagSvc, presenter)
+	xattrH := httpapi.NewXAttrHandler(authSvc, xattrSvc)
+	mediaH := httpapi.NewMediaHandler(authSvc, mediaSvc, presenter, urlSigner, cfg.Server.ExternalURL)
+	v3H := httpapi.NewV3Handler(authSvc, adminAuthSvc, folderSvc, fileSvc, trashSvc, shareSvc, publishSvc, userSvc, quotaSvc, cfg.Server.ExternalURL)
 
 	mux := http.NewServeMux()
-	httpapi.RegisterRoutes(mux, tokenH, csrfH, dispatchH, folderH, fileH, uploadH, downloadH, spaceH, selfConfigH, userH, adminH, trashH, publishH, weblinkH, shareH, thumbnailH, publicThumbH, videoH)
+	httpapi.RegisterRoutes(mux, tokenH, csrfH, dispatchH, folderH, fileH, uploadH, downloadH, spaceH, selfConfigH, userH, adminH, trashH, publishH, weblinkH, shareH, thumbnailH, publicThumbH, videoH, personalTokenH, sessionH, twoFactorH, impersonationH, webdavH, s3H, accessKeyH, sshKeyH, webH, extractH, changeH, searchH, tagH, xattrH, mediaH, v3H)
+
+	// --- Optional SFTP server ---
+
+	var sftpSrv *sftpd.Server
+	if cfg.SFTP.Port > 0 {
+		hostKey, err := sftpd.LoadOrCreateHostKey(cfg.SFTP.HostKeyFile)
+		if err != nil {
+			appLogger.Error("Failed to load SFTP host key: %v", err)
+			os.Exit(1)
+		}
+		ln, err := net.Listen("tcp", cfg.SFTPAddr())
+		if err != nil {
+			appLogger.Error("Failed to start SFTP listener: %v", err)
+			os.Exit(1)
+		}
+		sftpSrv = sftpd.NewServer(hostKey, tokenSvc, sshKeySvc, authSvc, folderSvc, fileSvc, uploadSvc, downloadSvc, trashSvc, appLogger)
+		appLogger.Info("SFTP server listening on %s", cfg.SFTPAddr())
+		go func() {
+			if err := sftpSrv.Serve(ln); err != nil {
+				appLogger.Error("SFTP server failed: %v", err)
+			}
+		}()
+	}
+
+	// Validated by config.Load.
+	retention, _ := vo.ParseRetentionPolicy(cfg.Versions.Retention)
+	pruner := service.NewVersionPruner(fileVersionRepo, contentRepo, diskStore, userRepo, retention, appLogger)
+	go pruner.Run(time.Duration(cfg.Versions.PruneIntervalMinutes) * time.Minute)
+	purger := service.NewTrashPurger(trashSvc, userRepo, cfg.Trash.RetentionDays, appLogger)
+	go purger.Run(time.Duration(cfg.Trash.PurgeIntervalMinutes) * time.Minute)
+	if cfg.Search.IndexContent {
+		indexer := service.NewContentIndexer(
+			sqlite.NewContentIndexRepository(db),
+			diskStore,
+			[]port.TextExtractor{textextract.Plain{}, textextract.PDF{}, textextract.OOXML{}},
+			cfg.Search.MaxContentSize,
+			appLogger,
+		)
+		go indexer.Run(time.Duration(cfg.Search.IndexIntervalMinutes) * time.Minute)
+	}
+	if cfg.Media.ExtractMetadata {
+		mediaIndexer := service.NewMediaIndexer(mediaRepo, diskStore, exif.Extractor{}, appLogger)
+		go mediaIndexer.Run(time.Duration(cfg.Media.IntervalMinutes) * time.Minute)
+	}
 
 	// --- Start server with graceful shutdown ---
 
@@ -277,5 +419,42 @@ func runServer(parsed *cli.CLI) {
 		appLogger.Error("Server failed: %v", err)
 		os.Exit(1)
 	}
+	if sftpSrv != nil {
+		sftpSrv.Close()
+	}
 	appLogger.Info("Tucha server stopped")
 }
+
+// urlSigningKey returns the configured signing key, or a random one when unset,
+// in which case signed URLs stop working after a restart.
+func urlSigningKey(configured string) []byte {
+	if configured != "" {
+		return []byte(configured)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Failed to generate URL signing key: %v", err)
+	}
+	return key
+}
+
+// oauthClients converts the configured OAuth clients to domain entities.
+// Grant types were validated when the configuration was loaded.
+func oauthClients(cfgClients []config.OAuthClientConfig) []entity.OAuthClient {
+	clients := make([]entity.OAuthClient, 0, len(cfgClients))
+	for _, c := range cfgClients {
+		grants := make([]vo.GrantType, 0, len(c.GrantTypes))
+		for _, g := range c.GrantTypes {
+			if grant, err := vo.ParseGrantType(g); err == nil {
+				grants = append(grants, grant)
+			}
+		}
+		clients = append(clients, entity.OAuthClient{
+			ID:              c.ID,
+			Secret:          c.Secret,
+			GrantTypes:      grants,
+			TokenTTLSeconds: c.TokenTTLSeconds,
+		})
+	}
+	return clients
+}
@@ -0,0 +1,18 @@
+package port
+
+import (
+	"io"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// MediaExtractor reads the metadata of photos, such as their EXIF capture
+// time, camera and location.
+type MediaExtractor interface {
+	// Supports reports whether the extractor handles files with the given name.
+	Supports(name string) bool
+
+	// Extract returns the metadata of the content, which is size bytes long.
+	// The Hash of the result is left to the caller.
+	Extract(r io.ReaderAt, size int64) (*entity.MediaInfo, error)
+}
@@ -0,0 +1,8 @@
+package port
+
+// PublicKeyParser validates SSH public keys supplied by users.
+type PublicKeyParser interface {
+	// Parse reads one key in authorized_keys format ("<type> <base64> [comment]").
+	// It returns the key without its comment, its SHA256 fingerprint and the comment.
+	Parse(authorizedKey string) (key, fingerprint, comment string, err error)
+}
@@ -0,0 +1,13 @@
+package port
+
+import "io"
+
+// TextExtractor extracts the searchable text of file content.
+type TextExtractor interface {
+	// Supports reports whether the extractor handles files with the given name.
+	Supports(name string) bool
+
+	// Extract returns the text of the content, which is size bytes long.
+	// It stops once limit bytes of text are extracted and returns at most limit bytes.
+	Extract(r io.ReaderAt, size int64, limit int) (string, error)
+}
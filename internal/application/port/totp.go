@@ -0,0 +1,18 @@
+// Package port defines application-layer interfaces for infrastructure concerns.
+// Implementations live in the infrastructure layer.
+package port
+
+// TOTP generates and verifies time-based one-time passwords for two-factor authentication.
+type TOTP interface {
+	// GenerateSecret returns a new random shared secret, base32-encoded.
+	GenerateSecret() (string, error)
+
+	// Verify reports whether code is valid for the secret at the current time,
+	// and returns the time step it belongs to. Implementations allow a small
+	// clock drift window.
+	Verify(secret, code string) (step int64, ok bool)
+
+	// ProvisioningURI returns the otpauth:// URI that authenticator apps
+	// import, usually rendered as a QR code.
+	ProvisioningURI(issuer, account, secret string) string
+}
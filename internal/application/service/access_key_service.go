@@ -0,0 +1,100 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+// accessKeyIDPrefix marks key IDs issued by this server, in the style of AWS "AKIA" keys.
+const accessKeyIDPrefix = "TK"
+
+// AccessKeyService issues and resolves the access keys used by the S3 gateway.
+type AccessKeyService struct {
+	keys  repository.AccessKeyRepository
+	users repository.UserRepository
+}
+
+// NewAccessKeyService creates a new AccessKeyService.
+func NewAccessKeyService(keys repository.AccessKeyRepository, users repository.UserRepository) *AccessKeyService {
+	return &AccessKeyService{keys: keys, users: users}
+}
+
+// Create issues a new access key for the user.
+// Returns ErrNotFound if the user does not exist.
+func (s *AccessKeyService) Create(userID int64, name string) (*entity.AccessKey, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	keyID, err := randomHex(9)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 30)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	k := &entity.AccessKey{
+		UserID: userID,
+		Name:   name,
+		KeyID:  accessKeyIDPrefix + strings.ToUpper(keyID),
+		Secret: base64.RawURLEncoding.EncodeToString(secret),
+	}
+	if err := s.keys.Create(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// List returns the user's access keys.
+func (s *AccessKeyService) List(userID int64) ([]entity.AccessKey, error) {
+	keys, err := s.keys.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []entity.AccessKey{}
+	}
+	return keys, nil
+}
+
+// Revoke deletes an access key owned by the user.
+// Returns ErrNotFound if it does not exist or belongs to another user.
+func (s *AccessKeyService) Revoke(userID, id int64) error {
+	k, err := s.keys.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if k == nil || k.UserID != userID {
+		return ErrNotFound
+	}
+	return s.keys.Delete(id)
+}
+
+// Lookup resolves a public key ID to its access key.
+// Returns ErrNotFound if the key ID is unknown.
+func (s *AccessKeyService) Lookup(keyID string) (*entity.AccessKey, error) {
+	k, err := s.keys.GetByKeyID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrNotFound
+	}
+	return k, nil
+}
+
+// RecordUse stores the time an access key last signed a valid request.
+func (s *AccessKeyService) RecordUse(k *entity.AccessKey) error {
+	return s.keys.MarkUsed(k.ID, time.Now().Unix())
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestAccessKeyService_Create(t *testing.T) {
+	var stored *entity.AccessKey
+	svc := NewAccessKeyService(
+		&mock.AccessKeyRepositoryMock{
+			CreateFunc: func(k *entity.AccessKey) error {
+				k.ID = 7
+				stored = k
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) { return mock.NewTestUser(id, "u@x.com"), nil },
+		},
+	)
+
+	k, err := svc.Create(1, "backup")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if stored != k || k.UserID != 1 || k.Name != "backup" {
+		t.Errorf("stored key = %+v", stored)
+	}
+	if !strings.HasPrefix(k.KeyID, accessKeyIDPrefix) || len(k.KeyID) != len(accessKeyIDPrefix)+18 || strings.ToUpper(k.KeyID) != k.KeyID {
+		t.Errorf("KeyID = %q, want %s followed by 18 upper-case hex digits", k.KeyID, accessKeyIDPrefix)
+	}
+	if len(k.Secret) != 40 {
+		t.Errorf("Secret length = %d, want 40", len(k.Secret))
+	}
+
+	other, err := svc.Create(1, "second")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if other.KeyID == k.KeyID || other.Secret == k.Secret {
+		t.Error("two keys share the same ID or secret")
+	}
+}
+
+func TestAccessKeyService_Create_unknownUser(t *testing.T) {
+	svc := NewAccessKeyService(&mock.AccessKeyRepositoryMock{}, &mock.UserRepositoryMock{})
+
+	if _, err := svc.Create(99, "backup"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAccessKeyService_Revoke(t *testing.T) {
+	var deleted int64
+	svc := NewAccessKeyService(
+		&mock.AccessKeyRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.AccessKey, error) {
+				return &entity.AccessKey{ID: id, UserID: 1}, nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = id
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{},
+	)
+
+	if err := svc.Revoke(2, 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke by another user: error = %v, want ErrNotFound", err)
+	}
+	if deleted != 0 {
+		t.Fatal("key of another user was deleted")
+	}
+	if err := svc.Revoke(1, 5); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if deleted != 5 {
+		t.Errorf("deleted = %d, want 5", deleted)
+	}
+}
+
+func TestAccessKeyService_Lookup(t *testing.T) {
+	svc := NewAccessKeyService(
+		&mock.AccessKeyRepositoryMock{
+			GetByKeyIDFunc: func(keyID string) (*entity.AccessKey, error) {
+				if keyID == "TKKNOWN" {
+					return &entity.AccessKey{ID: 1, KeyID: keyID, Secret: "s"}, nil
+				}
+				return nil, nil
+			},
+		},
+		&mock.UserRepositoryMock{},
+	)
+
+	k, err := svc.Lookup("TKKNOWN")
+	if err != nil || k.Secret != "s" {
+		t.Errorf("Lookup known key = %+v, %v", k, err)
+	}
+	if _, err := svc.Lookup("TKOTHER"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup unknown key: error = %v, want ErrNotFound", err)
+	}
+}
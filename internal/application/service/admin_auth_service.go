@@ -4,15 +4,20 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"sync"
+
+	"github.com/pozitronik/tucha/internal/application/port"
 )
 
 // AdminAuthService handles admin panel authentication using config-based credentials.
 // Tokens are stored in memory; a server restart invalidates all admin sessions.
 type AdminAuthService struct {
-	login    string
-	password string
-	mu       sync.RWMutex
-	tokens   map[string]bool
+	login      string
+	password   string
+	totpSecret string
+	totp       port.TOTP
+	guard      *codeGuard
+	mu         sync.RWMutex
+	tokens     map[string]bool
 }
 
 // NewAdminAuthService creates a new AdminAuthService with the given config credentials.
@@ -24,11 +29,33 @@ func NewAdminAuthService(login, password string) *AdminAuthService {
 	}
 }
 
+// WithTOTP requires a TOTP code from the given base32 secret on every login.
+func (s *AdminAuthService) WithTOTP(secret string, totp port.TOTP) *AdminAuthService {
+	s.totpSecret = secret
+	s.totp = totp
+	s.guard = newCodeGuard()
+	return s
+}
+
 // Login validates credentials against the config and returns a bearer token.
-func (s *AdminAuthService) Login(login, password string) (string, error) {
+// When TOTP is configured, code must be a valid one-time code that was not
+// used before; an empty code with correct credentials returns
+// ErrSecondFactorRequired, and too many wrong codes ErrTooManyAttempts.
+func (s *AdminAuthService) Login(login, password, code string) (string, error) {
 	if login != s.login || password != s.password {
 		return "", ErrForbidden
 	}
+	if s.totpSecret != "" {
+		if code == "" {
+			return "", ErrSecondFactorRequired
+		}
+		if err := s.guard.begin(0); err != nil {
+			return "", err
+		}
+		if step, ok := s.totp.Verify(s.totpSecret, code); !ok || !s.guard.accept(0, step) {
+			return "", ErrForbidden
+		}
+	}
 
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
@@ -43,6 +70,11 @@ func (s *AdminAuthService) Login(login, password string) (string, error) {
 	return token, nil
 }
 
+// Identity returns the admin login, recorded as the impersonator of support tokens.
+func (s *AdminAuthService) Identity() string {
+	return s.login
+}
+
 // Validate checks whether the given bearer token is active.
 func (s *AdminAuthService) Validate(token string) bool {
 	if token == "" {
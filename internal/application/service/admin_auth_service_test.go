@@ -3,11 +3,13 @@ package service
 import (
 	"sync"
 	"testing"
+
+	"github.com/pozitronik/tucha/internal/testutil/mock"
 )
 
 func TestAdminAuth_LoginSuccess(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	token, err := svc.Login("admin", "secret")
+	token, err := svc.Login("admin", "secret", "")
 	if err != nil {
 		t.Fatalf("Login: %v", err)
 	}
@@ -18,7 +20,7 @@ func TestAdminAuth_LoginSuccess(t *testing.T) {
 
 func TestAdminAuth_LoginWrongLogin(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	_, err := svc.Login("wrong", "secret")
+	_, err := svc.Login("wrong", "secret", "")
 	if err != ErrForbidden {
 		t.Errorf("Login(wrong login) error = %v, want ErrForbidden", err)
 	}
@@ -26,7 +28,7 @@ func TestAdminAuth_LoginWrongLogin(t *testing.T) {
 
 func TestAdminAuth_LoginWrongPassword(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	_, err := svc.Login("admin", "wrong")
+	_, err := svc.Login("admin", "wrong", "")
 	if err != ErrForbidden {
 		t.Errorf("Login(wrong password) error = %v, want ErrForbidden", err)
 	}
@@ -34,7 +36,7 @@ func TestAdminAuth_LoginWrongPassword(t *testing.T) {
 
 func TestAdminAuth_LoginEmpty(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	_, err := svc.Login("", "")
+	_, err := svc.Login("", "", "")
 	if err != ErrForbidden {
 		t.Errorf("Login(empty) error = %v, want ErrForbidden", err)
 	}
@@ -42,7 +44,7 @@ func TestAdminAuth_LoginEmpty(t *testing.T) {
 
 func TestAdminAuth_ValidateValid(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	token, _ := svc.Login("admin", "secret")
+	token, _ := svc.Login("admin", "secret", "")
 	if !svc.Validate(token) {
 		t.Error("Validate(valid token) = false, want true")
 	}
@@ -64,7 +66,7 @@ func TestAdminAuth_ValidateEmpty(t *testing.T) {
 
 func TestAdminAuth_Logout(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	token, _ := svc.Login("admin", "secret")
+	token, _ := svc.Login("admin", "secret", "")
 	svc.Logout(token)
 	if svc.Validate(token) {
 		t.Error("Validate after Logout = true, want false")
@@ -73,8 +75,8 @@ func TestAdminAuth_Logout(t *testing.T) {
 
 func TestAdminAuth_UniqueTokens(t *testing.T) {
 	svc := NewAdminAuthService("admin", "secret")
-	t1, _ := svc.Login("admin", "secret")
-	t2, _ := svc.Login("admin", "secret")
+	t1, _ := svc.Login("admin", "secret", "")
+	t2, _ := svc.Login("admin", "secret", "")
 	if t1 == t2 {
 		t.Error("two Login calls returned the same token")
 	}
@@ -90,7 +92,7 @@ func TestAdminAuth_concurrent(t *testing.T) {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			tok, err := svc.Login("admin", "secret")
+			tok, err := svc.Login("admin", "secret", "")
 			if err != nil {
 				t.Errorf("goroutine %d Login error: %v", idx, err)
 				return
@@ -115,3 +117,29 @@ func TestAdminAuth_concurrent(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestAdminAuth_LoginTOTP(t *testing.T) {
+	svc := NewAdminAuthService("admin", "secret").
+		WithTOTP("JBSWY3DPEHPK3PXP", &mock.TOTPMock{ValidCode: "123456", Step: 1})
+
+	if _, err := svc.Login("admin", "secret", ""); err != ErrSecondFactorRequired {
+		t.Errorf("Login(no code) error = %v, want ErrSecondFactorRequired", err)
+	}
+	if _, err := svc.Login("admin", "secret", "000000"); err != ErrForbidden {
+		t.Errorf("Login(wrong code) error = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.Login("admin", "wrong", ""); err != ErrForbidden {
+		t.Errorf("Login(wrong password) error = %v, want ErrForbidden", err)
+	}
+
+	token, err := svc.Login("admin", "secret", "123456")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !svc.Validate(token) {
+		t.Error("token from TOTP login should be valid")
+	}
+	if _, err := svc.Login("admin", "secret", "123456"); err != ErrForbidden {
+		t.Errorf("Login(replayed code) error = %v, want ErrForbidden", err)
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// ArchiveService builds ZIP archives of files and folder trees.
+// Archives are streamed as they are written, without temporary files.
+type ArchiveService struct {
+	nodes   repository.NodeRepository
+	storage port.ContentStorage
+	xattrs  repository.XAttrRepository
+}
+
+// NewArchiveService creates a new ArchiveService.
+func NewArchiveService(nodes repository.NodeRepository, storage port.ContentStorage) *ArchiveService {
+	return &ArchiveService{nodes: nodes, storage: storage}
+}
+
+// WithXAttrs exports the extended attributes of nodes with their archive entries.
+func (s *ArchiveService) WithXAttrs(xattrs repository.XAttrRepository) *ArchiveService {
+	s.xattrs = xattrs
+	return s
+}
+
+// ArchiveSource names a node to put into an archive: the tree it is read from,
+// its path there, and the name it gets at the top of the archive.
+// An empty Name defaults to the last path element. The contents of a root
+// folder are placed at the top of the archive directly.
+type ArchiveSource struct {
+	UserID int64
+	Path   vo.CloudPath
+	Name   string
+}
+
+// Archive is a resolved set of nodes, ready to be written as a ZIP file.
+type Archive struct {
+	storage port.ContentStorage
+	xattrs  repository.XAttrRepository
+	entries []archiveEntry
+}
+
+type archiveEntry struct {
+	name string
+	node entity.Node
+}
+
+// Prepare resolves the sources and their descendants.
+// Sources that end up with the same top-level name get numbered suffixes.
+// Returns ErrNotFound if any source does not exist.
+func (s *ArchiveService) Prepare(sources []ArchiveSource) (*Archive, error) {
+	archive := &Archive{storage: s.storage, xattrs: s.xattrs}
+	used := make(map[string]bool)
+
+	for _, src := range sources {
+		node, descendants, err := s.nodes.GetWithDescendants(src.UserID, src.Path)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, ErrNotFound
+		}
+
+		prefix := ""
+		if !node.Home.IsRoot() {
+			name := src.Name
+			if name == "" {
+				name = node.Name
+			}
+			prefix = uniqueName(name, used)
+			archive.entries = append(archive.entries, archiveEntry{name: prefix, node: *node})
+		}
+
+		sort.Slice(descendants, func(i, j int) bool {
+			return descendants[i].Home.String() < descendants[j].Home.String()
+		})
+		base := node.Home.String()
+		for _, d := range descendants {
+			rel := strings.TrimPrefix(strings.TrimPrefix(d.Home.String(), base), "/")
+			if prefix != "" {
+				rel = prefix + "/" + rel
+			}
+			archive.entries = append(archive.entries, archiveEntry{name: rel, node: d})
+		}
+	}
+	return archive, nil
+}
+
+// uniqueName returns name, or name with a numbered suffix if it is already used,
+// and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		ext := ""
+		if dot := strings.LastIndex(name, "."); dot > 0 {
+			ext = name[dot:]
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// Write streams the archive as a ZIP file. Entries keep the nodes' modification
+// times, and ZIP64 records are used where sizes or entry counts require them.
+// The extended attributes of a node, if any, are written as a JSON object in
+// the comment of its entry.
+// A failure mid-way leaves w with a truncated archive.
+func (a *Archive) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, flate.BestSpeed)
+	})
+
+	for i := range a.entries {
+		if err := a.writeEntry(zw, &a.entries[i]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (a *Archive) writeEntry(zw *zip.Writer, e *archiveEntry) error {
+	header := &zip.FileHeader{
+		Name:     e.name,
+		Modified: time.Unix(e.node.MTime, 0),
+	}
+	comment, err := a.xattrComment(&e.node)
+	if err != nil {
+		return err
+	}
+	header.Comment = comment
+
+	if e.node.IsFolder() {
+		header.Name += "/"
+		header.Method = zip.Store
+		_, err = zw.CreateHeader(header)
+		return err
+	}
+
+	header.Method = zip.Deflate
+	header.UncompressedSize64 = uint64(e.node.Size)
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	f, err := a.storage.Open(e.node.Hash)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", e.node.Home, err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+// xattrComment returns the extended attributes of the node as a JSON object,
+// or an empty string if it has none. Attributes that do not fit into the
+// 64 KiB of a ZIP entry comment are left out altogether.
+func (a *Archive) xattrComment(node *entity.Node) (string, error) {
+	if a.xattrs == nil {
+		return "", nil
+	}
+	attrs, err := a.xattrs.List(node.ID)
+	if err != nil || len(attrs) == 0 {
+		return "", err
+	}
+
+	values := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		values[attr.Name.String()] = attr.Value
+	}
+	comment, err := json.Marshal(values)
+	if err != nil || len(comment) > 0xffff {
+		return "", err
+	}
+	return string(comment), nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// newTestArchiveService serves the tree /docs/{a.txt, sub/, sub/b.txt} and the
+// file /other/a.txt of user 1. Every file holds "content"; /docs/a.txt has ID 2.
+func newTestArchiveService(t *testing.T) *ArchiveService {
+	t.Helper()
+
+	contentPath := filepath.Join(t.TempDir(), "content")
+	if err := os.WriteFile(contentPath, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	docs := mock.NewTestNode(1, "/docs", vo.NodeTypeFolder)
+	docs.MTime = 1700000000
+	sub := mock.NewTestNode(1, "/docs/sub", vo.NodeTypeFolder)
+	a := mock.NewTestFileNode(1, "/docs/a.txt", mock.ValidHash(), 7)
+	a.ID = 2
+	a.MTime = 1700000100
+	b := mock.NewTestFileNode(1, "/docs/sub/b.txt", mock.ValidHash(), 7)
+	other := mock.NewTestFileNode(1, "/other/a.txt", mock.ValidHash(), 7)
+
+	return NewArchiveService(
+		&mock.NodeRepositoryMock{
+			GetWithDescendantsFunc: func(userID int64, path vo.CloudPath) (*entity.Node, []entity.Node, error) {
+				switch path.String() {
+				case "/docs":
+					return docs, []entity.Node{*b, *sub, *a}, nil
+				case "/docs/a.txt":
+					return a, nil, nil
+				case "/other/a.txt":
+					return other, nil, nil
+				}
+				return nil, nil, nil
+			},
+		},
+		&mock.ContentStorageMock{
+			OpenFunc: func(hash vo.ContentHash) (*os.File, error) {
+				return os.Open(contentPath)
+			},
+		},
+	)
+}
+
+func writeTestArchive(t *testing.T, svc *ArchiveService, sources ...ArchiveSource) *zip.Reader {
+	t.Helper()
+
+	archive, err := svc.Prepare(sources)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	return zr
+}
+
+func archiveNames(zr *zip.Reader) []string {
+	names := make([]string, len(zr.File))
+	for i, f := range zr.File {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func TestArchiveService_folder(t *testing.T) {
+	svc := newTestArchiveService(t)
+
+	zr := writeTestArchive(t, svc, ArchiveSource{UserID: 1, Path: vo.NewCloudPath("/docs")})
+
+	want := []string{"docs/", "docs/a.txt", "docs/sub/", "docs/sub/b.txt"}
+	got := archiveNames(zr)
+	if len(got) != len(want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	f := zr.File[1]
+	if f.Modified.Unix() != 1700000100 {
+		t.Errorf("Modified = %v, want node mtime", f.Modified)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "content" {
+		t.Errorf("content = %q", data)
+	}
+}
+
+func TestArchiveService_renamesDuplicates(t *testing.T) {
+	svc := newTestArchiveService(t)
+
+	zr := writeTestArchive(t, svc,
+		ArchiveSource{UserID: 1, Path: vo.NewCloudPath("/docs/a.txt")},
+		ArchiveSource{UserID: 1, Path: vo.NewCloudPath("/other/a.txt")},
+		ArchiveSource{UserID: 1, Path: vo.NewCloudPath("/docs/a.txt"), Name: "mounted"},
+	)
+
+	got := archiveNames(zr)
+	want := []string{"a.txt", "a (2).txt", "mounted"}
+	if len(got) != len(want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestArchiveService_notFound(t *testing.T) {
+	svc := newTestArchiveService(t)
+
+	_, err := svc.Prepare([]ArchiveSource{
+		{UserID: 1, Path: vo.NewCloudPath("/docs")},
+		{UserID: 1, Path: vo.NewCloudPath("/missing")},
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestArchiveService_xattrs(t *testing.T) {
+	svc := newTestArchiveService(t).WithXAttrs(&mock.XAttrRepositoryMock{
+		ListFunc: func(nodeID int64) ([]entity.XAttr, error) {
+			if nodeID != 2 {
+				return nil, nil
+			}
+			return []entity.XAttr{{Name: "source", Value: "crm"}}, nil
+		},
+	})
+
+	zr := writeTestArchive(t, svc, ArchiveSource{UserID: 1, Path: vo.NewCloudPath("/docs")})
+
+	for _, f := range zr.File {
+		want := ""
+		if f.Name == "docs/a.txt" {
+			want = `{"source":"crm"}`
+		}
+		if f.Comment != want {
+			t.Errorf("%s comment = %q, want %q", f.Name, f.Comment, want)
+		}
+	}
+}
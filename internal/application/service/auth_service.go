@@ -3,10 +3,15 @@
 package service
 
 import (
+	"github.com/pozitronik/tucha/internal/application/port"
 	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // AuthenticatedUser holds the resolved user context from a validated token.
+// Personal access tokens additionally carry the scopes and path prefix they
+// are restricted to; session tokens are unrestricted. Support tokens record
+// the admin impersonating the user and are read-only unless elevated.
 type AuthenticatedUser struct {
 	UserID         int64
 	Email          string
@@ -14,12 +19,63 @@ type AuthenticatedUser struct {
 	CSRFToken      string
 	FileSizeLimit  int64
 	VersionHistory bool
+	Personal       bool
+	Scopes         []vo.TokenScope
+	PathPrefix     vo.CloudPath
+	Impersonator   string
+	Elevated       bool
+}
+
+// Impersonated reports whether an admin is acting as the user via a support token.
+func (a *AuthenticatedUser) Impersonated() bool {
+	return a.Impersonator != ""
+}
+
+// Can reports whether the token grants the given scope.
+// Session tokens grant every scope. For personal tokens, write implies read.
+// Support tokens grant only read unless they were explicitly elevated.
+func (a *AuthenticatedUser) Can(scope vo.TokenScope) bool {
+	if a.Impersonated() && !a.Elevated && scope != vo.ScopeRead {
+		return false
+	}
+	if !a.Personal {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope || (scope == vo.ScopeRead && s == vo.ScopeWrite) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccess reports whether the path lies within the token's path prefix.
+// The prefix itself and all of its descendants are accessible.
+func (a *AuthenticatedUser) CanAccess(path vo.CloudPath) bool {
+	if !a.Personal || a.PathPrefix.String() == "" || a.PathPrefix.IsRoot() {
+		return true
+	}
+	return path.String() == a.PathPrefix.String() || path.HasPrefix(a.PathPrefix)
+}
+
+// Allows reports whether the token grants the scope on every given path.
+func (a *AuthenticatedUser) Allows(scope vo.TokenScope, paths ...vo.CloudPath) bool {
+	if !a.Can(scope) {
+		return false
+	}
+	for _, p := range paths {
+		if !a.CanAccess(p) {
+			return false
+		}
+	}
+	return true
 }
 
 // AuthService validates access tokens and resolves user context.
 type AuthService struct {
 	tokens repository.TokenRepository
 	users  repository.UserRepository
+	audit  port.Logger
 }
 
 // NewAuthService creates a new AuthService.
@@ -27,6 +83,12 @@ func NewAuthService(tokens repository.TokenRepository, users repository.UserRepo
 	return &AuthService{tokens: tokens, users: users}
 }
 
+// WithAuditLog records every request made with a support token to the given logger.
+func (s *AuthService) WithAuditLog(audit port.Logger) *AuthService {
+	s.audit = audit
+	return s
+}
+
 // ResolveUser looks up a user by ID.
 // Returns nil, nil if the user does not exist.
 func (s *AuthService) ResolveUser(userID int64) (*AuthenticatedUser, error) {
@@ -48,6 +110,8 @@ func (s *AuthService) ResolveUser(userID int64) (*AuthenticatedUser, error) {
 
 // Validate checks an access token string and returns the authenticated user context.
 // Returns nil, nil if the token is not found, expired, or the user no longer exists.
+// Scope and path restrictions of personal tokens are carried in the result and
+// enforced by callers via Allows.
 func (s *AuthService) Validate(accessToken string) (*AuthenticatedUser, error) {
 	if accessToken == "" {
 		return nil, nil
@@ -75,6 +139,11 @@ func (s *AuthService) Validate(accessToken string) (*AuthenticatedUser, error) {
 		return nil, nil
 	}
 
+	if token.Impersonator != "" && s.audit != nil {
+		s.audit.Info("AUDIT impersonation: admin=%q acting as user=%q token_id=%d elevated=%v",
+			token.Impersonator, user.Email, token.ID, token.Elevated)
+	}
+
 	return &AuthenticatedUser{
 		UserID:         user.ID,
 		Email:          user.Email,
@@ -82,5 +151,10 @@ func (s *AuthService) Validate(accessToken string) (*AuthenticatedUser, error) {
 		CSRFToken:      token.CSRFToken,
 		FileSizeLimit:  user.FileSizeLimit,
 		VersionHistory: user.VersionHistory,
+		Personal:       token.Personal,
+		Scopes:         token.Scopes,
+		PathPrefix:     token.PathPrefix,
+		Impersonator:   token.Impersonator,
+		Elevated:       token.Elevated,
 	}, nil
 }
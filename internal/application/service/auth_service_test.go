@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 	"github.com/pozitronik/tucha/internal/testutil/mock"
 )
 
@@ -208,3 +209,72 @@ func TestAuthService_ResolveUser_error(t *testing.T) {
 		t.Errorf("error = %v, want %v", err, repoErr)
 	}
 }
+
+func TestAuthenticatedUser_Allows(t *testing.T) {
+	session := &AuthenticatedUser{UserID: 1}
+	backups := &AuthenticatedUser{
+		UserID:     1,
+		Personal:   true,
+		Scopes:     []vo.TokenScope{vo.ScopeWrite},
+		PathPrefix: vo.NewCloudPath("/backups"),
+	}
+	noScopes := &AuthenticatedUser{UserID: 1, Personal: true, PathPrefix: vo.NewCloudPath("/")}
+	support := &AuthenticatedUser{UserID: 1, Impersonator: "admin"}
+	elevated := &AuthenticatedUser{UserID: 1, Impersonator: "admin", Elevated: true}
+
+	tests := []struct {
+		name   string
+		authed *AuthenticatedUser
+		scope  vo.TokenScope
+		paths  []vo.CloudPath
+		want   bool
+	}{
+		{"session token allows everything", session, vo.ScopeTrash, []vo.CloudPath{vo.NewCloudPath("/")}, true},
+		{"write within prefix", backups, vo.ScopeWrite, []vo.CloudPath{vo.NewCloudPath("/backups/db.sql")}, true},
+		{"write implies read", backups, vo.ScopeRead, []vo.CloudPath{vo.NewCloudPath("/backups")}, true},
+		{"root is outside prefix", backups, vo.ScopeRead, []vo.CloudPath{vo.NewCloudPath("/")}, false},
+		{"sibling with common name prefix", backups, vo.ScopeRead, []vo.CloudPath{vo.NewCloudPath("/backups-old")}, false},
+		{"move target outside prefix", backups, vo.ScopeWrite, []vo.CloudPath{vo.NewCloudPath("/backups/a"), vo.NewCloudPath("/docs")}, false},
+		{"scope not granted", backups, vo.ScopePublish, []vo.CloudPath{vo.NewCloudPath("/backups/a")}, false},
+		{"personal token without scopes", noScopes, vo.ScopeRead, nil, false},
+		{"support token reads", support, vo.ScopeRead, []vo.CloudPath{vo.NewCloudPath("/")}, true},
+		{"support token cannot write", support, vo.ScopeWrite, []vo.CloudPath{vo.NewCloudPath("/a")}, false},
+		{"support token cannot trash", support, vo.ScopeTrash, nil, false},
+		{"elevated support token writes", elevated, vo.ScopeWrite, []vo.CloudPath{vo.NewCloudPath("/a")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.authed.Allows(tt.scope, tt.paths...); got != tt.want {
+				t.Errorf("Allows(%q, %v) = %v, want %v", tt.scope, tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthService_Validate_supportTokenIsAudited(t *testing.T) {
+	audit := &mock.LoggerMock{}
+	svc := NewAuthService(
+		&mock.TokenRepositoryMock{
+			LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+				return &entity.Token{ID: 5, UserID: 1, Personal: true, ExpiresAt: time.Now().Add(time.Hour).Unix(),
+					Scopes: []vo.TokenScope{vo.ScopeRead}, Impersonator: "admin"}, nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, Email: "user@example.com"}, nil
+			},
+		},
+	).WithAuditLog(audit)
+
+	authed, err := svc.Validate("support")
+	if err != nil || authed == nil {
+		t.Fatalf("Validate = %v, %v", authed, err)
+	}
+	if !authed.Impersonated() || authed.Impersonator != "admin" || authed.Elevated {
+		t.Errorf("authed = %+v, want read-only impersonation by admin", authed)
+	}
+	if len(audit.Captured) != 1 {
+		t.Errorf("audit entries = %d, want 1", len(audit.Captured))
+	}
+}
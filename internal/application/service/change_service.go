@@ -0,0 +1,277 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+const (
+	// changeRetention is how long change journal entries are kept.
+	changeRetention = 30 * 24 * time.Hour
+
+	// changePruneInterval is how often old journal entries are pruned.
+	changePruneInterval = time.Hour
+
+	// subscriptionBuffer is how many changes a subscriber may fall behind
+	// before its subscription is closed.
+	subscriptionBuffer = 256
+)
+
+// ChangeService keeps the per-user change journal used for incremental sync.
+// Services that modify the tree record their changes here. The Record methods
+// are safe to call on a nil *ChangeService, which records nothing.
+// Recorded changes are also delivered to the user's live subscriptions.
+type ChangeService struct {
+	changes repository.ChangeRepository
+	shares  *ShareService
+	now     func() time.Time
+
+	mu        sync.Mutex
+	lastPrune time.Time
+	subs      map[int64]map[*Subscription]struct{}
+}
+
+// NewChangeService creates a new ChangeService.
+func NewChangeService(changes repository.ChangeRepository) *ChangeService {
+	return &ChangeService{
+		changes: changes,
+		now:     time.Now,
+		subs:    make(map[int64]map[*Subscription]struct{}),
+	}
+}
+
+// WithShares also records changes inside shared folders to the journals of
+// the users who mounted them, at the path of their mount.
+func (s *ChangeService) WithShares(shares *ShareService) *ChangeService {
+	s.shares = shares
+	return s
+}
+
+// Subscription delivers a user's changes as they are recorded.
+// C is closed when the subscription is closed, or when the subscriber falls
+// too far behind; it then resumes from the journal with List.
+type Subscription struct {
+	C <-chan entity.Change
+
+	c       chan entity.Change
+	userID  int64
+	service *ChangeService
+}
+
+// Close stops the delivery of changes. It is safe to call more than once.
+func (sub *Subscription) Close() {
+	sub.service.mu.Lock()
+	defer sub.service.mu.Unlock()
+	sub.service.unsubscribe(sub)
+}
+
+// ChangePage is one page of a user's change feed.
+type ChangePage struct {
+	Changes []entity.Change
+	Cursor  int64 // Cursor to pass for the next page.
+	HasMore bool  // More changes are available after Cursor.
+}
+
+// Record appends a change to the user's journal, stamping it with the current time.
+// Errors are ignored: a failed journal write must not fail the recorded operation.
+func (s *ChangeService) Record(change entity.Change) {
+	if s == nil {
+		return
+	}
+	now := s.now()
+	change.Time = now.Unix()
+	s.insert(change)
+	s.recordMounted(change)
+	s.prune(now)
+}
+
+// RecordNode records a change of the given node.
+// from is the node's previous path for renames and moves, or "" otherwise.
+func (s *ChangeService) RecordNode(kind vo.ChangeKind, node *entity.Node, from string) {
+	if s == nil || node == nil {
+		return
+	}
+	s.Record(entity.Change{
+		UserID: node.UserID,
+		Kind:   kind,
+		Home:   node.Home,
+		From:   from,
+		Type:   node.Type,
+		Size:   node.Size,
+		Hash:   node.Hash,
+	})
+}
+
+// RecordFolder records a change of a folder that has no node of its own,
+// such as a mount point.
+func (s *ChangeService) RecordFolder(userID int64, kind vo.ChangeKind, path vo.CloudPath) {
+	s.Record(entity.Change{UserID: userID, Kind: kind, Home: path, Type: vo.NodeTypeFolder})
+}
+
+// Latest returns the cursor of the user's most recent change.
+// Clients that have just listed their tree start the feed from it.
+func (s *ChangeService) Latest(userID int64) (int64, error) {
+	return s.changes.Latest(userID)
+}
+
+// List returns up to limit changes made after the cursor, oldest first.
+// Returns ErrCursorExpired if changes after the cursor have already been pruned.
+func (s *ChangeService) List(userID int64, cursor int64, limit int) (*ChangePage, error) {
+	horizon, err := s.changes.Horizon(userID)
+	if err != nil {
+		return nil, err
+	}
+	if cursor < horizon {
+		return nil, ErrCursorExpired
+	}
+
+	changes, err := s.changes.ListAfter(userID, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ChangePage{Changes: changes, Cursor: cursor}
+	if len(changes) > limit {
+		page.Changes, page.HasMore = changes[:limit], true
+	}
+	if n := len(page.Changes); n > 0 {
+		page.Cursor = page.Changes[n-1].ID
+	}
+	return page, nil
+}
+
+// Subscribe starts delivering the user's changes as they are recorded.
+// The caller must Close the subscription when done.
+func (s *ChangeService) Subscribe(userID int64) *Subscription {
+	c := make(chan entity.Change, subscriptionBuffer)
+	sub := &Subscription{C: c, c: c, userID: userID, service: s}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs[userID] == nil {
+		s.subs[userID] = make(map[*Subscription]struct{})
+	}
+	s.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// insert appends a change to the journal and delivers it to the user's subscriptions.
+// Both happen under s.mu, so subscribers receive changes in the order of their
+// IDs: a stream that skips IDs at or below its cursor never drops one.
+func (s *ChangeService) insert(change entity.Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.changes.Insert(&change)
+	if err != nil {
+		return
+	}
+	change.ID = id
+
+	for sub := range s.subs[change.UserID] {
+		select {
+		case sub.c <- change:
+		default:
+			// The subscriber is not keeping up; it catches up from the journal.
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+// unsubscribe removes a subscription and closes its channel. s.mu must be held.
+func (s *ChangeService) unsubscribe(sub *Subscription) {
+	subs := s.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(s.subs, sub.userID)
+	}
+	close(sub.c)
+}
+
+// recordMounted records a change inside a shared folder to the journals of
+// the users who mounted it. A node moved into or out of the folder appears
+// to them as created or deleted.
+func (s *ChangeService) recordMounted(change entity.Change) {
+	if s.shares == nil || change.Kind == vo.ChangeMount || change.Kind == vo.ChangeUnmount {
+		return
+	}
+	shares, err := s.shares.ListMountedByOwner(change.UserID)
+	if err != nil {
+		return
+	}
+
+	for i := range shares {
+		share := &shares[i]
+		home, inHome := mountedPath(share, change.Home.String())
+		from, inFrom := vo.CloudPath{}, false
+		if change.From != "" {
+			from, inFrom = mountedPath(share, change.From)
+		}
+
+		mounted := change
+		mounted.UserID = *share.MountUserID
+		switch {
+		case inHome && (change.From == "" || inFrom):
+			mounted.Home = home
+			if inFrom {
+				mounted.From = from.String()
+			}
+		case inHome:
+			mounted.Kind, mounted.Home, mounted.From = vo.ChangeCreate, home, ""
+		case inFrom:
+			mounted.Kind, mounted.Home, mounted.From = vo.ChangeDelete, from, ""
+		default:
+			continue
+		}
+		s.insert(mounted)
+	}
+}
+
+// prune drops expired journal entries at most once per changePruneInterval.
+func (s *ChangeService) prune(now time.Time) {
+	s.mu.Lock()
+	due := now.Sub(s.lastPrune) >= changePruneInterval
+	if due {
+		s.lastPrune = now
+	}
+	s.mu.Unlock()
+
+	if due {
+		_ = s.changes.Prune(now.Add(-changeRetention).Unix())
+	}
+}
+
+// ensurePath creates the missing folders of path, like NodeRepository.EnsurePath,
+// and records each folder it creates.
+func ensurePath(nodes repository.NodeRepository, changes *ChangeService, userID int64, path vo.CloudPath) error {
+	if changes == nil {
+		return nodes.EnsurePath(userID, path)
+	}
+
+	var missing []vo.CloudPath
+	for p := path; !p.IsRoot(); p = p.Parent() {
+		exists, err := nodes.Exists(userID, p)
+		if err != nil {
+			return err
+		}
+		if exists {
+			break
+		}
+		missing = append(missing, p)
+	}
+
+	if err := nodes.EnsurePath(userID, path); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		changes.RecordFolder(userID, vo.ChangeCreate, missing[i])
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// recordingChanges returns a ChangeService that appends recorded changes to *got.
+func recordingChanges(got *[]entity.Change) *ChangeService {
+	return NewChangeService(&mock.ChangeRepositoryMock{
+		InsertFunc: func(change *entity.Change) (int64, error) {
+			*got = append(*got, *change)
+			return int64(len(*got)), nil
+		},
+	})
+}
+
+func TestChangeService_List(t *testing.T) {
+	journal := []entity.Change{{ID: 11}, {ID: 12}, {ID: 15}}
+	svc := NewChangeService(&mock.ChangeRepositoryMock{
+		HorizonFunc: func(userID int64) (int64, error) { return 10, nil },
+		ListAfterFunc: func(userID int64, cursor int64, limit int) ([]entity.Change, error) {
+			var out []entity.Change
+			for _, c := range journal {
+				if c.ID > cursor && len(out) < limit {
+					out = append(out, c)
+				}
+			}
+			return out, nil
+		},
+	})
+
+	t.Run("first page", func(t *testing.T) {
+		page, err := svc.List(1, 10, 2)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(page.Changes) != 2 || !page.HasMore || page.Cursor != 12 {
+			t.Errorf("page = %+v, want 2 changes up to 12 with more", page)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		page, err := svc.List(1, 12, 2)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(page.Changes) != 1 || page.HasMore || page.Cursor != 15 {
+			t.Errorf("page = %+v, want change 15 without more", page)
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		page, err := svc.List(1, 15, 2)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(page.Changes) != 0 || page.Cursor != 15 {
+			t.Errorf("page = %+v, want no changes and the same cursor", page)
+		}
+	})
+
+	t.Run("expired cursor", func(t *testing.T) {
+		if _, err := svc.List(1, 9, 2); !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("err = %v, want ErrCursorExpired", err)
+		}
+	})
+}
+
+func TestChangeService_Record(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var prunedBefore []int64
+	var got []entity.Change
+	svc := NewChangeService(&mock.ChangeRepositoryMock{
+		InsertFunc: func(change *entity.Change) (int64, error) {
+			got = append(got, *change)
+			return 1, nil
+		},
+		PruneFunc: func(before int64) error {
+			prunedBefore = append(prunedBefore, before)
+			return nil
+		},
+	})
+	svc.now = func() time.Time { return now }
+
+	svc.RecordNode(vo.ChangeRename, mock.NewTestFileNode(1, "/b.txt", mock.ValidHash(), 3), "/a.txt")
+	svc.RecordFolder(2, vo.ChangeMount, vo.NewCloudPath("/shared"))
+	now = now.Add(changePruneInterval)
+	svc.RecordNode(vo.ChangeDelete, nil, "")
+	svc.RecordFolder(2, vo.ChangeUnmount, vo.NewCloudPath("/shared"))
+
+	if len(got) != 3 {
+		t.Fatalf("recorded %d changes, want 3", len(got))
+	}
+	if c := got[0]; c.UserID != 1 || c.Kind != vo.ChangeRename || c.From != "/a.txt" || c.Size != 3 || c.Time != 1700000000 {
+		t.Errorf("change = %+v, want rename of /a.txt", c)
+	}
+	if c := got[1]; c.UserID != 2 || c.Kind != vo.ChangeMount || c.Type != vo.NodeTypeFolder {
+		t.Errorf("change = %+v, want folder mount", c)
+	}
+	if len(prunedBefore) != 2 || prunedBefore[1] != now.Add(-changeRetention).Unix() {
+		t.Errorf("pruned before %v, want once per interval", prunedBefore)
+	}
+
+	var nilSvc *ChangeService
+	nilSvc.RecordFolder(1, vo.ChangeCreate, vo.NewCloudPath("/x"))
+}
+
+func TestEnsurePath_recordsCreatedFolders(t *testing.T) {
+	existing := map[string]bool{"/a": true}
+	nodes := &mock.NodeRepositoryMock{
+		ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return existing[path.String()], nil },
+	}
+	var got []entity.Change
+
+	if err := ensurePath(nodes, recordingChanges(&got), 1, vo.NewCloudPath("/a/b/c")); err != nil {
+		t.Fatalf("ensurePath: %v", err)
+	}
+
+	if len(got) != 2 || got[0].Home.String() != "/a/b" || got[1].Home.String() != "/a/b/c" {
+		t.Errorf("recorded %+v, want creation of /a/b then /a/b/c", got)
+	}
+}
+
+func TestFileService_recordsChanges(t *testing.T) {
+	existing := map[string]bool{"/dir": true}
+	nodes := &mock.NodeRepositoryMock{
+		ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return existing[path.String()], nil },
+		RenameFunc: func(userID int64, path vo.CloudPath, newName string) (*entity.Node, error) {
+			return mock.NewTestFileNode(userID, path.Parent().Join(newName).String(), mock.ValidHash(), 1), nil
+		},
+	}
+	var got []entity.Change
+	svc := newFileServiceWithDefaults(
+		nodes,
+		&mock.ContentRepositoryMock{ExistsFunc: func(h vo.ContentHash) (bool, error) { return true, nil }},
+		&mock.ContentStorageMock{},
+		&mock.UserRepositoryMock{GetByIDFunc: func(id int64) (*entity.User, error) {
+			return &entity.User{ID: id, QuotaBytes: 1 << 30}, nil
+		}},
+	).WithChanges(recordingChanges(&got))
+
+	if _, err := svc.AddByHash(1, vo.NewCloudPath("/dir/a.txt"), mock.ValidHash(), 1, vo.ConflictReplace); err != nil {
+		t.Fatalf("AddByHash: %v", err)
+	}
+	existing["/dir/a.txt"] = true
+	if _, err := svc.AddByHash(1, vo.NewCloudPath("/dir/a.txt"), mock.ValidHash(), 1, vo.ConflictReplace); err != nil {
+		t.Fatalf("AddByHash: %v", err)
+	}
+	if _, err := svc.Rename(1, vo.NewCloudPath("/dir/a.txt"), "b.txt"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	want := []struct {
+		kind vo.ChangeKind
+		home string
+		from string
+	}{
+		{vo.ChangeCreate, "/dir/a.txt", ""},
+		{vo.ChangeModify, "/dir/a.txt", ""},
+		{vo.ChangeRename, "/dir/b.txt", "/dir/a.txt"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("recorded %+v, want %d changes", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Home.String() != w.home || got[i].From != w.from {
+			t.Errorf("change %d = %+v, want %s %s from %q", i, got[i], w.kind, w.home, w.from)
+		}
+	}
+}
+
+func TestChangeService_Subscribe(t *testing.T) {
+	var got []entity.Change
+	svc := recordingChanges(&got)
+
+	sub := svc.Subscribe(1)
+	other := svc.Subscribe(2)
+	defer other.Close()
+
+	svc.RecordFolder(1, vo.ChangeCreate, vo.NewCloudPath("/a"))
+	svc.RecordFolder(2, vo.ChangeCreate, vo.NewCloudPath("/b"))
+
+	select {
+	case c := <-sub.C:
+		if c.ID != 1 || c.Home.String() != "/a" {
+			t.Errorf("delivered %+v, want change 1 at /a", c)
+		}
+	default:
+		t.Fatal("change was not delivered")
+	}
+	select {
+	case c := <-sub.C:
+		t.Errorf("delivered another user's change %+v", c)
+	default:
+	}
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("channel still open after Close")
+	}
+}
+
+func TestChangeService_SubscribeDeliversInIDOrder(t *testing.T) {
+	var got []entity.Change
+	svc := recordingChanges(&got)
+	sub := svc.Subscribe(1)
+	defer sub.Close()
+
+	const writers, perWriter = 8, 20
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWriter {
+				svc.RecordFolder(1, vo.ChangeCreate, vo.NewCloudPath("/a"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	var last int64
+	for range writers * perWriter {
+		c := <-sub.C
+		if c.ID <= last {
+			t.Fatalf("delivered change %d after %d", c.ID, last)
+		}
+		last = c.ID
+	}
+}
+
+func TestChangeService_SubscriptionClosedWhenBehind(t *testing.T) {
+	var got []entity.Change
+	svc := recordingChanges(&got)
+	sub := svc.Subscribe(1)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		svc.RecordFolder(1, vo.ChangeCreate, vo.NewCloudPath("/a"))
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != subscriptionBuffer {
+		t.Errorf("received %d changes before close, want %d", n, subscriptionBuffer)
+	}
+	sub.Close()
+}
+
+func TestChangeService_RecordsInsideMountedShares(t *testing.T) {
+	mounter := int64(2)
+	shares := &mock.ShareRepositoryMock{
+		ListMountedByOwnerFunc: func(ownerID int64) ([]entity.Share, error) {
+			return []entity.Share{{
+				OwnerID:     1,
+				Home:        vo.NewCloudPath("/team"),
+				MountHome:   "/inbox/team",
+				MountUserID: &mounter,
+				Status:      vo.ShareAccepted,
+			}}, nil
+		},
+	}
+	tests := []struct {
+		name     string
+		change   entity.Change
+		wantKind vo.ChangeKind
+		wantHome string
+		wantFrom string
+	}{
+		{"inside", entity.Change{Kind: vo.ChangeModify, Home: vo.NewCloudPath("/team/a.txt")}, vo.ChangeModify, "/inbox/team/a.txt", ""},
+		{"shared folder itself", entity.Change{Kind: vo.ChangeCreate, Home: vo.NewCloudPath("/team")}, vo.ChangeCreate, "/inbox/team", ""},
+		{"renamed inside", entity.Change{Kind: vo.ChangeRename, Home: vo.NewCloudPath("/team/b.txt"), From: "/team/a.txt"}, vo.ChangeRename, "/inbox/team/b.txt", "/inbox/team/a.txt"},
+		{"moved in", entity.Change{Kind: vo.ChangeMove, Home: vo.NewCloudPath("/team/a.txt"), From: "/a.txt"}, vo.ChangeCreate, "/inbox/team/a.txt", ""},
+		{"moved out", entity.Change{Kind: vo.ChangeMove, Home: vo.NewCloudPath("/a.txt"), From: "/team/a.txt"}, vo.ChangeDelete, "/inbox/team/a.txt", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []entity.Change
+			svc := recordingChanges(&got).WithShares(NewShareService(shares, nil, nil, nil))
+
+			tt.change.UserID = 1
+			svc.Record(tt.change)
+
+			if len(got) != 2 {
+				t.Fatalf("recorded %d changes, want the owner's and the mounter's", len(got))
+			}
+			m := got[1]
+			if m.UserID != mounter || m.Kind != tt.wantKind || m.Home.String() != tt.wantHome || m.From != tt.wantFrom {
+				t.Errorf("mounter change = %+v, want %s %s from %q", m, tt.wantKind, tt.wantHome, tt.wantFrom)
+			}
+		})
+	}
+
+	t.Run("outside", func(t *testing.T) {
+		var got []entity.Change
+		svc := recordingChanges(&got).WithShares(NewShareService(shares, nil, nil, nil))
+
+		svc.Record(entity.Change{UserID: 1, Kind: vo.ChangeCreate, Home: vo.NewCloudPath("/teamwork")})
+
+		if len(got) != 1 {
+			t.Errorf("recorded %d changes, want only the owner's", len(got))
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package service
+
+import "github.com/pozitronik/tucha/internal/domain/entity"
+
+// ClientRegistry holds the OAuth clients allowed to obtain tokens.
+// It is built once at startup from configuration and is read-only afterwards.
+type ClientRegistry struct {
+	clients []entity.OAuthClient
+	byID    map[string]*entity.OAuthClient
+}
+
+// NewClientRegistry creates a ClientRegistry from the given clients.
+// Client IDs are expected to be unique (enforced by configuration validation).
+func NewClientRegistry(clients []entity.OAuthClient) *ClientRegistry {
+	r := &ClientRegistry{
+		clients: make([]entity.OAuthClient, len(clients)),
+		byID:    make(map[string]*entity.OAuthClient, len(clients)),
+	}
+	copy(r.clients, clients)
+	for i := range r.clients {
+		r.byID[r.clients[i].ID] = &r.clients[i]
+	}
+	return r
+}
+
+// Lookup returns the client with the given ID, or nil if it is not registered.
+func (r *ClientRegistry) Lookup(clientID string) *entity.OAuthClient {
+	return r.byID[clientID]
+}
+
+// IDs returns the IDs of all registered clients in configuration order.
+func (r *ClientRegistry) IDs() []string {
+	ids := make([]string, 0, len(r.clients))
+	for _, c := range r.clients {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
@@ -1,16 +1,19 @@
 package service
 
 import (
+	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/repository"
 	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // cloneTree recursively copies a folder tree from one user to another,
-// incrementing content reference counts for each file.
+// incrementing content reference counts for each file. The copies get the
+// extended attributes of the originals, unless xattrs is nil.
 // The destination folder must already exist.
 func cloneTree(
 	nodes repository.NodeRepository,
 	contents repository.ContentRepository,
+	xattrs repository.XAttrRepository,
 	srcUserID int64, srcFolder vo.CloudPath,
 	dstUserID int64, dstFolder vo.CloudPath,
 ) error {
@@ -21,20 +24,27 @@ func cloneTree(
 
 	for _, child := range children {
 		newPath := dstFolder.Join(child.Name)
+		var copied *entity.Node
 		if child.IsFile() {
 			if !child.Hash.IsZero() {
 				if _, err := contents.Insert(child.Hash, child.Size); err != nil {
 					return err
 				}
 			}
-			if _, err := nodes.CreateFile(dstUserID, newPath, child.Hash, child.Size); err != nil {
-				return err
-			}
+			copied, err = nodes.CreateFile(dstUserID, newPath, child.Hash, child.Size)
 		} else {
-			if _, err := nodes.CreateFolder(dstUserID, newPath); err != nil {
+			copied, err = nodes.CreateFolder(dstUserID, newPath)
+		}
+		if err != nil {
+			return err
+		}
+		if xattrs != nil {
+			if err := xattrs.Copy(child.ID, copied.ID); err != nil {
 				return err
 			}
-			if err := cloneTree(nodes, contents, srcUserID, child.Home, dstUserID, newPath); err != nil {
+		}
+		if child.IsFolder() {
+			if err := cloneTree(nodes, contents, xattrs, srcUserID, child.Home, dstUserID, newPath); err != nil {
 				return err
 			}
 		}
@@ -18,7 +18,7 @@ func TestCloneTree(t *testing.T) {
 		}
 		contentRepo := &mock.ContentRepositoryMock{}
 
-		err := cloneTree(nodeRepo, contentRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, contentRepo, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != nil {
 			t.Errorf("cloneTree() error = %v, want nil", err)
 		}
@@ -52,7 +52,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, contentRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, contentRepo, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != nil {
 			t.Fatalf("cloneTree() error = %v", err)
 		}
@@ -110,7 +110,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, contentRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, contentRepo, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != nil {
 			t.Fatalf("cloneTree() error = %v", err)
 		}
@@ -153,7 +153,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, contentRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, contentRepo, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != nil {
 			t.Fatalf("cloneTree() error = %v", err)
 		}
@@ -172,7 +172,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != expectedErr {
 			t.Errorf("cloneTree() error = %v, want %v", err, expectedErr)
 		}
@@ -195,7 +195,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, contentRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, contentRepo, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != expectedErr {
 			t.Errorf("cloneTree() error = %v, want %v", err, expectedErr)
 		}
@@ -221,7 +221,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, contentRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, contentRepo, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != expectedErr {
 			t.Errorf("cloneTree() error = %v, want %v", err, expectedErr)
 		}
@@ -241,7 +241,7 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != expectedErr {
 			t.Errorf("cloneTree() error = %v, want %v", err, expectedErr)
 		}
@@ -267,9 +267,48 @@ func TestCloneTree(t *testing.T) {
 			},
 		}
 
-		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, nil, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
 		if err != expectedErr {
 			t.Errorf("cloneTree() error = %v, want %v", err, expectedErr)
 		}
 	})
+
+	t.Run("copies extended attributes", func(t *testing.T) {
+		nodeRepo := &mock.NodeRepositoryMock{
+			ListChildrenFunc: func(userID int64, path vo.CloudPath, offset, limit int) ([]entity.Node, error) {
+				if path.String() == "/src" {
+					return []entity.Node{
+						{ID: 10, Name: "sub", Home: vo.NewCloudPath("/src/sub"), Type: vo.NodeTypeFolder},
+					}, nil
+				}
+				if path.String() == "/src/sub" {
+					return []entity.Node{
+						{ID: 11, Name: "a.txt", Home: vo.NewCloudPath("/src/sub/a.txt"), Type: vo.NodeTypeFile},
+					}, nil
+				}
+				return nil, nil
+			},
+			CreateFolderFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+				return &entity.Node{ID: 20, UserID: userID, Home: path, Type: vo.NodeTypeFolder}, nil
+			},
+			CreateFileFunc: func(userID int64, path vo.CloudPath, h vo.ContentHash, size int64) (*entity.Node, error) {
+				return &entity.Node{ID: 21, UserID: userID, Home: path, Type: vo.NodeTypeFile}, nil
+			},
+		}
+		copied := make(map[int64]int64)
+		xattrRepo := &mock.XAttrRepositoryMock{
+			CopyFunc: func(srcNodeID, dstNodeID int64) error {
+				copied[srcNodeID] = dstNodeID
+				return nil
+			},
+		}
+
+		err := cloneTree(nodeRepo, &mock.ContentRepositoryMock{}, xattrRepo, 1, vo.NewCloudPath("/src"), 2, vo.NewCloudPath("/dst"))
+		if err != nil {
+			t.Fatalf("cloneTree() error = %v", err)
+		}
+		if copied[10] != 20 || copied[11] != 21 {
+			t.Errorf("cloneTree() copied attributes %v, want 10->20 and 11->21", copied)
+		}
+	})
 }
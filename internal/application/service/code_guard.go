@@ -0,0 +1,92 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxCodeAttempts is how many wrong one-time codes are accepted in a row
+	// before further attempts are refused.
+	maxCodeAttempts = 5
+
+	// codeLockout is how long attempts are refused after too many wrong codes.
+	codeLockout = 15 * time.Minute
+)
+
+// codeGuard throttles one-time code attempts and rejects replayed TOTP codes.
+// State is kept in memory per key, such as a user ID; a server restart clears it.
+type codeGuard struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	state map[int64]*codeState
+}
+
+// codeState tracks the code attempts of one key.
+type codeState struct {
+	failures    int       // Attempts since the last accepted code.
+	lockedUntil time.Time // Attempts are refused until then.
+	lastStep    int64     // Time step of the last accepted TOTP code.
+}
+
+// newCodeGuard creates a codeGuard using the system clock.
+func newCodeGuard() *codeGuard {
+	return &codeGuard{now: time.Now, state: make(map[int64]*codeState)}
+}
+
+// begin registers an attempt to check a code for the key, counting it as
+// failed until accept or succeed says otherwise. Counting before the check
+// keeps concurrent attempts within the limit.
+// Returns ErrTooManyAttempts while the key is locked out.
+func (g *codeGuard) begin(key int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st := g.get(key)
+	now := g.now()
+	if now.Before(st.lockedUntil) {
+		return ErrTooManyAttempts
+	}
+	st.failures++
+	if st.failures >= maxCodeAttempts {
+		st.failures = 0
+		st.lockedUntil = now.Add(codeLockout)
+	}
+	return nil
+}
+
+// accept records a valid TOTP code of the given time step and clears the
+// failed attempts. Returns false if a code of that step or a later one was
+// already accepted, so each code is used once.
+func (g *codeGuard) accept(key, step int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st := g.get(key)
+	if step <= st.lastStep {
+		return false
+	}
+	st.lastStep = step
+	st.failures, st.lockedUntil = 0, time.Time{}
+	return true
+}
+
+// succeed clears the failed attempts of the key after a valid recovery code.
+func (g *codeGuard) succeed(key int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st := g.get(key)
+	st.failures, st.lockedUntil = 0, time.Time{}
+}
+
+// get returns the state of the key, creating it. g.mu must be held.
+func (g *codeGuard) get(key int64) *codeState {
+	st := g.state[key]
+	if st == nil {
+		st = &codeState{}
+		g.state[key] = st
+	}
+	return st
+}
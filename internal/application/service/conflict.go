@@ -0,0 +1,40 @@
+package service
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// resolveConflict decides where a new node meant for path goes when the path
+// may be taken. It returns the path to use and whether the node there has to
+// be replaced: strict fails with ErrAlreadyExists, rename picks the first free
+// numbered name, and replace keeps the path.
+func resolveConflict(nodes repository.NodeRepository, userID int64, path vo.CloudPath, isFile bool, conflict vo.ConflictMode) (vo.CloudPath, bool, error) {
+	exists, err := nodes.Exists(userID, path)
+	if err != nil || !exists {
+		return path, false, err
+	}
+	switch conflict {
+	case vo.ConflictStrict:
+		return path, false, ErrAlreadyExists
+	case vo.ConflictRename:
+		free, err := freePath(nodes, userID, path, isFile)
+		return free, false, err
+	default:
+		return path, true, nil
+	}
+}
+
+// freePath returns the first of path's numbered variants that does not exist.
+func freePath(nodes repository.NodeRepository, userID int64, path vo.CloudPath, isFile bool) (vo.CloudPath, error) {
+	for n := 1; ; n++ {
+		candidate := path.Numbered(n, isFile)
+		exists, err := nodes.Exists(userID, candidate)
+		if err != nil {
+			return vo.CloudPath{}, err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+const (
+	// contentIndexBatch is how many contents one indexing pass takes at a time.
+	contentIndexBatch = 50
+
+	// maxIndexedText caps the indexed text of one content, in bytes.
+	maxIndexedText = 1 << 20
+)
+
+// ContentIndexer extracts the text of stored file content in the background
+// and adds it to the content search index. Each content hash is indexed once,
+// however many files hold it.
+type ContentIndexer struct {
+	index      repository.ContentIndexRepository
+	storage    port.ContentStorage
+	extractors []port.TextExtractor
+	maxSize    int64
+	logger     port.Logger
+}
+
+// NewContentIndexer creates a ContentIndexer using the first of extractors
+// that supports a file. Content larger than maxSize bytes is not read; 0
+// reads content of any size.
+func NewContentIndexer(
+	index repository.ContentIndexRepository,
+	storage port.ContentStorage,
+	extractors []port.TextExtractor,
+	maxSize int64,
+	logger port.Logger,
+) *ContentIndexer {
+	return &ContentIndexer{
+		index:      index,
+		storage:    storage,
+		extractors: extractors,
+		maxSize:    maxSize,
+		logger:     logger,
+	}
+}
+
+// Run indexes new content every interval, and drops the index entries of
+// deleted content. It never returns.
+func (x *ContentIndexer) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		indexed := 0
+		for {
+			n, err := x.Index(contentIndexBatch)
+			indexed += n
+			if err != nil {
+				x.logger.Error("Content indexing failed: %v", err)
+				break
+			}
+			if n < contentIndexBatch {
+				break
+			}
+		}
+		if indexed > 0 {
+			x.logger.Info("Content indexing indexed %d files", indexed)
+		}
+
+		pruned, err := x.index.Prune()
+		if err != nil {
+			x.logger.Error("Content index pruning failed: %v", err)
+		}
+		if pruned > 0 {
+			x.logger.Info("Content index pruning removed %d entries", pruned)
+		}
+	}
+}
+
+// Index indexes up to limit of the contents not indexed yet. Content that no
+// extractor supports, that is too large or that fails to extract is indexed
+// without text, so it is not tried again. Returns the number of indexed contents.
+func (x *ContentIndexer) Index(limit int) (int, error) {
+	contents, err := x.index.ListUnindexed(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, c := range contents {
+		text, err := x.extract(&c)
+		if err != nil {
+			return i, err
+		}
+		if err := x.index.Store(c.Hash, text); err != nil {
+			return i, err
+		}
+	}
+	return len(contents), nil
+}
+
+// extract returns the text of the content, or "" if it has none to index.
+// Only storage errors other than missing content are returned.
+func (x *ContentIndexer) extract(c *repository.UnindexedContent) (string, error) {
+	if x.maxSize > 0 && c.Size > x.maxSize {
+		return "", nil
+	}
+	var extractor port.TextExtractor
+	for _, e := range x.extractors {
+		if e.Supports(c.Name) {
+			extractor = e
+			break
+		}
+	}
+	if extractor == nil {
+		return "", nil
+	}
+
+	f, err := x.storage.Open(c.Hash)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	text, err := extractor.Extract(f, c.Size, maxIndexedText)
+	if err != nil {
+		x.logger.Warn("Extracting text of %s (%s) failed: %v", c.Hash.String(), c.Name, err)
+		return "", nil
+	}
+	return text, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestContentIndexer_Index(t *testing.T) {
+	dir := t.TempDir()
+	content := func(n int) vo.ContentHash {
+		return vo.MustContentHash(strings.Repeat("0", 39) + string(rune('0'+n)))
+	}
+	for n, text := range map[int]string{1: "meeting notes", 3: "huge", 4: "broken"} {
+		if err := os.WriteFile(filepath.Join(dir, content(n).String()), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storage := &mock.ContentStorageMock{
+		OpenFunc: func(hash vo.ContentHash) (*os.File, error) {
+			return os.Open(filepath.Join(dir, hash.String()))
+		},
+	}
+
+	stored := map[string]string{}
+	index := &mock.ContentIndexRepositoryMock{
+		ListUnindexedFunc: func(limit int) ([]repository.UnindexedContent, error) {
+			return []repository.UnindexedContent{
+				{Hash: content(1), Name: "notes.txt", Size: 13},
+				{Hash: content(2), Name: "photo.jpg", Size: 10},
+				{Hash: content(3), Name: "huge.txt", Size: 2000},
+				{Hash: content(4), Name: "broken.txt", Size: 6},
+				{Hash: content(5), Name: "missing.txt", Size: 7},
+			}, nil
+		},
+		StoreFunc: func(hash vo.ContentHash, text string) error {
+			stored[hash.String()] = text
+			return nil
+		},
+	}
+	extractor := &mock.TextExtractorMock{
+		SupportsFunc: func(name string) bool { return strings.HasSuffix(name, ".txt") },
+		ExtractFunc: func(r io.ReaderAt, size int64, limit int) (string, error) {
+			data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+			if string(data) == "broken" {
+				return "", errors.New("corrupt")
+			}
+			return string(data), err
+		},
+	}
+	logger := &mock.LoggerMock{}
+	indexer := NewContentIndexer(index, storage, []port.TextExtractor{extractor}, 1000, logger)
+
+	n, err := indexer.Index(10)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if n != 5 || len(stored) != 5 {
+		t.Fatalf("indexed %d, stored %v, want all 5 contents", n, stored)
+	}
+	if got := stored[content(1).String()]; got != "meeting notes" {
+		t.Errorf("text of notes.txt = %q, want the extracted text", got)
+	}
+	for i := 2; i <= 5; i++ {
+		if got := stored[content(i).String()]; got != "" {
+			t.Errorf("text of content %d = %q, want it indexed without text", i, got)
+		}
+	}
+	if len(logger.Captured) != 1 || logger.Captured[0].Level != "WARN" {
+		t.Errorf("logged %v, want one warning about the failed extraction", logger.Captured)
+	}
+}
+
+func TestContentIndexer_Index_storageError(t *testing.T) {
+	index := &mock.ContentIndexRepositoryMock{
+		ListUnindexedFunc: func(limit int) ([]repository.UnindexedContent, error) {
+			return []repository.UnindexedContent{{Hash: vo.MustContentHash(strings.Repeat("A", 40)), Name: "a.txt", Size: 1}}, nil
+		},
+		StoreFunc: func(hash vo.ContentHash, text string) error {
+			t.Error("content stored although it could not be read")
+			return nil
+		},
+	}
+	storage := &mock.ContentStorageMock{
+		OpenFunc: func(hash vo.ContentHash) (*os.File, error) { return nil, os.ErrPermission },
+	}
+	indexer := NewContentIndexer(index, storage, []port.TextExtractor{&mock.TextExtractorMock{}}, 0, &mock.LoggerMock{})
+
+	if n, err := indexer.Index(10); !errors.Is(err, os.ErrPermission) || n != 0 {
+		t.Errorf("Index = %d, %v, want 0 and the storage error to retry later", n, err)
+	}
+}
@@ -18,4 +18,32 @@ var (
 
 	// ErrForbidden indicates the caller lacks permission for the operation.
 	ErrForbidden = errors.New("forbidden")
+
+	// ErrSecondFactorRequired indicates the password was correct but a
+	// two-factor code must also be supplied.
+	ErrSecondFactorRequired = errors.New("second factor required")
+
+	// ErrTooManyAttempts indicates too many wrong two-factor codes were tried;
+	// further attempts are refused for a while.
+	ErrTooManyAttempts = errors.New("too many attempts")
+
+	// ErrInvalidPart indicates a multipart upload was completed with a part
+	// that was never uploaded, or with parts out of order.
+	ErrInvalidPart = errors.New("invalid part")
+
+	// ErrInvalidKey indicates a supplied SSH public key could not be parsed.
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrTooLarge indicates a value exceeds its size limit.
+	ErrTooLarge = errors.New("too large")
+
+	// ErrBusy indicates the user already has a background job of the same kind running.
+	ErrBusy = errors.New("busy")
+
+	// ErrUnsupportedArchive indicates a file is not an archive format that can be extracted.
+	ErrUnsupportedArchive = errors.New("unsupported archive")
+
+	// ErrCursorExpired indicates a change feed cursor is older than the retained
+	// journal, so the client must resynchronize from a full listing.
+	ErrCursorExpired = errors.New("cursor expired")
 )
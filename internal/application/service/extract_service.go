@@ -0,0 +1,417 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// JobKindExtract identifies archive extraction jobs.
+const JobKindExtract = "extract"
+
+// maxExtractEntries caps the number of entries read from one archive.
+const maxExtractEntries = 100000
+
+// Archive formats that can be extracted, recognized by file name.
+const (
+	formatZip   = "zip"
+	formatTar   = "tar"
+	formatTarGz = "tar.gz"
+)
+
+// Reasons an extraction stops. They are reported in the job's error.
+var (
+	errUnsafeEntry     = errors.New("archive entry points outside the target folder")
+	errTooManyEntries  = fmt.Errorf("archive has more than %d entries", maxExtractEntries)
+	errEntryTooLarge   = errors.New("archive entry exceeds the file size limit")
+	errExtractConflict = errors.New("a file in the archive conflicts with an existing item")
+)
+
+// ExtractService unpacks zip and tar archives stored in a user's tree.
+// Extraction runs in the background and reports progress through a job.
+// Each entry is stored like an upload: hashed, deduplicated against existing
+// content, and checked against the user's quota and file size limit. Reading
+// stops as soon as an entry grows past either limit, whatever sizes the archive
+// declares, so highly compressed archives cannot exhaust the disk.
+type ExtractService struct {
+	nodes    repository.NodeRepository
+	contents repository.ContentRepository
+	storage  port.ContentStorage
+	hasher   port.Hasher
+	files    *FileService
+	quota    *QuotaService
+	jobs     *JobRegistry
+	logger   port.Logger
+	changes  *ChangeService
+}
+
+// NewExtractService creates a new ExtractService.
+func NewExtractService(
+	nodes repository.NodeRepository,
+	contents repository.ContentRepository,
+	storage port.ContentStorage,
+	hasher port.Hasher,
+	files *FileService,
+	quota *QuotaService,
+	jobs *JobRegistry,
+	logger port.Logger,
+) *ExtractService {
+	return &ExtractService{
+		nodes:    nodes,
+		contents: contents,
+		storage:  storage,
+		hasher:   hasher,
+		files:    files,
+		quota:    quota,
+		jobs:     jobs,
+		logger:   logger,
+	}
+}
+
+// WithChanges records created folders to the given change journal.
+// Extracted files are recorded by the FileService.
+func (s *ExtractService) WithChanges(changes *ChangeService) *ExtractService {
+	s.changes = changes
+	return s
+}
+
+// ExtractRequest describes an archive to unpack.
+type ExtractRequest struct {
+	UserID        int64
+	Archive       vo.CloudPath
+	Target        vo.CloudPath // Folder to unpack into; created if missing.
+	Conflict      vo.ConflictMode
+	FileSizeLimit int64 // Largest file that may be extracted; 0 for no limit.
+}
+
+// Start checks the request and begins extracting in the background.
+// Returns ErrNotFound if the archive is not a file, ErrUnsupportedArchive if its
+// format is not known, ErrAlreadyExists if the target is a file, and ErrBusy if
+// the user is already extracting another archive.
+func (s *ExtractService) Start(req ExtractRequest) (Job, error) {
+	node, err := s.nodes.Get(req.UserID, req.Archive)
+	if err != nil {
+		return Job{}, err
+	}
+	if node == nil || !node.IsFile() {
+		return Job{}, ErrNotFound
+	}
+	format := archiveFormat(node.Name)
+	if format == "" {
+		return Job{}, ErrUnsupportedArchive
+	}
+
+	target, err := s.nodes.Get(req.UserID, req.Target)
+	if err != nil {
+		return Job{}, err
+	}
+	if target != nil && !target.IsFolder() {
+		return Job{}, ErrAlreadyExists
+	}
+
+	job, err := s.jobs.Start(req.UserID, JobKindExtract, req.Target.String())
+	if err != nil {
+		return Job{}, err
+	}
+
+	go func() {
+		err := s.run(job.ID, req, node.Hash, node.Size, format)
+		if err != nil {
+			s.logger.Warn("Archive extraction failed: user=%d archive=%q err=%v", req.UserID, req.Archive.String(), err)
+		}
+		s.jobs.Finish(job.ID, err)
+	}()
+	return job, nil
+}
+
+// archiveFormat recognizes an archive by its file name.
+func archiveFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar
+	}
+	return ""
+}
+
+// DefaultExtractTarget returns the folder an archive is unpacked into when no
+// target is given: a sibling folder named after the archive without its extension.
+func DefaultExtractTarget(archive vo.CloudPath) vo.CloudPath {
+	name := archive.Name()
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) && len(name) > len(ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	return archive.Parent().Join(name)
+}
+
+// archiveItem is one entry of an archive being extracted.
+type archiveItem struct {
+	name    string
+	dir     bool
+	regular bool
+	open    func() (io.ReadCloser, error)
+}
+
+// run extracts the archive content into the target folder.
+func (s *ExtractService) run(jobID string, req ExtractRequest, hash vo.ContentHash, size int64, format string) error {
+	usage, err := s.quota.GetUsage(req.UserID)
+	if err != nil {
+		return err
+	}
+
+	src, err := s.storage.Open(hash)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	spool, err := os.CreateTemp("", "tucha-extract-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	x := &extraction{
+		svc:     s,
+		jobID:   jobID,
+		req:     req,
+		spool:   spool,
+		budget:  usage.BytesTotal - usage.BytesUsed,
+		folders: make(map[string]bool),
+	}
+	if err := x.ensureFolder(req.Target); err != nil {
+		return err
+	}
+
+	switch format {
+	case formatZip:
+		return x.extractZip(src, size)
+	case formatTarGz:
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return fmt.Errorf("reading gzip stream: %w", err)
+		}
+		defer gz.Close()
+		return x.extractTar(gz)
+	default:
+		return x.extractTar(src)
+	}
+}
+
+// extraction holds the state of one running extraction.
+type extraction struct {
+	svc     *ExtractService
+	jobID   string
+	req     ExtractRequest
+	spool   *os.File
+	budget  int64 // Bytes left in the user's quota.
+	written int64
+	entries int
+	folders map[string]bool // Paths known to be folders.
+}
+
+func (x *extraction) extractZip(src io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(src, size)
+	if err != nil {
+		return fmt.Errorf("reading zip archive: %w", err)
+	}
+	x.svc.jobs.Update(x.jobID, func(job *Job) { job.Total = len(zr.File) })
+
+	for _, f := range zr.File {
+		item := archiveItem{
+			name:    f.Name,
+			dir:     f.FileInfo().IsDir(),
+			regular: f.Mode().IsRegular(),
+			open:    f.Open,
+		}
+		if err := x.add(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extraction) extractTar(src io.Reader) error {
+	tr := tar.NewReader(src)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar archive: %w", err)
+		}
+
+		item := archiveItem{
+			name:    header.Name,
+			dir:     header.Typeflag == tar.TypeDir,
+			regular: header.Typeflag == tar.TypeReg,
+			open:    func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		}
+		if err := x.add(item); err != nil {
+			return err
+		}
+	}
+}
+
+// add extracts one entry. Links and other special entries are skipped.
+func (x *extraction) add(item archiveItem) error {
+	x.entries++
+	if x.entries > maxExtractEntries {
+		return errTooManyEntries
+	}
+
+	rel, err := entryPath(item.name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case rel == "":
+	case item.dir:
+		if err := x.ensureFolder(x.req.Target.Join(rel)); err != nil {
+			return err
+		}
+	case item.regular:
+		if err := x.addFile(x.req.Target.Join(rel), item); err != nil {
+			return err
+		}
+	}
+
+	x.svc.jobs.Update(x.jobID, func(job *Job) {
+		job.Done = x.entries
+		job.Bytes = x.written
+	})
+	return nil
+}
+
+// addFile stores an entry's content and creates the file node.
+func (x *extraction) addFile(dst vo.CloudPath, item archiveItem) error {
+	if err := x.ensureFolder(dst.Parent()); err != nil {
+		return err
+	}
+
+	limit, tooLarge := x.budget-x.written, ErrOverQuota
+	if l := x.req.FileSizeLimit; l > 0 && l < limit {
+		limit, tooLarge = l, errEntryTooLarge
+	}
+	if limit < 0 {
+		return ErrOverQuota
+	}
+
+	r, err := item.open()
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", item.name, err)
+	}
+	defer r.Close()
+
+	// Spool the content so it can be hashed before it is stored.
+	if err := x.spool.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := x.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	size, err := io.Copy(x.spool, io.LimitReader(r, limit+1))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", item.name, err)
+	}
+	if size > limit {
+		return tooLarge
+	}
+
+	if _, err := x.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hash, err := x.svc.hasher.ComputeReader(x.spool, size)
+	if err != nil {
+		return err
+	}
+
+	known, err := x.svc.contents.Exists(hash)
+	if err != nil {
+		return err
+	}
+	if !known || !x.svc.storage.Exists(hash) {
+		if _, err := x.spool.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := x.svc.storage.Write(hash, x.spool); err != nil {
+			return err
+		}
+	}
+
+	if _, err := x.svc.files.AddByHash(x.req.UserID, dst, hash, size, x.req.Conflict); err != nil {
+		if errors.Is(err, ErrAlreadyExists) {
+			return fmt.Errorf("%w: %s", errExtractConflict, dst)
+		}
+		return err
+	}
+	x.written += size
+	return nil
+}
+
+// ensureFolder creates the folder and its missing ancestors.
+// Fails if any of them is a file.
+func (x *extraction) ensureFolder(p vo.CloudPath) error {
+	if p.IsRoot() || x.folders[p.String()] {
+		return nil
+	}
+	if err := x.ensureFolder(p.Parent()); err != nil {
+		return err
+	}
+
+	node, err := x.svc.nodes.Get(x.req.UserID, p)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		folder, err := x.svc.nodes.CreateFolder(x.req.UserID, p)
+		if err != nil {
+			return err
+		}
+		x.svc.changes.RecordNode(vo.ChangeCreate, folder, "")
+	} else if !node.IsFolder() {
+		return fmt.Errorf("%w: %s", errExtractConflict, p)
+	}
+	x.folders[p.String()] = true
+	return nil
+}
+
+// entryPath turns an archive entry name into a path relative to the target folder.
+// Returns "" for entries naming the archive root, and errUnsafeEntry for absolute
+// paths and paths that climb out of the target folder.
+func entryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return "", fmt.Errorf("%w: %q", errUnsafeEntry, name)
+	}
+
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %q", errUnsafeEntry, name)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
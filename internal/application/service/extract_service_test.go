@@ -0,0 +1,367 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// extractFixture is an in-memory tree of user 1 holding a single archive file.
+type extractFixture struct {
+	mu     sync.Mutex
+	nodes  map[string]*entity.Node
+	writes map[string]int // Storage writes per content hash.
+	svc    *ExtractService
+}
+
+func newExtractFixture(t *testing.T, name string, archive []byte, quota int64) *extractFixture {
+	t.Helper()
+
+	archivePath := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(archivePath, archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archiveHash := mock.ValidHash()
+
+	f := &extractFixture{
+		nodes:  make(map[string]*entity.Node),
+		writes: make(map[string]int),
+	}
+	f.nodes["/in"] = mock.NewTestNode(1, "/in", vo.NodeTypeFolder)
+	f.nodes["/in/"+name] = mock.NewTestFileNode(1, "/in/"+name, archiveHash, int64(len(archive)))
+	f.nodes["/in/note.txt"] = mock.NewTestFileNode(1, "/in/note.txt", archiveHash, 1)
+
+	nodes := &mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.nodes[path.String()], nil
+		},
+		ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.nodes[path.String()] != nil, nil
+		},
+		CreateFolderFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			node := mock.NewTestNode(userID, path.String(), vo.NodeTypeFolder)
+			f.nodes[path.String()] = node
+			return node, nil
+		},
+		CreateFileFunc: func(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			node := mock.NewTestFileNode(userID, path.String(), hash, size)
+			f.nodes[path.String()] = node
+			return node, nil
+		},
+		TotalSizeFunc: func(userID int64) (int64, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var total int64
+			for _, node := range f.nodes {
+				total += node.Size
+			}
+			return total, nil
+		},
+	}
+	contents := &mock.ContentRepositoryMock{
+		ExistsFunc: func(hash vo.ContentHash) (bool, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.writes[hash.String()] > 0, nil
+		},
+	}
+	storage := &mock.ContentStorageMock{
+		OpenFunc: func(hash vo.ContentHash) (*os.File, error) {
+			if hash != archiveHash {
+				return nil, os.ErrNotExist
+			}
+			return os.Open(archivePath)
+		},
+		WriteFunc: func(hash vo.ContentHash, r io.Reader) (int64, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.writes[hash.String()]++
+			return io.Copy(io.Discard, r)
+		},
+		ExistsFunc: func(hash vo.ContentHash) bool {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.writes[hash.String()] > 0
+		},
+	}
+	hasher := &mock.HasherMock{
+		ComputeReaderFunc: func(r io.Reader, size int64) (vo.ContentHash, error) {
+			h := sha1.New()
+			if _, err := io.Copy(h, r); err != nil {
+				return vo.ContentHash{}, err
+			}
+			return vo.NewContentHash(fmt.Sprintf("%X", h.Sum(nil)))
+		},
+	}
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			return &entity.User{ID: id, QuotaBytes: quota}, nil
+		},
+	}
+
+	quotaSvc := NewQuotaService(nodes, users)
+	files := NewFileService(nodes, contents, storage, quotaSvc, nil)
+	f.svc = NewExtractService(nodes, contents, storage, hasher, files, quotaSvc, NewJobRegistry(), &mock.LoggerMock{})
+	return f
+}
+
+// extract starts extracting /in/<name> into /out and waits for the job to end.
+func (f *extractFixture) extract(t *testing.T, name string, fileSizeLimit int64) Job {
+	t.Helper()
+
+	job, err := f.svc.Start(ExtractRequest{
+		UserID:        1,
+		Archive:       vo.NewCloudPath("/in/" + name),
+		Target:        vo.NewCloudPath("/out"),
+		Conflict:      vo.ConflictStrict,
+		FileSizeLimit: fileSizeLimit,
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err = f.svc.jobs.Get(1, job.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if job.State != JobRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("extraction did not finish")
+	return job
+}
+
+func (f *extractFixture) node(path string) *entity.Node {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nodes[path]
+}
+
+// testEntry is a file or, when the name ends with a slash, a folder.
+type testEntry struct {
+	name string
+	body string
+}
+
+func buildZip(t *testing.T, entries ...testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T, entries ...testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(e.name, "/") {
+			header.Typeflag, header.Mode = tar.TypeDir, 0o755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractService_zip(t *testing.T) {
+	archive := buildZip(t,
+		testEntry{name: "docs/"},
+		testEntry{name: "docs/a.txt", body: "same"},
+		testEntry{name: "docs/deep/b.txt", body: "same"},
+		testEntry{name: "c.txt", body: "other"},
+	)
+	f := newExtractFixture(t, "files.zip", archive, 1<<20)
+
+	job := f.extract(t, "files.zip", 0)
+
+	if job.State != JobDone {
+		t.Fatalf("state = %s (%s), want done", job.State, job.Error)
+	}
+	if job.Total != 4 || job.Done != 4 || job.Bytes != 13 {
+		t.Errorf("progress = %d/%d, %d bytes; want 4/4, 13 bytes", job.Done, job.Total, job.Bytes)
+	}
+	for _, p := range []string{"/out", "/out/docs", "/out/docs/deep"} {
+		if n := f.node(p); n == nil || !n.IsFolder() {
+			t.Errorf("%s is not a folder", p)
+		}
+	}
+	for _, p := range []string{"/out/docs/a.txt", "/out/docs/deep/b.txt", "/out/c.txt"} {
+		if n := f.node(p); n == nil || !n.IsFile() {
+			t.Errorf("%s is not a file", p)
+		}
+	}
+	if a, b := f.node("/out/docs/a.txt"), f.node("/out/docs/deep/b.txt"); a != nil && b != nil && a.Hash != b.Hash {
+		t.Error("identical entries got different hashes")
+	}
+	for hash, n := range f.writes {
+		if n != 1 {
+			t.Errorf("content %s written %d times, want once", hash, n)
+		}
+	}
+}
+
+func TestExtractService_tarGz(t *testing.T) {
+	archive := buildTarGz(t,
+		testEntry{name: "./dir/"},
+		testEntry{name: "./dir/a.txt", body: "hello"},
+	)
+	f := newExtractFixture(t, "files.tar.gz", archive, 1<<20)
+
+	job := f.extract(t, "files.tar.gz", 0)
+
+	if job.State != JobDone {
+		t.Fatalf("state = %s (%s), want done", job.State, job.Error)
+	}
+	if n := f.node("/out/dir/a.txt"); n == nil || n.Size != 5 {
+		t.Errorf("/out/dir/a.txt = %v, want a 5-byte file", n)
+	}
+	if f.node("/out/link") != nil {
+		t.Error("symlink entry was extracted")
+	}
+}
+
+func TestExtractService_rejectsUnsafeEntries(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "a/../../evil.txt", "/etc/evil.txt", "C:\\evil.txt", "..\\evil.txt"} {
+		t.Run(name, func(t *testing.T) {
+			archive := buildZip(t, testEntry{name: "ok.txt", body: "ok"}, testEntry{name: name, body: "evil"})
+			f := newExtractFixture(t, "files.zip", archive, 1<<20)
+
+			job := f.extract(t, "files.zip", 0)
+
+			if job.State != JobFailed || !strings.Contains(job.Error, errUnsafeEntry.Error()) {
+				t.Errorf("job = %s (%s), want failed on unsafe entry", job.State, job.Error)
+			}
+			for p := range f.nodes {
+				if strings.Contains(p, "evil") {
+					t.Errorf("unsafe entry created %s", p)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractService_limits(t *testing.T) {
+	// A megabyte of zeros compresses to about a kilobyte.
+	bomb := buildZip(t, testEntry{name: "zeros.bin", body: strings.Repeat("\x00", 1<<20)})
+
+	t.Run("file size limit", func(t *testing.T) {
+		f := newExtractFixture(t, "bomb.zip", bomb, 1<<30)
+
+		job := f.extract(t, "bomb.zip", 1024)
+
+		if job.State != JobFailed || job.Error != errEntryTooLarge.Error() {
+			t.Errorf("job = %s (%s), want failed on size limit", job.State, job.Error)
+		}
+		if f.node("/out/zeros.bin") != nil {
+			t.Error("oversized entry was extracted")
+		}
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		f := newExtractFixture(t, "bomb.zip", bomb, int64(len(bomb))+1+4096)
+
+		job := f.extract(t, "bomb.zip", 0)
+
+		if job.State != JobFailed || job.Error != ErrOverQuota.Error() {
+			t.Errorf("job = %s (%s), want failed over quota", job.State, job.Error)
+		}
+	})
+}
+
+func TestExtractService_Start(t *testing.T) {
+	f := newExtractFixture(t, "files.zip", buildZip(t, testEntry{name: "a.txt", body: "a"}), 1<<20)
+
+	tests := []struct {
+		name    string
+		archive string
+		target  string
+		want    error
+	}{
+		{"missing archive", "/in/missing.zip", "/out", ErrNotFound},
+		{"folder", "/in", "/out", ErrNotFound},
+		{"unknown format", "/in/note.txt", "/out", ErrUnsupportedArchive},
+		{"target is a file", "/in/files.zip", "/in/note.txt", ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.svc.Start(ExtractRequest{
+				UserID:  1,
+				Archive: vo.NewCloudPath(tt.archive),
+				Target:  vo.NewCloudPath(tt.target),
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultExtractTarget(t *testing.T) {
+	tests := map[string]string{
+		"/a/photos.zip":    "/a/photos",
+		"/a/src.TAR.GZ":    "/a/src",
+		"/backup.tgz":      "/backup",
+		"/a/.zip":          "/a/.zip",
+		"/a/data.tar.part": "/a/data.tar.part",
+	}
+	for in, want := range tests {
+		if got := DefaultExtractTarget(vo.NewCloudPath(in)).String(); got != want {
+			t.Errorf("DefaultExtractTarget(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"os"
+
 	"github.com/pozitronik/tucha/internal/application/port"
 	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/repository"
@@ -15,6 +18,7 @@ type FileService struct {
 	storage  port.ContentStorage
 	quota    *QuotaService
 	versions repository.FileVersionRepository
+	changes  *ChangeService
 }
 
 // NewFileService creates a new FileService.
@@ -34,6 +38,12 @@ func NewFileService(
 	}
 }
 
+// WithChanges records the service's changes to the given change journal.
+func (s *FileService) WithChanges(changes *ChangeService) *FileService {
+	s.changes = changes
+	return s
+}
+
 // Get retrieves a single node by path.
 func (s *FileService) Get(userID int64, path vo.CloudPath) (*entity.Node, error) {
 	return s.nodes.Get(userID, path)
@@ -45,7 +55,8 @@ func (s *FileService) CountChildren(userID int64, path vo.CloudPath) (folders, f
 }
 
 // AddByHash registers a file by its content hash (deduplication endpoint).
-// Returns the created node or an error.
+// Returns the created node, whose path differs from the requested one when
+// the conflict mode renamed it, or an error.
 func (s *FileService) AddByHash(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64, conflict vo.ConflictMode) (*entity.Node, error) {
 	// Check if content exists in DB or on disk.
 	dbExists, err := s.contents.Exists(hash)
@@ -71,21 +82,23 @@ func (s *FileService) AddByHash(userID int64, path vo.CloudPath, hash vo.Content
 	}
 
 	// Handle conflict.
-	exists, err := s.nodes.Exists(userID, path)
+	path, replace, err := resolveConflict(s.nodes, userID, path, true, conflict)
 	if err != nil {
 		return nil, err
 	}
-	if exists {
-		if conflict == vo.ConflictStrict {
-			return nil, ErrAlreadyExists
-		}
+	var existing *entity.Node
+	if replace {
 		// Delete existing node before creating the replacement.
+		existing, _ = s.nodes.Get(userID, path)
 		if err := s.nodes.Delete(userID, path); err != nil {
 			return nil, err
 		}
+		if existing != nil && existing.HasContent() {
+			s.releaseContent(existing.Hash)
+		}
 	}
 
-	if err := s.nodes.EnsurePath(userID, path.Parent()); err != nil {
+	if err := ensurePath(s.nodes, s.changes, userID, path.Parent()); err != nil {
 		return nil, err
 	}
 
@@ -93,17 +106,36 @@ func (s *FileService) AddByHash(userID int64, path vo.CloudPath, hash vo.Content
 	if err != nil {
 		return nil, err
 	}
+	// A new revision of a file keeps its star and tags.
+	if existing != nil {
+		if err := keepLabels(s.nodes, existing, node); err != nil {
+			return nil, err
+		}
+	}
 
-	// Record version entry; errors are silently ignored.
-	if s.versions != nil {
-		_ = s.versions.Insert(&entity.FileVersion{
-			UserID: userID,
-			Home:   node.Home,
-			Name:   node.Name,
-			Hash:   node.Hash,
-			Size:   node.Size,
-			Rev:    node.Rev,
-		})
+	kind := vo.ChangeCreate
+	if replace {
+		kind = vo.ChangeModify
+	}
+	s.changes.RecordNode(kind, node, "")
+
+	// Record version entry with its own content reference, so the content
+	// outlives the node while the history points to it; errors are silently ignored.
+	// Only users with version history can restore versions, so content is not
+	// kept for anyone else.
+	if s.versions != nil && s.keepsVersions(userID) {
+		if _, err := s.contents.Insert(node.Hash, node.Size); err == nil {
+			err = s.versions.Insert(&entity.FileVersion{
+				UserID: userID,
+				Home:   node.Home,
+				Name:   node.Name,
+				Hash:   node.Hash,
+				Size:   node.Size,
+			})
+			if err != nil {
+				s.releaseContent(node.Hash)
+			}
+		}
 	}
 
 	return node, nil
@@ -114,34 +146,112 @@ func (s *FileService) AddByHash(userID int64, path vo.CloudPath, hash vo.Content
 func (s *FileService) Remove(userID int64, path vo.CloudPath) error {
 	node, _ := s.nodes.Get(userID, path)
 	if node != nil && node.HasContent() {
-		deleted, _ := s.contents.Decrement(node.Hash)
-		if deleted {
-			_ = s.storage.Delete(node.Hash)
-		}
+		s.releaseContent(node.Hash)
+	}
+
+	if err := s.nodes.Delete(userID, path); err != nil {
+		return err
 	}
+	s.changes.RecordNode(vo.ChangeDelete, node, "")
+	return nil
+}
+
+// keepsVersions reports whether the user has version history enabled.
+func (s *FileService) keepsVersions(userID int64) bool {
+	user, err := s.quota.users.GetByID(userID)
+	return err == nil && user != nil && user.VersionHistory
+}
 
-	return s.nodes.Delete(userID, path)
+// releaseContent drops a reference to the content, deleting it from disk
+// when nothing refers to it anymore.
+func (s *FileService) releaseContent(hash vo.ContentHash) {
+	deleted, _ := s.contents.Decrement(hash)
+	if deleted {
+		_ = s.storage.Delete(hash)
+	}
 }
 
 // Rename changes the name of a file or folder.
 func (s *FileService) Rename(userID int64, path vo.CloudPath, newName string) (*entity.Node, error) {
-	return s.nodes.Rename(userID, path, newName)
+	node, err := s.nodes.Rename(userID, path, newName)
+	if err != nil {
+		return nil, err
+	}
+	s.changes.RecordNode(vo.ChangeRename, node, path.String())
+	return node, nil
 }
 
 // Move moves a file or folder to a target directory.
-func (s *FileService) Move(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error) {
-	if err := s.nodes.EnsurePath(userID, targetFolder); err != nil {
+// conflict determines how to handle an existing node with the same name there;
+// the returned node carries the final path.
+func (s *FileService) Move(userID int64, srcPath, targetFolder vo.CloudPath, conflict vo.ConflictMode) (*entity.Node, error) {
+	src, err := s.nodes.Get(userID, srcPath)
+	if err != nil {
 		return nil, err
 	}
-	return s.nodes.Move(userID, srcPath, targetFolder)
+	if src == nil {
+		return nil, ErrNotFound
+	}
+	if targetFolder.Join(src.Name) == srcPath {
+		// Already there.
+		return src, nil
+	}
+
+	target, err := s.prepareTarget(userID, targetFolder.Join(src.Name), src.IsFile(), conflict)
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.nodes.Move(userID, srcPath, target)
+	if err != nil {
+		return nil, err
+	}
+	s.changes.RecordNode(vo.ChangeMove, node, srcPath.String())
+	return node, nil
 }
 
 // Copy duplicates a file or folder into a target directory.
-func (s *FileService) Copy(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error) {
-	if err := s.nodes.EnsurePath(userID, targetFolder); err != nil {
+// conflict determines how to handle an existing node with the same name there;
+// the returned node carries the final path.
+func (s *FileService) Copy(userID int64, srcPath, targetFolder vo.CloudPath, conflict vo.ConflictMode) (*entity.Node, error) {
+	src, err := s.nodes.Get(userID, srcPath)
+	if err != nil {
 		return nil, err
 	}
-	return s.nodes.Copy(userID, srcPath, targetFolder)
+	if src == nil {
+		return nil, ErrNotFound
+	}
+	if targetFolder.Join(src.Name) == srcPath && conflict == vo.ConflictReplace {
+		// Replacing a node with its own copy changes nothing.
+		return src, nil
+	}
+
+	target, err := s.prepareTarget(userID, targetFolder.Join(src.Name), src.IsFile(), conflict)
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.nodes.Copy(userID, srcPath, target)
+	if err != nil {
+		return nil, err
+	}
+	s.changes.RecordNode(vo.ChangeCreate, node, "")
+	return node, nil
+}
+
+// prepareTarget makes path ready to receive a moved or copied node: it
+// creates the parent folders and resolves a name conflict, removing the node
+// that gets replaced. Returns the path the node goes to.
+func (s *FileService) prepareTarget(userID int64, path vo.CloudPath, isFile bool, conflict vo.ConflictMode) (vo.CloudPath, error) {
+	if err := ensurePath(s.nodes, s.changes, userID, path.Parent()); err != nil {
+		return vo.CloudPath{}, err
+	}
+	path, replace, err := resolveConflict(s.nodes, userID, path, isFile, conflict)
+	if err != nil || !replace {
+		return path, err
+	}
+	if err := s.Remove(userID, path); err != nil {
+		return vo.CloudPath{}, err
+	}
+	return path, nil
 }
 
 // History returns the version history for a file at the given path.
@@ -164,3 +274,44 @@ func (s *FileService) History(userID int64, path vo.CloudPath, versionHistory bo
 
 	return versions, nil
 }
+
+// Version returns the entry with the given revision of a file's history.
+// Returns ErrNotFound if there is none.
+func (s *FileService) Version(userID int64, path vo.CloudPath, rev int64) (*entity.FileVersion, error) {
+	version, err := s.versions.GetByRev(userID, path, rev)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, ErrNotFound
+	}
+	return version, nil
+}
+
+// RestoreVersion makes the given revision of a file current, recreating the
+// file if it was deleted. The restored content becomes the newest revision.
+// Returns ErrContentNotFound if the revision's content is gone.
+func (s *FileService) RestoreVersion(userID int64, path vo.CloudPath, rev int64) (*entity.Node, error) {
+	version, err := s.Version(userID, path, rev)
+	if err != nil {
+		return nil, err
+	}
+	return s.AddByHash(userID, path, version.Hash, version.Size, vo.ConflictReplace)
+}
+
+// OpenVersion opens the content of the given revision of a file.
+// Returns ErrContentNotFound if the revision's content is gone.
+func (s *FileService) OpenVersion(userID int64, path vo.CloudPath, rev int64) (*entity.FileVersion, *os.File, error) {
+	version, err := s.Version(userID, path, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := s.storage.Open(version.Hash)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrContentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return version, f, nil
+}
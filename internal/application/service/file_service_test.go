@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"os"
 	"testing"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
@@ -159,6 +160,166 @@ func TestFileService_AddByHash_conflictReplace(t *testing.T) {
 	}
 }
 
+func TestFileService_AddByHash_conflictReplaceKeepsLabels(t *testing.T) {
+	existing := mock.NewTestFileNode(1, "/plan.txt", mock.ValidHash(), 10)
+	existing.Starred = true
+	existing.Tags = []vo.Tag{"work"}
+	var starred bool
+	var tags []vo.Tag
+
+	svc := newFileServiceWithDefaults(
+		&mock.NodeRepositoryMock{
+			GetFunc:       func(userID int64, path vo.CloudPath) (*entity.Node, error) { return existing, nil },
+			ExistsFunc:    func(userID int64, path vo.CloudPath) (bool, error) { return true, nil },
+			TotalSizeFunc: func(userID int64) (int64, error) { return 0, nil },
+			SetStarredFunc: func(userID int64, path vo.CloudPath, s bool) error {
+				starred = s
+				return nil
+			},
+			SetTagsFunc: func(userID int64, path vo.CloudPath, t []vo.Tag) error {
+				tags = t
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			ExistsFunc: func(h vo.ContentHash) (bool, error) { return true, nil },
+		},
+		&mock.ContentStorageMock{},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1073741824}, nil
+			},
+		},
+	)
+
+	node, err := svc.AddByHash(1, vo.NewCloudPath("/plan.txt"), mock.ValidHash(), 20, vo.ConflictReplace)
+	if err != nil {
+		t.Fatalf("AddByHash(replace): %v", err)
+	}
+	if !starred || len(tags) != 1 || tags[0] != "work" {
+		t.Errorf("stored starred %v, tags %v, want the replaced file's labels", starred, tags)
+	}
+	if !node.Starred || len(node.Tags) != 1 {
+		t.Errorf("node starred %v, tags %v, want the replaced file's labels", node.Starred, node.Tags)
+	}
+}
+
+func TestFileService_AddByHash_conflictRename(t *testing.T) {
+	hash := mock.ValidHash()
+	taken := map[string]bool{"/report.docx": true, "/report (1).docx": true}
+
+	svc := newFileServiceWithDefaults(
+		&mock.NodeRepositoryMock{
+			ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return taken[path.String()], nil },
+			DeleteFunc: func(userID int64, path vo.CloudPath) error {
+				t.Errorf("Delete(%s) called, want both files kept", path)
+				return nil
+			},
+			TotalSizeFunc: func(userID int64) (int64, error) { return 0, nil },
+		},
+		&mock.ContentRepositoryMock{
+			ExistsFunc: func(h vo.ContentHash) (bool, error) { return true, nil },
+		},
+		&mock.ContentStorageMock{},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1073741824}, nil
+			},
+		},
+	)
+
+	node, err := svc.AddByHash(1, vo.NewCloudPath("/report.docx"), hash, 100, vo.ConflictRename)
+	if err != nil {
+		t.Fatalf("AddByHash(rename): %v", err)
+	}
+	if node.Home.String() != "/report (2).docx" {
+		t.Errorf("AddByHash(rename) home = %q, want %q", node.Home, "/report (2).docx")
+	}
+}
+
+func TestFileService_Move_conflict(t *testing.T) {
+	src := mock.NewTestFileNode(1, "/a/report.docx", mock.ValidHash(), 100)
+	tests := []struct {
+		conflict vo.ConflictMode
+		wantDst  string
+		wantErr  error
+	}{
+		{vo.ConflictStrict, "", ErrAlreadyExists},
+		{vo.ConflictRename, "/b/report (1).docx", nil},
+		{vo.ConflictReplace, "/b/report.docx", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.conflict.String(), func(t *testing.T) {
+			var moved, deleted string
+			nodes := &mock.NodeRepositoryMock{
+				GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+					if path.String() == src.Home.String() {
+						return src, nil
+					}
+					return nil, nil
+				},
+				ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) {
+					return path.String() == "/b" || path.String() == "/b/report.docx", nil
+				},
+				DeleteFunc: func(userID int64, path vo.CloudPath) error {
+					deleted = path.String()
+					return nil
+				},
+				MoveFunc: func(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error) {
+					moved = dstPath.String()
+					return mock.NewTestFileNode(userID, dstPath.String(), src.Hash, src.Size), nil
+				},
+			}
+			svc := newFileServiceWithDefaults(nodes, &mock.ContentRepositoryMock{}, &mock.ContentStorageMock{}, &mock.UserRepositoryMock{})
+
+			node, err := svc.Move(1, src.Home, vo.NewCloudPath("/b"), tt.conflict)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Move() error = %v, want %v", err, tt.wantErr)
+			}
+			if moved != tt.wantDst {
+				t.Errorf("moved to %q, want %q", moved, tt.wantDst)
+			}
+			if err == nil && node.Home.String() != tt.wantDst {
+				t.Errorf("Move() home = %q, want %q", node.Home, tt.wantDst)
+			}
+			if replaced := deleted != ""; replaced != (tt.conflict == vo.ConflictReplace) {
+				t.Errorf("deleted %q with conflict %s", deleted, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestFileService_Copy_sameFolderRenames(t *testing.T) {
+	src := mock.NewTestNode(1, "/photos", vo.NodeTypeFolder)
+	var copied string
+	nodes := &mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			if path.String() == "/photos" {
+				return src, nil
+			}
+			return nil, nil
+		},
+		ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return path.String() == "/photos", nil },
+		CopyFunc: func(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error) {
+			copied = dstPath.String()
+			return mock.NewTestNode(userID, dstPath.String(), vo.NodeTypeFolder), nil
+		},
+	}
+	svc := newFileServiceWithDefaults(nodes, &mock.ContentRepositoryMock{}, &mock.ContentStorageMock{}, &mock.UserRepositoryMock{})
+
+	node, err := svc.Copy(1, src.Home, vo.NewCloudPath("/"), vo.ConflictRename)
+	if err != nil {
+		t.Fatalf("Copy(): %v", err)
+	}
+	if copied != "/photos (1)" || node.Home.String() != "/photos (1)" {
+		t.Errorf("copied to %q, returned %q, want /photos (1)", copied, node.Home)
+	}
+
+	if _, err := svc.Copy(1, vo.NewCloudPath("/missing"), vo.NewCloudPath("/"), vo.ConflictRename); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Copy(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
 func TestFileService_Remove_fileWithContent(t *testing.T) {
 	hash := mock.ValidHash()
 	node := mock.NewTestFileNode(1, "/file.txt", hash, 100)
@@ -272,7 +433,7 @@ func TestFileService_AddByHash_recordsVersion(t *testing.T) {
 		&mock.ContentStorageMock{},
 		&mock.UserRepositoryMock{
 			GetByIDFunc: func(id int64) (*entity.User, error) {
-				return &entity.User{ID: 1, QuotaBytes: 1073741824}, nil
+				return &entity.User{ID: 1, QuotaBytes: 1073741824, VersionHistory: true}, nil
 			},
 		},
 		&mock.FileVersionRepositoryMock{
@@ -327,7 +488,7 @@ func TestFileService_AddByHash_twoCallsAccumulateVersions(t *testing.T) {
 		&mock.ContentStorageMock{},
 		&mock.UserRepositoryMock{
 			GetByIDFunc: func(id int64) (*entity.User, error) {
-				return &entity.User{ID: 1, QuotaBytes: 1073741824}, nil
+				return &entity.User{ID: 1, QuotaBytes: 1073741824, VersionHistory: true}, nil
 			},
 		},
 		&mock.FileVersionRepositoryMock{
@@ -494,3 +655,194 @@ func TestFileService_History_empty(t *testing.T) {
 		t.Errorf("len(versions) = %d, want 0", len(versions))
 	}
 }
+
+// refCounter returns a ContentRepositoryMock that tracks reference counts per hash.
+func refCounter(refs map[vo.ContentHash]int) *mock.ContentRepositoryMock {
+	return &mock.ContentRepositoryMock{
+		ExistsFunc: func(h vo.ContentHash) (bool, error) { return refs[h] > 0, nil },
+		InsertFunc: func(h vo.ContentHash, size int64) (bool, error) {
+			refs[h]++
+			return refs[h] == 1, nil
+		},
+		DecrementFunc: func(h vo.ContentHash) (bool, error) {
+			refs[h]--
+			return refs[h] == 0, nil
+		},
+	}
+}
+
+func TestFileService_AddByHash_versionsHoldContent(t *testing.T) {
+	hash1 := mock.ValidHash()
+	hash2, _ := vo.NewContentHash("BBBB000000000000000000000000000000000002")
+	refs := map[vo.ContentHash]int{hash1: 1, hash2: 1} // Uploads hold one reference each.
+	var current *entity.Node
+	var deletedFromDisk []vo.ContentHash
+
+	svc := newFileServiceWithVersions(
+		&mock.NodeRepositoryMock{
+			ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return current != nil, nil },
+			GetFunc:    func(userID int64, path vo.CloudPath) (*entity.Node, error) { return current, nil },
+			DeleteFunc: func(userID int64, path vo.CloudPath) error {
+				current = nil
+				return nil
+			},
+			CreateFileFunc: func(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error) {
+				current = mock.NewTestFileNode(userID, path.String(), hash, size)
+				return current, nil
+			},
+		},
+		refCounter(refs),
+		&mock.ContentStorageMock{
+			DeleteFunc: func(h vo.ContentHash) error {
+				deletedFromDisk = append(deletedFromDisk, h)
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1073741824, VersionHistory: true}, nil
+			},
+		},
+		&mock.FileVersionRepositoryMock{},
+	)
+	path := vo.NewCloudPath("/file.bin")
+
+	if _, err := svc.AddByHash(1, path, hash1, 100, vo.ConflictReplace); err != nil {
+		t.Fatalf("AddByHash (1st): %v", err)
+	}
+	if _, err := svc.AddByHash(1, path, hash2, 200, vo.ConflictReplace); err != nil {
+		t.Fatalf("AddByHash (2nd): %v", err)
+	}
+	if err := svc.Remove(1, path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	// The upload references are still held; each version holds one more.
+	if refs[hash1] != 2 || refs[hash2] != 2 {
+		t.Errorf("refs = %v, want 2 for each hash", refs)
+	}
+	if len(deletedFromDisk) != 0 {
+		t.Errorf("deleted from disk: %v, want nothing while versions refer to it", deletedFromDisk)
+	}
+}
+
+func TestFileService_AddByHash_versionInsertFailureReleasesContent(t *testing.T) {
+	hash := mock.ValidHash()
+	refs := map[vo.ContentHash]int{hash: 1}
+
+	svc := newFileServiceWithVersions(
+		&mock.NodeRepositoryMock{},
+		refCounter(refs),
+		&mock.ContentStorageMock{},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1073741824, VersionHistory: true}, nil
+			},
+		},
+		&mock.FileVersionRepositoryMock{
+			InsertFunc: func(version *entity.FileVersion) error { return errors.New("db write error") },
+		},
+	)
+
+	if _, err := svc.AddByHash(1, vo.NewCloudPath("/file.txt"), hash, 100, vo.ConflictRename); err != nil {
+		t.Fatalf("AddByHash: %v", err)
+	}
+	if refs[hash] != 2 {
+		t.Errorf("refs = %d, want 2 (upload and node, no version)", refs[hash])
+	}
+}
+
+func TestFileService_AddByHash_noVersionsWithoutHistory(t *testing.T) {
+	hash := mock.ValidHash()
+	refs := map[vo.ContentHash]int{hash: 1}
+
+	svc := newFileServiceWithVersions(
+		&mock.NodeRepositoryMock{},
+		refCounter(refs),
+		&mock.ContentStorageMock{},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1073741824}, nil
+			},
+		},
+		&mock.FileVersionRepositoryMock{
+			InsertFunc: func(version *entity.FileVersion) error {
+				t.Error("version recorded for a user without version history")
+				return nil
+			},
+		},
+	)
+
+	if _, err := svc.AddByHash(1, vo.NewCloudPath("/file.txt"), hash, 100, vo.ConflictRename); err != nil {
+		t.Fatalf("AddByHash: %v", err)
+	}
+	if refs[hash] != 2 {
+		t.Errorf("refs = %d, want 2 (upload and node, no version)", refs[hash])
+	}
+}
+
+func TestFileService_RestoreVersion(t *testing.T) {
+	oldHash, _ := vo.NewContentHash("AAAA000000000000000000000000000000000001")
+	path := vo.NewCloudPath("/doc.txt")
+	var created vo.ContentHash
+
+	versions := &mock.FileVersionRepositoryMock{
+		GetByRevFunc: func(userID int64, p vo.CloudPath, rev int64) (*entity.FileVersion, error) {
+			if rev == 1 {
+				return &entity.FileVersion{UserID: userID, Home: p, Name: "doc.txt", Hash: oldHash, Size: 10, Rev: 1}, nil
+			}
+			return nil, nil
+		},
+	}
+	svc := newFileServiceWithVersions(
+		&mock.NodeRepositoryMock{
+			ExistsFunc: func(userID int64, p vo.CloudPath) (bool, error) { return true, nil },
+			CreateFileFunc: func(userID int64, p vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error) {
+				created = hash
+				return mock.NewTestFileNode(userID, p.String(), hash, size), nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			ExistsFunc: func(h vo.ContentHash) (bool, error) { return true, nil },
+		},
+		&mock.ContentStorageMock{},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1073741824}, nil
+			},
+		},
+		versions,
+	)
+
+	node, err := svc.RestoreVersion(1, path, 1)
+	if err != nil {
+		t.Fatalf("RestoreVersion: %v", err)
+	}
+	if created != oldHash || node.Hash != oldHash {
+		t.Errorf("restored hash = %v, want %v", created, oldHash)
+	}
+
+	if _, err := svc.RestoreVersion(1, path, 7); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreVersion(unknown rev) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileService_OpenVersion_contentGone(t *testing.T) {
+	svc := newFileServiceWithVersions(
+		&mock.NodeRepositoryMock{},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{
+			OpenFunc: func(hash vo.ContentHash) (*os.File, error) { return nil, os.ErrNotExist },
+		},
+		&mock.UserRepositoryMock{},
+		&mock.FileVersionRepositoryMock{
+			GetByRevFunc: func(userID int64, p vo.CloudPath, rev int64) (*entity.FileVersion, error) {
+				return &entity.FileVersion{Home: p, Hash: mock.ValidHash(), Rev: rev}, nil
+			},
+		},
+	)
+
+	if _, _, err := svc.OpenVersion(1, vo.NewCloudPath("/doc.txt"), 1); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("OpenVersion error = %v, want ErrContentNotFound", err)
+	}
+}
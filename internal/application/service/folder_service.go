@@ -8,7 +8,8 @@ import (
 
 // FolderService handles folder listing and creation.
 type FolderService struct {
-	nodes repository.NodeRepository
+	nodes   repository.NodeRepository
+	changes *ChangeService
 }
 
 // NewFolderService creates a new FolderService.
@@ -16,6 +17,12 @@ func NewFolderService(nodes repository.NodeRepository) *FolderService {
 	return &FolderService{nodes: nodes}
 }
 
+// WithChanges records the service's changes to the given change journal.
+func (s *FolderService) WithChanges(changes *ChangeService) *FolderService {
+	s.changes = changes
+	return s
+}
+
 // Get retrieves a single node by path.
 // Returns nil, nil if not found.
 func (s *FolderService) Get(userID int64, path vo.CloudPath) (*entity.Node, error) {
@@ -42,8 +49,34 @@ func (s *FolderService) CreateFolder(userID int64, path vo.CloudPath) (*entity.N
 	if exists {
 		return nil, ErrAlreadyExists
 	}
-	if err := s.nodes.EnsurePath(userID, path.Parent()); err != nil {
+	if err := ensurePath(s.nodes, s.changes, userID, path.Parent()); err != nil {
 		return nil, err
 	}
-	return s.nodes.CreateFolder(userID, path)
+	node, err := s.nodes.CreateFolder(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	s.changes.RecordNode(vo.ChangeCreate, node, "")
+	return node, nil
+}
+
+// ListTree returns all descendants of the given folder, at any depth.
+// Returns ErrNotFound if the path does not exist or is not a folder.
+func (s *FolderService) ListTree(userID int64, path vo.CloudPath) ([]entity.Node, error) {
+	node, descendants, err := s.nodes.GetWithDescendants(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || !node.IsFolder() {
+		return nil, ErrNotFound
+	}
+
+	// The repository matches descendants with LIKE, which also treats "_" and "%" in names as wildcards.
+	tree := make([]entity.Node, 0, len(descendants))
+	for _, n := range descendants {
+		if path.IsRoot() || n.Home.HasPrefix(path) {
+			tree = append(tree, n)
+		}
+	}
+	return tree, nil
 }
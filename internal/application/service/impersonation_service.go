@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+const (
+	// DefaultImpersonationTTL is the support token lifetime when none is requested (15 minutes).
+	DefaultImpersonationTTL = 15 * 60
+	// MaxImpersonationTTL caps the support token lifetime (1 hour).
+	MaxImpersonationTTL = 60 * 60
+)
+
+// ImpersonationService lets an admin act as a user for support purposes.
+// Every support token is recorded in the audit log when it is issued.
+type ImpersonationService struct {
+	tokens repository.TokenRepository
+	users  repository.UserRepository
+	audit  port.Logger
+}
+
+// NewImpersonationService creates a new ImpersonationService.
+func NewImpersonationService(tokens repository.TokenRepository, users repository.UserRepository, audit port.Logger) *ImpersonationService {
+	return &ImpersonationService{tokens: tokens, users: users, audit: audit}
+}
+
+// Start issues a short-lived support token for the user on behalf of impersonator.
+// The token is read-only unless elevated. A ttlSeconds of 0 uses
+// DefaultImpersonationTTL; longer lifetimes are capped at MaxImpersonationTTL.
+// Returns ErrNotFound if the user does not exist.
+func (s *ImpersonationService) Start(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("looking up user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	if ttlSeconds <= 0 {
+		ttlSeconds = DefaultImpersonationTTL
+	}
+	if ttlSeconds > MaxImpersonationTTL {
+		ttlSeconds = MaxImpersonationTTL
+	}
+
+	token, err := s.tokens.CreateImpersonation(userID, impersonator, elevated, ttlSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Warn("AUDIT impersonation started: admin=%q user=%q token_id=%d elevated=%v expires_at=%d",
+		impersonator, user.Email, token.ID, elevated, token.ExpiresAt)
+	return token, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestImpersonationService_Start(t *testing.T) {
+	var gotTTL int
+	var gotElevated bool
+	audit := &mock.LoggerMock{}
+	svc := NewImpersonationService(
+		&mock.TokenRepositoryMock{
+			CreateImpersonationFunc: func(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error) {
+				gotTTL, gotElevated = ttlSeconds, elevated
+				return &entity.Token{ID: 9, UserID: userID, Personal: true, Impersonator: impersonator, Elevated: elevated}, nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1, Email: "user@example.com"}, nil
+				}
+				return nil, nil
+			},
+		},
+		audit,
+	)
+
+	tests := []struct {
+		name     string
+		ttl      int
+		elevated bool
+		wantTTL  int
+	}{
+		{"default lifetime", 0, false, DefaultImpersonationTTL},
+		{"custom lifetime", 120, false, 120},
+		{"capped lifetime", 24 * 3600, true, MaxImpersonationTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit.Captured = nil
+			token, err := svc.Start(1, "admin", tt.elevated, tt.ttl)
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			if token.Impersonator != "admin" || gotElevated != tt.elevated || gotTTL != tt.wantTTL {
+				t.Errorf("token = %+v, ttl = %d, want ttl %d", token, gotTTL, tt.wantTTL)
+			}
+			if len(audit.Captured) != 1 || audit.Captured[0].Level != "WARN" {
+				t.Errorf("audit entries = %+v, want one WARN", audit.Captured)
+			}
+		})
+	}
+
+	t.Run("unknown user", func(t *testing.T) {
+		if _, err := svc.Start(2, "admin", false, 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("error = %v, want ErrNotFound", err)
+		}
+	})
+}
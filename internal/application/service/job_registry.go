@@ -0,0 +1,127 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// JobState is the lifecycle state of a background job.
+type JobState string
+
+const (
+	// JobRunning means the job is still in progress.
+	JobRunning JobState = "running"
+	// JobDone means the job finished successfully.
+	JobDone JobState = "done"
+	// JobFailed means the job stopped with an error.
+	JobFailed JobState = "failed"
+)
+
+// jobRetention is how long finished jobs stay available for status queries.
+const jobRetention = time.Hour
+
+// Job reports the progress of a background operation started by a user.
+type Job struct {
+	ID       string
+	UserID   int64
+	Kind     string
+	State    JobState
+	Target   string // Path the job writes to.
+	Total    int    // Number of items to process, or 0 if not known in advance.
+	Done     int    // Number of items processed so far.
+	Bytes    int64  // Bytes written so far.
+	Error    string // Reason of a failure.
+	Started  int64
+	Finished int64
+}
+
+// JobRegistry keeps background jobs in memory. Jobs are lost on restart,
+// and finished jobs are dropped an hour after they end.
+type JobRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	now  func() time.Time
+}
+
+// NewJobRegistry creates an empty JobRegistry.
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{jobs: make(map[string]*Job), now: time.Now}
+}
+
+// Start registers a running job of the given kind for the user.
+// A user runs one job of each kind at a time.
+// Returns a snapshot of the new job, or ErrBusy if one is already running.
+func (r *JobRegistry) Start(userID int64, kind, target string) (Job, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return Job{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+
+	for _, job := range r.jobs {
+		if job.UserID == userID && job.Kind == kind && job.State == JobRunning {
+			return Job{}, ErrBusy
+		}
+	}
+
+	job := &Job{
+		ID:      id,
+		UserID:  userID,
+		Kind:    kind,
+		State:   JobRunning,
+		Target:  target,
+		Started: r.now().Unix(),
+	}
+	r.jobs[id] = job
+	return *job, nil
+}
+
+// Update applies fn to a running job.
+func (r *JobRegistry) Update(id string, fn func(job *Job)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok && job.State == JobRunning {
+		fn(job)
+	}
+}
+
+// Finish marks a job as done, or as failed if err is not nil.
+func (r *JobRegistry) Finish(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+	job.State = JobDone
+	if err != nil {
+		job.State = JobFailed
+		job.Error = err.Error()
+	}
+	job.Finished = r.now().Unix()
+}
+
+// Get returns a snapshot of the user's job.
+// Returns ErrNotFound if there is no such job or it belongs to another user.
+func (r *JobRegistry) Get(userID int64, id string) (Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.UserID != userID {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// prune drops jobs that finished more than jobRetention ago. Callers hold mu.
+func (r *JobRegistry) prune() {
+	cutoff := r.now().Add(-jobRetention).Unix()
+	for id, job := range r.jobs {
+		if job.State != JobRunning && job.Finished < cutoff {
+			delete(r.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobRegistry_lifecycle(t *testing.T) {
+	r := NewJobRegistry()
+
+	job, err := r.Start(1, "extract", "/out")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if job.State != JobRunning {
+		t.Errorf("State = %s, want running", job.State)
+	}
+
+	r.Update(job.ID, func(j *Job) { j.Done = 3 })
+	r.Finish(job.ID, errors.New("boom"))
+	r.Update(job.ID, func(j *Job) { j.Done = 4 })
+
+	got, err := r.Get(1, job.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.State != JobFailed || got.Error != "boom" || got.Done != 3 {
+		t.Errorf("job = %+v, want failed with boom after 3 items", got)
+	}
+	if _, err := r.Get(2, job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(other user) err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestJobRegistry_oneRunningJobPerKind(t *testing.T) {
+	r := NewJobRegistry()
+
+	job, _ := r.Start(1, "extract", "/a")
+	if _, err := r.Start(1, "extract", "/b"); !errors.Is(err, ErrBusy) {
+		t.Errorf("second Start err = %v, want ErrBusy", err)
+	}
+	if _, err := r.Start(2, "extract", "/b"); err != nil {
+		t.Errorf("Start(other user): %v", err)
+	}
+
+	r.Finish(job.ID, nil)
+	if _, err := r.Start(1, "extract", "/b"); err != nil {
+		t.Errorf("Start after finish: %v", err)
+	}
+}
+
+func TestJobRegistry_prunesFinishedJobs(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := NewJobRegistry()
+	r.now = func() time.Time { return now }
+
+	job, _ := r.Start(1, "extract", "/a")
+	r.Finish(job.ID, nil)
+
+	now = now.Add(jobRetention + time.Minute)
+	_, _ = r.Start(1, "extract", "/b")
+
+	if _, err := r.Get(1, job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(expired) err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+// mediaIndexBatch is how many contents one metadata extraction pass takes at a time.
+const mediaIndexBatch = 50
+
+// MediaIndexer extracts the metadata of photos in the background, such as
+// their capture time, camera and location. Each content hash is read once,
+// however many files hold it.
+type MediaIndexer struct {
+	media     repository.MediaRepository
+	storage   port.ContentStorage
+	extractor port.MediaExtractor
+	logger    port.Logger
+}
+
+// NewMediaIndexer creates a MediaIndexer.
+func NewMediaIndexer(
+	media repository.MediaRepository,
+	storage port.ContentStorage,
+	extractor port.MediaExtractor,
+	logger port.Logger,
+) *MediaIndexer {
+	return &MediaIndexer{
+		media:     media,
+		storage:   storage,
+		extractor: extractor,
+		logger:    logger,
+	}
+}
+
+// Run extracts the metadata of new content every interval, and drops the
+// metadata of deleted content. It never returns.
+func (x *MediaIndexer) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		scanned := 0
+		for {
+			n, err := x.Index(mediaIndexBatch)
+			scanned += n
+			if err != nil {
+				x.logger.Error("Media metadata extraction failed: %v", err)
+				break
+			}
+			if n < mediaIndexBatch {
+				break
+			}
+		}
+		if scanned > 0 {
+			x.logger.Info("Media metadata extraction scanned %d files", scanned)
+		}
+
+		pruned, err := x.media.Prune()
+		if err != nil {
+			x.logger.Error("Media metadata pruning failed: %v", err)
+		}
+		if pruned > 0 {
+			x.logger.Info("Media metadata pruning removed %d entries", pruned)
+		}
+	}
+}
+
+// Index extracts the metadata of up to limit of the contents not scanned yet.
+// Content the extractor does not support or fails to read is recorded as not
+// being a photo, so it is not tried again. Returns the number of scanned contents.
+func (x *MediaIndexer) Index(limit int) (int, error) {
+	contents, err := x.media.ListUnscanned(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, c := range contents {
+		info, err := x.extract(&c)
+		if err != nil {
+			return i, err
+		}
+		if err := x.media.Store(c.Hash, info); err != nil {
+			return i, err
+		}
+	}
+	return len(contents), nil
+}
+
+// extract returns the metadata of the content, or nil if it is not a photo.
+// Only storage errors other than missing content are returned.
+func (x *MediaIndexer) extract(c *repository.UnindexedContent) (*entity.MediaInfo, error) {
+	if !x.extractor.Supports(c.Name) {
+		return nil, nil
+	}
+
+	f, err := x.storage.Open(c.Hash)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := x.extractor.Extract(f, c.Size)
+	if err != nil {
+		x.logger.Warn("Extracting metadata of %s (%s) failed: %v", c.Hash.String(), c.Name, err)
+		return nil, nil
+	}
+	info.Hash = c.Hash
+	return info, nil
+}
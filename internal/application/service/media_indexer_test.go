@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestMediaIndexer_Index(t *testing.T) {
+	dir := t.TempDir()
+	content := func(n int) vo.ContentHash {
+		return vo.MustContentHash(strings.Repeat("0", 39) + string(rune('0'+n)))
+	}
+	for n, data := range map[int]string{1: "photo", 3: "broken"} {
+		if err := os.WriteFile(filepath.Join(dir, content(n).String()), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storage := &mock.ContentStorageMock{
+		OpenFunc: func(hash vo.ContentHash) (*os.File, error) {
+			return os.Open(filepath.Join(dir, hash.String()))
+		},
+	}
+
+	stored := map[vo.ContentHash]*entity.MediaInfo{}
+	media := &mock.MediaRepositoryMock{
+		ListUnscannedFunc: func(limit int) ([]repository.UnindexedContent, error) {
+			return []repository.UnindexedContent{
+				{Hash: content(1), Name: "beach.jpg", Size: 5},
+				{Hash: content(2), Name: "notes.txt", Size: 10},
+				{Hash: content(3), Name: "broken.jpg", Size: 6},
+				{Hash: content(4), Name: "missing.jpg", Size: 7},
+			}, nil
+		},
+		StoreFunc: func(hash vo.ContentHash, info *entity.MediaInfo) error {
+			stored[hash] = info
+			return nil
+		},
+	}
+	extractor := &mock.MediaExtractorMock{
+		SupportsFunc: func(name string) bool { return strings.HasSuffix(name, ".jpg") },
+		ExtractFunc: func(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+			data, _ := io.ReadAll(io.NewSectionReader(r, 0, size))
+			if string(data) == "broken" {
+				return nil, errors.New("corrupt")
+			}
+			return &entity.MediaInfo{Width: 4, Height: 3, Model: "EOS R6"}, nil
+		},
+	}
+	logger := &mock.LoggerMock{}
+	indexer := NewMediaIndexer(media, storage, extractor, logger)
+
+	n, err := indexer.Index(10)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if n != 4 || len(stored) != 4 {
+		t.Fatalf("scanned %d, stored %v, want all 4 contents", n, stored)
+	}
+	if info := stored[content(1)]; info == nil || info.Model != "EOS R6" || info.Hash != content(1) {
+		t.Errorf("metadata of beach.jpg = %+v, want the extracted metadata", info)
+	}
+	for i := 2; i <= 4; i++ {
+		if info := stored[content(i)]; info != nil {
+			t.Errorf("metadata of content %d = %+v, want it recorded as not a photo", i, info)
+		}
+	}
+	if len(logger.Captured) != 1 || logger.Captured[0].Level != "WARN" {
+		t.Errorf("logged %v, want one warning about the failed extraction", logger.Captured)
+	}
+}
+
+func TestMediaIndexer_Index_storageError(t *testing.T) {
+	media := &mock.MediaRepositoryMock{
+		ListUnscannedFunc: func(limit int) ([]repository.UnindexedContent, error) {
+			return []repository.UnindexedContent{{Hash: vo.MustContentHash(strings.Repeat("A", 40)), Name: "a.jpg", Size: 1}}, nil
+		},
+		StoreFunc: func(hash vo.ContentHash, info *entity.MediaInfo) error {
+			t.Error("content stored although it could not be read")
+			return nil
+		},
+	}
+	storage := &mock.ContentStorageMock{
+		OpenFunc: func(hash vo.ContentHash) (*os.File, error) { return nil, os.ErrPermission },
+	}
+	indexer := NewMediaIndexer(media, storage, &mock.MediaExtractorMock{}, &mock.LoggerMock{})
+
+	if n, err := indexer.Index(10); !errors.Is(err, os.ErrPermission) || n != 0 {
+		t.Errorf("Index = %d, %v, want 0 and the storage error to retry later", n, err)
+	}
+}
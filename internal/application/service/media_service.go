@@ -0,0 +1,80 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// PhotoDay holds the photos of one day of a timeline.
+type PhotoDay struct {
+	Date   string // Capture date, as YYYY-MM-DD.
+	Photos []repository.Photo
+}
+
+// MediaService provides the metadata extracted from photos by the
+// MediaIndexer, and a timeline of a user's photos across folders.
+type MediaService struct {
+	nodes repository.NodeRepository
+	media repository.MediaRepository
+}
+
+// NewMediaService creates a new MediaService.
+func NewMediaService(nodes repository.NodeRepository, media repository.MediaRepository) *MediaService {
+	return &MediaService{
+		nodes: nodes,
+		media: media,
+	}
+}
+
+// Info returns the metadata of the file at the given path, or nil if it is
+// not a photo or has not been scanned yet.
+// Returns ErrNotFound if the file does not exist.
+func (s *MediaService) Info(userID int64, path vo.CloudPath) (*entity.MediaInfo, error) {
+	node, err := s.nodes.Get(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || !node.IsFile() {
+		return nil, ErrNotFound
+	}
+	if node.Hash.IsZero() {
+		return nil, nil
+	}
+	return s.media.Get(node.Hash)
+}
+
+// Timeline returns a page of the user's photos, newest first and grouped by
+// the day they were taken, and whether more photos follow. Photos without a
+// capture time are placed by their modification time. The last day of a page
+// may continue on the next one.
+func (s *MediaService) Timeline(userID int64, offset, limit int) ([]PhotoDay, bool, error) {
+	photos, err := s.media.Timeline(userID, offset, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	more := len(photos) > limit
+	photos = photos[:min(limit, len(photos))]
+
+	var days []PhotoDay
+	for _, photo := range photos {
+		date := time.Unix(photoTime(&photo), 0).UTC().Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, PhotoDay{Date: date})
+		}
+		day := &days[len(days)-1]
+		day.Photos = append(day.Photos, photo)
+	}
+	return days, more, nil
+}
+
+// photoTime returns when the photo was taken, as recorded by the camera,
+// or its modification time if the camera recorded none.
+func photoTime(photo *repository.Photo) int64 {
+	if photo.Media.TakenAt != 0 {
+		return photo.Media.TakenAt
+	}
+	return photo.Node.MTime
+}
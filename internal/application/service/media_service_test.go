@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestMediaService_Timeline(t *testing.T) {
+	at := func(day, hour int) int64 { return time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC).Unix() }
+	photo := func(path string, taken, mtime int64) repository.Photo {
+		node := mock.NewTestFileNode(1, path, mock.ValidHash(), 10)
+		node.MTime = mtime
+		return repository.Photo{Node: *node, Media: entity.MediaInfo{TakenAt: taken}}
+	}
+	photos := []repository.Photo{
+		photo("/Camera/a.jpg", at(31, 23), 0),
+		photo("/Trip/b.jpg", at(31, 1), 0),
+		photo("/Scans/c.png", 0, at(30, 12)),
+		photo("/Camera/d.jpg", at(2, 8), 0),
+	}
+	var gotLimit int
+	svc := NewMediaService(&mock.NodeRepositoryMock{}, &mock.MediaRepositoryMock{
+		TimelineFunc: func(userID int64, offset, limit int) ([]repository.Photo, error) {
+			gotLimit = limit
+			return photos[offset:min(offset+limit, len(photos))], nil
+		},
+	})
+
+	days, more, err := svc.Timeline(1, 0, 3)
+	if err != nil {
+		t.Fatalf("Timeline: %v", err)
+	}
+	if gotLimit != 4 || !more {
+		t.Errorf("asked for %d photos, more = %v, want 4 and true", gotLimit, more)
+	}
+	if len(days) != 2 || days[0].Date != "2024-05-31" || len(days[0].Photos) != 2 || days[1].Date != "2024-05-30" {
+		t.Fatalf("days = %+v, want two photos on 2024-05-31 and one on 2024-05-30", days)
+	}
+
+	days, more, _ = svc.Timeline(1, 3, 3)
+	if more || len(days) != 1 || days[0].Date != "2024-05-02" {
+		t.Errorf("second page = %+v, more %v", days, more)
+	}
+}
+
+func TestMediaService_Info(t *testing.T) {
+	file := mock.NewTestFileNode(1, "/beach.jpg", mock.ValidHash(), 10)
+	svc := NewMediaService(
+		&mock.NodeRepositoryMock{
+			GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+				switch path.String() {
+				case "/beach.jpg":
+					return file, nil
+				case "/Camera":
+					return mock.NewTestNode(1, "/Camera", vo.NodeTypeFolder), nil
+				}
+				return nil, nil
+			},
+		},
+		&mock.MediaRepositoryMock{
+			GetFunc: func(hash vo.ContentHash) (*entity.MediaInfo, error) {
+				return &entity.MediaInfo{Hash: hash, Model: "EOS R6"}, nil
+			},
+		},
+	)
+
+	if info, err := svc.Info(1, file.Home); err != nil || info == nil || info.Model != "EOS R6" {
+		t.Errorf("Info = %+v, %v, want the metadata of the content", info, err)
+	}
+	for _, path := range []string{"/missing.jpg", "/Camera"} {
+		if _, err := svc.Info(1, vo.NewCloudPath(path)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Info(%s) error = %v, want ErrNotFound", path, err)
+		}
+	}
+}
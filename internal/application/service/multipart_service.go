@@ -0,0 +1,227 @@
+package service
+
+import (
+	"io"
+	"os"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// MultipartService assembles files uploaded in parts, as in S3 multipart uploads.
+// Each part is stored as ordinary content; completing the upload stores the
+// assembled file through UploadService and FileService, so deduplication and
+// quota checks apply as for single uploads. Parts are released afterwards.
+type MultipartService struct {
+	uploads  repository.MultipartRepository
+	contents repository.ContentRepository
+	storage  port.ContentStorage
+	uploader *UploadService
+	files    *FileService
+}
+
+// NewMultipartService creates a new MultipartService.
+func NewMultipartService(
+	uploads repository.MultipartRepository,
+	contents repository.ContentRepository,
+	storage port.ContentStorage,
+	uploader *UploadService,
+	files *FileService,
+) *MultipartService {
+	return &MultipartService{
+		uploads:  uploads,
+		contents: contents,
+		storage:  storage,
+		uploader: uploader,
+		files:    files,
+	}
+}
+
+// Start begins a multipart upload to the given path.
+func (s *MultipartService) Start(userID int64, path vo.CloudPath) (*entity.MultipartUpload, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	u := &entity.MultipartUpload{ID: id, UserID: userID, Home: path}
+	if err := s.uploads.Create(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Get returns an upload owned by the user.
+// Returns ErrNotFound if it does not exist or belongs to another user.
+func (s *MultipartService) Get(userID int64, uploadID string) (*entity.MultipartUpload, error) {
+	u, err := s.uploads.Get(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || u.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// UploadPart stores one part of size bytes read from content.
+// Uploading the same part number again replaces it.
+// Returns ErrNotFound if the upload does not exist or belongs to another user.
+func (s *MultipartService) UploadPart(userID int64, uploadID string, number int, content io.ReaderAt, size int64) (*entity.MultipartPart, error) {
+	if _, err := s.Get(userID, uploadID); err != nil {
+		return nil, err
+	}
+
+	hash, err := s.uploader.UploadFile(content, size)
+	if err != nil {
+		return nil, err
+	}
+
+	part := &entity.MultipartPart{UploadID: uploadID, Number: number, Hash: hash, Size: size}
+	replaced, err := s.uploads.PutPart(part)
+	if err != nil {
+		return nil, err
+	}
+	if replaced != nil {
+		s.release(replaced.Hash)
+	}
+	return part, nil
+}
+
+// Parts lists the uploaded parts ordered by part number.
+// Returns ErrNotFound if the upload does not exist or belongs to another user.
+func (s *MultipartService) Parts(userID int64, uploadID string) ([]entity.MultipartPart, error) {
+	if _, err := s.Get(userID, uploadID); err != nil {
+		return nil, err
+	}
+	parts, err := s.uploads.ListParts(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if parts == nil {
+		parts = []entity.MultipartPart{}
+	}
+	return parts, nil
+}
+
+// Complete concatenates the given parts into the destination file, replacing
+// any existing file, and discards the upload.
+// Part numbers must be uploaded and strictly ascending, otherwise ErrInvalidPart is returned.
+func (s *MultipartService) Complete(userID int64, uploadID string, numbers []int) (*entity.Node, error) {
+	u, err := s.Get(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := s.uploads.ListParts(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(numbers) == 0 {
+		return nil, ErrInvalidPart
+	}
+
+	byNumber := make(map[int]entity.MultipartPart, len(parts))
+	for _, p := range parts {
+		byNumber[p.Number] = p
+	}
+
+	for i, n := range numbers {
+		if _, ok := byNumber[n]; !ok || (i > 0 && n <= numbers[i-1]) {
+			return nil, ErrInvalidPart
+		}
+	}
+
+	hashes := make([]vo.ContentHash, len(numbers))
+	var size int64
+	for i, n := range numbers {
+		hashes[i] = byNumber[n].Hash
+		size += byNumber[n].Size
+	}
+
+	open := func() (io.ReadCloser, error) {
+		return &partsReader{storage: s.storage, hashes: hashes}, nil
+	}
+	hash, err := s.uploader.UploadFrom(open, size)
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.files.AddByHash(u.UserID, u.Home, hash, size, vo.ConflictReplace)
+	if err != nil {
+		return nil, err
+	}
+
+	s.discard(uploadID, parts)
+	return node, nil
+}
+
+// Abort discards an upload and its parts.
+// Returns ErrNotFound if the upload does not exist or belongs to another user.
+func (s *MultipartService) Abort(userID int64, uploadID string) error {
+	if _, err := s.Get(userID, uploadID); err != nil {
+		return err
+	}
+	parts, err := s.uploads.ListParts(uploadID)
+	if err != nil {
+		return err
+	}
+	s.discard(uploadID, parts)
+	return nil
+}
+
+// partsReader reads stored contents one after another, opening each only
+// when the previous one is exhausted.
+type partsReader struct {
+	storage port.ContentStorage
+	hashes  []vo.ContentHash
+	current *os.File
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.hashes) == 0 {
+				return 0, io.EOF
+			}
+			f, err := r.storage.Open(r.hashes[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.hashes = f, r.hashes[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
+
+// discard deletes the upload record and releases the content of its parts.
+// Errors are ignored: leftover parts only cost disk space.
+func (s *MultipartService) discard(uploadID string, parts []entity.MultipartPart) {
+	_ = s.uploads.Delete(uploadID)
+	for _, p := range parts {
+		s.release(p.Hash)
+	}
+}
+
+// release drops one reference to a part's content and removes it from disk when unused.
+func (s *MultipartService) release(hash vo.ContentHash) {
+	deleted, err := s.contents.Decrement(hash)
+	if err == nil && deleted {
+		_ = s.storage.Delete(hash)
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+var (
+	partHash1 = vo.MustContentHash("1111111111111111111111111111111111111111")
+	partHash2 = vo.MustContentHash("2222222222222222222222222222222222222222")
+)
+
+// newMultipartServiceWithDefaults wires a MultipartService whose upload belongs to user 1
+// and targets /docs/report.txt. Part content is read from files in dir named by hash.
+func newMultipartServiceWithDefaults(
+	t *testing.T,
+	uploads *mock.MultipartRepositoryMock,
+	contents *mock.ContentRepositoryMock,
+	hasher *mock.HasherMock,
+	nodes *mock.NodeRepositoryMock,
+) *MultipartService {
+	t.Helper()
+	dir := t.TempDir()
+	if uploads.GetFunc == nil {
+		uploads.GetFunc = func(id string) (*entity.MultipartUpload, error) {
+			if id != "up1" {
+				return nil, nil
+			}
+			return &entity.MultipartUpload{ID: id, UserID: 1, Home: vo.NewCloudPath("/docs/report.txt")}, nil
+		}
+	}
+
+	storage := &mock.ContentStorageMock{
+		WriteFunc: func(h vo.ContentHash, r io.Reader) (int64, error) {
+			data, _ := io.ReadAll(r)
+			return int64(len(data)), os.WriteFile(filepath.Join(dir, h.String()), data, 0o644)
+		},
+		OpenFunc: func(h vo.ContentHash) (*os.File, error) {
+			return os.Open(filepath.Join(dir, h.String()))
+		},
+		ExistsFunc: func(h vo.ContentHash) bool { return true },
+	}
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) { return mock.NewTestUser(id, "u@x.com"), nil },
+	}
+	uploader := NewUploadService(hasher, storage, contents)
+	files := NewFileService(nodes, contents, storage, NewQuotaService(nodes, users), nil)
+	return NewMultipartService(uploads, contents, storage, uploader, files)
+}
+
+func TestMultipartService_Get_otherUser(t *testing.T) {
+	svc := newMultipartServiceWithDefaults(t, &mock.MultipartRepositoryMock{}, &mock.ContentRepositoryMock{}, &mock.HasherMock{}, &mock.NodeRepositoryMock{})
+
+	if _, err := svc.Get(2, "up1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get by another user: error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Get(1, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing upload: error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.UploadPart(2, "up1", 1, strings.NewReader("x"), 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UploadPart by another user: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMultipartService_UploadPart_replaceReleasesOldPart(t *testing.T) {
+	var decremented []vo.ContentHash
+	svc := newMultipartServiceWithDefaults(t,
+		&mock.MultipartRepositoryMock{
+			PutPartFunc: func(p *entity.MultipartPart) (*entity.MultipartPart, error) {
+				return &entity.MultipartPart{UploadID: p.UploadID, Number: p.Number, Hash: partHash1, Size: 3}, nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			DecrementFunc: func(h vo.ContentHash) (bool, error) {
+				decremented = append(decremented, h)
+				return true, nil
+			},
+		},
+		&mock.HasherMock{FixedHash: partHash2},
+		&mock.NodeRepositoryMock{},
+	)
+
+	part, err := svc.UploadPart(1, "up1", 1, strings.NewReader("new"), 3)
+	if err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+	if part.Hash != partHash2 || part.Size != 3 || part.Number != 1 {
+		t.Errorf("part = %+v", part)
+	}
+	if len(decremented) != 1 || decremented[0] != partHash1 {
+		t.Errorf("released = %v, want the replaced part %s", decremented, partHash1)
+	}
+}
+
+func TestMultipartService_Complete(t *testing.T) {
+	final := mock.ValidHash()
+	var assembled string
+	var created vo.CloudPath
+	var deletedUpload string
+	var decremented []vo.ContentHash
+
+	uploads := &mock.MultipartRepositoryMock{
+		ListPartsFunc: func(uploadID string) ([]entity.MultipartPart, error) {
+			return []entity.MultipartPart{
+				{UploadID: uploadID, Number: 1, Hash: partHash1, Size: 6},
+				{UploadID: uploadID, Number: 3, Hash: partHash2, Size: 5},
+			}, nil
+		},
+		DeleteFunc: func(id string) error {
+			deletedUpload = id
+			return nil
+		},
+	}
+	hasher := &mock.HasherMock{
+		ComputeFunc: func(data []byte) vo.ContentHash {
+			switch string(data) {
+			case "hello ":
+				return partHash1
+			case "world":
+				return partHash2
+			}
+			return mock.ValidHash()
+		},
+		ComputeReaderFunc: func(r io.Reader, size int64) (vo.ContentHash, error) {
+			data, _ := io.ReadAll(r)
+			if int64(len(data)) != size {
+				t.Errorf("hashed %d bytes, size %d", len(data), size)
+			}
+			assembled = string(data)
+			return final, nil
+		},
+	}
+	svc := newMultipartServiceWithDefaults(t, uploads,
+		&mock.ContentRepositoryMock{
+			ExistsFunc: func(h vo.ContentHash) (bool, error) { return true, nil },
+			DecrementFunc: func(h vo.ContentHash) (bool, error) {
+				decremented = append(decremented, h)
+				return true, nil
+			},
+		},
+		hasher,
+		&mock.NodeRepositoryMock{
+			CreateFileFunc: func(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error) {
+				created = path
+				return &entity.Node{UserID: userID, Home: path, Name: path.Name(), Type: vo.NodeTypeFile, Hash: hash, Size: size}, nil
+			},
+		},
+	)
+
+	// Store the part content where the storage mock can open it.
+	if _, err := svc.uploader.Upload([]byte("hello ")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.uploader.Upload([]byte("world")); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := svc.Complete(1, "up1", []int{1, 3})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if assembled != "hello world" {
+		t.Errorf("assembled content = %q, want %q", assembled, "hello world")
+	}
+	if created.String() != "/docs/report.txt" || node.Hash != final || node.Size != 11 {
+		t.Errorf("created %s with %+v", created, node)
+	}
+	if deletedUpload != "up1" {
+		t.Errorf("deleted upload = %q, want up1", deletedUpload)
+	}
+	if len(decremented) != 2 {
+		t.Errorf("released %d parts, want 2", len(decremented))
+	}
+}
+
+func TestMultipartService_Complete_invalidParts(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []int
+	}{
+		{"no parts", nil},
+		{"missing part", []int{1, 2}},
+		{"descending", []int{3, 1}},
+		{"duplicate", []int{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newMultipartServiceWithDefaults(t,
+				&mock.MultipartRepositoryMock{
+					ListPartsFunc: func(uploadID string) ([]entity.MultipartPart, error) {
+						return []entity.MultipartPart{
+							{UploadID: uploadID, Number: 1, Hash: partHash1, Size: 6},
+							{UploadID: uploadID, Number: 3, Hash: partHash2, Size: 5},
+						}, nil
+					},
+					DeleteFunc: func(id string) error {
+						t.Error("upload discarded after a failed completion")
+						return nil
+					},
+				},
+				&mock.ContentRepositoryMock{},
+				&mock.HasherMock{},
+				&mock.NodeRepositoryMock{},
+			)
+
+			if _, err := svc.Complete(1, "up1", tt.numbers); !errors.Is(err, ErrInvalidPart) {
+				t.Errorf("error = %v, want ErrInvalidPart", err)
+			}
+		})
+	}
+}
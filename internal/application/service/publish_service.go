@@ -11,6 +11,7 @@ import (
 type PublishService struct {
 	nodes    repository.NodeRepository
 	contents repository.ContentRepository
+	changes  *ChangeService
 }
 
 // NewPublishService creates a new PublishService.
@@ -24,6 +25,12 @@ func NewPublishService(
 	}
 }
 
+// WithChanges records cloned nodes to the given change journal.
+func (s *PublishService) WithChanges(changes *ChangeService) *PublishService {
+	s.changes = changes
+	return s
+}
+
 // Publish assigns a public weblink to the node at the given path.
 // If the node is already published, returns the existing weblink.
 func (s *PublishService) Publish(userID int64, path vo.CloudPath) (string, error) {
@@ -115,21 +122,16 @@ func (s *PublishService) Clone(callerUserID int64, weblinkID string, targetFolde
 		return nil, ErrForbidden
 	}
 
-	if err := s.nodes.EnsurePath(callerUserID, targetFolder); err != nil {
+	if err := ensurePath(s.nodes, s.changes, callerUserID, targetFolder); err != nil {
 		return nil, err
 	}
 
-	targetPath := targetFolder.Join(source.Name)
-
 	// Handle conflict at target path.
-	exists, err := s.nodes.Exists(callerUserID, targetPath)
+	targetPath, replace, err := resolveConflict(s.nodes, callerUserID, targetFolder.Join(source.Name), source.IsFile(), conflict)
 	if err != nil {
 		return nil, err
 	}
-	if exists {
-		if conflict == vo.ConflictStrict {
-			return nil, ErrAlreadyExists
-		}
+	if replace {
 		if err := s.nodes.Delete(callerUserID, targetPath); err != nil {
 			return nil, err
 		}
@@ -142,7 +144,12 @@ func (s *PublishService) Clone(callerUserID int64, weblinkID string, targetFolde
 				return nil, err
 			}
 		}
-		return s.nodes.CreateFile(callerUserID, targetPath, source.Hash, source.Size)
+		node, err := s.nodes.CreateFile(callerUserID, targetPath, source.Hash, source.Size)
+		if err != nil {
+			return nil, err
+		}
+		s.changes.RecordNode(vo.ChangeCreate, node, "")
+		return node, nil
 	}
 
 	// For folders: create the folder, then recursively copy children.
@@ -154,6 +161,7 @@ func (s *PublishService) Clone(callerUserID int64, weblinkID string, targetFolde
 	if err := s.cloneChildren(source.UserID, source.Home, callerUserID, targetPath); err != nil {
 		return nil, err
 	}
+	s.changes.RecordNode(vo.ChangeCreate, newFolder, "")
 
 	return newFolder, nil
 }
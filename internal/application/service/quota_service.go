@@ -6,8 +6,11 @@ import (
 
 // QuotaService checks storage quota usage on a per-user basis.
 type QuotaService struct {
-	nodes repository.NodeRepository
-	users repository.UserRepository
+	nodes        repository.NodeRepository
+	users        repository.UserRepository
+	versions     repository.FileVersionRepository
+	trash        repository.TrashRepository
+	countTrashed bool
 }
 
 // NewQuotaService creates a new QuotaService.
@@ -15,11 +18,29 @@ func NewQuotaService(nodes repository.NodeRepository, users repository.UserRepos
 	return &QuotaService{nodes: nodes, users: users}
 }
 
+// WithVersions reports the storage held by file version history in usage.
+func (s *QuotaService) WithVersions(versions repository.FileVersionRepository) *QuotaService {
+	s.versions = versions
+	return s
+}
+
+// WithTrash reports the size of the trash in usage. If countTrashed is true,
+// trashed files also count toward the quota.
+func (s *QuotaService) WithTrash(trash repository.TrashRepository, countTrashed bool) *QuotaService {
+	s.trash = trash
+	s.countTrashed = countTrashed
+	return s
+}
+
 // SpaceUsage holds the result of a quota check.
+// BytesVersions is the storage held by older file versions; it is not counted in BytesUsed.
+// BytesTrash is the size of the trash; it is counted in BytesUsed only when trash counts toward the quota.
 type SpaceUsage struct {
-	Overquota  bool
-	BytesTotal int64
-	BytesUsed  int64
+	Overquota     bool
+	BytesTotal    int64
+	BytesUsed     int64
+	BytesVersions int64
+	BytesTrash    int64
 }
 
 // GetUsage returns the current storage usage for the given user.
@@ -32,15 +53,22 @@ func (s *QuotaService) GetUsage(userID int64) (*SpaceUsage, error) {
 		return nil, ErrNotFound
 	}
 
-	used, err := s.nodes.TotalSize(userID)
+	used, trashed, err := s.used(userID)
 	if err != nil {
 		return nil, err
 	}
-	return &SpaceUsage{
+	usage := &SpaceUsage{
 		Overquota:  used > user.QuotaBytes,
 		BytesTotal: user.QuotaBytes,
 		BytesUsed:  used,
-	}, nil
+		BytesTrash: trashed,
+	}
+	if s.versions != nil {
+		if usage.BytesVersions, err = s.versions.TotalSize(userID); err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
 }
 
 // CheckQuota returns true if adding additionalBytes would exceed the user's quota.
@@ -53,9 +81,28 @@ func (s *QuotaService) CheckQuota(userID int64, additionalBytes int64) (bool, er
 		return false, ErrNotFound
 	}
 
-	used, err := s.nodes.TotalSize(userID)
+	used, _, err := s.used(userID)
 	if err != nil {
 		return false, err
 	}
 	return used+additionalBytes > user.QuotaBytes, nil
 }
+
+// used returns the bytes counted toward the user's quota and the size of their trash.
+func (s *QuotaService) used(userID int64) (used, trashed int64, err error) {
+	used, err = s.nodes.TotalSize(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if s.trash == nil {
+		return used, 0, nil
+	}
+	trashed, err = s.trash.TotalSize(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if s.countTrashed {
+		used += trashed
+	}
+	return used, trashed, nil
+}
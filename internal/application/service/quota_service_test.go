@@ -122,3 +122,68 @@ func TestQuotaService_CheckQuota(t *testing.T) {
 		t.Error("CheckQuota(300) = false, want true")
 	}
 }
+
+func TestQuotaService_GetUsage_versions(t *testing.T) {
+	svc := NewQuotaService(
+		&mock.NodeRepositoryMock{
+			TotalSizeFunc: func(userID int64) (int64, error) { return 500, nil },
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return &entity.User{ID: 1, QuotaBytes: 1000}, nil
+			},
+		},
+	).WithVersions(&mock.FileVersionRepositoryMock{
+		TotalSizeFunc: func(userID int64) (int64, error) { return 700, nil },
+	})
+
+	usage, err := svc.GetUsage(1)
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if usage.BytesVersions != 700 {
+		t.Errorf("BytesVersions = %d, want 700", usage.BytesVersions)
+	}
+	if usage.BytesUsed != 500 || usage.Overquota {
+		t.Errorf("BytesUsed = %d, Overquota = %v, want 500 and false", usage.BytesUsed, usage.Overquota)
+	}
+}
+
+func TestQuotaService_trash(t *testing.T) {
+	newSvc := func(countTrashed bool) *QuotaService {
+		return NewQuotaService(
+			&mock.NodeRepositoryMock{
+				TotalSizeFunc: func(userID int64) (int64, error) { return 500, nil },
+			},
+			&mock.UserRepositoryMock{
+				GetByIDFunc: func(id int64) (*entity.User, error) {
+					return &entity.User{ID: 1, QuotaBytes: 1000}, nil
+				},
+			},
+		).WithTrash(&mock.TrashRepositoryMock{
+			TotalSizeFunc: func(userID int64) (int64, error) { return 400, nil },
+		}, countTrashed)
+	}
+
+	usage, err := newSvc(false).GetUsage(1)
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if usage.BytesTrash != 400 || usage.BytesUsed != 500 {
+		t.Errorf("BytesTrash = %d, BytesUsed = %d, want 400 and 500", usage.BytesTrash, usage.BytesUsed)
+	}
+	if over, _ := newSvc(false).CheckQuota(1, 200); over {
+		t.Error("CheckQuota = true, want trash not counted")
+	}
+
+	usage, err = newSvc(true).GetUsage(1)
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if usage.BytesTrash != 400 || usage.BytesUsed != 900 {
+		t.Errorf("BytesTrash = %d, BytesUsed = %d, want 400 and 900", usage.BytesTrash, usage.BytesUsed)
+	}
+	if over, _ := newSvc(true).CheckQuota(1, 200); !over {
+		t.Error("CheckQuota = false, want trash counted")
+	}
+}
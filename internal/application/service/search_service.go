@@ -0,0 +1,123 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// SearchService finds nodes by name, metadata and file content in a user's
+// tree, including the folders shared with the user and mounted in it.
+type SearchService struct {
+	search repository.NodeSearchRepository
+	shares *ShareService
+}
+
+// NewSearchService creates a new SearchService.
+func NewSearchService(search repository.NodeSearchRepository, shares *ShareService) *SearchService {
+	return &SearchService{
+		search: search,
+		shares: shares,
+	}
+}
+
+// searchFunc searches one user's tree, such as NodeSearchRepository.Search.
+type searchFunc func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error)
+
+// Find returns a page of the nodes matching the query, best match first, and
+// whether more matches follow it. query.Under limits the search to a folder of
+// the user's tree; nodes inside mounted shares are returned at their paths in it.
+func (s *SearchService) Find(userID int64, query repository.NodeQuery, offset, limit int) ([]entity.Node, bool, error) {
+	matches, more, err := s.find(userID, query, offset, limit, s.search.Search)
+	if err != nil {
+		return nil, false, err
+	}
+	nodes := make([]entity.Node, len(matches))
+	for i := range matches {
+		nodes[i] = matches[i].Node
+	}
+	return nodes, more, nil
+}
+
+// FindContent returns a page of the files whose content contains the words of
+// query.Text, best match first, with snippets of the matching text, and whether
+// more matches follow it. The other query fields filter the files as in Find.
+func (s *SearchService) FindContent(userID int64, query repository.NodeQuery, offset, limit int) ([]repository.NodeMatch, bool, error) {
+	return s.find(userID, query, offset, limit, s.search.SearchContent)
+}
+
+// find runs the search in the user's tree and the mounted shares, and returns
+// a page of the merged matches.
+func (s *SearchService) find(userID int64, query repository.NodeQuery, offset, limit int, search searchFunc) ([]repository.NodeMatch, bool, error) {
+	if query.Under.String() == "" {
+		query.Under = vo.NewCloudPath("/")
+	}
+	window := offset + limit + 1
+
+	// A folder inside a mounted share is searched in its owner's tree only.
+	resolution, err := s.shares.ResolveMount(userID, query.Under)
+	if err != nil {
+		return nil, false, err
+	}
+	var matches []repository.NodeMatch
+	if resolution != nil {
+		matches, err = searchShare(search, resolution.Share, resolution.OwnerPath, query, window)
+	} else {
+		matches, err = s.searchTree(search, userID, query, window)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Rank != matches[j].Rank {
+			return matches[i].Rank < matches[j].Rank
+		}
+		return matches[i].Node.Home.String() < matches[j].Node.Home.String()
+	})
+
+	more := len(matches) > offset+limit
+	return matches[min(offset, len(matches)):min(offset+limit, len(matches))], more, nil
+}
+
+// searchTree searches the user's own tree and the shares mounted under query.Under.
+func (s *SearchService) searchTree(search searchFunc, userID int64, query repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+	matches, err := search(userID, &query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	mounted, err := s.shares.ListMounted(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mounted {
+		share := &mounted[i]
+		mountPath := vo.NewCloudPath(share.MountHome)
+		if !query.Under.IsRoot() && !mountPath.HasPrefix(query.Under) {
+			continue
+		}
+		shared, err := searchShare(search, share, share.Home, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, shared...)
+	}
+	return matches, nil
+}
+
+// searchShare searches the owner's tree below ownerPath inside a mounted share,
+// and maps the matches to the mount path.
+func searchShare(search searchFunc, share *entity.Share, ownerPath vo.CloudPath, query repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+	query.Under = ownerPath
+	matches, err := search(share.OwnerID, &query, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i].Node.Home, _ = mountedPath(share, matches[i].Node.Home.String())
+	}
+	return matches, nil
+}
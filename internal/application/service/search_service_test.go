@@ -0,0 +1,137 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newSearchServiceWithShare(t *testing.T, searched map[int64]string) *SearchService {
+	t.Helper()
+	share := mock.NewTestShare(2, "/team", "user@example.com")
+	share.MountHome = "/Shared"
+
+	matches := map[int64][]repository.NodeMatch{
+		1: {
+			{Node: *mock.NewTestNode(1, "/plan.txt", vo.NodeTypeFile), Rank: -2},
+			{Node: *mock.NewTestNode(1, "/old/plan.txt", vo.NodeTypeFile), Rank: -1},
+		},
+		2: {
+			{Node: *mock.NewTestNode(2, "/team/plan.doc", vo.NodeTypeFile), Rank: -3},
+			{Node: *mock.NewTestNode(2, "/team/q1/plan.doc", vo.NodeTypeFile), Rank: -1},
+		},
+	}
+	return NewSearchService(
+		&mock.NodeSearchRepositoryMock{
+			SearchFunc: func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+				searched[userID] = query.Under.String()
+				return append([]repository.NodeMatch(nil), matches[userID]...), nil
+			},
+		},
+		NewShareService(
+			&mock.ShareRepositoryMock{
+				ListMountedByUserFunc: func(userID int64) ([]entity.Share, error) { return []entity.Share{*share}, nil },
+			},
+			&mock.NodeRepositoryMock{}, &mock.ContentRepositoryMock{}, &mock.UserRepositoryMock{},
+		),
+	)
+}
+
+func nodeHomes(nodes []entity.Node) []string {
+	result := make([]string, len(nodes))
+	for i := range nodes {
+		result[i] = nodes[i].Home.String()
+	}
+	return result
+}
+
+func TestSearchService_Find_includesMountedShares(t *testing.T) {
+	searched := map[int64]string{}
+	svc := newSearchServiceWithShare(t, searched)
+
+	nodes, more, err := svc.Find(1, repository.NodeQuery{Text: "plan"}, 0, 10)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	want := []string{"/Shared/plan.doc", "/plan.txt", "/Shared/q1/plan.doc", "/old/plan.txt"}
+	if got := nodeHomes(nodes); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Errorf("Find = %v, want %v", got, want)
+	}
+	if more {
+		t.Error("more = true for a complete result")
+	}
+	if searched[2] != "/team" {
+		t.Errorf("share searched under %q, want /team", searched[2])
+	}
+
+	nodes, more, _ = svc.Find(1, repository.NodeQuery{Text: "plan"}, 1, 2)
+	if got := nodeHomes(nodes); len(got) != 2 || got[0] != "/plan.txt" || got[1] != "/Shared/q1/plan.doc" || !more {
+		t.Errorf("Find(offset 1, limit 2) = %v, more %v", got, more)
+	}
+}
+
+func TestSearchService_Find_insideMount(t *testing.T) {
+	searched := map[int64]string{}
+	svc := newSearchServiceWithShare(t, searched)
+
+	if _, _, err := svc.Find(1, repository.NodeQuery{Text: "plan", Under: vo.NewCloudPath("/Shared/q1")}, 0, 10); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if _, ok := searched[1]; ok {
+		t.Error("own tree searched for a folder inside a mount")
+	}
+	if searched[2] != "/team/q1" {
+		t.Errorf("share searched under %q, want /team/q1", searched[2])
+	}
+
+	searched = map[int64]string{}
+	svc = newSearchServiceWithShare(t, searched)
+	if _, _, err := svc.Find(1, repository.NodeQuery{Text: "plan", Under: vo.NewCloudPath("/old")}, 0, 10); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if _, ok := searched[2]; ok {
+		t.Error("share mounted outside the folder was searched")
+	}
+}
+
+func TestSearchService_FindContent(t *testing.T) {
+	share := mock.NewTestShare(2, "/team", "user@example.com")
+	share.MountHome = "/Shared"
+	svc := NewSearchService(
+		&mock.NodeSearchRepositoryMock{
+			SearchFunc: func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+				t.Error("name search used for a content search")
+				return nil, nil
+			},
+			SearchContentFunc: func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+				if userID == 2 {
+					return []repository.NodeMatch{{Node: *mock.NewTestNode(2, "/team/budget.txt", vo.NodeTypeFile), Rank: -5, Snippet: "the <mark>budget</mark>"}}, nil
+				}
+				return []repository.NodeMatch{{Node: *mock.NewTestNode(1, "/notes.md", vo.NodeTypeFile), Rank: -1, Snippet: "<mark>budget</mark> cuts"}}, nil
+			},
+		},
+		NewShareService(
+			&mock.ShareRepositoryMock{
+				ListMountedByUserFunc: func(userID int64) ([]entity.Share, error) { return []entity.Share{*share}, nil },
+			},
+			&mock.NodeRepositoryMock{}, &mock.ContentRepositoryMock{}, &mock.UserRepositoryMock{},
+		),
+	)
+
+	matches, more, err := svc.FindContent(1, repository.NodeQuery{Text: "budget"}, 0, 10)
+	if err != nil {
+		t.Fatalf("FindContent: %v", err)
+	}
+	if len(matches) != 2 || more {
+		t.Fatalf("FindContent = %v, more %v, want 2 matches", matches, more)
+	}
+	if matches[0].Node.Home.String() != "/Shared/budget.txt" || matches[0].Snippet != "the <mark>budget</mark>" {
+		t.Errorf("first match = %s %q, want the mounted file with its snippet", matches[0].Node.Home, matches[0].Snippet)
+	}
+	if matches[1].Node.Home.String() != "/notes.md" {
+		t.Errorf("second match = %s, want /notes.md", matches[1].Node.Home)
+	}
+}
@@ -18,6 +18,8 @@ type ShareService struct {
 	nodes    repository.NodeRepository
 	contents repository.ContentRepository
 	users    repository.UserRepository
+	changes  *ChangeService
+	xattrs   repository.XAttrRepository
 }
 
 // NewShareService creates a new ShareService.
@@ -35,6 +37,18 @@ func NewShareService(
 	}
 }
 
+// WithChanges records mounts and unmounts to the given change journal.
+func (s *ShareService) WithChanges(changes *ChangeService) *ShareService {
+	s.changes = changes
+	return s
+}
+
+// WithXAttrs carries the extended attributes of nodes into the trees it clones.
+func (s *ShareService) WithXAttrs(xattrs repository.XAttrRepository) *ShareService {
+	s.xattrs = xattrs
+	return s
+}
+
 // Share creates a folder sharing invitation.
 // Cannot share with self (owner email must differ from invited email).
 func (s *ShareService) Share(ownerID int64, home vo.CloudPath, email string, access vo.AccessLevel) (*entity.Share, error) {
@@ -108,7 +122,13 @@ func (s *ShareService) Unshare(ownerID int64, home vo.CloudPath, email string) e
 		return ErrNotFound
 	}
 
-	return s.shares.Delete(share.ID)
+	if err := s.shares.Delete(share.ID); err != nil {
+		return err
+	}
+	if share.IsAccepted() && share.MountUserID != nil {
+		s.changes.RecordFolder(*share.MountUserID, vo.ChangeUnmount, vo.NewCloudPath(share.MountHome))
+	}
+	return nil
 }
 
 // GetShareInfo returns all share members for a given folder.
@@ -116,6 +136,11 @@ func (s *ShareService) GetShareInfo(ownerID int64, home vo.CloudPath) ([]entity.
 	return s.shares.ListByOwnerPath(ownerID, home)
 }
 
+// ListOutgoing returns all invitations to the owner's folders.
+func (s *ShareService) ListOutgoing(ownerID int64) ([]entity.Share, error) {
+	return s.shares.ListByOwnerPathPrefix(ownerID, vo.NewCloudPath("/"))
+}
+
 // ListIncoming returns all pending share invitations for the given email.
 func (s *ShareService) ListIncoming(email string) ([]entity.Share, error) {
 	return s.shares.ListIncoming(email)
@@ -144,8 +169,7 @@ func (s *ShareService) Mount(userID int64, mountName string, inviteToken string,
 	mountName = strings.TrimLeft(mountName, "/")
 
 	// Check if mount point already exists and handle conflict
-	mountHome := "/" + mountName
-	mountPath := vo.NewCloudPath(mountHome)
+	mountPath := vo.NewCloudPath("/" + mountName)
 	existing, err := s.nodes.Get(userID, mountPath)
 	if err != nil {
 		return err
@@ -156,21 +180,18 @@ func (s *ShareService) Mount(userID int64, mountName string, inviteToken string,
 		case vo.ConflictStrict:
 			return ErrAlreadyExists
 		case vo.ConflictRename:
-			// Find unique name by appending suffix
-			base := mountName
-			for i := 1; existing != nil; i++ {
-				mountName = fmt.Sprintf("%s (%d)", base, i)
-				mountHome = "/" + mountName
-				mountPath = vo.NewCloudPath(mountHome)
-				existing, err = s.nodes.Get(userID, mountPath)
-				if err != nil {
-					return err
-				}
+			if mountPath, err = freePath(s.nodes, userID, mountPath, false); err != nil {
+				return err
 			}
 		}
 	}
+	mountHome := mountPath.String()
 
-	return s.shares.Accept(inviteToken, userID, mountHome)
+	if err := s.shares.Accept(inviteToken, userID, mountHome); err != nil {
+		return err
+	}
+	s.changes.RecordFolder(userID, vo.ChangeMount, mountPath)
+	return nil
 }
 
 // Unmount removes a mount point. If cloneCopy is true, copies the shared content
@@ -183,13 +204,14 @@ func (s *ShareService) Unmount(userID int64, mountHome string, cloneCopy bool) e
 	if share == nil {
 		return ErrNotFound
 	}
+	s.changes.RecordFolder(userID, vo.ChangeUnmount, vo.NewCloudPath(mountHome))
 
 	if cloneCopy {
 		dstPath := vo.NewCloudPath(mountHome)
-		if err := s.nodes.EnsurePath(userID, dstPath); err != nil {
+		if err := ensurePath(s.nodes, s.changes, userID, dstPath); err != nil {
 			return err
 		}
-		if err := cloneTree(s.nodes, s.contents, share.OwnerID, share.Home, userID, dstPath); err != nil {
+		if err := cloneTree(s.nodes, s.contents, s.xattrs, share.OwnerID, share.Home, userID, dstPath); err != nil {
 			return err
 		}
 	}
@@ -275,6 +297,31 @@ func (s *ShareService) ResolveMount(userID int64, path vo.CloudPath) (*MountReso
 	return nil, nil
 }
 
+// ListMounted returns the shares the user has accepted and mounted.
+func (s *ShareService) ListMounted(userID int64) ([]entity.Share, error) {
+	return s.shares.ListMountedByUser(userID)
+}
+
+// ListMountedByOwner returns the owner's shares that are currently mounted by their recipients.
+func (s *ShareService) ListMountedByOwner(ownerID int64) ([]entity.Share, error) {
+	return s.shares.ListMountedByOwner(ownerID)
+}
+
+// mountedPath maps a path in the owner's tree to the mount user's tree, the
+// reverse of ResolveMount. Returns false if the path is outside the shared folder.
+func mountedPath(share *entity.Share, ownerPath string) (vo.CloudPath, bool) {
+	ownerHome := share.Home.String()
+	mountHome := vo.NewCloudPath(share.MountHome).String()
+
+	if ownerPath == ownerHome {
+		return vo.NewCloudPath(mountHome), true
+	}
+	if strings.HasPrefix(ownerPath, ownerHome+"/") {
+		return vo.NewCloudPath(mountHome + ownerPath[len(ownerHome):]), true
+	}
+	return vo.CloudPath{}, false
+}
+
 // generateInviteToken produces a random 32-character hex string for share tokens.
 func generateInviteToken() (string, error) {
 	b := make([]byte, 16)
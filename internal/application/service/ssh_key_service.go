@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+// SSHKeyService manages the public keys users authorize for SFTP logins.
+type SSHKeyService struct {
+	keys   repository.SSHKeyRepository
+	users  repository.UserRepository
+	parser port.PublicKeyParser
+}
+
+// NewSSHKeyService creates a new SSHKeyService.
+func NewSSHKeyService(keys repository.SSHKeyRepository, users repository.UserRepository, parser port.PublicKeyParser) *SSHKeyService {
+	return &SSHKeyService{keys: keys, users: users, parser: parser}
+}
+
+// Add authorizes a public key given in authorized_keys format for the user.
+// When name is empty the key comment is used.
+// Returns ErrInvalidKey if the key cannot be parsed, ErrAlreadyExists if the
+// user already added it, and ErrNotFound if the user does not exist.
+func (s *SSHKeyService) Add(userID int64, name, authorizedKey string) (*entity.SSHKey, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	key, fingerprint, comment, err := s.parser.Parse(authorizedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if name == "" {
+		name = comment
+	}
+
+	existing, err := s.keys.GetByFingerprint(userID, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyExists
+	}
+
+	k := &entity.SSHKey{UserID: userID, Name: name, PublicKey: key, Fingerprint: fingerprint}
+	if err := s.keys.Create(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// List returns the user's keys.
+func (s *SSHKeyService) List(userID int64) ([]entity.SSHKey, error) {
+	keys, err := s.keys.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []entity.SSHKey{}
+	}
+	return keys, nil
+}
+
+// Revoke deletes a key owned by the user.
+// Returns ErrNotFound if it does not exist or belongs to another user.
+func (s *SSHKeyService) Revoke(userID, id int64) error {
+	k, err := s.keys.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if k == nil || k.UserID != userID {
+		return ErrNotFound
+	}
+	return s.keys.Delete(id)
+}
+
+// Authenticate resolves the user who logs in as email with the key of the given
+// fingerprint, and records the key's use.
+// Returns ErrNotFound if the user does not exist or has not authorized the key.
+func (s *SSHKeyService) Authenticate(email, fingerprint string) (*entity.User, error) {
+	user, err := s.users.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	k, err := s.keys.GetByFingerprint(user.ID, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrNotFound
+	}
+
+	_ = s.keys.MarkUsed(k.ID, time.Now().Unix())
+	return user, nil
+}
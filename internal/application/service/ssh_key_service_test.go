@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestSSHKeyService_Add(t *testing.T) {
+	var stored *entity.SSHKey
+	svc := NewSSHKeyService(
+		&mock.SSHKeyRepositoryMock{
+			CreateFunc: func(k *entity.SSHKey) error {
+				k.ID = 3
+				stored = k
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) { return mock.NewTestUser(id, "u@x.com"), nil },
+		},
+		&mock.PublicKeyParserMock{
+			ParseFunc: func(string) (string, string, string, error) {
+				return "ssh-ed25519 AAAA", "SHA256:abc", "laptop", nil
+			},
+		},
+	)
+
+	k, err := svc.Add(1, "", "ssh-ed25519 AAAA laptop")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if stored != k || k.UserID != 1 || k.PublicKey != "ssh-ed25519 AAAA" || k.Fingerprint != "SHA256:abc" {
+		t.Errorf("stored key = %+v", stored)
+	}
+	if k.Name != "laptop" {
+		t.Errorf("Name = %q, want the key comment", k.Name)
+	}
+
+	k, err = svc.Add(1, "work", "ssh-ed25519 AAAA laptop")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if k.Name != "work" {
+		t.Errorf("Name = %q, want %q", k.Name, "work")
+	}
+}
+
+func TestSSHKeyService_Add_errors(t *testing.T) {
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			if id != 1 {
+				return nil, nil
+			}
+			return mock.NewTestUser(id, "u@x.com"), nil
+		},
+	}
+	keys := &mock.SSHKeyRepositoryMock{
+		GetByFingerprintFunc: func(userID int64, fingerprint string) (*entity.SSHKey, error) {
+			if fingerprint == "SHA256:dup" {
+				return &entity.SSHKey{ID: 2, UserID: userID}, nil
+			}
+			return nil, nil
+		},
+	}
+	svc := NewSSHKeyService(keys, users, &mock.PublicKeyParserMock{})
+
+	tests := []struct {
+		name   string
+		userID int64
+		key    string
+		want   error
+	}{
+		{"unknown user", 99, "key", ErrNotFound},
+		{"invalid key", 1, "", ErrInvalidKey},
+		{"duplicate", 1, "dup", ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Add(tt.userID, "name", tt.key); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSHKeyService_Revoke(t *testing.T) {
+	var deleted int64
+	svc := NewSSHKeyService(
+		&mock.SSHKeyRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.SSHKey, error) {
+				return &entity.SSHKey{ID: id, UserID: 1}, nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = id
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{},
+		&mock.PublicKeyParserMock{},
+	)
+
+	if err := svc.Revoke(2, 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke by another user: error = %v, want ErrNotFound", err)
+	}
+	if deleted != 0 {
+		t.Fatal("key of another user was deleted")
+	}
+	if err := svc.Revoke(1, 5); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if deleted != 5 {
+		t.Errorf("deleted = %d, want 5", deleted)
+	}
+}
+
+func TestSSHKeyService_Authenticate(t *testing.T) {
+	var used int64
+	svc := NewSSHKeyService(
+		&mock.SSHKeyRepositoryMock{
+			GetByFingerprintFunc: func(userID int64, fingerprint string) (*entity.SSHKey, error) {
+				if userID == 1 && fingerprint == "SHA256:abc" {
+					return &entity.SSHKey{ID: 4, UserID: 1}, nil
+				}
+				return nil, nil
+			},
+			MarkUsedFunc: func(id int64, ts int64) error {
+				used = id
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByEmailFunc: func(email string) (*entity.User, error) {
+				if email != "u@x.com" {
+					return nil, nil
+				}
+				return mock.NewTestUser(1, email), nil
+			},
+		},
+		&mock.PublicKeyParserMock{},
+	)
+
+	user, err := svc.Authenticate("u@x.com", "SHA256:abc")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.ID != 1 {
+		t.Errorf("user ID = %d, want 1", user.ID)
+	}
+	if used != 4 {
+		t.Errorf("marked key = %d, want 4", used)
+	}
+
+	if _, err := svc.Authenticate("u@x.com", "SHA256:other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown key: error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Authenticate("v@x.com", "SHA256:abc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown user: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// TagService handles the user-defined labels of nodes: tags and the starred
+// flag. Labels stay with a node when it is renamed or moved, and are copied
+// with it.
+type TagService struct {
+	nodes repository.NodeRepository
+}
+
+// NewTagService creates a new TagService.
+func NewTagService(nodes repository.NodeRepository) *TagService {
+	return &TagService{nodes: nodes}
+}
+
+// AddTags adds the tags to the node at the given path and returns the node.
+// Returns ErrNotFound if the node does not exist.
+func (s *TagService) AddTags(userID int64, path vo.CloudPath, tags []vo.Tag) (*entity.Node, error) {
+	return s.updateTags(userID, path, func(current []vo.Tag) []vo.Tag {
+		return vo.SortTags(append(slices.Clone(current), tags...))
+	})
+}
+
+// RemoveTags removes the tags from the node at the given path and returns the node.
+// Returns ErrNotFound if the node does not exist.
+func (s *TagService) RemoveTags(userID int64, path vo.CloudPath, tags []vo.Tag) (*entity.Node, error) {
+	return s.updateTags(userID, path, func(current []vo.Tag) []vo.Tag {
+		return slices.DeleteFunc(slices.Clone(current), func(t vo.Tag) bool {
+			return slices.Contains(tags, t)
+		})
+	})
+}
+
+// updateTags replaces the node's tags with the result of change.
+func (s *TagService) updateTags(userID int64, path vo.CloudPath, change func([]vo.Tag) []vo.Tag) (*entity.Node, error) {
+	node, err := s.nodes.Get(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || path.IsRoot() {
+		return nil, ErrNotFound
+	}
+
+	tags := change(node.Tags)
+	if slices.Equal(tags, node.Tags) {
+		return node, nil
+	}
+	if err := s.nodes.SetTags(userID, path, tags); err != nil {
+		return nil, err
+	}
+	node.Tags = tags
+	return node, nil
+}
+
+// SetStarred stars or unstars the node at the given path and returns the node.
+// Returns ErrNotFound if the node does not exist.
+func (s *TagService) SetStarred(userID int64, path vo.CloudPath, starred bool) (*entity.Node, error) {
+	node, err := s.nodes.Get(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || path.IsRoot() {
+		return nil, ErrNotFound
+	}
+
+	if node.Starred != starred {
+		if err := s.nodes.SetStarred(userID, path, starred); err != nil {
+			return nil, err
+		}
+		node.Starred = starred
+	}
+	return node, nil
+}
+
+// ListTagged returns all of the user's nodes that have the tag, ordered by path.
+func (s *TagService) ListTagged(userID int64, tag vo.Tag) ([]entity.Node, error) {
+	return s.nodes.ListTagged(userID, tag)
+}
+
+// ListStarred returns all of the user's starred nodes, ordered by path.
+func (s *TagService) ListStarred(userID int64) ([]entity.Node, error) {
+	return s.nodes.ListStarred(userID)
+}
+
+// keepLabels gives node, which replaces previous at its path, the star and
+// tags of previous.
+func keepLabels(nodes repository.NodeRepository, previous, node *entity.Node) error {
+	if previous.Starred {
+		if err := nodes.SetStarred(node.UserID, node.Home, true); err != nil {
+			return err
+		}
+		node.Starred = true
+	}
+	if len(previous.Tags) > 0 {
+		if err := nodes.SetTags(node.UserID, node.Home, previous.Tags); err != nil {
+			return err
+		}
+		node.Tags = previous.Tags
+	}
+	return nil
+}
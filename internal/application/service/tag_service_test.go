@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestTagService_AddRemoveTags(t *testing.T) {
+	node := mock.NewTestFileNode(1, "/plan.txt", mock.ValidHash(), 10)
+	node.Tags = []vo.Tag{"q3", "work"}
+	var stored []vo.Tag
+	saves := 0
+	svc := NewTagService(&mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			copied := *node
+			return &copied, nil
+		},
+		SetTagsFunc: func(userID int64, path vo.CloudPath, tags []vo.Tag) error {
+			stored = tags
+			saves++
+			return nil
+		},
+	})
+
+	got, err := svc.AddTags(1, node.Home, []vo.Tag{"urgent", "work"})
+	if err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if want := []vo.Tag{"q3", "urgent", "work"}; !reflect.DeepEqual(stored, want) || !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("AddTags stored %v, returned %v, want %v", stored, got.Tags, want)
+	}
+	if !reflect.DeepEqual(node.Tags, []vo.Tag{"q3", "work"}) {
+		t.Errorf("AddTags changed the loaded tags to %v", node.Tags)
+	}
+
+	got, err = svc.RemoveTags(1, node.Home, []vo.Tag{"work", "missing"})
+	if err != nil {
+		t.Fatalf("RemoveTags: %v", err)
+	}
+	if want := []vo.Tag{"q3"}; !reflect.DeepEqual(stored, want) || !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("RemoveTags stored %v, returned %v, want %v", stored, got.Tags, want)
+	}
+
+	saves = 0
+	if _, err := svc.AddTags(1, node.Home, []vo.Tag{"q3"}); err != nil || saves != 0 {
+		t.Errorf("AddTags of present tags: err %v, %d saves, want none", err, saves)
+	}
+}
+
+func TestTagService_notFound(t *testing.T) {
+	root := mock.NewTestNode(1, "/", vo.NodeTypeFolder)
+	svc := NewTagService(&mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			if path.IsRoot() {
+				return root, nil
+			}
+			return nil, nil
+		},
+	})
+
+	for _, path := range []string{"/missing", "/"} {
+		if _, err := svc.AddTags(1, vo.NewCloudPath(path), []vo.Tag{"x"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddTags(%s) error = %v, want ErrNotFound", path, err)
+		}
+		if _, err := svc.SetStarred(1, vo.NewCloudPath(path), true); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetStarred(%s) error = %v, want ErrNotFound", path, err)
+		}
+	}
+}
+
+func TestTagService_SetStarred(t *testing.T) {
+	var calls []bool
+	svc := NewTagService(&mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			return mock.NewTestFileNode(1, path.String(), mock.ValidHash(), 10), nil
+		},
+		SetStarredFunc: func(userID int64, path vo.CloudPath, starred bool) error {
+			calls = append(calls, starred)
+			return nil
+		},
+	})
+
+	node, err := svc.SetStarred(1, vo.NewCloudPath("/plan.txt"), true)
+	if err != nil || !node.Starred {
+		t.Fatalf("SetStarred = %+v, %v, want a starred node", node, err)
+	}
+	if _, err := svc.SetStarred(1, vo.NewCloudPath("/plan.txt"), false); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(calls, []bool{true}) {
+		t.Errorf("stored %v, want only the change", calls)
+	}
+}
@@ -3,34 +3,159 @@ package service
 import (
 	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // TokenService handles token creation and credential-based authentication.
 type TokenService struct {
-	tokens repository.TokenRepository
-	users  repository.UserRepository
+	tokens       repository.TokenRepository
+	users        repository.UserRepository
+	appPasswords repository.AppPasswordRepository
 }
 
 // NewTokenService creates a new TokenService.
-func NewTokenService(tokens repository.TokenRepository, users repository.UserRepository) *TokenService {
-	return &TokenService{tokens: tokens, users: users}
+func NewTokenService(tokens repository.TokenRepository, users repository.UserRepository, appPasswords repository.AppPasswordRepository) *TokenService {
+	return &TokenService{tokens: tokens, users: users, appPasswords: appPasswords}
 }
 
-// Create generates a new token set for the given user.
-func (s *TokenService) Create(userID int64, ttlSeconds int) (*entity.Token, error) {
-	return s.tokens.Create(userID, ttlSeconds)
+// Create generates a new session token set for the given user, issued to clientID.
+func (s *TokenService) Create(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+	return s.tokens.Create(userID, clientID, ttlSeconds)
 }
 
-// Authenticate validates credentials against the user repository and creates a token.
+// Authenticate validates credentials against the user repository and creates a token issued to clientID.
 // Returns ErrNotFound if the email does not exist, or credentials do not match.
-func (s *TokenService) Authenticate(email, password string, ttlSeconds int) (*entity.Token, error) {
+func (s *TokenService) Authenticate(email, password, clientID string, ttlSeconds int) (*entity.Token, error) {
+	user, err := s.Verify(email, password)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokens.Create(user.ID, clientID, ttlSeconds)
+}
+
+// Verify checks a login and password without issuing a token.
+// When the user has two-factor authentication enabled, only app passwords are
+// accepted, since password-based clients cannot carry a second factor.
+// Returns ErrNotFound if the email does not exist, or credentials do not match.
+func (s *TokenService) Verify(email, password string) (*entity.User, error) {
 	user, err := s.users.GetByEmail(email)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil || user.Password != password {
+	if user == nil {
 		return nil, ErrNotFound
 	}
 
-	return s.tokens.Create(user.ID, ttlSeconds)
+	if user.TOTPEnabled {
+		ok, err := matchAppPassword(s.appPasswords, user.ID, password)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrNotFound
+		}
+	} else if user.Password != password {
+		return nil, ErrNotFound
+	}
+
+	return user, nil
+}
+
+// Refresh exchanges a refresh token for a new token set and revokes the old one.
+// Returns ErrNotFound if the refresh token is unknown or was issued to a different client.
+func (s *TokenService) Refresh(refreshToken, clientID string, ttlSeconds int) (*entity.Token, error) {
+	if refreshToken == "" {
+		return nil, ErrNotFound
+	}
+
+	old, err := s.tokens.LookupRefresh(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil || old.ClientID != clientID {
+		return nil, ErrNotFound
+	}
+
+	token, err := s.tokens.Create(old.UserID, clientID, ttlSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.Delete(old.ID); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Revoke deletes the session identified by an access token, ending it.
+// Unknown tokens are ignored. Personal tokens are not revoked this way.
+func (s *TokenService) Revoke(accessToken string) error {
+	if accessToken == "" {
+		return nil
+	}
+	token, err := s.tokens.LookupAccess(accessToken)
+	if err != nil {
+		return err
+	}
+	if token == nil || token.Personal {
+		return nil
+	}
+	return s.tokens.Delete(token.ID)
+}
+
+// ListSessions returns the OAuth session tokens of the given user.
+func (s *TokenService) ListSessions(userID int64) ([]entity.Token, error) {
+	tokens, err := s.tokens.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []entity.Token{}
+	}
+	return tokens, nil
+}
+
+// RevokeClientSessions deletes all sessions the given client holds for the user.
+// Returns the number of sessions revoked.
+func (s *TokenService) RevokeClientSessions(userID int64, clientID string) (int64, error) {
+	return s.tokens.DeleteSessionsByClient(userID, clientID)
+}
+
+// CreatePersonal issues a personal access token for automation.
+// A ttlSeconds of 0 creates a token that never expires.
+// Returns ErrNotFound if the user does not exist.
+func (s *TokenService) CreatePersonal(userID int64, name string, scopes []vo.TokenScope, pathPrefix vo.CloudPath, ttlSeconds int) (*entity.Token, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+	return s.tokens.CreatePersonal(userID, name, scopes, pathPrefix, ttlSeconds)
+}
+
+// ListPersonal returns the personal access tokens of the given user.
+func (s *TokenService) ListPersonal(userID int64) ([]entity.Token, error) {
+	tokens, err := s.tokens.ListPersonal(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []entity.Token{}
+	}
+	return tokens, nil
+}
+
+// RevokePersonal deletes a personal access token owned by the given user.
+// Returns ErrNotFound if the token does not exist, is not personal, or
+// belongs to another user.
+func (s *TokenService) RevokePersonal(userID, tokenID int64) error {
+	token, err := s.tokens.GetByID(tokenID)
+	if err != nil {
+		return err
+	}
+	if token == nil || !token.Personal || token.UserID != userID {
+		return ErrNotFound
+	}
+	return s.tokens.Delete(tokenID)
 }
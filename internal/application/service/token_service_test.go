@@ -1,23 +1,26 @@
 package service
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 	"github.com/pozitronik/tucha/internal/testutil/mock"
 )
 
 func TestTokenService_Create(t *testing.T) {
 	svc := NewTokenService(
 		&mock.TokenRepositoryMock{
-			CreateFunc: func(userID int64, ttlSeconds int) (*entity.Token, error) {
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
 				return &entity.Token{ID: 1, UserID: userID, AccessToken: "at"}, nil
 			},
 		},
 		&mock.UserRepositoryMock{},
+		&mock.AppPasswordRepositoryMock{},
 	)
 
-	tok, err := svc.Create(42, 3600)
+	tok, err := svc.Create(42, "cloud-win", 3600)
 	if err != nil {
 		t.Fatalf("Create: %v", err)
 	}
@@ -32,7 +35,7 @@ func TestTokenService_Authenticate_success(t *testing.T) {
 
 	svc := NewTokenService(
 		&mock.TokenRepositoryMock{
-			CreateFunc: func(userID int64, ttlSeconds int) (*entity.Token, error) {
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
 				return &entity.Token{ID: 1, UserID: userID, AccessToken: "new-at"}, nil
 			},
 		},
@@ -44,9 +47,10 @@ func TestTokenService_Authenticate_success(t *testing.T) {
 				return nil, nil
 			},
 		},
+		&mock.AppPasswordRepositoryMock{},
 	)
 
-	tok, err := svc.Authenticate("user@example.com", "correct", 3600)
+	tok, err := svc.Authenticate("user@example.com", "correct", "cloud-win", 3600)
 	if err != nil {
 		t.Fatalf("Authenticate: %v", err)
 	}
@@ -66,9 +70,10 @@ func TestTokenService_Authenticate_wrongPassword(t *testing.T) {
 				return user, nil
 			},
 		},
+		&mock.AppPasswordRepositoryMock{},
 	)
 
-	_, err := svc.Authenticate("user@example.com", "wrong", 3600)
+	_, err := svc.Authenticate("user@example.com", "wrong", "cloud-win", 3600)
 	if err != ErrNotFound {
 		t.Errorf("Authenticate(wrong password) error = %v, want ErrNotFound", err)
 	}
@@ -82,10 +87,182 @@ func TestTokenService_Authenticate_unknownEmail(t *testing.T) {
 				return nil, nil
 			},
 		},
+		&mock.AppPasswordRepositoryMock{},
 	)
 
-	_, err := svc.Authenticate("unknown@example.com", "any", 3600)
+	_, err := svc.Authenticate("unknown@example.com", "any", "cloud-win", 3600)
 	if err != ErrNotFound {
 		t.Errorf("Authenticate(unknown email) error = %v, want ErrNotFound", err)
 	}
 }
+
+func TestTokenService_CreatePersonal_unknownUser(t *testing.T) {
+	svc := NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{}, &mock.AppPasswordRepositoryMock{})
+
+	_, err := svc.CreatePersonal(99, "ci", []vo.TokenScope{vo.ScopeRead}, vo.NewCloudPath("/"), 0)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTokenService_RevokePersonal(t *testing.T) {
+	tokens := map[int64]*entity.Token{
+		1: {ID: 1, UserID: 1, Personal: true},
+		2: {ID: 2, UserID: 2, Personal: true},
+		3: {ID: 3, UserID: 1},
+	}
+	var deleted []int64
+	svc := NewTokenService(
+		&mock.TokenRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.Token, error) {
+				return tokens[id], nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = append(deleted, id)
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{},
+		&mock.AppPasswordRepositoryMock{},
+	)
+
+	tests := []struct {
+		name    string
+		tokenID int64
+		wantErr error
+	}{
+		{"own personal token", 1, nil},
+		{"other user's token", 2, ErrNotFound},
+		{"session token", 3, ErrNotFound},
+		{"missing token", 4, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.RevokePersonal(1, tt.tokenID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RevokePersonal(1, %d) = %v, want %v", tt.tokenID, err, tt.wantErr)
+			}
+		})
+	}
+	if len(deleted) != 1 || deleted[0] != 1 {
+		t.Errorf("deleted = %v, want [1]", deleted)
+	}
+}
+
+func TestTokenService_Revoke(t *testing.T) {
+	tokens := map[string]*entity.Token{
+		"session":  {ID: 1, UserID: 1},
+		"personal": {ID: 2, UserID: 1, Personal: true},
+	}
+	var deleted []int64
+	svc := NewTokenService(
+		&mock.TokenRepositoryMock{
+			LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+				return tokens[accessToken], nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = append(deleted, id)
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{},
+		&mock.AppPasswordRepositoryMock{},
+	)
+
+	for _, token := range []string{"session", "personal", "unknown", ""} {
+		if err := svc.Revoke(token); err != nil {
+			t.Errorf("Revoke(%q) = %v", token, err)
+		}
+	}
+	if len(deleted) != 1 || deleted[0] != 1 {
+		t.Errorf("deleted = %v, want [1]", deleted)
+	}
+}
+
+func TestTokenService_Refresh_otherClient(t *testing.T) {
+	svc := NewTokenService(
+		&mock.TokenRepositoryMock{
+			LookupRefreshFunc: func(refreshToken string) (*entity.Token, error) {
+				return &entity.Token{ID: 1, UserID: 1, RefreshToken: refreshToken, ClientID: "cloud-win"}, nil
+			},
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+				t.Error("Create should not be called for a refresh token of another client")
+				return nil, nil
+			},
+		},
+		&mock.UserRepositoryMock{},
+		&mock.AppPasswordRepositoryMock{},
+	)
+
+	if _, err := svc.Refresh("rt", "backup-tool", 3600); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Refresh error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTokenService_Authenticate_twoFactorRequiresAppPassword(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com", Password: "correct", TOTPEnabled: true}
+	var markedUsed int64
+	svc := NewTokenService(
+		&mock.TokenRepositoryMock{},
+		&mock.UserRepositoryMock{
+			GetByEmailFunc: func(email string) (*entity.User, error) {
+				return user, nil
+			},
+		},
+		&mock.AppPasswordRepositoryMock{
+			ListByUserFunc: func(userID int64) ([]entity.AppPassword, error) {
+				return []entity.AppPassword{{ID: 7, UserID: 1, PasswordHash: hashSecret("app-secret")}}, nil
+			},
+			MarkUsedFunc: func(id int64, ts int64) error {
+				markedUsed = id
+				return nil
+			},
+		},
+	)
+
+	if _, err := svc.Authenticate("user@example.com", "correct", "cloud-win", 3600); !errors.Is(err, ErrNotFound) {
+		t.Errorf("account password error = %v, want ErrNotFound", err)
+	}
+
+	tok, err := svc.Authenticate("user@example.com", "app-secret", "cloud-win", 3600)
+	if err != nil {
+		t.Fatalf("app password: %v", err)
+	}
+	if tok == nil {
+		t.Fatal("expected token for app password")
+	}
+	if markedUsed != 7 {
+		t.Errorf("MarkUsed id = %d, want 7", markedUsed)
+	}
+}
+
+func TestTokenService_Verify_doesNotIssueToken(t *testing.T) {
+	user := mock.NewTestUser(1, "user@example.com")
+	user.Password = "correct"
+
+	svc := NewTokenService(
+		&mock.TokenRepositoryMock{
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+				t.Fatal("Verify must not create a token")
+				return nil, nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByEmailFunc: func(email string) (*entity.User, error) {
+				return user, nil
+			},
+		},
+		&mock.AppPasswordRepositoryMock{},
+	)
+
+	got, err := svc.Verify("user@example.com", "correct")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got.ID != 1 {
+		t.Errorf("ID = %d, want 1", got.ID)
+	}
+
+	if _, err := svc.Verify("user@example.com", "wrong"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("wrong password: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+// TrashPurger permanently deletes trash items older than the retention period.
+type TrashPurger struct {
+	trash  *TrashService
+	users  repository.UserRepository
+	days   int
+	logger port.Logger
+}
+
+// NewTrashPurger creates a TrashPurger keeping trash items for the given number
+// of days for users without their own period. 0 keeps them forever.
+func NewTrashPurger(trash *TrashService, users repository.UserRepository, days int, logger port.Logger) *TrashPurger {
+	return &TrashPurger{trash: trash, users: users, days: days, logger: logger}
+}
+
+// Run purges expired trash items every interval. It never returns.
+func (p *TrashPurger) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		removed, err := p.Purge(now)
+		if err != nil {
+			p.logger.Error("Trash purge failed: %v", err)
+		}
+		if removed > 0 {
+			p.logger.Info("Trash purge removed %d items", removed)
+		}
+	}
+}
+
+// Purge deletes the trash items of every user that are older than their
+// retention period at the time now. Returns the number of deleted items.
+func (p *TrashPurger) Purge(now time.Time) (int, error) {
+	users, err := p.users.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, user := range users {
+		days := p.days
+		if user.TrashRetentionDays != nil {
+			days = *user.TrashRetentionDays
+		}
+		if days <= 0 {
+			continue
+		}
+		n, err := p.trash.Purge(user.ID, now.AddDate(0, 0, -days).Unix())
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
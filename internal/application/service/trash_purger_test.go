@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestTrashPurger_Purge(t *testing.T) {
+	now := time.Unix(1_000_000_000, 0)
+	forever, week := 0, 7
+
+	purged := map[int64]int64{}
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{},
+		&mock.TrashRepositoryMock{
+			DeleteOlderThanFunc: func(userID int64, before int64) ([]entity.TrashItem, error) {
+				purged[userID] = before
+				return []entity.TrashItem{{ID: userID, Type: vo.NodeTypeFolder}}, nil
+			},
+		},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+	purger := NewTrashPurger(svc, &mock.UserRepositoryMock{
+		ListFunc: func() ([]entity.User, error) {
+			return []entity.User{{ID: 1}, {ID: 2, TrashRetentionDays: &forever}, {ID: 3, TrashRetentionDays: &week}}, nil
+		},
+	}, 30, &mock.LoggerMock{})
+
+	removed, err := purger.Purge(now)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if removed != 2 || len(purged) != 2 {
+		t.Fatalf("removed %d, purged %v, want users 1 and 3", removed, purged)
+	}
+	if purged[1] != now.AddDate(0, 0, -30).Unix() {
+		t.Errorf("user 1 purged before %d, want the server default of 30 days", purged[1])
+	}
+	if purged[3] != now.AddDate(0, 0, -7).Unix() {
+		t.Errorf("user 3 purged before %d, want their own 7 days", purged[3])
+	}
+}
+
+func TestTrashPurger_Purge_disabled(t *testing.T) {
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{},
+		&mock.TrashRepositoryMock{
+			DeleteOlderThanFunc: func(userID int64, before int64) ([]entity.TrashItem, error) {
+				t.Errorf("user %d purged with retention disabled", userID)
+				return nil, nil
+			},
+		},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+	purger := NewTrashPurger(svc, &mock.UserRepositoryMock{
+		ListFunc: func() ([]entity.User, error) { return []entity.User{{ID: 1}}, nil },
+	}, 0, &mock.LoggerMock{})
+
+	if removed, err := purger.Purge(time.Now()); removed != 0 || err != nil {
+		t.Errorf("Purge = %d, %v, want 0, nil", removed, err)
+	}
+}
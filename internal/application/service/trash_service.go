@@ -1,6 +1,8 @@
 package service
 
 import (
+	"strings"
+
 	"github.com/pozitronik/tucha/internal/application/port"
 	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/repository"
@@ -14,6 +16,8 @@ type TrashService struct {
 	contents repository.ContentRepository
 	storage  port.ContentStorage
 	shares   repository.ShareRepository
+	changes  *ChangeService
+	xattrs   repository.XAttrRepository
 }
 
 // NewTrashService creates a new TrashService.
@@ -33,6 +37,18 @@ func NewTrashService(
 	}
 }
 
+// WithChanges records the service's changes to the given change journal.
+func (s *TrashService) WithChanges(changes *ChangeService) *TrashService {
+	s.changes = changes
+	return s
+}
+
+// WithXAttrs carries the extended attributes of nodes into the trees it clones.
+func (s *TrashService) WithXAttrs(xattrs repository.XAttrRepository) *TrashService {
+	s.xattrs = xattrs
+	return s
+}
+
 // Trash soft-deletes a node by moving it (and its descendants) to the trash table,
 // then hard-deleting from nodes. Content ref counts are NOT decremented -- content
 // remains available while in trash.
@@ -59,15 +75,18 @@ func (s *TrashService) Trash(userID int64, path vo.CloudPath, deletedBy int64) e
 		return err
 	}
 
-	// Remove all share records pointing at the trashed subtree.
+	// Remove all share records pointing at the trashed subtree. This comes
+	// before recording the deletion, whose mounts were already recorded as removed.
 	s.deleteShares(affectedShares)
+	s.changes.RecordNode(vo.ChangeDelete, node, "")
 
 	return nil
 }
 
 // cloneMountedRWShares finds shares affected by trashing the given path.
-// For each mounted RW share, it clones the shared content into the mount user's
-// tree so the data persists after the source is deleted.
+// Every mount of them is recorded as removed. For each mounted RW share, it clones
+// the shared content into the mount user's tree so the data persists after the
+// source is deleted.
 // Returns the list of affected shares for later cleanup.
 func (s *TrashService) cloneMountedRWShares(ownerID int64, path vo.CloudPath) []entity.Share {
 	shares, err := s.shares.ListByOwnerPathPrefix(ownerID, path)
@@ -77,12 +96,16 @@ func (s *TrashService) cloneMountedRWShares(ownerID int64, path vo.CloudPath) []
 
 	for i := range shares {
 		share := &shares[i]
-		if !share.IsAccepted() || share.Access != vo.AccessReadWrite || share.MountUserID == nil {
+		if !share.IsAccepted() || share.MountUserID == nil {
 			continue
 		}
 		mountPath := vo.NewCloudPath(share.MountHome)
-		_ = s.nodes.EnsurePath(*share.MountUserID, mountPath)
-		_ = cloneTree(s.nodes, s.contents, ownerID, share.Home, *share.MountUserID, mountPath)
+		s.changes.RecordFolder(*share.MountUserID, vo.ChangeUnmount, mountPath)
+		if share.Access != vo.AccessReadWrite {
+			continue
+		}
+		_ = ensurePath(s.nodes, s.changes, *share.MountUserID, mountPath)
+		_ = cloneTree(s.nodes, s.contents, s.xattrs, ownerID, share.Home, *share.MountUserID, mountPath)
 	}
 
 	return shares
@@ -100,49 +123,122 @@ func (s *TrashService) List(userID int64) ([]entity.TrashItem, error) {
 	return s.trash.List(userID)
 }
 
-// Restore moves a trash item back into the active filesystem.
+// Restore moves a trash item back into the active filesystem at its original path.
 // The item is identified by its original path and revision.
 // conflict determines how to handle an existing node at the target path.
 func (s *TrashService) Restore(userID int64, path vo.CloudPath, rev int64, conflict vo.ConflictMode) error {
+	_, err := s.RestoreTo(userID, path, rev, path.Parent(), conflict)
+	return err
+}
+
+// RestoreTo moves a trash item back into the active filesystem, into the given
+// folder, and returns the restored node. A folder is restored together with
+// the items trashed inside it, and restored nodes keep their modification times.
+// A restore renamed by the conflict mode returns the node under its new name.
+// The item is identified by its original path and revision.
+// conflict determines how to handle an existing node at the target path.
+func (s *TrashService) RestoreTo(userID int64, path vo.CloudPath, rev int64, folder vo.CloudPath, conflict vo.ConflictMode) (*entity.Node, error) {
 	item, err := s.trash.GetByPathAndRev(userID, path, rev)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if item == nil {
-		return ErrNotFound
+		return nil, ErrNotFound
+	}
+	var descendants []entity.TrashItem
+	if item.IsFolder() {
+		if descendants, err = s.trash.ListDescendants(item); err != nil {
+			return nil, err
+		}
 	}
 
-	// Ensure parent directory exists.
-	parentPath := path.Parent()
-	if err := s.nodes.EnsurePath(userID, parentPath); err != nil {
-		return err
+	// Ensure the target folder exists.
+	if err := ensurePath(s.nodes, s.changes, userID, folder); err != nil {
+		return nil, err
 	}
 
 	// Handle conflict at target path.
-	exists, err := s.nodes.Exists(userID, path)
+	target, replace, err := resolveConflict(s.nodes, userID, folder.Join(item.Name), item.IsFile(), conflict)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if exists {
-		if conflict == vo.ConflictStrict {
-			return ErrAlreadyExists
+	if replace {
+		// The replaced node goes to the trash, keeping its content referenced
+		// and journaling the deletion, like any other delete.
+		if err := s.Trash(userID, target, userID); err != nil {
+			return nil, err
 		}
-		if err := s.nodes.Delete(userID, path); err != nil {
-			return err
+	}
+
+	// Recreate the node and its descendants in the active filesystem.
+	node, err := s.restoreNode(userID, item, target)
+	if err != nil {
+		return nil, err
+	}
+	prefix := item.Home.String()
+	for i := range descendants {
+		d := &descendants[i]
+		dst := vo.NewCloudPath(target.String() + strings.TrimPrefix(d.Home.String(), prefix))
+		if _, err := s.restoreNode(userID, d, dst); err != nil {
+			return nil, err
 		}
 	}
+	s.changes.RecordNode(vo.ChangeRestore, node, "")
+
+	return node, nil
+}
 
-	// Recreate the node in the active filesystem.
+// restoreNode recreates a single trash item at the given path with its
+// modification time and extended attributes, and removes it from the trash.
+func (s *TrashService) restoreNode(userID int64, item *entity.TrashItem, path vo.CloudPath) (*entity.Node, error) {
+	var node *entity.Node
+	var err error
 	if item.IsFile() {
-		_, err = s.nodes.CreateFile(userID, path, item.Hash, item.Size)
+		node, err = s.nodes.CreateFile(userID, path, item.Hash, item.Size)
 	} else {
-		_, err = s.nodes.CreateFolder(userID, path)
+		node, err = s.nodes.CreateFolder(userID, path)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.nodes.SetMTime(userID, path, item.MTime); err != nil {
+		return nil, err
+	}
+	node.MTime = item.MTime
+	if err := s.trash.RestoreXAttrs(item.ID, node.ID); err != nil {
+		return nil, err
+	}
+
+	return node, s.trash.Delete(item.ID)
+}
+
+// Remove permanently deletes a trash item, together with the items trashed
+// inside it if it is a folder, and cleans up unreferenced content from disk.
+// The item is identified by its original path and revision.
+func (s *TrashService) Remove(userID int64, path vo.CloudPath, rev int64) error {
+	item, err := s.trash.GetByPathAndRev(userID, path, rev)
 	if err != nil {
 		return err
 	}
+	if item == nil {
+		return ErrNotFound
+	}
+	items := []entity.TrashItem{*item}
+	if item.IsFolder() {
+		descendants, err := s.trash.ListDescendants(item)
+		if err != nil {
+			return err
+		}
+		items = append(items, descendants...)
+	}
 
-	return s.trash.Delete(item.ID)
+	for i := range items {
+		if err := s.trash.Delete(items[i].ID); err != nil {
+			return err
+		}
+	}
+	s.releaseContent(items)
+	return nil
 }
 
 // Empty permanently deletes all items in the user's trashbin and cleans up
@@ -152,7 +248,24 @@ func (s *TrashService) Empty(userID int64) error {
 	if err != nil {
 		return err
 	}
+	s.releaseContent(items)
+	return nil
+}
+
+// Purge permanently deletes the items trashed before the given Unix time and
+// cleans up unreferenced content from disk. Returns the number of deleted items.
+func (s *TrashService) Purge(userID int64, before int64) (int, error) {
+	items, err := s.trash.DeleteOlderThan(userID, before)
+	if err != nil {
+		return 0, err
+	}
+	s.releaseContent(items)
+	return len(items), nil
+}
 
+// releaseContent decrements the content ref counts of deleted trash items,
+// removing content that is no longer referenced from disk.
+func (s *TrashService) releaseContent(items []entity.TrashItem) {
 	for _, item := range items {
 		if item.HasContent() {
 			deleted, err := s.contents.Decrement(item.Hash)
@@ -164,6 +277,4 @@ func (s *TrashService) Empty(userID int64) error {
 			}
 		}
 	}
-
-	return nil
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
@@ -140,6 +141,47 @@ func TestTrashService_Restore_success(t *testing.T) {
 	}
 }
 
+func TestTrashService_Restore_xattrs(t *testing.T) {
+	item := mock.NewTestTrashItem(1, "/file.txt", vo.NodeTypeFile)
+	item.ID = 7
+	var restoredFrom, restoredTo int64
+	deleted := false
+
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{
+			CreateFileFunc: func(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error) {
+				return &entity.Node{ID: 42, UserID: userID, Home: path, Type: vo.NodeTypeFile}, nil
+			},
+		},
+		&mock.TrashRepositoryMock{
+			GetByPathAndRevFunc: func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error) {
+				return item, nil
+			},
+			RestoreXAttrsFunc: func(itemID, nodeID int64) error {
+				if deleted {
+					t.Error("attributes restored after the trash item was deleted")
+				}
+				restoredFrom, restoredTo = itemID, nodeID
+				return nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = true
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+
+	if err := svc.Restore(1, vo.NewCloudPath("/file.txt"), 0, vo.ConflictRename); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restoredFrom != 7 || restoredTo != 42 {
+		t.Errorf("RestoreXAttrs(%d, %d), want (7, 42)", restoredFrom, restoredTo)
+	}
+}
+
 func TestTrashService_Restore_notFound(t *testing.T) {
 	svc := NewTrashService(
 		&mock.NodeRepositoryMock{},
@@ -182,6 +224,182 @@ func TestTrashService_Restore_conflictStrict(t *testing.T) {
 	}
 }
 
+func TestTrashService_Restore_conflictRename(t *testing.T) {
+	item := mock.NewTestTrashItem(1, "/file.txt", vo.NodeTypeFile)
+
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{
+			ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return path.String() == "/file.txt", nil },
+			DeleteFunc: func(userID int64, path vo.CloudPath) error {
+				t.Errorf("Delete(%s) called, want both files kept", path)
+				return nil
+			},
+		},
+		&mock.TrashRepositoryMock{
+			GetByPathAndRevFunc: func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error) {
+				return item, nil
+			},
+		},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+
+	node, err := svc.RestoreTo(1, vo.NewCloudPath("/file.txt"), 0, vo.NewCloudPath("/"), vo.ConflictRename)
+	if err != nil {
+		t.Fatalf("RestoreTo(rename): %v", err)
+	}
+	if node.Home.String() != "/file (1).txt" {
+		t.Errorf("RestoreTo(rename) home = %q, want %q", node.Home, "/file (1).txt")
+	}
+}
+
+func TestTrashService_Restore_conflictReplaceTrashesExisting(t *testing.T) {
+	item := mock.NewTestTrashItem(1, "/file.txt", vo.NodeTypeFile)
+	existing := mock.NewTestFileNode(1, "/file.txt", mock.ValidHash(), 10)
+
+	var trashed []string
+	var deleted []string
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{
+			ExistsFunc: func(userID int64, path vo.CloudPath) (bool, error) { return path.String() == "/file.txt", nil },
+			GetWithDescendantsFunc: func(userID int64, path vo.CloudPath) (*entity.Node, []entity.Node, error) {
+				return existing, nil, nil
+			},
+			DeleteFunc: func(userID int64, path vo.CloudPath) error {
+				deleted = append(deleted, path.String())
+				return nil
+			},
+		},
+		&mock.TrashRepositoryMock{
+			GetByPathAndRevFunc: func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error) {
+				return item, nil
+			},
+			InsertFunc: func(userID int64, node *entity.Node, descendants []entity.Node, deletedBy int64) error {
+				trashed = append(trashed, node.Home.String())
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			DecrementFunc: func(hash vo.ContentHash) (bool, error) {
+				t.Errorf("Decrement(%s) called, want the replaced content kept in the trash", hash)
+				return false, nil
+			},
+		},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+
+	if err := svc.Restore(1, vo.NewCloudPath("/file.txt"), 0, vo.ConflictReplace); err != nil {
+		t.Fatalf("Restore(replace): %v", err)
+	}
+	if len(trashed) != 1 || trashed[0] != "/file.txt" {
+		t.Errorf("trashed = %v, want the replaced /file.txt", trashed)
+	}
+	if len(deleted) != 1 || deleted[0] != "/file.txt" {
+		t.Errorf("deleted = %v, want /file.txt", deleted)
+	}
+}
+
+func TestTrashService_RestoreTo_subtree(t *testing.T) {
+	item := &entity.TrashItem{ID: 1, UserID: 1, Name: "docs", Home: vo.NewCloudPath("/gone/docs"), Type: vo.NodeTypeFolder, MTime: 100}
+	descendants := []entity.TrashItem{
+		{ID: 2, Name: "sub", Home: vo.NewCloudPath("/gone/docs/sub"), Type: vo.NodeTypeFolder, MTime: 200},
+		{ID: 3, Name: "a.txt", Home: vo.NewCloudPath("/gone/docs/sub/a.txt"), Type: vo.NodeTypeFile, Hash: mock.ValidHash(), Size: 5, MTime: 300},
+	}
+
+	var created []string
+	mtimes := map[string]int64{}
+	var removed []int64
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{
+			CreateFolderFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+				created = append(created, path.String())
+				return mock.NewTestNode(userID, path.String(), vo.NodeTypeFolder), nil
+			},
+			CreateFileFunc: func(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error) {
+				created = append(created, path.String())
+				return mock.NewTestFileNode(userID, path.String(), hash, size), nil
+			},
+			SetMTimeFunc: func(userID int64, path vo.CloudPath, mtime int64) error {
+				mtimes[path.String()] = mtime
+				return nil
+			},
+		},
+		&mock.TrashRepositoryMock{
+			GetByPathAndRevFunc: func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error) {
+				return item, nil
+			},
+			ListDescendantsFunc: func(*entity.TrashItem) ([]entity.TrashItem, error) { return descendants, nil },
+			DeleteFunc: func(id int64) error {
+				removed = append(removed, id)
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+
+	node, err := svc.RestoreTo(1, item.Home, 1, vo.NewCloudPath("/restored"), vo.ConflictStrict)
+	if err != nil {
+		t.Fatalf("RestoreTo: %v", err)
+	}
+	if node.Home.String() != "/restored/docs" || node.MTime != 100 {
+		t.Errorf("node = %s at %d, want /restored/docs at 100", node.Home, node.MTime)
+	}
+	want := []string{"/restored/docs", "/restored/docs/sub", "/restored/docs/sub/a.txt"}
+	if strings.Join(created, ",") != strings.Join(want, ",") {
+		t.Errorf("created %v, want %v", created, want)
+	}
+	if mtimes["/restored/docs/sub"] != 200 || mtimes["/restored/docs/sub/a.txt"] != 300 {
+		t.Errorf("mtimes = %v, want the original ones", mtimes)
+	}
+	if len(removed) != 3 {
+		t.Errorf("removed trash items %v, want all three", removed)
+	}
+}
+
+func TestTrashService_Remove_folder(t *testing.T) {
+	item := &entity.TrashItem{ID: 1, Home: vo.NewCloudPath("/docs"), Type: vo.NodeTypeFolder}
+	var removed []int64
+	var decrements int
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{},
+		&mock.TrashRepositoryMock{
+			GetByPathAndRevFunc: func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error) {
+				return item, nil
+			},
+			ListDescendantsFunc: func(*entity.TrashItem) ([]entity.TrashItem, error) {
+				return []entity.TrashItem{{ID: 2, Home: vo.NewCloudPath("/docs/a.txt"), Type: vo.NodeTypeFile, Hash: mock.ValidHash()}}, nil
+			},
+			DeleteFunc: func(id int64) error {
+				removed = append(removed, id)
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			DecrementFunc: func(vo.ContentHash) (bool, error) {
+				decrements++
+				return false, nil
+			},
+		},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+
+	if err := svc.Remove(1, item.Home, 1); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if len(removed) != 2 || decrements != 1 {
+		t.Errorf("removed %v with %d decrements, want both items and 1", removed, decrements)
+	}
+	item = nil
+	if err := svc.Remove(1, vo.NewCloudPath("/docs"), 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
 func TestTrashService_Empty_contentCleanup(t *testing.T) {
 	hash := mock.ValidHash()
 	items := []entity.TrashItem{
@@ -222,6 +440,48 @@ func TestTrashService_Empty_contentCleanup(t *testing.T) {
 	}
 }
 
+func TestTrashService_Purge(t *testing.T) {
+	hash := mock.ValidHash()
+	var gotBefore int64
+	var decrements int
+	svc := NewTrashService(
+		&mock.NodeRepositoryMock{},
+		&mock.TrashRepositoryMock{
+			DeleteOlderThanFunc: func(userID int64, before int64) ([]entity.TrashItem, error) {
+				gotBefore = before
+				return []entity.TrashItem{
+					{ID: 1, Type: vo.NodeTypeFolder},
+					{ID: 2, Type: vo.NodeTypeFile, Hash: hash, Size: 100},
+				}, nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			DecrementFunc: func(h vo.ContentHash) (bool, error) {
+				decrements++
+				return false, nil
+			},
+		},
+		&mock.ContentStorageMock{
+			DeleteFunc: func(h vo.ContentHash) error {
+				t.Error("content still referenced was deleted from disk")
+				return nil
+			},
+		},
+		&mock.ShareRepositoryMock{},
+	)
+
+	n, err := svc.Purge(1, 12345)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if n != 2 || gotBefore != 12345 {
+		t.Errorf("Purge = %d items before %d, want 2 before 12345", n, gotBefore)
+	}
+	if decrements != 1 {
+		t.Errorf("decrements = %d, want 1 for the file", decrements)
+	}
+}
+
 func TestTrashService_Trash_deletesShareRecords(t *testing.T) {
 	folder := mock.NewTestNode(1, "/shared", vo.NodeTypeFolder)
 	pendingShare := entity.Share{ID: 10, OwnerID: 1, Home: vo.NewCloudPath("/shared"), Access: vo.AccessReadOnly, Status: vo.SharePending}
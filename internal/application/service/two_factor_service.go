@@ -0,0 +1,308 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+// recoveryCodeCount is the number of recovery codes issued when 2FA is confirmed.
+const recoveryCodeCount = 10
+
+// TwoFactorService manages TOTP enrollment, second-factor verification,
+// recovery codes, and app passwords.
+type TwoFactorService struct {
+	users        repository.UserRepository
+	appPasswords repository.AppPasswordRepository
+	totp         port.TOTP
+	issuer       string
+	guard        *codeGuard
+}
+
+// NewTwoFactorService creates a new TwoFactorService.
+// The issuer is shown as the account label in authenticator apps.
+func NewTwoFactorService(users repository.UserRepository, appPasswords repository.AppPasswordRepository, totp port.TOTP, issuer string) *TwoFactorService {
+	return &TwoFactorService{users: users, appPasswords: appPasswords, totp: totp, issuer: issuer, guard: newCodeGuard()}
+}
+
+// Enrollment holds the data an authenticator app needs to register an account.
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorState summarizes a user's two-factor configuration.
+type TwoFactorState struct {
+	Enabled           bool
+	Pending           bool // a secret was issued but not confirmed yet
+	RecoveryCodesLeft int
+}
+
+// Status returns the user's two-factor configuration.
+func (s *TwoFactorService) Status(userID int64) (*TwoFactorState, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorState{
+		Enabled:           user.TOTPEnabled,
+		Pending:           !user.TOTPEnabled && user.TOTPSecret != "",
+		RecoveryCodesLeft: len(user.RecoveryCodes),
+	}, nil
+}
+
+// Enroll generates a new TOTP secret for the user. 2FA stays disabled until
+// Confirm is called with a valid code; calling Enroll again replaces the pending secret.
+// Returns ErrAlreadyExists if 2FA is already enabled.
+func (s *TwoFactorService) Enroll(userID int64) (*Enrollment, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyExists
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+	if err := s.users.Update(user); err != nil {
+		return nil, fmt.Errorf("storing TOTP secret: %w", err)
+	}
+
+	return &Enrollment{
+		Secret: secret,
+		URI:    s.totp.ProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables 2FA once the user proves possession of the secret.
+// Returns the plaintext recovery codes; they are stored hashed and cannot be shown again.
+// Returns ErrNotFound if no enrollment is pending, ErrForbidden if the code is
+// wrong and ErrTooManyAttempts after too many wrong codes.
+func (s *TwoFactorService) Confirm(userID int64, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyExists
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrNotFound
+	}
+	if err := s.guard.begin(user.ID); err != nil {
+		return nil, err
+	}
+	if step, ok := s.totp.Verify(user.TOTPSecret, code); !ok || !s.guard.accept(user.ID, step) {
+		return nil, ErrForbidden
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashSecret(normalizeRecoveryCode(codes[i]))
+	}
+
+	user.TOTPEnabled = true
+	user.RecoveryCodes = hashes
+	if err := s.users.Update(user); err != nil {
+		return nil, fmt.Errorf("enabling 2FA: %w", err)
+	}
+	return codes, nil
+}
+
+// Disable turns 2FA off after verifying a TOTP or recovery code.
+// Returns ErrNotFound if 2FA is not enabled, ErrForbidden if the code is wrong
+// and ErrTooManyAttempts after too many wrong codes.
+func (s *TwoFactorService) Disable(userID int64, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrNotFound
+	}
+
+	ok, err := s.VerifySecondFactor(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+
+	user.ClearTwoFactor()
+	return s.users.Update(user)
+}
+
+// VerifySecondFactor checks a TOTP code or, failing that, a recovery code.
+// A matching recovery code is consumed, and a TOTP code is accepted only once.
+// After maxCodeAttempts wrong codes in a row, ErrTooManyAttempts is returned
+// for codeLockout, whatever the code.
+func (s *TwoFactorService) VerifySecondFactor(user *entity.User, code string) (bool, error) {
+	if !user.TOTPEnabled || code == "" {
+		return false, nil
+	}
+	if err := s.guard.begin(user.ID); err != nil {
+		return false, err
+	}
+	if step, ok := s.totp.Verify(user.TOTPSecret, code); ok {
+		return s.guard.accept(user.ID, step), nil
+	}
+
+	hash := hashSecret(normalizeRecoveryCode(code))
+	for i, h := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			if err := s.users.Update(user); err != nil {
+				return false, fmt.Errorf("consuming recovery code: %w", err)
+			}
+			s.guard.succeed(user.ID)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CheckLogin verifies an interactive (web) login: the account password and,
+// when 2FA is enabled, a TOTP or recovery code.
+// Returns ErrForbidden for wrong credentials, ErrSecondFactorRequired
+// when the password is correct but no code was supplied, and ErrTooManyAttempts
+// after too many wrong codes.
+func (s *TwoFactorService) CheckLogin(email, password, code string) (*entity.User, error) {
+	user, err := s.users.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return nil, ErrForbidden
+	}
+	if !user.TOTPEnabled {
+		return user, nil
+	}
+	if code == "" {
+		return nil, ErrSecondFactorRequired
+	}
+
+	ok, err := s.VerifySecondFactor(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// CreateAppPassword issues a new app password for the user.
+// Returns the stored record and the plaintext password, which is shown only once.
+func (s *TwoFactorService) CreateAppPassword(userID int64, name string) (*entity.AppPassword, string, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, "", err
+	}
+
+	plain, err := randomHex(12)
+	if err != nil {
+		return nil, "", err
+	}
+
+	p := &entity.AppPassword{UserID: userID, Name: name, PasswordHash: hashSecret(plain)}
+	if err := s.appPasswords.Create(p); err != nil {
+		return nil, "", err
+	}
+	return p, plain, nil
+}
+
+// ListAppPasswords returns the user's app passwords.
+func (s *TwoFactorService) ListAppPasswords(userID int64) ([]entity.AppPassword, error) {
+	passwords, err := s.appPasswords.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if passwords == nil {
+		passwords = []entity.AppPassword{}
+	}
+	return passwords, nil
+}
+
+// RevokeAppPassword deletes an app password owned by the user.
+// Returns ErrNotFound if it does not exist or belongs to another user.
+func (s *TwoFactorService) RevokeAppPassword(userID, id int64) error {
+	p, err := s.appPasswords.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if p == nil || p.UserID != userID {
+		return ErrNotFound
+	}
+	return s.appPasswords.Delete(id)
+}
+
+// getUser loads a user or returns ErrNotFound.
+func (s *TwoFactorService) getUser(userID int64) (*entity.User, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("looking up user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+	return user, nil
+}
+
+// matchAppPassword reports whether password matches one of the user's app
+// passwords, and records its use.
+func matchAppPassword(appPasswords repository.AppPasswordRepository, userID int64, password string) (bool, error) {
+	passwords, err := appPasswords.ListByUser(userID)
+	if err != nil {
+		return false, err
+	}
+
+	hash := hashSecret(password)
+	for _, p := range passwords {
+		if subtle.ConstantTimeCompare([]byte(p.PasswordHash), []byte(hash)) == 1 {
+			if err := appPasswords.MarkUsed(p.ID, time.Now().Unix()); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// normalizeRecoveryCode lowercases a recovery code and strips separators,
+// so codes are accepted with or without the dash.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// hashSecret returns the SHA-256 hex digest used to store recovery codes and app passwords.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes encoded as lowercase hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// newTwoFactorFixture returns a service backed by a single in-memory user.
+func newTwoFactorFixture(user *entity.User) *TwoFactorService {
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			if id != user.ID {
+				return nil, nil
+			}
+			copied := *user
+			return &copied, nil
+		},
+		GetByEmailFunc: func(email string) (*entity.User, error) {
+			if email != user.Email {
+				return nil, nil
+			}
+			copied := *user
+			return &copied, nil
+		},
+		UpdateFunc: func(u *entity.User) error {
+			*user = *u
+			return nil
+		},
+	}
+	totp := &mock.TOTPMock{FixedSecret: "JBSWY3DPEHPK3PXP", ValidCode: "123456"}
+	return NewTwoFactorService(users, &mock.AppPasswordRepositoryMock{}, totp, "Tucha")
+}
+
+func TestTwoFactorService_EnrollAndConfirm(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com", Password: "pw"}
+	svc := newTwoFactorFixture(user)
+
+	enrollment, err := svc.Enroll(1)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if enrollment.Secret != "JBSWY3DPEHPK3PXP" || enrollment.URI == "" {
+		t.Errorf("Enroll = %+v", enrollment)
+	}
+	if user.TOTPEnabled {
+		t.Error("2FA should stay disabled until confirmed")
+	}
+
+	if _, err := svc.Confirm(1, "000000"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Confirm(wrong code) error = %v, want ErrForbidden", err)
+	}
+
+	codes, err := svc.Confirm(1, "123456")
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(user.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("recovery codes = %d issued, %d stored; want %d", len(codes), len(user.RecoveryCodes), recoveryCodeCount)
+	}
+	if !user.TOTPEnabled {
+		t.Error("2FA should be enabled after Confirm")
+	}
+	for _, h := range user.RecoveryCodes {
+		for _, c := range codes {
+			if h == c {
+				t.Fatal("recovery codes must be stored hashed")
+			}
+		}
+	}
+
+	if _, err := svc.Enroll(1); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Enroll(enabled) error = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestTwoFactorService_ConfirmWithoutEnroll(t *testing.T) {
+	svc := newTwoFactorFixture(&entity.User{ID: 1})
+
+	if _, err := svc.Confirm(1, "123456"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTwoFactorService_RecoveryCodeIsConsumed(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com"}
+	svc := newTwoFactorFixture(user)
+	if _, err := svc.Enroll(1); err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	codes, err := svc.Confirm(1, "123456")
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	ok, err := svc.VerifySecondFactor(user, codes[0])
+	if err != nil || !ok {
+		t.Fatalf("VerifySecondFactor(recovery) = %v, %v; want true", ok, err)
+	}
+	if len(user.RecoveryCodes) != recoveryCodeCount-1 {
+		t.Errorf("recovery codes left = %d, want %d", len(user.RecoveryCodes), recoveryCodeCount-1)
+	}
+
+	ok, _ = svc.VerifySecondFactor(user, codes[0])
+	if ok {
+		t.Error("a recovery code must not be accepted twice")
+	}
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	user := &entity.User{ID: 1, TOTPEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP", RecoveryCodes: []string{"x"}}
+	svc := newTwoFactorFixture(user)
+
+	if err := svc.Disable(1, "000000"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Disable(wrong code) error = %v, want ErrForbidden", err)
+	}
+	if err := svc.Disable(1, "123456"); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if user.TOTPEnabled || user.TOTPSecret != "" || len(user.RecoveryCodes) != 0 {
+		t.Errorf("2FA state not cleared: %+v", user)
+	}
+	if err := svc.Disable(1, "123456"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Disable(disabled) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTwoFactorService_CheckLogin(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com", Password: "pw", TOTPEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP"}
+	svc := newTwoFactorFixture(user)
+
+	tests := []struct {
+		name     string
+		password string
+		code     string
+		wantErr  error
+	}{
+		{"wrong password", "bad", "123456", ErrForbidden},
+		{"missing code", "pw", "", ErrSecondFactorRequired},
+		{"wrong code", "pw", "000000", ErrForbidden},
+		{"valid", "pw", "123456", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CheckLogin("user@example.com", tt.password, tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTwoFactorService_TOTPCodeUsedOnce(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com", Password: "pw", TOTPEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP"}
+	svc := newTwoFactorFixture(user)
+	totp := svc.totp.(*mock.TOTPMock)
+	totp.Step = 100
+
+	if _, err := svc.CheckLogin("user@example.com", "pw", "123456"); err != nil {
+		t.Fatalf("CheckLogin: %v", err)
+	}
+	if _, err := svc.CheckLogin("user@example.com", "pw", "123456"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("CheckLogin(replayed code) error = %v, want ErrForbidden", err)
+	}
+	// A code from an earlier step, still inside the drift window, is rejected too.
+	totp.Step = 99
+	if _, err := svc.CheckLogin("user@example.com", "pw", "123456"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("CheckLogin(earlier code) error = %v, want ErrForbidden", err)
+	}
+	totp.Step = 101
+	if _, err := svc.CheckLogin("user@example.com", "pw", "123456"); err != nil {
+		t.Errorf("CheckLogin(next code): %v", err)
+	}
+}
+
+func TestTwoFactorService_LockoutAfterWrongCodes(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com", Password: "pw", TOTPEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP"}
+	svc := newTwoFactorFixture(user)
+	now := time.Unix(1_000_000_000, 0)
+	svc.guard.now = func() time.Time { return now }
+
+	for i := 0; i < maxCodeAttempts; i++ {
+		if _, err := svc.CheckLogin("user@example.com", "pw", "000000"); !errors.Is(err, ErrForbidden) {
+			t.Fatalf("attempt %d: error = %v, want ErrForbidden", i+1, err)
+		}
+	}
+	if _, err := svc.CheckLogin("user@example.com", "pw", "123456"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("CheckLogin(locked out) error = %v, want ErrTooManyAttempts", err)
+	}
+	if err := svc.Disable(1, "123456"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Disable(locked out) error = %v, want ErrTooManyAttempts", err)
+	}
+
+	now = now.Add(codeLockout)
+	if _, err := svc.CheckLogin("user@example.com", "pw", "123456"); err != nil {
+		t.Errorf("CheckLogin after the lockout: %v", err)
+	}
+}
+
+func TestTwoFactorService_RevokeAppPassword(t *testing.T) {
+	var deleted int64
+	svc := NewTwoFactorService(
+		&mock.UserRepositoryMock{},
+		&mock.AppPasswordRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.AppPassword, error) {
+				if id == 5 {
+					return &entity.AppPassword{ID: 5, UserID: 2}, nil
+				}
+				return nil, nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = id
+				return nil
+			},
+		},
+		&mock.TOTPMock{},
+		"Tucha",
+	)
+
+	if err := svc.RevokeAppPassword(1, 5); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other user's password error = %v, want ErrNotFound", err)
+	}
+	if err := svc.RevokeAppPassword(2, 5); err != nil {
+		t.Fatalf("RevokeAppPassword: %v", err)
+	}
+	if deleted != 5 {
+		t.Errorf("deleted = %d, want 5", deleted)
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 
 	"github.com/pozitronik/tucha/internal/application/port"
 	"github.com/pozitronik/tucha/internal/domain/repository"
@@ -44,3 +46,45 @@ func (s *UploadService) Upload(data []byte) (vo.ContentHash, error) {
 
 	return hash, nil
 }
+
+// UploadFrom is Upload for content too large to hold in memory. The content
+// is read twice from open: once to compute the hash and once to store it.
+// Returns the content hash.
+func (s *UploadService) UploadFrom(open func() (io.ReadCloser, error), size int64) (vo.ContentHash, error) {
+	r, err := open()
+	if err != nil {
+		return vo.ContentHash{}, err
+	}
+	hash, err := s.hasher.ComputeReader(r, size)
+	r.Close()
+	if err != nil {
+		return vo.ContentHash{}, err
+	}
+
+	if r, err = open(); err != nil {
+		return vo.ContentHash{}, err
+	}
+	n, err := s.storage.Write(hash, r)
+	r.Close()
+	if err != nil {
+		return vo.ContentHash{}, err
+	}
+	if n != size {
+		return vo.ContentHash{}, fmt.Errorf("stored %d bytes, want %d", n, size)
+	}
+
+	if _, err := s.contents.Insert(hash, size); err != nil {
+		return vo.ContentHash{}, err
+	}
+
+	return hash, nil
+}
+
+// UploadFile is UploadFrom for content spooled to a file, such as the
+// temporary file of a streamed upload. Returns the content hash.
+func (s *UploadService) UploadFile(f io.ReaderAt, size int64) (vo.ContentHash, error) {
+	open := func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(f, 0, size)), nil
+	}
+	return s.UploadFrom(open, size)
+}
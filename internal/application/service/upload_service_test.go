@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/pozitronik/tucha/internal/domain/vo"
@@ -96,3 +97,31 @@ func TestUploadService_emptyData(t *testing.T) {
 		t.Error("Upload(empty) returned zero hash")
 	}
 }
+
+func TestUploadService_UploadFile(t *testing.T) {
+	hash := mock.ValidHash()
+	var stored []byte
+
+	svc := NewUploadService(
+		&mock.HasherMock{FixedHash: hash},
+		&mock.ContentStorageMock{
+			WriteFunc: func(h vo.ContentHash, r io.Reader) (int64, error) {
+				data, err := io.ReadAll(r)
+				stored = data
+				return int64(len(data)), err
+			},
+		},
+		&mock.ContentRepositoryMock{},
+	)
+
+	got, err := svc.UploadFile(strings.NewReader("hello world"), 5)
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if got.String() != hash.String() {
+		t.Errorf("hash = %q, want %q", got.String(), hash.String())
+	}
+	if string(stored) != "hello" {
+		t.Errorf("stored %q, want %q", stored, "hello")
+	}
+}
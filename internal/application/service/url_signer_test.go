@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := NewURLSigner([]byte("key"), 5*time.Minute)
+	signer.now = func() time.Time { return now }
+
+	path := vo.NewCloudPath("/docs/report.pdf")
+	sig := signer.Sign(7, path)
+	if sig.Expires != now.Add(5*time.Minute).Unix() {
+		t.Errorf("Expires = %d, want %d", sig.Expires, now.Add(5*time.Minute).Unix())
+	}
+
+	if err := signer.Verify(path, sig); err != nil {
+		t.Errorf("Verify(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path vo.CloudPath
+		sig  Signature
+	}{
+		{"other path", vo.NewCloudPath("/docs/other.pdf"), sig},
+		{"other user", path, Signature{UserID: 8, Expires: sig.Expires, Value: sig.Value}},
+		{"extended expiry", path, Signature{UserID: 7, Expires: sig.Expires + 3600, Value: sig.Value}},
+		{"tampered signature", path, Signature{UserID: 7, Expires: sig.Expires, Value: sig.Value + "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.path, tt.sig); !errors.Is(err, ErrForbidden) {
+				t.Errorf("Verify = %v, want ErrForbidden", err)
+			}
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		signer.now = func() time.Time { return now.Add(6 * time.Minute) }
+		if err := signer.Verify(path, sig); !errors.Is(err, ErrForbidden) {
+			t.Errorf("Verify = %v, want ErrForbidden", err)
+		}
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		other := NewURLSigner([]byte("other"), 5*time.Minute)
+		other.now = func() time.Time { return now }
+		if err := other.Verify(path, sig); !errors.Is(err, ErrForbidden) {
+			t.Errorf("Verify = %v, want ErrForbidden", err)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // UserService handles user CRUD operations.
@@ -59,6 +60,19 @@ type UserWithUsage struct {
 	BytesUsed int64
 }
 
+// Get returns the user with the given ID.
+// Returns ErrNotFound if there is none.
+func (s *UserService) Get(id int64) (*entity.User, error) {
+	user, err := s.users.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("looking up user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+	return user, nil
+}
+
 // List returns all users.
 func (s *UserService) List() ([]entity.User, error) {
 	return s.users.List()
@@ -113,6 +127,51 @@ func (s *UserService) Update(user *entity.User) error {
 	return s.users.Update(existing)
 }
 
+// SetVersionRetention sets the user's version retention policy;
+// nil restores the server default.
+func (s *UserService) SetVersionRetention(userID int64, policy *vo.RetentionPolicy) error {
+	existing, err := s.users.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if existing == nil {
+		return ErrNotFound
+	}
+
+	existing.VersionRetention = policy
+	return s.users.Update(existing)
+}
+
+// SetTrashRetention sets the number of days the user's trash items are kept;
+// 0 keeps them forever and nil restores the server default.
+func (s *UserService) SetTrashRetention(userID int64, days *int) error {
+	existing, err := s.users.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if existing == nil {
+		return ErrNotFound
+	}
+
+	existing.TrashRetentionDays = days
+	return s.users.Update(existing)
+}
+
+// ResetTwoFactor disables two-factor authentication for a user who lost their
+// authenticator, so they can sign in with their account password again.
+func (s *UserService) ResetTwoFactor(userID int64) error {
+	existing, err := s.users.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if existing == nil {
+		return ErrNotFound
+	}
+
+	existing.ClearTwoFactor()
+	return s.users.Update(existing)
+}
+
 // Delete removes a user by ID.
 func (s *UserService) Delete(targetID int64) error {
 	existing, err := s.users.GetByID(targetID)
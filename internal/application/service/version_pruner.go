@@ -0,0 +1,124 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// noHistoryPolicy applies to users without version history. They cannot
+// list or restore old revisions, so only the newest of each file is kept.
+var noHistoryPolicy = vo.RetentionPolicy{KeepLast: 1}
+
+// VersionPruner removes file versions that the retention policy no longer
+// keeps, releasing their content.
+type VersionPruner struct {
+	versions repository.FileVersionRepository
+	contents repository.ContentRepository
+	storage  port.ContentStorage
+	users    repository.UserRepository
+	policy   vo.RetentionPolicy
+	logger   port.Logger
+}
+
+// NewVersionPruner creates a VersionPruner applying policy to users without their own.
+func NewVersionPruner(
+	versions repository.FileVersionRepository,
+	contents repository.ContentRepository,
+	storage port.ContentStorage,
+	users repository.UserRepository,
+	policy vo.RetentionPolicy,
+	logger port.Logger,
+) *VersionPruner {
+	return &VersionPruner{
+		versions: versions,
+		contents: contents,
+		storage:  storage,
+		users:    users,
+		policy:   policy,
+		logger:   logger,
+	}
+}
+
+// Run prunes expired versions every interval. It never returns.
+func (p *VersionPruner) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		removed, err := p.Prune(now)
+		if err != nil {
+			p.logger.Error("Version pruning failed: %v", err)
+		}
+		if removed > 0 {
+			p.logger.Info("Version pruning removed %d file versions", removed)
+		}
+	}
+}
+
+// Prune removes the versions of every user that their retention policy no
+// longer keeps at the time now. Returns the number of removed versions.
+func (p *VersionPruner) Prune(now time.Time) (int, error) {
+	users, err := p.users.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i := range users {
+		n, err := p.pruneUser(&users[i], now.Unix())
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// pruneUser removes a user's expired versions, path by path.
+func (p *VersionPruner) pruneUser(user *entity.User, now int64) (int, error) {
+	policy := p.policy
+	switch {
+	case !user.VersionHistory:
+		policy = noHistoryPolicy
+	case user.VersionRetention != nil:
+		policy = *user.VersionRetention
+	}
+
+	versions, err := p.versions.ListByUser(user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for start := 0; start < len(versions); {
+		end := start + 1
+		for end < len(versions) && versions[end].Home == versions[start].Home {
+			end++
+		}
+		history := versions[start:end]
+		start = end
+
+		times := make([]int64, len(history))
+		for i := range history {
+			times[i] = history[i].Time
+		}
+		for i, keep := range policy.Keep(times, now) {
+			if keep {
+				continue
+			}
+			if err := p.versions.Delete(history[i].ID); err != nil {
+				return removed, err
+			}
+			if history[i].ContentRef {
+				if deleted, _ := p.contents.Decrement(history[i].Hash); deleted {
+					_ = p.storage.Delete(history[i].Hash)
+				}
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
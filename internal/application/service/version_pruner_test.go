@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestVersionPruner_Prune(t *testing.T) {
+	now := time.Unix(1_000_000_000, 0)
+	hash := mock.ValidHash()
+	a, b := vo.NewCloudPath("/a.txt"), vo.NewCloudPath("/b.txt")
+	// Newest first per path, as ListByUser returns them.
+	history := map[int64][]entity.FileVersion{
+		1: {
+			{ID: 1, Home: a, Hash: hash, Time: now.Unix() - 10, ContentRef: true},
+			{ID: 2, Home: a, Hash: hash, Time: now.Unix() - 20, ContentRef: true},
+			{ID: 3, Home: a, Hash: hash, Time: now.Unix() - 30},
+			{ID: 4, Home: b, Hash: hash, Time: now.Unix() - 40, ContentRef: true},
+		},
+		2: {
+			{ID: 5, Home: a, Hash: hash, Time: now.Unix() - 10, ContentRef: true},
+			{ID: 6, Home: a, Hash: hash, Time: now.Unix() - 20, ContentRef: true},
+		},
+		3: {
+			{ID: 7, Home: a, Hash: hash, Time: now.Unix() - 10, ContentRef: true},
+			{ID: 8, Home: a, Hash: hash, Time: now.Unix() - 20, ContentRef: true},
+		},
+	}
+	own := vo.RetentionPolicy{KeepLast: 2}
+
+	var deleted []int64
+	var decrements, storageDeletes int
+	pruner := NewVersionPruner(
+		&mock.FileVersionRepositoryMock{
+			ListByUserFunc: func(userID int64) ([]entity.FileVersion, error) { return history[userID], nil },
+			DeleteFunc: func(id int64) error {
+				deleted = append(deleted, id)
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{
+			DecrementFunc: func(vo.ContentHash) (bool, error) {
+				decrements++
+				return true, nil
+			},
+		},
+		&mock.ContentStorageMock{
+			DeleteFunc: func(vo.ContentHash) error {
+				storageDeletes++
+				return nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			ListFunc: func() ([]entity.User, error) {
+				return []entity.User{
+					{ID: 1, VersionHistory: true},
+					{ID: 2, VersionHistory: true, VersionRetention: &own},
+					{ID: 3, VersionRetention: &own},
+				}, nil
+			},
+		},
+		vo.RetentionPolicy{KeepLast: 1},
+		&mock.LoggerMock{},
+	)
+
+	removed, err := pruner.Prune(now)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	// User 1 keeps the newest version of each path; user 2's own policy keeps both.
+	// User 3 has no version history, so only the newest version is kept whatever the policy.
+	if removed != 3 || len(deleted) != 3 || deleted[0] != 2 || deleted[1] != 3 || deleted[2] != 8 {
+		t.Errorf("removed %d, deleted %v, want versions 2, 3 and 8", removed, deleted)
+	}
+	// Version 3 does not hold a content reference.
+	if decrements != 2 || storageDeletes != 2 {
+		t.Errorf("decrements = %d, storage deletes = %d, want 2 and 2", decrements, storageDeletes)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// MaxXAttrValueSize is the largest value of one extended attribute, in bytes.
+const MaxXAttrValueSize = 8 << 10
+
+// MaxXAttrsSize is the largest total size of the extended attributes of one
+// node: the bytes of all their names and values.
+const MaxXAttrsSize = 64 << 10
+
+// XAttrService handles the extended attributes of nodes: named values the
+// user attaches to files and folders. Attributes stay with a node when it is
+// renamed or moved, are copied with it and are kept while it is in the trash.
+type XAttrService struct {
+	nodes  repository.NodeRepository
+	xattrs repository.XAttrRepository
+}
+
+// NewXAttrService creates a new XAttrService.
+func NewXAttrService(nodes repository.NodeRepository, xattrs repository.XAttrRepository) *XAttrService {
+	return &XAttrService{
+		nodes:  nodes,
+		xattrs: xattrs,
+	}
+}
+
+// List returns the attributes of the node at the given path, ordered by name.
+// Returns ErrNotFound if the node does not exist.
+func (s *XAttrService) List(userID int64, path vo.CloudPath) ([]entity.XAttr, error) {
+	node, err := s.node(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.xattrs.List(node.ID)
+}
+
+// Get returns the named attribute of the node at the given path.
+// Returns ErrNotFound if the node does not exist or the attribute is not set.
+func (s *XAttrService) Get(userID int64, path vo.CloudPath, name vo.XAttrName) (*entity.XAttr, error) {
+	node, err := s.node(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	attr, err := s.xattrs.Get(node.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	if attr == nil {
+		return nil, ErrNotFound
+	}
+	return attr, nil
+}
+
+// Set creates or replaces the named attribute of the node at the given path
+// and returns the attributes of the node.
+// Returns ErrNotFound if the node does not exist, and ErrTooLarge if the value
+// or the attributes of the node would exceed their size limits.
+func (s *XAttrService) Set(userID int64, path vo.CloudPath, name vo.XAttrName, value string) ([]entity.XAttr, error) {
+	if len(value) > MaxXAttrValueSize {
+		return nil, ErrTooLarge
+	}
+	node, err := s.node(userID, path)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := s.xattrs.List(node.ID)
+	if err != nil {
+		return nil, err
+	}
+	total := entity.XAttr{Name: name, Value: value}.Size()
+	for _, attr := range attrs {
+		if attr.Name != name {
+			total += attr.Size()
+		}
+	}
+	if total > MaxXAttrsSize {
+		return nil, ErrTooLarge
+	}
+
+	if err := s.xattrs.Set(node.ID, name, value); err != nil {
+		return nil, err
+	}
+	return s.xattrs.List(node.ID)
+}
+
+// Delete removes the named attribute of the node at the given path.
+// Returns ErrNotFound if the node does not exist or the attribute is not set.
+func (s *XAttrService) Delete(userID int64, path vo.CloudPath, name vo.XAttrName) error {
+	node, err := s.node(userID, path)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.xattrs.Delete(node.ID, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// node returns the node at the given path, or ErrNotFound if it does not
+// exist or is the root folder.
+func (s *XAttrService) node(userID int64, path vo.CloudPath) (*entity.Node, error) {
+	node, err := s.nodes.Get(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || path.IsRoot() {
+		return nil, ErrNotFound
+	}
+	return node, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestXAttrService_Set(t *testing.T) {
+	node := mock.NewTestFileNode(1, "/report.pdf", mock.ValidHash(), 10)
+	node.ID = 5
+	attrs := map[vo.XAttrName]string{"notes": strings.Repeat("n", MaxXAttrValueSize)}
+	svc := NewXAttrService(
+		&mock.NodeRepositoryMock{
+			GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+				if path != node.Home {
+					return nil, nil
+				}
+				return node, nil
+			},
+		},
+		&mock.XAttrRepositoryMock{
+			ListFunc: func(nodeID int64) ([]entity.XAttr, error) {
+				var list []entity.XAttr
+				for name, value := range attrs {
+					list = append(list, entity.XAttr{Name: name, Value: value})
+				}
+				return list, nil
+			},
+			SetFunc: func(nodeID int64, name vo.XAttrName, value string) error {
+				if nodeID != node.ID {
+					t.Errorf("Set on node %d, want %d", nodeID, node.ID)
+				}
+				attrs[name] = value
+				return nil
+			},
+		},
+	)
+
+	list, err := svc.Set(1, node.Home, "source", "crm")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if len(list) != 2 || attrs["source"] != "crm" {
+		t.Errorf("Set returned %d attributes, stored %v", len(list), attrs)
+	}
+
+	if _, err := svc.Set(1, node.Home, "source", strings.Repeat("x", MaxXAttrValueSize+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Set of a too large value error = %v, want ErrTooLarge", err)
+	}
+
+	// Fill the node up to its limit; replacing a value only counts the new one.
+	for i := 0; len(attrs) < 9; i++ {
+		attrs[vo.XAttrName("fill"+string(rune('a'+i)))] = strings.Repeat("f", MaxXAttrValueSize-10)
+	}
+	if _, err := svc.Set(1, node.Home, "notes", strings.Repeat("s", 100)); err != nil {
+		t.Errorf("Set replacing a value: %v", err)
+	}
+	if _, err := svc.Set(1, node.Home, "extra", strings.Repeat("e", MaxXAttrValueSize)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Set over the node limit error = %v, want ErrTooLarge", err)
+	}
+
+	if _, err := svc.Set(1, vo.NewCloudPath("/missing"), "source", "crm"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Set on a missing node error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestXAttrService_GetDelete(t *testing.T) {
+	node := mock.NewTestFileNode(1, "/report.pdf", mock.ValidHash(), 10)
+	svc := NewXAttrService(
+		&mock.NodeRepositoryMock{
+			GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) { return node, nil },
+		},
+		&mock.XAttrRepositoryMock{
+			GetFunc: func(nodeID int64, name vo.XAttrName) (*entity.XAttr, error) {
+				if name != "source" {
+					return nil, nil
+				}
+				return &entity.XAttr{Name: name, Value: "crm"}, nil
+			},
+			DeleteFunc: func(nodeID int64, name vo.XAttrName) (bool, error) {
+				return name == "source", nil
+			},
+		},
+	)
+
+	if attr, err := svc.Get(1, node.Home, "source"); err != nil || attr.Value != "crm" {
+		t.Errorf("Get(source) = %+v, %v, want crm", attr, err)
+	}
+	if _, err := svc.Get(1, node.Home, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := svc.Delete(1, node.Home, "source"); err != nil {
+		t.Errorf("Delete(source): %v", err)
+	}
+	if err := svc.Delete(1, node.Home, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.List(1, vo.NewCloudPath("/")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("List of the root folder error = %v, want ErrNotFound", err)
+	}
+}
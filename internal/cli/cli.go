@@ -64,6 +64,10 @@ func Parse(args []string) (*CLI, error) {
 			cli.Command = CmdConfigCheck
 			return cli, nil
 
+		case arg == "--totp-secret" || arg == "-totp-secret":
+			cli.Command = CmdTOTPSecret
+			return cli, nil
+
 		case arg == "--user" || arg == "-user":
 			return parseUserCommand(cli, args[i+1:])
 
@@ -85,7 +89,7 @@ func Parse(args []string) (*CLI, error) {
 // parseUserCommand parses the --user subcommand.
 func parseUserCommand(cli *CLI, args []string) (*CLI, error) {
 	if len(args) == 0 {
-		return nil, fmt.Errorf("--user requires a subcommand (list, add, remove, pwd, quota, sizelimit, history, info)")
+		return nil, fmt.Errorf("--user requires a subcommand (list, add, remove, pwd, quota, sizelimit, history, info, 2fa-reset, impersonate, s3-key)")
 	}
 
 	subCmd := strings.ToLower(args[0])
@@ -145,6 +149,27 @@ func parseUserCommand(cli *CLI, args []string) (*CLI, error) {
 		}
 		cli.Args = rest // email
 
+	case "impersonate":
+		cli.Command = CmdUserImpersonate
+		if len(rest) < 1 {
+			return nil, fmt.Errorf("--user impersonate requires <email> [ro|rw]")
+		}
+		cli.Args = rest // email, optional ro|rw
+
+	case "s3-key":
+		cli.Command = CmdUserS3Key
+		if len(rest) < 1 {
+			return nil, fmt.Errorf("--user s3-key requires <email> [name]")
+		}
+		cli.Args = rest // email, optional key name
+
+	case "2fa-reset":
+		cli.Command = CmdUserTwoFactorReset
+		if len(rest) < 1 {
+			return nil, fmt.Errorf("--user 2fa-reset requires <email>")
+		}
+		cli.Args = rest // email
+
 	default:
 		return nil, fmt.Errorf("unknown --user subcommand: %s", subCmd)
 	}
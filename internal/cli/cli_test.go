@@ -178,6 +178,44 @@ func TestParse(t *testing.T) {
 			args:    []string{"tucha", "--user", "info"},
 			wantErr: true,
 		},
+		{
+			name:     "user 2fa-reset",
+			args:     []string{"tucha", "--user", "2fa-reset", "user@example.com"},
+			wantCmd:  CmdUserTwoFactorReset,
+			wantArgs: []string{"user@example.com"},
+		},
+		{
+			name:    "user 2fa-reset missing email",
+			args:    []string{"tucha", "--user", "2fa-reset"},
+			wantErr: true,
+		},
+		{
+			name:     "user impersonate",
+			args:     []string{"tucha", "--user", "impersonate", "user@example.com", "rw"},
+			wantCmd:  CmdUserImpersonate,
+			wantArgs: []string{"user@example.com", "rw"},
+		},
+		{
+			name:    "user impersonate missing email",
+			args:    []string{"tucha", "--user", "impersonate"},
+			wantErr: true,
+		},
+		{
+			name:     "user s3-key",
+			args:     []string{"tucha", "--user", "s3-key", "user@example.com", "backup"},
+			wantCmd:  CmdUserS3Key,
+			wantArgs: []string{"user@example.com", "backup"},
+		},
+		{
+			name:    "user s3-key missing email",
+			args:    []string{"tucha", "--user", "s3-key"},
+			wantErr: true,
+		},
+		{
+			name:    "totp secret",
+			args:    []string{"tucha", "--totp-secret"},
+			wantCmd: CmdTOTPSecret,
+		},
 		{
 			name:    "unknown user subcommand",
 			args:    []string{"tucha", "--user", "unknown"},
@@ -5,21 +5,25 @@ type Command int
 
 // CLI commands.
 const (
-	CmdRun           Command = iota // Default: run server in foreground
-	CmdHelp                         // Show help message
-	CmdVersion                      // Show version and exit
-	CmdBackground                   // Run server in background (daemon mode)
-	CmdStatus                       // Show if server is running
-	CmdStop                         // Stop background server
-	CmdConfigCheck                  // Validate configuration file
-	CmdUserList                     // List users
-	CmdUserAdd                      // Add user
-	CmdUserRemove                   // Remove user
-	CmdUserPwd                      // Set user password
-	CmdUserQuota                    // Set user quota
-	CmdUserSizeLimit                // Set user file size limit
-	CmdUserHistory                  // Set user version history mode
-	CmdUserInfo                     // Show user details
+	CmdRun                Command = iota // Default: run server in foreground
+	CmdHelp                              // Show help message
+	CmdVersion                           // Show version and exit
+	CmdBackground                        // Run server in background (daemon mode)
+	CmdStatus                            // Show if server is running
+	CmdStop                              // Stop background server
+	CmdConfigCheck                       // Validate configuration file
+	CmdUserList                          // List users
+	CmdUserAdd                           // Add user
+	CmdUserRemove                        // Remove user
+	CmdUserPwd                           // Set user password
+	CmdUserQuota                         // Set user quota
+	CmdUserSizeLimit                     // Set user file size limit
+	CmdUserHistory                       // Set user version history mode
+	CmdUserInfo                          // Show user details
+	CmdUserTwoFactorReset                // Disable user's two-factor authentication
+	CmdUserImpersonate                   // Issue a support token for a user
+	CmdUserS3Key                         // Issue an S3 access key for a user
+	CmdTOTPSecret                        // Generate a TOTP secret for admin.totp_secret
 )
 
 // Exit codes.
@@ -47,6 +51,7 @@ Commands:
   --status           Show if server is running
   --stop             Stop background server
   --config-check     Validate configuration file
+  --totp-secret      Generate a secret for admin.totp_secret
 
 User Management:
   --user list [mask]                   List users (optional email filter)
@@ -57,6 +62,9 @@ User Management:
   --user sizelimit <email> <size>      Set file size limit (0 = unlimited)
   --user history <email> <on|off>      Set version history (on = paid tier)
   --user info <email>                  Show user details
+  --user 2fa-reset <email>             Disable two-factor authentication
+  --user impersonate <email> [ro|rw]   Issue a 15-minute support token (default: ro)
+  --user s3-key <email> [name]         Issue an S3 access key
 
 Examples:
   tucha                            Start in foreground
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/pozitronik/tucha/internal/application/service"
 	"github.com/pozitronik/tucha/internal/domain/repository"
@@ -12,15 +13,19 @@ import (
 
 // UserCommands handles CLI user management operations.
 type UserCommands struct {
-	userService *service.UserService
-	userRepo    repository.UserRepository
+	userService   *service.UserService
+	userRepo      repository.UserRepository
+	impersonation *service.ImpersonationService
+	accessKeys    *service.AccessKeyService
 }
 
 // NewUserCommands creates a new UserCommands instance.
-func NewUserCommands(userService *service.UserService, userRepo repository.UserRepository) *UserCommands {
+func NewUserCommands(userService *service.UserService, userRepo repository.UserRepository, impersonation *service.ImpersonationService, accessKeys *service.AccessKeyService) *UserCommands {
 	return &UserCommands{
-		userService: userService,
-		userRepo:    userRepo,
+		userService:   userService,
+		userRepo:      userRepo,
+		impersonation: impersonation,
+		accessKeys:    accessKeys,
 	}
 }
 
@@ -261,6 +266,91 @@ func (c *UserCommands) Info(w io.Writer, email string) error {
 		fmt.Fprintf(w, "Version history: free\n")
 	}
 
+	if user.TOTPEnabled {
+		fmt.Fprintf(w, "Two-factor:     on (%d recovery codes left)\n", len(user.RecoveryCodes))
+	} else {
+		fmt.Fprintf(w, "Two-factor:     off\n")
+	}
+
+	return nil
+}
+
+// Impersonate issues a support token for a user. Mode "rw" allows changes;
+// the default "ro" token is read-only.
+func (c *UserCommands) Impersonate(w io.Writer, email, mode string) error {
+	var elevated bool
+	switch strings.ToLower(mode) {
+	case "", "ro":
+		elevated = false
+	case "rw":
+		elevated = true
+	default:
+		return fmt.Errorf("invalid mode %q: use ro/rw", mode)
+	}
+
+	user, err := c.userRepo.GetByEmail(email)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("user not found: %s", email)
+	}
+
+	token, err := c.impersonation.Start(user.ID, "cli", elevated, 0)
+	if err != nil {
+		return fmt.Errorf("issuing support token: %w", err)
+	}
+
+	access := "read-only"
+	if elevated {
+		access = "read-write"
+	}
+	fmt.Fprintf(w, "Support token for %s (%s, expires %s):\n%s\n",
+		email, access, time.Unix(token.ExpiresAt, 0).Format(time.RFC3339), token.AccessToken)
+	return nil
+}
+
+// S3Key issues an S3 access key for a user and prints the key pair.
+// The secret cannot be shown again later.
+func (c *UserCommands) S3Key(w io.Writer, email, name string) error {
+	if name == "" {
+		name = "cli"
+	}
+
+	user, err := c.userRepo.GetByEmail(email)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("user not found: %s", email)
+	}
+
+	key, err := c.accessKeys.Create(user.ID, name)
+	if err != nil {
+		return fmt.Errorf("issuing access key: %w", err)
+	}
+
+	fmt.Fprintf(w, "S3 access key for %s (%s):\n", email, name)
+	fmt.Fprintf(w, "Access key ID:     %s\n", key.KeyID)
+	fmt.Fprintf(w, "Secret access key: %s\n", key.Secret)
+	return nil
+}
+
+// ResetTwoFactor disables two-factor authentication for a user.
+func (c *UserCommands) ResetTwoFactor(w io.Writer, email string) error {
+	user, err := c.userRepo.GetByEmail(email)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("user not found: %s", email)
+	}
+
+	if err := c.userService.ResetTwoFactor(user.ID); err != nil {
+		return fmt.Errorf("resetting two-factor authentication: %w", err)
+	}
+
+	fmt.Fprintf(w, "Two-factor authentication disabled for %s\n", email)
 	return nil
 }
 
@@ -2,11 +2,15 @@
 package config
 
 import (
+	"encoding/base32"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // Config holds the complete server configuration.
@@ -17,6 +21,11 @@ type Config struct {
 	Auth      AuthConfig      `yaml:"auth"`
 	Logging   LoggingConfig   `yaml:"logging"`
 	Endpoints EndpointsConfig `yaml:"endpoints"`
+	SFTP      SFTPConfig      `yaml:"sftp"`
+	Versions  VersionsConfig  `yaml:"versions"`
+	Trash     TrashConfig     `yaml:"trash"`
+	Search    SearchConfig    `yaml:"search"`
+	Media     MediaConfig     `yaml:"media"`
 }
 
 // ServerConfig holds HTTP server settings.
@@ -29,8 +38,9 @@ type ServerConfig struct {
 
 // AdminConfig holds the admin panel credentials (not stored in DB).
 type AdminConfig struct {
-	Login    string `yaml:"login"`
-	Password string `yaml:"password"`
+	Login      string `yaml:"login"`
+	Password   string `yaml:"password"`
+	TOTPSecret string `yaml:"totp_secret"` // Optional base32 secret; when set, admin login requires a TOTP code
 }
 
 // StorageConfig holds database and content storage settings.
@@ -43,9 +53,27 @@ type StorageConfig struct {
 
 // AuthConfig holds authentication settings.
 type AuthConfig struct {
-	TokenTTLSeconds int `yaml:"token_ttl_seconds"`
+	TokenTTLSeconds     int                 `yaml:"token_ttl_seconds"`
+	Clients             []OAuthClientConfig `yaml:"clients"`                // Optional, defaults to the desktop client "cloud-win"
+	URLSigningKey       string              `yaml:"url_signing_key"`        // Optional; random per start when empty
+	SignedURLTTLSeconds int                 `yaml:"signed_url_ttl_seconds"` // Optional, defaults to 300
+	InsecureCookies     bool                `yaml:"insecure_cookies"`       // Optional; sends web session cookies over plain HTTP too
+}
+
+// OAuthClientConfig describes one client allowed to obtain tokens at /token.
+type OAuthClientConfig struct {
+	ID              string   `yaml:"id"`
+	Secret          string   `yaml:"secret"`            // Optional; when set, client_secret must match
+	GrantTypes      []string `yaml:"grant_types"`       // password, refresh_token (default: password, refresh_token)
+	TokenTTLSeconds int      `yaml:"token_ttl_seconds"` // Optional, defaults to auth.token_ttl_seconds
 }
 
+// DefaultClientID is the client registered when no clients are configured.
+const DefaultClientID = "cloud-win"
+
+// validGrantTypes lists grant types accepted in auth.clients[].grant_types.
+var validGrantTypes = map[string]bool{"password": true, "refresh_token": true}
+
 // LoggingConfig holds logging settings.
 type LoggingConfig struct {
 	Level  string `yaml:"level"`  // DEBUG, INFO, WARN, ERROR (default: INFO)
@@ -63,6 +91,39 @@ type EndpointsConfig struct {
 	Download   string `yaml:"download"`
 }
 
+// SFTPConfig holds the optional embedded SFTP server settings.
+type SFTPConfig struct {
+	Port        int    `yaml:"port"`          // 0 disables the SFTP server
+	Host        string `yaml:"host"`          // Optional, defaults to server.host
+	HostKeyFile string `yaml:"host_key_file"` // Optional, defaults to "sftp_host_key" next to the database; generated if missing
+}
+
+// VersionsConfig holds the file version history retention settings.
+type VersionsConfig struct {
+	Retention            string `yaml:"retention"`              // Optional default policy, e.g. "last=10,days=30,daily=90,weekly=365" (the default)
+	PruneIntervalMinutes int    `yaml:"prune_interval_minutes"` // Optional, defaults to 60
+}
+
+// TrashConfig holds the trash retention settings.
+type TrashConfig struct {
+	RetentionDays        int  `yaml:"retention_days"`         // Optional, 0 (default) keeps trash items forever
+	CountInQuota         bool `yaml:"count_in_quota"`         // Optional, trashed files count toward the quota
+	PurgeIntervalMinutes int  `yaml:"purge_interval_minutes"` // Optional, defaults to 60
+}
+
+// SearchConfig holds the content search settings.
+type SearchConfig struct {
+	IndexContent         bool  `yaml:"index_content"`          // Optional, indexes the text of documents for content search
+	IndexIntervalMinutes int   `yaml:"index_interval_minutes"` // Optional, defaults to 1
+	MaxContentSize       int64 `yaml:"max_content_size"`       // Optional, largest indexed file in bytes, defaults to 52428800 (50 MB)
+}
+
+// MediaConfig holds the photo metadata extraction settings.
+type MediaConfig struct {
+	ExtractMetadata bool `yaml:"extract_metadata"` // Optional, reads EXIF metadata of photos for the timeline
+	IntervalMinutes int  `yaml:"interval_minutes"` // Optional, defaults to 1
+}
+
 // Load reads and parses a YAML configuration file from the given path.
 // Returns an error if the file cannot be read or parsed.
 func Load(path string) (*Config, error) {
@@ -90,18 +151,72 @@ func (c *Config) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
 
+// SFTPAddr returns the SFTP listen address in "host:port" format.
+func (c *Config) SFTPAddr() string {
+	return fmt.Sprintf("%s:%d", c.SFTP.Host, c.SFTP.Port)
+}
+
 // applyDefaults fills in unset configuration values with sensible defaults.
 func (c *Config) applyDefaults() {
 	// Auth defaults
 	if c.Auth.TokenTTLSeconds <= 0 {
 		c.Auth.TokenTTLSeconds = 86400 // 24 hours
 	}
+	if c.Auth.SignedURLTTLSeconds <= 0 {
+		c.Auth.SignedURLTTLSeconds = 300 // 5 minutes
+	}
+	if len(c.Auth.Clients) == 0 {
+		c.Auth.Clients = []OAuthClientConfig{{ID: DefaultClientID}}
+	}
+	for i := range c.Auth.Clients {
+		client := &c.Auth.Clients[i]
+		if len(client.GrantTypes) == 0 {
+			client.GrantTypes = []string{"password", "refresh_token"}
+		}
+		if client.TokenTTLSeconds <= 0 {
+			client.TokenTTLSeconds = c.Auth.TokenTTLSeconds
+		}
+	}
 
 	// Storage defaults
 	if c.Storage.ThumbnailDir == "" {
 		c.Storage.ThumbnailDir = c.Storage.ContentDir + "/thumbs"
 	}
 
+	// SFTP defaults
+	if c.SFTP.Host == "" {
+		c.SFTP.Host = c.Server.Host
+	}
+	if c.SFTP.HostKeyFile == "" {
+		c.SFTP.HostKeyFile = filepath.Join(filepath.Dir(c.Storage.DBPath), "sftp_host_key")
+	}
+
+	// Version retention defaults
+	if c.Versions.Retention == "" {
+		c.Versions.Retention = vo.DefaultRetentionPolicy.String()
+	}
+	if c.Versions.PruneIntervalMinutes <= 0 {
+		c.Versions.PruneIntervalMinutes = 60
+	}
+
+	// Trash defaults
+	if c.Trash.PurgeIntervalMinutes <= 0 {
+		c.Trash.PurgeIntervalMinutes = 60
+	}
+
+	// Search defaults
+	if c.Search.IndexIntervalMinutes <= 0 {
+		c.Search.IndexIntervalMinutes = 1
+	}
+	if c.Search.MaxContentSize == 0 {
+		c.Search.MaxContentSize = 50 << 20
+	}
+
+	// Media defaults
+	if c.Media.IntervalMinutes <= 0 {
+		c.Media.IntervalMinutes = 1
+	}
+
 	// Logging defaults
 	if c.Logging.Level == "" {
 		c.Logging.Level = "info"
@@ -143,6 +258,12 @@ func (c *Config) validate() error {
 	if c.Admin.Password == "" {
 		return fmt.Errorf("admin.password is required")
 	}
+	if c.Admin.TOTPSecret != "" {
+		secret := strings.ToUpper(strings.TrimRight(c.Admin.TOTPSecret, "="))
+		if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret); err != nil {
+			return fmt.Errorf("admin.totp_secret must be a base32 string")
+		}
+	}
 	if c.Storage.DBPath == "" {
 		return fmt.Errorf("storage.db_path is required")
 	}
@@ -153,6 +274,44 @@ func (c *Config) validate() error {
 		return fmt.Errorf("storage.quota_bytes must be positive")
 	}
 
+	if c.SFTP.Port < 0 || c.SFTP.Port > 65535 {
+		return fmt.Errorf("sftp.port must be between 0 and 65535")
+	}
+	if c.SFTP.Port != 0 && c.SFTP.Port == c.Server.Port && (c.SFTP.Host == "" || c.SFTP.Host == c.Server.Host) {
+		return fmt.Errorf("sftp.port must differ from server.port")
+	}
+
+	if c.Versions.Retention != "" {
+		if _, err := vo.ParseRetentionPolicy(c.Versions.Retention); err != nil {
+			return fmt.Errorf("versions.retention: %v", err)
+		}
+	}
+
+	if c.Trash.RetentionDays < 0 {
+		return fmt.Errorf("trash.retention_days must not be negative")
+	}
+
+	if c.Search.MaxContentSize < 0 {
+		return fmt.Errorf("search.max_content_size must not be negative")
+	}
+
+	// OAuth clients: unique non-empty IDs and known grant types
+	seenClients := make(map[string]bool, len(c.Auth.Clients))
+	for i, client := range c.Auth.Clients {
+		if client.ID == "" {
+			return fmt.Errorf("auth.clients[%d].id is required", i)
+		}
+		if seenClients[client.ID] {
+			return fmt.Errorf("auth.clients: duplicate client id %q", client.ID)
+		}
+		seenClients[client.ID] = true
+		for _, g := range client.GrantTypes {
+			if !validGrantTypes[g] {
+				return fmt.Errorf("auth.clients[%d].grant_types: unknown grant type %q", i, g)
+			}
+		}
+	}
+
 	// Logging validation: file path required for file/both output modes
 	output := strings.ToLower(c.Logging.Output)
 	if (output == "file" || output == "both") && c.Logging.File == "" {
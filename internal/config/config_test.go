@@ -127,6 +127,37 @@ admin: { login: "a", password: "b" }
 storage: { db_path: "x", content_dir: "y", quota_bytes: 0 }`,
 			"quota_bytes",
 		},
+		{
+			"client without id",
+			`server: { host: "", port: 8080, external_url: "http://x" }
+admin: { login: "a", password: "b" }
+storage: { db_path: "x", content_dir: "y", quota_bytes: 1 }
+auth: { clients: [ { secret: "s" } ] }`,
+			"auth.clients[0].id",
+		},
+		{
+			"duplicate client id",
+			`server: { host: "", port: 8080, external_url: "http://x" }
+admin: { login: "a", password: "b" }
+storage: { db_path: "x", content_dir: "y", quota_bytes: 1 }
+auth: { clients: [ { id: "a" }, { id: "a" } ] }`,
+			"duplicate client id",
+		},
+		{
+			"unknown grant type",
+			`server: { host: "", port: 8080, external_url: "http://x" }
+admin: { login: "a", password: "b" }
+storage: { db_path: "x", content_dir: "y", quota_bytes: 1 }
+auth: { clients: [ { id: "a", grant_types: [ "client_credentials" ] } ] }`,
+			"grant_types",
+		},
+		{
+			"invalid admin totp secret",
+			`server: { host: "", port: 8080, external_url: "http://x" }
+admin: { login: "a", password: "b", totp_secret: "not base32!" }
+storage: { db_path: "x", content_dir: "y", quota_bytes: 1 }`,
+			"admin.totp_secret",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -142,6 +173,114 @@ storage: { db_path: "x", content_dir: "y", quota_bytes: 0 }`,
 	}
 }
 
+func TestLoad_sftpValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		sftp      string
+		errSubstr string
+	}{
+		{"negative port", `sftp: { port: -1 }`, "sftp.port"},
+		{"port too high", `sftp: { port: 70000 }`, "sftp.port"},
+		{"same port as HTTP", `sftp: { port: 8080 }`, "sftp.port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := writeConfig(t, validYAML+tt.sftp)
+			_, err := Load(p)
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.errSubstr)
+			}
+		})
+	}
+}
+
+func TestLoad_sftpDefaults(t *testing.T) {
+	p := writeConfig(t, validYAML+`sftp: { port: 2022 }`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if got := cfg.SFTPAddr(); got != "0.0.0.0:2022" {
+		t.Errorf("SFTPAddr() = %q, want %q", got, "0.0.0.0:2022")
+	}
+	if want := filepath.Join("/tmp", "sftp_host_key"); cfg.SFTP.HostKeyFile != want {
+		t.Errorf("HostKeyFile = %q, want %q", cfg.SFTP.HostKeyFile, want)
+	}
+}
+
+func TestLoad_versions(t *testing.T) {
+	cfg, err := Load(writeConfig(t, validYAML))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Versions.Retention != "last=10,days=30,daily=90,weekly=365" || cfg.Versions.PruneIntervalMinutes != 60 {
+		t.Errorf("defaults = %+v", cfg.Versions)
+	}
+
+	cfg, err = Load(writeConfig(t, validYAML+`versions: { retention: "last=5" }`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Versions.Retention != "last=5" {
+		t.Errorf("Retention = %q, want last=5", cfg.Versions.Retention)
+	}
+
+	_, err = Load(writeConfig(t, validYAML+`versions: { retention: "monthly=3" }`))
+	if err == nil || !strings.Contains(err.Error(), "versions.retention") {
+		t.Errorf("error = %v, want a versions.retention error", err)
+	}
+}
+
+func TestLoad_trash(t *testing.T) {
+	cfg, err := Load(writeConfig(t, validYAML))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Trash.RetentionDays != 0 || cfg.Trash.CountInQuota || cfg.Trash.PurgeIntervalMinutes != 60 {
+		t.Errorf("defaults = %+v", cfg.Trash)
+	}
+
+	cfg, err = Load(writeConfig(t, validYAML+`trash: { retention_days: 30, count_in_quota: true }`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Trash.RetentionDays != 30 || !cfg.Trash.CountInQuota {
+		t.Errorf("Trash = %+v, want 30 days counted in quota", cfg.Trash)
+	}
+
+	_, err = Load(writeConfig(t, validYAML+`trash: { retention_days: -1 }`))
+	if err == nil || !strings.Contains(err.Error(), "trash.retention_days") {
+		t.Errorf("error = %v, want a trash.retention_days error", err)
+	}
+}
+
+func TestLoad_search(t *testing.T) {
+	cfg, err := Load(writeConfig(t, validYAML))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Search.IndexContent || cfg.Search.IndexIntervalMinutes != 1 || cfg.Search.MaxContentSize != 50<<20 {
+		t.Errorf("defaults = %+v", cfg.Search)
+	}
+
+	cfg, err = Load(writeConfig(t, validYAML+`search: { index_content: true, index_interval_minutes: 5, max_content_size: 1048576 }`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Search.IndexContent || cfg.Search.IndexIntervalMinutes != 5 || cfg.Search.MaxContentSize != 1<<20 {
+		t.Errorf("Search = %+v, want indexing every 5 minutes up to 1 MB", cfg.Search)
+	}
+
+	_, err = Load(writeConfig(t, validYAML+`search: { max_content_size: -1 }`))
+	if err == nil || !strings.Contains(err.Error(), "search.max_content_size") {
+		t.Errorf("error = %v, want a search.max_content_size error", err)
+	}
+}
+
 func TestConfig_Addr(t *testing.T) {
 	cfg := &Config{
 		Server: ServerConfig{Host: "127.0.0.1", Port: 9090},
@@ -321,3 +460,71 @@ logging:
 		t.Errorf("Logging.File = %q, want %q", cfg.Logging.File, "/tmp/tucha.log")
 	}
 }
+
+func TestLoad_authClientDefaults(t *testing.T) {
+	p := writeConfig(t, validYAML)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(cfg.Auth.Clients) != 1 {
+		t.Fatalf("len(Auth.Clients) = %d, want 1", len(cfg.Auth.Clients))
+	}
+	c := cfg.Auth.Clients[0]
+	if c.ID != DefaultClientID {
+		t.Errorf("client ID = %q, want %q", c.ID, DefaultClientID)
+	}
+	if c.TokenTTLSeconds != 86400 {
+		t.Errorf("client TokenTTLSeconds = %d, want 86400", c.TokenTTLSeconds)
+	}
+	if len(c.GrantTypes) != 2 {
+		t.Errorf("client GrantTypes = %v, want password and refresh_token", c.GrantTypes)
+	}
+}
+
+func TestLoad_authClients(t *testing.T) {
+	p := writeConfig(t, validYAML+`
+auth:
+  token_ttl_seconds: 600
+  clients:
+    - id: "cloud-win"
+    - id: "backup-tool"
+      secret: "s3cret"
+      grant_types: ["password"]
+      token_ttl_seconds: 60
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(cfg.Auth.Clients) != 2 {
+		t.Fatalf("len(Auth.Clients) = %d, want 2", len(cfg.Auth.Clients))
+	}
+	if got := cfg.Auth.Clients[0].TokenTTLSeconds; got != 600 {
+		t.Errorf("cloud-win TokenTTLSeconds = %d, want 600 (inherited)", got)
+	}
+	tool := cfg.Auth.Clients[1]
+	if tool.Secret != "s3cret" || tool.TokenTTLSeconds != 60 || len(tool.GrantTypes) != 1 {
+		t.Errorf("backup-tool = %+v", tool)
+	}
+}
+
+func TestLoad_media(t *testing.T) {
+	cfg, err := Load(writeConfig(t, validYAML))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Media.ExtractMetadata || cfg.Media.IntervalMinutes != 1 {
+		t.Errorf("defaults = %+v", cfg.Media)
+	}
+
+	cfg, err = Load(writeConfig(t, validYAML+`media: { extract_metadata: true, interval_minutes: 10 }`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Media.ExtractMetadata || cfg.Media.IntervalMinutes != 10 {
+		t.Errorf("Media = %+v, want extraction every 10 minutes", cfg.Media)
+	}
+}
@@ -0,0 +1,14 @@
+package entity
+
+// AccessKey is an S3-style credential pair used to sign requests to the S3 gateway.
+// Unlike app passwords the secret is kept in plain form, since SigV4 verification
+// needs it to recompute request signatures.
+type AccessKey struct {
+	ID       int64
+	UserID   int64
+	Name     string
+	KeyID    string // Public access key ID sent in the Credential of each request
+	Secret   string
+	Created  int64
+	LastUsed int64 // 0 = never used
+}
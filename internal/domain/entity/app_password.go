@@ -0,0 +1,12 @@
+package entity
+
+// AppPassword is a per-application password that replaces the account password
+// for OAuth clients once two-factor authentication is enabled.
+type AppPassword struct {
+	ID           int64
+	UserID       int64
+	Name         string
+	PasswordHash string // SHA-256 hex digest; the plaintext is shown only at creation
+	Created      int64
+	LastUsed     int64 // 0 = never used
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// Change is one entry of a user's change journal.
+// IDs increase with every recorded change and serve as sync cursors.
+type Change struct {
+	ID     int64
+	UserID int64
+	Kind   vo.ChangeKind
+	Home   vo.CloudPath // Path of the node after the change.
+	From   string       // Previous path for renames and moves; empty otherwise.
+	Type   vo.NodeType
+	Size   int64
+	Hash   vo.ContentHash
+	Time   int64
+}
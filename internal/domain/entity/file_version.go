@@ -14,4 +14,8 @@ type FileVersion struct {
 	Size   int64
 	Rev    int64
 	Time   int64
+
+	// ContentRef reports whether the entry holds a reference to its content.
+	// Entries recorded before versions kept their content alive do not.
+	ContentRef bool
 }
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// MediaInfo holds the metadata of a photo, recorded once per content hash.
+// Zero fields were not recorded in the file.
+type MediaInfo struct {
+	Hash        vo.ContentHash
+	Width       int    // Width as displayed, after Orientation is applied.
+	Height      int    // Height as displayed, after Orientation is applied.
+	Orientation int    // EXIF orientation, 1 to 8.
+	TakenAt     int64  // Capture time as shown by the camera clock, in Unix seconds read as UTC.
+	Make        string // Camera maker.
+	Model       string // Camera model.
+	HasLocation bool
+	Latitude    float64 // Degrees, negative in the south.
+	Longitude   float64 // Degrees, negative in the west.
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "github.com/pozitronik/tucha/internal/domain/vo"
+
+// MultipartUpload is an S3 multipart upload in progress.
+// Parts are stored as ordinary content until the upload is completed or aborted.
+type MultipartUpload struct {
+	ID      string
+	UserID  int64
+	Home    vo.CloudPath // Destination path of the assembled file
+	Created int64
+}
+
+// MultipartPart is one uploaded part of a multipart upload.
+type MultipartPart struct {
+	UploadID string
+	Number   int
+	Hash     vo.ContentHash
+	Size     int64
+}
@@ -20,6 +20,8 @@ type Node struct {
 	Tree     string
 	Weblink  string
 	Created  int64
+	Starred  bool
+	Tags     []vo.Tag // Sorted, without duplicates.
 }
 
 // IsFile returns true if this node is a file.
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"crypto/subtle"
+
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// OAuthClient describes an application allowed to obtain tokens at the OAuth endpoint.
+type OAuthClient struct {
+	ID              string
+	Secret          string // Empty for public clients
+	GrantTypes      []vo.GrantType
+	TokenTTLSeconds int
+}
+
+// AllowsGrant returns true if the client may use the given grant type.
+func (c *OAuthClient) AllowsGrant(grant vo.GrantType) bool {
+	for _, g := range c.GrantTypes {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSecret returns true if the presented secret matches the client secret.
+// Public clients (no secret configured) accept any value.
+func (c *OAuthClient) CheckSecret(secret string) bool {
+	if c.Secret == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(c.Secret), []byte(secret)) == 1
+}
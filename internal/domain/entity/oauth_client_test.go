@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestOAuthClient_AllowsGrant(t *testing.T) {
+	c := &OAuthClient{ID: "cloud-win", GrantTypes: []vo.GrantType{vo.GrantPassword}}
+
+	if !c.AllowsGrant(vo.GrantPassword) {
+		t.Error("AllowsGrant(password) = false, want true")
+	}
+	if c.AllowsGrant(vo.GrantRefreshToken) {
+		t.Error("AllowsGrant(refresh_token) = true, want false")
+	}
+}
+
+func TestOAuthClient_CheckSecret(t *testing.T) {
+	public := &OAuthClient{ID: "cloud-win"}
+	if !public.CheckSecret("") || !public.CheckSecret("anything") {
+		t.Error("public client should accept any secret")
+	}
+
+	confidential := &OAuthClient{ID: "backup-tool", Secret: "s3cret"}
+	if !confidential.CheckSecret("s3cret") {
+		t.Error("CheckSecret(correct) = false, want true")
+	}
+	if confidential.CheckSecret("") || confidential.CheckSecret("wrong") {
+		t.Error("CheckSecret(wrong) = true, want false")
+	}
+}
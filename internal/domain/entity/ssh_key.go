@@ -0,0 +1,12 @@
+package entity
+
+// SSHKey is a public key a user has authorized for SFTP logins.
+type SSHKey struct {
+	ID          int64
+	UserID      int64
+	Name        string
+	PublicKey   string // authorized_keys form without the comment: "<type> <base64>"
+	Fingerprint string // SHA256 fingerprint as printed by ssh-keygen -l
+	Created     int64
+	LastUsed    int64 // 0 = never used
+}
@@ -1,19 +1,38 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
 
 // Token represents an authentication token stored in the database.
+// Session tokens issued by the OAuth flow are unrestricted and record the
+// issuing client; personal access tokens carry a name, a set of scopes,
+// and an optional path prefix. Support tokens minted by an admin to
+// impersonate a user are personal tokens that record the impersonator.
 type Token struct {
 	ID           int64
 	UserID       int64
 	AccessToken  string
 	RefreshToken string
 	CSRFToken    string
-	ExpiresAt    int64
+	ExpiresAt    int64 // 0 = never expires (personal tokens only)
 	Created      int64
+	ClientID     string // OAuth client that issued the session; empty for personal tokens
+	Personal     bool
+	Name         string
+	Scopes       []vo.TokenScope
+	PathPrefix   vo.CloudPath
+	Impersonator string // admin who minted a support token; empty otherwise
+	Elevated     bool   // support token allowed to modify data
 }
 
 // IsExpired returns true if the token has passed its expiration time.
+// Personal tokens without an expiration time never expire.
 func (t *Token) IsExpired() bool {
+	if t.Personal && t.ExpiresAt == 0 {
+		return false
+	}
 	return time.Now().Unix() > t.ExpiresAt
 }
@@ -27,3 +27,15 @@ func TestToken_IsExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestToken_IsExpired_personalWithoutExpiry(t *testing.T) {
+	tok := &Token{Personal: true, ExpiresAt: 0}
+	if tok.IsExpired() {
+		t.Error("personal token without expiry should not be expired")
+	}
+
+	session := &Token{ExpiresAt: 0}
+	if !session.IsExpired() {
+		t.Error("session token with zero expiry should be expired")
+	}
+}
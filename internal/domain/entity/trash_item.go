@@ -22,6 +22,7 @@ type TrashItem struct {
 	DeletedFrom string
 	DeletedBy   int64
 	Created     int64
+	BatchID     int64 // ID of the item whose deletion trashed this one; 0 for items trashed by earlier releases
 }
 
 // IsFile returns true if this trash item was a file.
@@ -1,6 +1,8 @@
 // Package entity defines domain entities with behavior.
 package entity
 
+import "github.com/pozitronik/tucha/internal/domain/vo"
+
 // User represents a registered user account.
 type User struct {
 	ID             int64
@@ -11,4 +13,21 @@ type User struct {
 	FileSizeLimit  int64 // 0 = unlimited
 	VersionHistory bool  // true = paid tier
 	Created        int64
+
+	// VersionRetention overrides the server's version retention policy; nil = server default.
+	VersionRetention *vo.RetentionPolicy
+	// TrashRetentionDays overrides the server's trash retention period; nil = server default, 0 = forever.
+	TrashRetentionDays *int
+
+	// Two-factor authentication state.
+	TOTPSecret    string   // Base32 shared secret; set at enrollment, before confirmation
+	TOTPEnabled   bool     // true once enrollment is confirmed with a valid code
+	RecoveryCodes []string // SHA-256 hex digests of unused recovery codes
+}
+
+// ClearTwoFactor removes the TOTP secret, recovery codes, and enabled flag.
+func (u *User) ClearTwoFactor() {
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	u.RecoveryCodes = nil
 }
@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// XAttr is an extended attribute of a node: a named value attached by the user.
+type XAttr struct {
+	Name  vo.XAttrName
+	Value string
+}
+
+// Size returns the space the attribute takes: the bytes of its name and value.
+func (a XAttr) Size() int {
+	return len(a.Name) + len(a.Value)
+}
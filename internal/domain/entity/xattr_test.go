@@ -0,0 +1,10 @@
+package entity
+
+import "testing"
+
+func TestXAttr_Size(t *testing.T) {
+	attr := XAttr{Name: "source", Value: "crm"}
+	if got := attr.Size(); got != 9 {
+		t.Errorf("Size() = %d, want 9", got)
+	}
+}
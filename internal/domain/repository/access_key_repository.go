@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/pozitronik/tucha/internal/domain/entity"
+
+// AccessKeyRepository persists S3 access keys.
+type AccessKeyRepository interface {
+	// Create stores a new access key and assigns its ID and creation time.
+	Create(k *entity.AccessKey) error
+
+	// GetByID retrieves an access key by ID. Returns nil, nil if not found.
+	GetByID(id int64) (*entity.AccessKey, error)
+
+	// GetByKeyID retrieves an access key by its public key ID. Returns nil, nil if not found.
+	GetByKeyID(keyID string) (*entity.AccessKey, error)
+
+	// ListByUser returns all access keys of the given user, newest first.
+	ListByUser(userID int64) ([]entity.AccessKey, error)
+
+	// MarkUsed records the time an access key was last used.
+	MarkUsed(id int64, ts int64) error
+
+	// Delete removes an access key by ID.
+	Delete(id int64) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/pozitronik/tucha/internal/domain/entity"
+
+// AppPasswordRepository persists application passwords.
+type AppPasswordRepository interface {
+	// Create stores a new app password and assigns its ID and creation time.
+	Create(p *entity.AppPassword) error
+
+	// GetByID retrieves an app password by ID. Returns nil, nil if not found.
+	GetByID(id int64) (*entity.AppPassword, error)
+
+	// ListByUser returns all app passwords of the given user, newest first.
+	ListByUser(userID int64) ([]entity.AppPassword, error)
+
+	// MarkUsed records the time an app password was last used.
+	MarkUsed(id int64, ts int64) error
+
+	// Delete removes an app password by ID.
+	Delete(id int64) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// ChangeRepository persists the per-user change journal.
+type ChangeRepository interface {
+	// Insert appends a change to the user's journal and returns its ID.
+	Insert(change *entity.Change) (int64, error)
+
+	// ListAfter returns up to limit changes of the user with IDs greater than cursor, oldest first.
+	ListAfter(userID int64, cursor int64, limit int) ([]entity.Change, error)
+
+	// Latest returns the highest change ID recorded for the user, including pruned ones, or 0.
+	Latest(userID int64) (int64, error)
+
+	// Horizon returns the highest change ID pruned from the user's journal, or 0.
+	// Cursors below the horizon have missed changes.
+	Horizon(userID int64) (int64, error)
+
+	// Prune deletes changes recorded before the given Unix time.
+	Prune(before int64) error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// UnindexedContent is stored content that has not been indexed yet.
+type UnindexedContent struct {
+	Hash vo.ContentHash
+	Name string // Name of one of the files holding the content, which tells its format.
+	Size int64
+}
+
+// ContentIndexRepository manages the full-text index of file content.
+// Content is indexed once per hash, however many files hold it.
+type ContentIndexRepository interface {
+	// ListUnindexed returns up to limit of the contents held by files that
+	// have not been indexed yet.
+	ListUnindexed(limit int) ([]UnindexedContent, error)
+
+	// Store indexes the text of the content. Empty text marks content
+	// without text as indexed.
+	Store(hash vo.ContentHash, text string) error
+
+	// Prune drops the index entries of content that is no longer stored.
+	// Returns the number of dropped entries.
+	Prune() (int, error)
+}
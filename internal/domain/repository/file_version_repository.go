@@ -7,9 +7,23 @@ import (
 
 // FileVersionRepository persists and retrieves file version history entries.
 type FileVersionRepository interface {
-	// Insert records a new version entry.
+	// Insert records a new version entry holding a reference to its content.
+	// The entry gets the next revision number of its path; ID, Rev and Time are set on version.
 	Insert(version *entity.FileVersion) error
 
 	// ListByPath returns all version entries for the given user and path, ordered by time ascending.
 	ListByPath(userID int64, path vo.CloudPath) ([]entity.FileVersion, error)
+
+	// GetByRev returns the version entry with the given revision of a path, or nil if there is none.
+	GetByRev(userID int64, path vo.CloudPath, rev int64) (*entity.FileVersion, error)
+
+	// ListByUser returns all version entries of the user, grouped by path and newest first within a path.
+	ListByUser(userID int64) ([]entity.FileVersion, error)
+
+	// Delete removes a version entry. The caller releases its content reference.
+	Delete(id int64) error
+
+	// TotalSize returns the total size of the user's version entries, leaving out
+	// those with the same content as the current file at their path.
+	TotalSize(userID int64) (int64, error)
 }
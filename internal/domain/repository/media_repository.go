@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// Photo is a file of a user together with the metadata of its content.
+type Photo struct {
+	Node  entity.Node
+	Media entity.MediaInfo
+}
+
+// MediaRepository manages the metadata extracted from photos.
+// Metadata is recorded once per hash, however many files hold the content.
+type MediaRepository interface {
+	// ListUnscanned returns up to limit of the contents held by files whose
+	// metadata has not been extracted yet.
+	ListUnscanned(limit int) ([]UnindexedContent, error)
+
+	// Store records the metadata of the content. A nil info marks content
+	// that is not a photo as scanned.
+	Store(hash vo.ContentHash, info *entity.MediaInfo) error
+
+	// Get returns the metadata of the content, or nil if it is not a photo
+	// or has not been scanned yet.
+	Get(hash vo.ContentHash) (*entity.MediaInfo, error)
+
+	// Timeline returns up to limit of the user's photos after the first
+	// offset, newest first: by capture time, or by modification time for
+	// photos without one.
+	Timeline(userID int64, offset, limit int) ([]Photo, error)
+
+	// Prune drops the metadata of content that is no longer stored.
+	// Returns the number of dropped entries.
+	Prune() (int, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/pozitronik/tucha/internal/domain/entity"
+
+// MultipartRepository persists S3 multipart uploads and their parts.
+type MultipartRepository interface {
+	// Create stores a new upload and assigns its creation time.
+	Create(u *entity.MultipartUpload) error
+
+	// Get retrieves an upload by ID. Returns nil, nil if not found.
+	Get(id string) (*entity.MultipartUpload, error)
+
+	// PutPart stores a part, returning the part it replaced (nil if the number was new).
+	PutPart(p *entity.MultipartPart) (*entity.MultipartPart, error)
+
+	// ListParts returns the parts of an upload ordered by part number.
+	ListParts(uploadID string) ([]entity.MultipartPart, error)
+
+	// Delete removes an upload together with its parts.
+	Delete(id string) error
+}
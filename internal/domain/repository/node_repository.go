@@ -29,6 +29,9 @@ type NodeRepository interface {
 	// CreateFile creates a new file node at the given path with the specified hash and size.
 	CreateFile(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error)
 
+	// SetMTime sets the modification time of the node at the given path.
+	SetMTime(userID int64, path vo.CloudPath, mtime int64) error
+
 	// Delete removes a node at the given path.
 	// For folders, cascading delete is handled by the database constraints.
 	Delete(userID int64, path vo.CloudPath) error
@@ -36,11 +39,13 @@ type NodeRepository interface {
 	// Rename changes the name of a node, updating its path and all descendant paths.
 	Rename(userID int64, path vo.CloudPath, newName string) (*entity.Node, error)
 
-	// Move moves a node from srcPath into the target folder.
-	Move(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error)
+	// Move moves a node from srcPath to dstPath, whose parent folder must exist.
+	// The node takes the name of dstPath.
+	Move(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error)
 
-	// Copy duplicates a node (and its children for folders) from srcPath into the target folder.
-	Copy(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error)
+	// Copy duplicates a node (and its children for folders) from srcPath to dstPath,
+	// whose parent folder must exist.
+	Copy(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error)
 
 	// EnsurePath creates all intermediate folders for the given path, skipping
 	// any that already exist. Analogous to "mkdir -p".
@@ -64,6 +69,18 @@ type NodeRepository interface {
 	// ListByWeblink returns all nodes with a non-empty weblink for the given user.
 	ListByWeblink(userID int64) ([]entity.Node, error)
 
+	// SetStarred stars or unstars the node at the given path.
+	SetStarred(userID int64, path vo.CloudPath, starred bool) error
+
+	// SetTags replaces the tags of the node at the given path.
+	SetTags(userID int64, path vo.CloudPath, tags []vo.Tag) error
+
+	// ListStarred returns all starred nodes of the given user, ordered by path.
+	ListStarred(userID int64) ([]entity.Node, error)
+
+	// ListTagged returns all nodes of the given user that have the tag, ordered by path.
+	ListTagged(userID int64, tag vo.Tag) ([]entity.Node, error)
+
 	// Exists checks whether a node exists at the given path for the user.
 	Exists(userID int64, path vo.CloudPath) (bool, error)
 }
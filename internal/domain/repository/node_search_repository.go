@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// NodeQuery holds the criteria of a node search. Zero fields do not filter.
+type NodeQuery struct {
+	Text      string       // Words each name must contain, matched as word prefixes.
+	Type      vo.NodeType  // Only files or only folders.
+	MinSize   int64        // Smallest size in bytes.
+	MaxSize   int64        // Largest size in bytes.
+	After     int64        // Earliest modification time, in Unix seconds.
+	Before    int64        // Latest modification time, in Unix seconds.
+	Extension string       // File name extension, without the dot.
+	Under     vo.CloudPath // Folder whose descendants are searched.
+	Tag       vo.Tag       // Tag the nodes must have.
+
+	XAttr      vo.XAttrName // Extended attribute the nodes must have.
+	XAttrValue string       // Value the XAttr must have; any value if empty.
+}
+
+// NodeMatch is a node found by a search. A lower rank is a better match.
+type NodeMatch struct {
+	Node    entity.Node
+	Rank    float64
+	Snippet string // Matching text of the content, with matches in <mark> tags.
+}
+
+// NodeSearchRepository finds nodes by name, metadata and file content.
+type NodeSearchRepository interface {
+	// Search returns up to limit of the user's nodes matching the query,
+	// best match first. The root folder is never returned.
+	Search(userID int64, query *NodeQuery, limit int) ([]NodeMatch, error)
+
+	// SearchContent returns up to limit of the user's files whose indexed
+	// content contains the words of query.Text, best match first, with
+	// snippets. The other query fields filter the files as in Search.
+	SearchContent(userID int64, query *NodeQuery, limit int) ([]NodeMatch, error)
+}
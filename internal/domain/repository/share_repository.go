@@ -51,4 +51,7 @@ type ShareRepository interface {
 
 	// ListMountedByUser returns all accepted shares mounted by the given user.
 	ListMountedByUser(userID int64) ([]entity.Share, error)
+
+	// ListMountedByOwner returns all accepted shares of the given owner that are mounted.
+	ListMountedByOwner(ownerID int64) ([]entity.Share, error)
 }
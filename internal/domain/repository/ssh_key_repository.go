@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/pozitronik/tucha/internal/domain/entity"
+
+// SSHKeyRepository persists users' authorized SSH public keys.
+type SSHKeyRepository interface {
+	// Create stores a new key and assigns its ID and creation time.
+	Create(k *entity.SSHKey) error
+
+	// GetByID retrieves a key by ID. Returns nil, nil if not found.
+	GetByID(id int64) (*entity.SSHKey, error)
+
+	// GetByFingerprint retrieves a user's key by fingerprint. Returns nil, nil if not found.
+	GetByFingerprint(userID int64, fingerprint string) (*entity.SSHKey, error)
+
+	// ListByUser returns all keys of the given user, newest first.
+	ListByUser(userID int64) ([]entity.SSHKey, error)
+
+	// MarkUsed records the time a key was last used.
+	MarkUsed(id int64, ts int64) error
+
+	// Delete removes a key by ID.
+	Delete(id int64) error
+}
@@ -2,17 +2,43 @@ package repository
 
 import (
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // TokenRepository persists and retrieves authentication tokens.
 type TokenRepository interface {
-	// Create generates a new token set for the given user and stores it.
-	Create(userID int64, ttlSeconds int) (*entity.Token, error)
+	// Create generates a new session token set for the given user, issued to clientID, and stores it.
+	Create(userID int64, clientID string, ttlSeconds int) (*entity.Token, error)
+
+	// CreatePersonal generates a personal access token restricted to the given
+	// scopes and path prefix. A ttlSeconds of 0 creates a token that never expires.
+	CreatePersonal(userID int64, name string, scopes []vo.TokenScope, pathPrefix vo.CloudPath, ttlSeconds int) (*entity.Token, error)
+
+	// CreateImpersonation generates a short-lived support token that lets the
+	// impersonator act as the user. Unless elevated, it only grants read access.
+	CreateImpersonation(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error)
 
 	// LookupAccess finds a token by its access_token value.
 	// Returns nil, nil if not found. Does NOT check expiration -- that is the caller's responsibility.
 	LookupAccess(accessToken string) (*entity.Token, error)
 
+	// LookupRefresh finds a session token by its refresh_token value.
+	// Returns nil, nil if not found.
+	LookupRefresh(refreshToken string) (*entity.Token, error)
+
+	// GetByID retrieves a token by its ID. Returns nil, nil if not found.
+	GetByID(id int64) (*entity.Token, error)
+
+	// ListPersonal returns all personal access tokens of the given user, newest first.
+	ListPersonal(userID int64) ([]entity.Token, error)
+
+	// ListSessions returns all session (non-personal) tokens of the given user, newest first.
+	ListSessions(userID int64) ([]entity.Token, error)
+
 	// Delete removes a token by its ID.
 	Delete(id int64) error
+
+	// DeleteSessionsByClient removes all session tokens the given client holds for the user.
+	// Returns the number of tokens removed.
+	DeleteSessionsByClient(userID int64, clientID string) (int64, error)
 }
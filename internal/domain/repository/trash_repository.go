@@ -9,6 +9,7 @@ import (
 type TrashRepository interface {
 	// Insert copies a node and its descendants into the trash table.
 	// deletedFrom records the original parent path, deletedBy records who performed the deletion.
+	// The extended attributes of the nodes are kept with the trash items.
 	Insert(userID int64, node *entity.Node, descendants []entity.Node, deletedBy int64) error
 
 	// List returns all trash items for a given user.
@@ -18,10 +19,25 @@ type TrashRepository interface {
 	// Returns nil, nil if not found.
 	GetByPathAndRev(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error)
 
+	// ListDescendants returns the items trashed together with a folder item
+	// that were inside it, ordered by path so parents precede their children.
+	ListDescendants(item *entity.TrashItem) ([]entity.TrashItem, error)
+
+	// RestoreXAttrs gives the node nodeID the extended attributes kept with
+	// the trash item itemID.
+	RestoreXAttrs(itemID, nodeID int64) error
+
 	// Delete removes a single trash item by ID.
 	Delete(id int64) error
 
 	// DeleteAll removes all trash items for a user and returns the deleted items
 	// so callers can clean up associated content.
 	DeleteAll(userID int64) ([]entity.TrashItem, error)
+
+	// DeleteOlderThan removes a user's trash items deleted before the given
+	// Unix time and returns them so callers can clean up associated content.
+	DeleteOlderThan(userID int64, before int64) ([]entity.TrashItem, error)
+
+	// TotalSize returns the total size of the files in a user's trash.
+	TotalSize(userID int64) (int64, error)
 }
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// XAttrRepository manages the extended attributes of nodes. Attributes are
+// removed together with their node.
+type XAttrRepository interface {
+	// List returns the attributes of the node, ordered by name.
+	List(nodeID int64) ([]entity.XAttr, error)
+
+	// Get returns the named attribute of the node, or nil if it is not set.
+	Get(nodeID int64, name vo.XAttrName) (*entity.XAttr, error)
+
+	// Set creates or replaces the named attribute of the node.
+	Set(nodeID int64, name vo.XAttrName, value string) error
+
+	// Delete removes the named attribute of the node.
+	// Returns false if it was not set.
+	Delete(nodeID int64, name vo.XAttrName) (bool, error)
+
+	// Copy gives the node dstNodeID all attributes of the node srcNodeID.
+	Copy(srcNodeID, dstNodeID int64) error
+}
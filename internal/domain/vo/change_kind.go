@@ -0,0 +1,41 @@
+package vo
+
+import "fmt"
+
+// ChangeKind identifies what happened to a node in a change journal entry.
+type ChangeKind string
+
+const (
+	// ChangeCreate means a file or folder was created.
+	ChangeCreate ChangeKind = "create"
+	// ChangeModify means a file's content was replaced.
+	ChangeModify ChangeKind = "modify"
+	// ChangeRename means a node was renamed within its folder.
+	ChangeRename ChangeKind = "rename"
+	// ChangeMove means a node was moved to another folder.
+	ChangeMove ChangeKind = "move"
+	// ChangeDelete means a node was deleted or moved to the trashbin.
+	ChangeDelete ChangeKind = "delete"
+	// ChangeRestore means a node was restored from the trashbin.
+	ChangeRestore ChangeKind = "restore"
+	// ChangeMount means a shared folder was mounted.
+	ChangeMount ChangeKind = "mount"
+	// ChangeUnmount means a mounted shared folder was removed.
+	ChangeUnmount ChangeKind = "unmount"
+)
+
+// ParseChangeKind converts a raw string to a ChangeKind.
+// Returns an error for unknown values.
+func ParseChangeKind(raw string) (ChangeKind, error) {
+	switch k := ChangeKind(raw); k {
+	case ChangeCreate, ChangeModify, ChangeRename, ChangeMove, ChangeDelete, ChangeRestore, ChangeMount, ChangeUnmount:
+		return k, nil
+	default:
+		return "", fmt.Errorf("unknown change kind: %q", raw)
+	}
+}
+
+// String returns the string representation of the change kind.
+func (k ChangeKind) String() string {
+	return string(k)
+}
@@ -0,0 +1,34 @@
+package vo
+
+import "testing"
+
+func TestParseChangeKind(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ChangeKind
+		wantErr bool
+	}{
+		{"create", ChangeCreate, false},
+		{"modify", ChangeModify, false},
+		{"rename", ChangeRename, false},
+		{"move", ChangeMove, false},
+		{"delete", ChangeDelete, false},
+		{"restore", ChangeRestore, false},
+		{"mount", ChangeMount, false},
+		{"unmount", ChangeUnmount, false},
+		{"", "", true},
+		{"CREATE", "", true},
+		{"copy", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseChangeKind(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChangeKind(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseChangeKind(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package vo
 
 import (
+	"fmt"
 	"path"
 	"strings"
 )
@@ -44,6 +45,22 @@ func (p CloudPath) Join(name string) CloudPath {
 	return CloudPath{value: normalize(path.Join(p.value, name))}
 }
 
+// Numbered returns the path with " (n)" added to its name, the way name
+// conflicts are resolved by renaming. For files the number goes before the
+// extension: "report.docx" becomes "report (1).docx".
+func (p CloudPath) Numbered(n int, isFile bool) CloudPath {
+	name := p.Name()
+	ext := ""
+	if isFile {
+		ext = path.Ext(name)
+		if ext == name {
+			// A dot file such as ".bashrc" has no extension to keep.
+			ext = ""
+		}
+	}
+	return p.Parent().Join(fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext))
+}
+
 // HasPrefix returns true if this path starts with prefix followed by "/".
 // Used for identifying descendants during rename/move.
 func (p CloudPath) HasPrefix(prefix CloudPath) bool {
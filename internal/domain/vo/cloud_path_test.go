@@ -134,3 +134,26 @@ func TestCloudPath_HasPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudPath_Numbered(t *testing.T) {
+	tests := []struct {
+		path   string
+		n      int
+		isFile bool
+		want   string
+	}{
+		{"/docs/report.docx", 1, true, "/docs/report (1).docx"},
+		{"/docs/archive.tar.gz", 2, true, "/docs/archive.tar (2).gz"},
+		{"/docs/README", 1, true, "/docs/README (1)"},
+		{"/.bashrc", 3, true, "/.bashrc (3)"},
+		{"/docs/photos.2024", 1, false, "/docs/photos.2024 (1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := NewCloudPath(tt.path).Numbered(tt.n, tt.isFile).String()
+			if got != tt.want {
+				t.Errorf("CloudPath(%q).Numbered(%d, %v) = %q, want %q", tt.path, tt.n, tt.isFile, got, tt.want)
+			}
+		})
+	}
+}
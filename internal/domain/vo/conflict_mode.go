@@ -8,8 +8,8 @@ type ConflictMode string
 const (
 	// ConflictStrict returns an error if the target path already exists.
 	ConflictStrict ConflictMode = "strict"
-	// ConflictRename replaces the existing file at the target path.
-	// Named "rename" per the protocol, but the actual behavior is replacement.
+	// ConflictRename keeps both items, numbering the new one's name:
+	// "report.docx" becomes "report (1).docx".
 	ConflictRename ConflictMode = "rename"
 	// ConflictReplace explicitly replaces the existing file.
 	ConflictReplace ConflictMode = "replace"
@@ -0,0 +1,31 @@
+package vo
+
+import "fmt"
+
+// GrantType represents an OAuth2 grant type accepted at the token endpoint.
+type GrantType string
+
+const (
+	// GrantPassword exchanges user credentials for a token set.
+	GrantPassword GrantType = "password"
+	// GrantRefreshToken exchanges a refresh token for a new token set.
+	GrantRefreshToken GrantType = "refresh_token"
+)
+
+// ParseGrantType converts a raw string to a GrantType.
+// Returns an error for unknown values.
+func ParseGrantType(raw string) (GrantType, error) {
+	switch raw {
+	case "password":
+		return GrantPassword, nil
+	case "refresh_token":
+		return GrantRefreshToken, nil
+	default:
+		return "", fmt.Errorf("unknown grant type: %q", raw)
+	}
+}
+
+// String returns the string representation of the grant type.
+func (g GrantType) String() string {
+	return string(g)
+}
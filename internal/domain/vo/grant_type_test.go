@@ -0,0 +1,27 @@
+package vo
+
+import "testing"
+
+func TestParseGrantType(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    GrantType
+		wantErr bool
+	}{
+		{"password", GrantPassword, false},
+		{"refresh_token", GrantRefreshToken, false},
+		{"", "", true},
+		{"client_credentials", "", true},
+	}
+	for _, tt := range tests {
+		t.Run("input="+tt.input, func(t *testing.T) {
+			got, err := ParseGrantType(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGrantType(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGrantType(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
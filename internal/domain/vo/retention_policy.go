@@ -0,0 +1,85 @@
+package vo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	secondsPerDay  = 24 * 60 * 60
+	secondsPerWeek = 7 * secondsPerDay
+)
+
+// RetentionPolicy decides which revisions of a file's history are kept.
+// Revisions not kept by any of the rules expire.
+type RetentionPolicy struct {
+	KeepLast   int // The newest revisions that are always kept.
+	KeepDays   int // Every revision younger than this many days is kept.
+	DailyDays  int // Up to this age in days, the newest revision of each day is kept.
+	WeeklyDays int // Up to this age in days, the newest revision of each week is kept.
+}
+
+// DefaultRetentionPolicy keeps the last 10 revisions, everything for 30 days,
+// then one revision a day for 90 days and one a week for a year.
+var DefaultRetentionPolicy = RetentionPolicy{KeepLast: 10, KeepDays: 30, DailyDays: 90, WeeklyDays: 365}
+
+// ParseRetentionPolicy converts a string such as "last=10,days=30,daily=90,weekly=365"
+// to a RetentionPolicy. Omitted rules are zero, keeping nothing.
+func ParseRetentionPolicy(raw string) (RetentionPolicy, error) {
+	var p RetentionPolicy
+	if strings.TrimSpace(raw) == "" {
+		return p, fmt.Errorf("empty retention policy")
+	}
+	for _, rule := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(rule), "=")
+		n, err := strconv.Atoi(value)
+		if !ok || err != nil || n < 0 {
+			return RetentionPolicy{}, fmt.Errorf("invalid retention rule: %q", rule)
+		}
+		switch key {
+		case "last":
+			p.KeepLast = n
+		case "days":
+			p.KeepDays = n
+		case "daily":
+			p.DailyDays = n
+		case "weekly":
+			p.WeeklyDays = n
+		default:
+			return RetentionPolicy{}, fmt.Errorf("unknown retention rule: %q", key)
+		}
+	}
+	return p, nil
+}
+
+// String returns the policy in the form accepted by ParseRetentionPolicy.
+func (p RetentionPolicy) String() string {
+	return fmt.Sprintf("last=%d,days=%d,daily=%d,weekly=%d", p.KeepLast, p.KeepDays, p.DailyDays, p.WeeklyDays)
+}
+
+// Keep reports which of a file's revisions the policy keeps at the time now.
+// times holds the revision times in Unix seconds, newest first.
+func (p RetentionPolicy) Keep(times []int64, now int64) []bool {
+	keep := make([]bool, len(times))
+	days := map[int64]bool{}
+	weeks := map[int64]bool{}
+	for i, t := range times {
+		age := now - t
+		day, week := t/secondsPerDay, t/secondsPerWeek
+		switch {
+		case i < p.KeepLast, age < int64(p.KeepDays)*secondsPerDay:
+			keep[i] = true
+		case age < int64(p.DailyDays)*secondsPerDay:
+			keep[i] = !days[day]
+		case age < int64(p.WeeklyDays)*secondsPerDay:
+			keep[i] = !weeks[week]
+		}
+		// A kept revision is the newest of its day and week.
+		if keep[i] {
+			days[day] = true
+			weeks[week] = true
+		}
+	}
+	return keep
+}
@@ -0,0 +1,71 @@
+package vo
+
+import "testing"
+
+func TestParseRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    RetentionPolicy
+		wantErr bool
+	}{
+		{"last=10,days=30,daily=90,weekly=365", RetentionPolicy{10, 30, 90, 365}, false},
+		{"last=3", RetentionPolicy{KeepLast: 3}, false},
+		{" days=7 , last=1 ", RetentionPolicy{KeepLast: 1, KeepDays: 7}, false},
+		{"", RetentionPolicy{}, true},
+		{"last", RetentionPolicy{}, true},
+		{"last=-1", RetentionPolicy{}, true},
+		{"monthly=12", RetentionPolicy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRetentionPolicy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRetentionPolicy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRetentionPolicy(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionPolicy_String(t *testing.T) {
+	got := DefaultRetentionPolicy.String()
+	if got != "last=10,days=30,daily=90,weekly=365" {
+		t.Errorf("String() = %q", got)
+	}
+	if parsed, _ := ParseRetentionPolicy(got); parsed != DefaultRetentionPolicy {
+		t.Errorf("round trip = %+v, want %+v", parsed, DefaultRetentionPolicy)
+	}
+}
+
+func TestRetentionPolicy_Keep(t *testing.T) {
+	const day = secondsPerDay
+	now := int64(1000*secondsPerWeek + day/2) // Midday after a week boundary, so buckets are predictable.
+	p := RetentionPolicy{KeepLast: 2, KeepDays: 1, DailyDays: 7, WeeklyDays: 28}
+
+	times := []int64{
+		now - 10,              // 0: within keep_days
+		now - 3*day,           // 1: among the last two
+		now - 3*day - 60,      // 2: same day as 1, which is newer
+		now - 4*day,           // 3: newest of its day
+		now - 10*day,          // 4: newest of its week
+		now - 10*day - 3600,   // 5: same week as 4
+		now - 20*day,          // 6: newest of its week
+		now - 40*day,          // 7: past weekly_days
+		now - 40*day - 7*day,  // 8: past weekly_days
+		now - 100*day - 7*day, // 9: past weekly_days
+	}
+	want := []bool{true, true, false, true, true, false, true, false, false, false}
+
+	got := p.Keep(times, now)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Keep()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if kept := (RetentionPolicy{}).Keep([]int64{now}, now); kept[0] {
+		t.Error("zero policy kept a revision")
+	}
+}
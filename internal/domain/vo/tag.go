@@ -0,0 +1,68 @@
+package vo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTagLength is the longest tag, in characters.
+const maxTagLength = 64
+
+// Tag is a user-defined label on a node. Tags are case-insensitive and
+// stored in lower case; they cannot contain commas or control characters.
+type Tag string
+
+// ParseTag converts a raw string to a Tag, trimming spaces and lowering case.
+func ParseTag(raw string) (Tag, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return "", fmt.Errorf("empty tag")
+	}
+	if utf8.RuneCountInString(s) > maxTagLength {
+		return "", fmt.Errorf("tag longer than %d characters: %q", maxTagLength, raw)
+	}
+	if strings.ContainsFunc(s, func(r rune) bool { return r == ',' || unicode.IsControl(r) }) {
+		return "", fmt.Errorf("tag contains a comma or a control character: %q", raw)
+	}
+	return Tag(s), nil
+}
+
+// ParseTags converts a comma-separated list into tags, sorted and without
+// duplicates. An empty list gives no tags.
+func ParseTags(raw string) ([]Tag, error) {
+	var tags []Tag
+	for _, f := range strings.Split(raw, ",") {
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		tag, err := ParseTag(f)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return SortTags(tags), nil
+}
+
+// SortTags sorts tags in place, removes duplicates and returns the result.
+func SortTags(tags []Tag) []Tag {
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// FormatTags joins tags into a comma-separated string (storage form).
+func FormatTags(tags []Tag) string {
+	parts := make([]string, len(tags))
+	for i, t := range tags {
+		parts[i] = string(t)
+	}
+	return strings.Join(parts, ",")
+}
+
+// String returns the string representation of the tag.
+func (t Tag) String() string {
+	return string(t)
+}
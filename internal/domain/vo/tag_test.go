@@ -0,0 +1,70 @@
+package vo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Tag
+		wantErr bool
+	}{
+		{"work", "work", false},
+		{"  Work ", "work", false},
+		{"Отчёты 2024", "отчёты 2024", false},
+		{strings.Repeat("я", 64), Tag(strings.Repeat("я", 64)), false},
+		{strings.Repeat("я", 65), "", true},
+		{"", "", true},
+		{"   ", "", true},
+		{"a,b", "", true},
+		{"a\tb", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTag(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTag(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTag(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []Tag
+		wantErr bool
+	}{
+		{"work", []Tag{"work"}, false},
+		{"work,Home, work", []Tag{"home", "work"}, false},
+		{"", nil, false},
+		{" , ", nil, false},
+		{"work," + strings.Repeat("x", 65), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTags(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTags(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTags(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	if got := FormatTags([]Tag{"home", "work"}); got != "home,work" {
+		t.Errorf("FormatTags = %q, want %q", got, "home,work")
+	}
+	if got := FormatTags(nil); got != "" {
+		t.Errorf("FormatTags(nil) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,73 @@
+package vo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenScope represents a permission granted to a personal access token.
+type TokenScope string
+
+const (
+	// ScopeRead allows listing folders, reading metadata, and downloading files.
+	ScopeRead TokenScope = "read"
+	// ScopeWrite allows creating, uploading, renaming, moving, and copying nodes.
+	// Write implies read.
+	ScopeWrite TokenScope = "write"
+	// ScopePublish allows creating and removing public weblinks.
+	ScopePublish TokenScope = "publish"
+	// ScopeShare allows managing folder shares, invites, and mounts.
+	ScopeShare TokenScope = "share"
+	// ScopeTrash allows moving nodes to the trashbin and managing its contents.
+	ScopeTrash TokenScope = "trash"
+)
+
+// ParseTokenScope converts a raw string to a TokenScope.
+func ParseTokenScope(raw string) (TokenScope, error) {
+	switch TokenScope(raw) {
+	case ScopeRead, ScopeWrite, ScopePublish, ScopeShare, ScopeTrash:
+		return TokenScope(raw), nil
+	default:
+		return "", fmt.Errorf("unknown token scope: %q", raw)
+	}
+}
+
+// ParseTokenScopes converts a comma- or space-separated list into scopes.
+// Duplicates are removed; an empty list is an error.
+func ParseTokenScopes(raw string) ([]TokenScope, error) {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no token scopes given")
+	}
+
+	scopes := make([]TokenScope, 0, len(fields))
+	seen := make(map[TokenScope]bool, len(fields))
+	for _, f := range fields {
+		scope, err := ParseTokenScope(strings.ToLower(f))
+		if err != nil {
+			return nil, err
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// FormatTokenScopes joins scopes into a comma-separated string (storage and API form).
+func FormatTokenScopes(scopes []TokenScope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+// String returns the string representation of the token scope.
+func (s TokenScope) String() string {
+	return string(s)
+}
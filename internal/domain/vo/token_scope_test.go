@@ -0,0 +1,69 @@
+package vo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTokenScope(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TokenScope
+		wantErr bool
+	}{
+		{"read", ScopeRead, false},
+		{"write", ScopeWrite, false},
+		{"publish", ScopePublish, false},
+		{"share", ScopeShare, false},
+		{"trash", ScopeTrash, false},
+		{"", "", true},
+		{"admin", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTokenScope(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTokenScope(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTokenScope(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTokenScopes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []TokenScope
+		wantErr bool
+	}{
+		{"read", []TokenScope{ScopeRead}, false},
+		{"read,write", []TokenScope{ScopeRead, ScopeWrite}, false},
+		{"write, trash", []TokenScope{ScopeWrite, ScopeTrash}, false},
+		{"READ read", []TokenScope{ScopeRead}, false},
+		{"", nil, true},
+		{" , ", nil, true},
+		{"read,delete", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTokenScopes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTokenScopes(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTokenScopes(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTokenScopes(t *testing.T) {
+	if got := FormatTokenScopes([]TokenScope{ScopeRead, ScopeShare}); got != "read,share" {
+		t.Errorf("FormatTokenScopes() = %q, want %q", got, "read,share")
+	}
+	if got := FormatTokenScopes(nil); got != "" {
+		t.Errorf("FormatTokenScopes(nil) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,35 @@
+package vo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxXAttrNameLength is the longest extended attribute name, in bytes.
+const maxXAttrNameLength = 128
+
+// XAttrName is the name of an extended attribute of a node. Names are
+// case-sensitive and consist of ASCII letters, digits, dots, dashes and
+// underscores, such as "source.system" or "retention_class".
+type XAttrName string
+
+// ParseXAttrName converts a raw string to an XAttrName.
+func ParseXAttrName(raw string) (XAttrName, error) {
+	if raw == "" {
+		return "", fmt.Errorf("empty attribute name")
+	}
+	if len(raw) > maxXAttrNameLength {
+		return "", fmt.Errorf("attribute name longer than %d characters: %q", maxXAttrNameLength, raw)
+	}
+	if strings.ContainsFunc(raw, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_')
+	}) {
+		return "", fmt.Errorf("attribute name contains an invalid character: %q", raw)
+	}
+	return XAttrName(raw), nil
+}
+
+// String returns the string representation of the name.
+func (n XAttrName) String() string {
+	return string(n)
+}
@@ -0,0 +1,35 @@
+package vo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseXAttrName(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    XAttrName
+		wantErr bool
+	}{
+		{"source", "source", false},
+		{"Source.System", "Source.System", false},
+		{"retention_class-2", "retention_class-2", false},
+		{strings.Repeat("a", 128), XAttrName(strings.Repeat("a", 128)), false},
+		{strings.Repeat("a", 129), "", true},
+		{"", "", true},
+		{"source system", "", true},
+		{"source:system", "", true},
+		{"источник", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseXAttrName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseXAttrName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseXAttrName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package exif reads photo metadata from the EXIF block of JPEG images.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoExif indicates the image is not a JPEG file with an EXIF block.
+var ErrNoExif = errors.New("no EXIF data")
+
+// maxSegments caps how many JPEG segments are read looking for the EXIF block,
+// which cameras place right after the start of the image.
+const maxSegments = 32
+
+// dateLayout is the format of EXIF date and time values.
+const dateLayout = "2006:01:02 15:04:05"
+
+// EXIF tags read from the image, the EXIF and the GPS directories.
+const (
+	tagMake             = 0x010f
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+// Metadata holds the EXIF fields of an image. Zero fields were not recorded.
+type Metadata struct {
+	Orientation int       // 1 to 8, as in the EXIF Orientation tag.
+	Taken       time.Time // Original capture time, or the last change time; the camera clock read as UTC.
+	Make        string
+	Model       string
+	HasLocation bool
+	Latitude    float64 // Degrees, negative in the south.
+	Longitude   float64 // Degrees, negative in the west.
+}
+
+// Read returns the EXIF metadata of a JPEG image, which is size bytes long.
+// Returns ErrNoExif if the image has none.
+func Read(r io.ReaderAt, size int64) (*Metadata, error) {
+	block, err := findBlock(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return parse(block)
+}
+
+// findBlock returns the TIFF structure of the APP1 Exif segment of a JPEG file.
+func findBlock(r io.ReaderAt, size int64) ([]byte, error) {
+	var head [4]byte
+	if _, err := r.ReadAt(head[:2], 0); err != nil || head[0] != 0xff || head[1] != 0xd8 {
+		return nil, ErrNoExif
+	}
+
+	off := int64(2)
+	for i := 0; i < maxSegments && off+4 <= size; i++ {
+		if _, err := r.ReadAt(head[:], off); err != nil {
+			return nil, err
+		}
+		if head[0] != 0xff {
+			return nil, ErrNoExif
+		}
+		marker := head[1]
+		length := int64(binary.BigEndian.Uint16(head[2:]))
+		// Start of scan and end of image: the metadata segments are behind.
+		if marker == 0xda || marker == 0xd9 || length < 2 {
+			return nil, ErrNoExif
+		}
+		if marker == 0xe1 && off+2+length <= size {
+			segment := make([]byte, length-2)
+			if _, err := r.ReadAt(segment, off+4); err != nil {
+				return nil, err
+			}
+			if block, ok := bytes.CutPrefix(segment, []byte("Exif\x00\x00")); ok {
+				return block, nil
+			}
+		}
+		off += 2 + length
+	}
+	return nil, ErrNoExif
+}
+
+// tiff is a TIFF structure with its byte order.
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// entry is a directory entry: a tag value of count items of the given type.
+type entry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// parse reads the metadata from the TIFF structure of an EXIF block.
+func parse(block []byte) (*Metadata, error) {
+	if len(block) < 8 {
+		return nil, ErrNoExif
+	}
+	t := &tiff{data: block}
+	switch string(block[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+	if t.order.Uint16(block[2:]) != 42 {
+		return nil, ErrNoExif
+	}
+
+	ifd0 := t.directory(t.order.Uint32(block[4:]))
+	meta := &Metadata{
+		Orientation: int(t.uint(ifd0[tagOrientation])),
+		Make:        t.ascii(ifd0[tagMake]),
+		Model:       t.ascii(ifd0[tagModel]),
+	}
+	if meta.Orientation < 1 || meta.Orientation > 8 {
+		meta.Orientation = 0
+	}
+
+	taken := t.ascii(ifd0[tagDateTime])
+	if off := t.uint(ifd0[tagExifIFD]); off != 0 {
+		if original := t.ascii(t.directory(off)[tagDateTimeOriginal]); original != "" {
+			taken = original
+		}
+	}
+	if parsed, err := time.Parse(dateLayout, taken); err == nil {
+		meta.Taken = parsed
+	}
+
+	if off := t.uint(ifd0[tagGPSIFD]); off != 0 {
+		gps := t.directory(off)
+		lat, latOK := t.degrees(gps[tagGPSLatitude])
+		lon, lonOK := t.degrees(gps[tagGPSLongitude])
+		if latOK && lonOK {
+			if t.ascii(gps[tagGPSLatitudeRef]) == "S" {
+				lat = -lat
+			}
+			if t.ascii(gps[tagGPSLongitudeRef]) == "W" {
+				lon = -lon
+			}
+			meta.HasLocation = true
+			meta.Latitude, meta.Longitude = lat, lon
+		}
+	}
+	return meta, nil
+}
+
+// typeSizes maps TIFF value types to the size of one item, in bytes.
+var typeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// directory returns the entries of the image file directory at off, by tag.
+// Entries that point outside the structure are left out; a broken directory
+// yields no entries.
+func (t *tiff) directory(off uint32) map[uint16]entry {
+	entries := make(map[uint16]entry)
+	if uint64(off)+2 > uint64(len(t.data)) {
+		return entries
+	}
+	n := uint64(t.order.Uint16(t.data[off:]))
+	start := uint64(off) + 2
+	if start+n*12 > uint64(len(t.data)) {
+		return entries
+	}
+
+	for i := uint64(0); i < n; i++ {
+		raw := t.data[start+i*12 : start+i*12+12]
+		e := entry{typ: t.order.Uint16(raw[2:]), count: t.order.Uint32(raw[4:])}
+		size := uint64(typeSizes[e.typ]) * uint64(e.count)
+		if size == 0 {
+			continue
+		}
+		if size <= 4 {
+			e.value = raw[8 : 8+size]
+		} else {
+			valueOff := uint64(t.order.Uint32(raw[8:]))
+			if valueOff+size > uint64(len(t.data)) {
+				continue
+			}
+			e.value = t.data[valueOff : valueOff+size]
+		}
+		entries[t.order.Uint16(raw)] = e
+	}
+	return entries
+}
+
+// ascii returns an ASCII value without its terminating NUL and padding.
+func (t *tiff) ascii(e entry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	s, _, _ := strings.Cut(string(e.value), "\x00")
+	return strings.TrimSpace(s)
+}
+
+// uint returns the first item of a SHORT or LONG value, or 0.
+func (t *tiff) uint(e entry) uint32 {
+	switch e.typ {
+	case 3:
+		return uint32(t.order.Uint16(e.value))
+	case 4:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// degrees returns a GPS coordinate given as degrees, minutes and seconds.
+func (t *tiff) degrees(e entry) (float64, bool) {
+	if e.typ != 5 || e.count != 3 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := t.order.Uint32(e.value[i*8:])
+		den := t.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
@@ -0,0 +1,209 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+	"time"
+)
+
+// testTag is a directory entry written by buildTIFF.
+type testTag struct {
+	id, typ uint16
+	count   uint32
+	data    []byte
+}
+
+func asciiTag(id uint16, s string) testTag {
+	return testTag{id, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func shortTag(order binary.ByteOrder, id uint16, v uint16) testTag {
+	data := make([]byte, 2)
+	order.PutUint16(data, v)
+	return testTag{id, 3, 1, data}
+}
+
+func longTag(order binary.ByteOrder, id uint16, v uint32) testTag {
+	data := make([]byte, 4)
+	order.PutUint32(data, v)
+	return testTag{id, 4, 1, data}
+}
+
+// dmsTag is a GPS coordinate of whole degrees and minutes, and seconds in hundredths.
+func dmsTag(order binary.ByteOrder, id uint16, deg, min, centisec uint32) testTag {
+	data := make([]byte, 24)
+	for i, r := range [][2]uint32{{deg, 1}, {min, 1}, {centisec, 100}} {
+		order.PutUint32(data[i*8:], r[0])
+		order.PutUint32(data[i*8+4:], r[1])
+	}
+	return testTag{id, 5, 3, data}
+}
+
+// buildTIFF lays out a TIFF structure with the image directory and, unless
+// nil, the EXIF and GPS directories it points to.
+func buildTIFF(order binary.ByteOrder, ifd0, exifIFD, gpsIFD []testTag) []byte {
+	ifdSize := func(n int) int { return 2 + 12*n + 4 }
+	n0 := len(ifd0)
+	if exifIFD != nil {
+		n0++
+	}
+	if gpsIFD != nil {
+		n0++
+	}
+	exifOff := 8 + ifdSize(n0)
+	gpsOff := exifOff + ifdSize(len(exifIFD))
+	dataOff := gpsOff + ifdSize(len(gpsIFD))
+	if exifIFD != nil {
+		ifd0 = append(ifd0, longTag(order, tagExifIFD, uint32(exifOff)))
+	}
+	if gpsIFD != nil {
+		ifd0 = append(ifd0, longTag(order, tagGPSIFD, uint32(gpsOff)))
+	}
+
+	buf := make([]byte, dataOff)
+	if order == binary.ByteOrder(binary.LittleEndian) {
+		copy(buf, "II")
+	} else {
+		copy(buf, "MM")
+	}
+	order.PutUint16(buf[2:], 42)
+	order.PutUint32(buf[4:], 8)
+
+	var data []byte
+	write := func(off int, tags []testTag) {
+		order.PutUint16(buf[off:], uint16(len(tags)))
+		for i, tag := range tags {
+			e := buf[off+2+12*i:]
+			order.PutUint16(e, tag.id)
+			order.PutUint16(e[2:], tag.typ)
+			order.PutUint32(e[4:], tag.count)
+			if len(tag.data) <= 4 {
+				copy(e[8:], tag.data)
+			} else {
+				order.PutUint32(e[8:], uint32(dataOff+len(data)))
+				data = append(data, tag.data...)
+			}
+		}
+	}
+	write(8, ifd0)
+	write(exifOff, exifIFD)
+	write(gpsOff, gpsIFD)
+	return append(buf, data...)
+}
+
+// jpegWithExif encodes a w x h JPEG image with the TIFF structure as its EXIF block.
+func jpegWithExif(t *testing.T, w, h int, block []byte) []byte {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := append([]byte("Exif\x00\x00"), block...)
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xff, 0xe1, byte((len(segment) + 2) >> 8), byte(len(segment) + 2)})
+	out.Write(segment)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			block := buildTIFF(order,
+				[]testTag{
+					asciiTag(tagMake, "Canon"),
+					asciiTag(tagModel, "Canon EOS 5D Mark IV"),
+					shortTag(order, tagOrientation, 6),
+					asciiTag(tagDateTime, "2024:06:02 10:00:00"),
+				},
+				[]testTag{asciiTag(tagDateTimeOriginal, "2024:05:31 18:45:12")},
+				[]testTag{
+					asciiTag(tagGPSLatitudeRef, "N"),
+					dmsTag(order, tagGPSLatitude, 55, 45, 1800),
+					asciiTag(tagGPSLongitudeRef, "W"),
+					dmsTag(order, tagGPSLongitude, 37, 37, 0),
+				},
+			)
+			data := jpegWithExif(t, 4, 2, block)
+
+			meta, err := Read(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if meta.Make != "Canon" || meta.Model != "Canon EOS 5D Mark IV" || meta.Orientation != 6 {
+				t.Errorf("camera = %q %q, orientation %d", meta.Make, meta.Model, meta.Orientation)
+			}
+			if want := time.Date(2024, 5, 31, 18, 45, 12, 0, time.UTC); !meta.Taken.Equal(want) {
+				t.Errorf("Taken = %v, want %v", meta.Taken, want)
+			}
+			if !meta.HasLocation || math.Abs(meta.Latitude-55.755) > 1e-9 || math.Abs(meta.Longitude+37.616667) > 1e-6 {
+				t.Errorf("location = %v %v, %v", meta.HasLocation, meta.Latitude, meta.Longitude)
+			}
+		})
+	}
+}
+
+func TestRead_fallbacks(t *testing.T) {
+	order := binary.BigEndian
+	block := buildTIFF(order,
+		[]testTag{
+			asciiTag(tagDateTime, "2024:06:02 10:00:00"),
+			shortTag(order, tagOrientation, 12),
+		},
+		nil,
+		[]testTag{asciiTag(tagGPSLatitudeRef, "N")},
+	)
+	data := jpegWithExif(t, 1, 1, block)
+
+	meta, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if want := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC); !meta.Taken.Equal(want) {
+		t.Errorf("Taken = %v, want the change time %v", meta.Taken, want)
+	}
+	if meta.Orientation != 0 || meta.HasLocation {
+		t.Errorf("orientation %d, location %v, want neither", meta.Orientation, meta.HasLocation)
+	}
+}
+
+func TestRead_noExif(t *testing.T) {
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 1, 1)), nil); err != nil {
+		t.Fatal(err)
+	}
+	truncated := jpegWithExif(t, 1, 1, buildTIFF(binary.BigEndian, nil, nil, nil))[:30]
+
+	for name, data := range map[string][]byte{
+		"plain JPEG": img.Bytes(),
+		"not JPEG":   []byte("GIF89a"),
+		"truncated":  truncated,
+	} {
+		if _, err := Read(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrNoExif) {
+			t.Errorf("%s: error = %v, want ErrNoExif", name, err)
+		}
+	}
+}
+
+func TestRead_brokenDirectory(t *testing.T) {
+	order := binary.LittleEndian
+	block := buildTIFF(order, []testTag{asciiTag(tagModel, "Pixel 8")}, nil, nil)
+	// Point the model past the end of the structure.
+	order.PutUint32(block[8+2+8:], 0xfffffff0)
+	data := jpegWithExif(t, 1, 1, block)
+
+	meta, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if meta.Model != "" {
+		t.Errorf("Model = %q, want the broken entry skipped", meta.Model)
+	}
+}
@@ -0,0 +1,70 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // Registers the GIF format for image.DecodeConfig.
+	_ "image/jpeg" // Registers the JPEG format for image.DecodeConfig.
+	_ "image/png"  // Registers the PNG format for image.DecodeConfig.
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// Extractor reads the dimensions of JPEG, PNG and GIF images and the EXIF
+// metadata of JPEG images. Only the headers of the images are read.
+type Extractor struct{}
+
+// Supports reports whether the name has an image extension.
+func (Extractor) Supports(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Extract returns the dimensions of the image and, for JPEG images, the
+// capture time, camera, location and orientation recorded in it.
+func (Extractor) Extract(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	config, format, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("reading image header: %w", err)
+	}
+	info := &entity.MediaInfo{Width: config.Width, Height: config.Height}
+	if format != "jpeg" {
+		return info, nil
+	}
+
+	meta, err := Read(r, size)
+	if errors.Is(err, ErrNoExif) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info.Orientation = meta.Orientation
+	if Transposed(meta.Orientation) {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	if !meta.Taken.IsZero() {
+		info.TakenAt = meta.Taken.Unix()
+	}
+	info.Make = meta.Make
+	info.Model = meta.Model
+	info.HasLocation = meta.HasLocation
+	info.Latitude = meta.Latitude
+	info.Longitude = meta.Longitude
+	return info, nil
+}
+
+// Transposed reports whether an image with the EXIF orientation is stored
+// rotated by a quarter turn, so its width and height swap when displayed.
+func Transposed(orientation int) bool {
+	return orientation >= 5 && orientation <= 8
+}
@@ -0,0 +1,64 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestExtractor_Supports(t *testing.T) {
+	for name, want := range map[string]bool{
+		"IMG_0001.JPG": true, "photo.jpeg": true, "scan.png": true, "anim.gif": true,
+		"raw.cr2": false, "notes.txt": false, "jpg": false,
+	} {
+		if got := (Extractor{}).Supports(name); got != want {
+			t.Errorf("Supports(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExtractor_Extract(t *testing.T) {
+	order := binary.BigEndian
+	block := buildTIFF(order,
+		[]testTag{asciiTag(tagModel, "iPhone 15"), shortTag(order, tagOrientation, 6)},
+		[]testTag{asciiTag(tagDateTimeOriginal, "2024:05:31 18:45:12")},
+		nil,
+	)
+	data := jpegWithExif(t, 40, 30, block)
+
+	info, err := Extractor{}.Extract(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if info.Width != 30 || info.Height != 40 {
+		t.Errorf("dimensions = %dx%d, want the rotated 30x40", info.Width, info.Height)
+	}
+	if info.Model != "iPhone 15" || info.Orientation != 6 || info.HasLocation {
+		t.Errorf("info = %+v", info)
+	}
+	if want := time.Date(2024, 5, 31, 18, 45, 12, 0, time.UTC).Unix(); info.TakenAt != want {
+		t.Errorf("TakenAt = %d, want %d", info.TakenAt, want)
+	}
+}
+
+func TestExtractor_Extract_png(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 7, 5))); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Extractor{}.Extract(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if info.Width != 7 || info.Height != 5 || info.TakenAt != 0 {
+		t.Errorf("info = %+v, want 7x5 without a capture time", info)
+	}
+
+	if _, err := (Extractor{}).Extract(bytes.NewReader([]byte("not an image")), 12); err == nil {
+		t.Error("Extract of a broken image succeeded")
+	}
+}
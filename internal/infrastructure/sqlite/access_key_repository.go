@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// accessKeyColumns is the column list matching scanAccessKey.
+const accessKeyColumns = `id, user_id, name, key_id, secret, created, last_used`
+
+// AccessKeyRepository implements repository.AccessKeyRepository using SQLite.
+type AccessKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAccessKeyRepository creates an AccessKeyRepository from the given database connection.
+func NewAccessKeyRepository(db *DB) *AccessKeyRepository {
+	return &AccessKeyRepository{db: db.Conn()}
+}
+
+// Create stores a new access key and assigns its ID and creation time.
+func (r *AccessKeyRepository) Create(k *entity.AccessKey) error {
+	k.Created = time.Now().Unix()
+	res, err := r.db.Exec(
+		`INSERT INTO access_keys (user_id, name, key_id, secret, created) VALUES (?, ?, ?, ?, ?)`,
+		k.UserID, k.Name, k.KeyID, k.Secret, k.Created,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting access key: %w", err)
+	}
+	k.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves an access key by ID. Returns nil, nil if not found.
+func (r *AccessKeyRepository) GetByID(id int64) (*entity.AccessKey, error) {
+	return r.getOne(`SELECT `+accessKeyColumns+` FROM access_keys WHERE id = ?`, id)
+}
+
+// GetByKeyID retrieves an access key by its public key ID. Returns nil, nil if not found.
+func (r *AccessKeyRepository) GetByKeyID(keyID string) (*entity.AccessKey, error) {
+	return r.getOne(`SELECT `+accessKeyColumns+` FROM access_keys WHERE key_id = ?`, keyID)
+}
+
+// ListByUser returns all access keys of the given user, newest first.
+func (r *AccessKeyRepository) ListByUser(userID int64) ([]entity.AccessKey, error) {
+	rows, err := r.db.Query(
+		`SELECT `+accessKeyColumns+` FROM access_keys WHERE user_id = ? ORDER BY id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing access keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []entity.AccessKey
+	for rows.Next() {
+		k, err := scanAccessKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning access key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// MarkUsed records the time an access key was last used.
+func (r *AccessKeyRepository) MarkUsed(id int64, ts int64) error {
+	if _, err := r.db.Exec(`UPDATE access_keys SET last_used = ? WHERE id = ?`, ts, id); err != nil {
+		return fmt.Errorf("marking access key used: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an access key by ID.
+func (r *AccessKeyRepository) Delete(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM access_keys WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting access key: %w", err)
+	}
+	return nil
+}
+
+// getOne runs a single-row access key query. Returns nil, nil if no row matches.
+func (r *AccessKeyRepository) getOne(query string, args ...any) (*entity.AccessKey, error) {
+	k, err := scanAccessKey(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting access key: %w", err)
+	}
+	return k, nil
+}
+
+// scanAccessKey scans a row selected with accessKeyColumns.
+func scanAccessKey(s interface{ Scan(...any) error }) (*entity.AccessKey, error) {
+	var k entity.AccessKey
+	if err := s.Scan(&k.ID, &k.UserID, &k.Name, &k.KeyID, &k.Secret, &k.Created, &k.LastUsed); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
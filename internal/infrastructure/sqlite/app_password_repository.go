@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// AppPasswordRepository implements repository.AppPasswordRepository using SQLite.
+type AppPasswordRepository struct {
+	db *sql.DB
+}
+
+// NewAppPasswordRepository creates an AppPasswordRepository from the given database connection.
+func NewAppPasswordRepository(db *DB) *AppPasswordRepository {
+	return &AppPasswordRepository{db: db.Conn()}
+}
+
+// Create stores a new app password and assigns its ID and creation time.
+func (r *AppPasswordRepository) Create(p *entity.AppPassword) error {
+	p.Created = time.Now().Unix()
+	res, err := r.db.Exec(
+		`INSERT INTO app_passwords (user_id, name, password_hash, created) VALUES (?, ?, ?, ?)`,
+		p.UserID, p.Name, p.PasswordHash, p.Created,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting app password: %w", err)
+	}
+	p.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves an app password by ID. Returns nil, nil if not found.
+func (r *AppPasswordRepository) GetByID(id int64) (*entity.AppPassword, error) {
+	var p entity.AppPassword
+	err := r.db.QueryRow(
+		`SELECT id, user_id, name, password_hash, created, last_used FROM app_passwords WHERE id = ?`,
+		id,
+	).Scan(&p.ID, &p.UserID, &p.Name, &p.PasswordHash, &p.Created, &p.LastUsed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting app password: %w", err)
+	}
+	return &p, nil
+}
+
+// ListByUser returns all app passwords of the given user, newest first.
+func (r *AppPasswordRepository) ListByUser(userID int64) ([]entity.AppPassword, error) {
+	rows, err := r.db.Query(
+		`SELECT id, user_id, name, password_hash, created, last_used FROM app_passwords WHERE user_id = ? ORDER BY id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing app passwords: %w", err)
+	}
+	defer rows.Close()
+
+	var passwords []entity.AppPassword
+	for rows.Next() {
+		var p entity.AppPassword
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.PasswordHash, &p.Created, &p.LastUsed); err != nil {
+			return nil, fmt.Errorf("scanning app password: %w", err)
+		}
+		passwords = append(passwords, p)
+	}
+	return passwords, rows.Err()
+}
+
+// MarkUsed records the time an app password was last used.
+func (r *AppPasswordRepository) MarkUsed(id int64, ts int64) error {
+	if _, err := r.db.Exec(`UPDATE app_passwords SET last_used = ? WHERE id = ?`, ts, id); err != nil {
+		return fmt.Errorf("marking app password used: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an app password by ID.
+func (r *AppPasswordRepository) Delete(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM app_passwords WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting app password: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// ChangeRepository implements repository.ChangeRepository using SQLite.
+type ChangeRepository struct {
+	db *sql.DB
+}
+
+// NewChangeRepository creates a ChangeRepository from the given database connection.
+func NewChangeRepository(db *DB) *ChangeRepository {
+	return &ChangeRepository{db: db.Conn()}
+}
+
+// Insert appends a change to the user's journal and returns its ID.
+func (r *ChangeRepository) Insert(change *entity.Change) (int64, error) {
+	var hashStr *string
+	if !change.Hash.IsZero() {
+		s := change.Hash.String()
+		hashStr = &s
+	}
+
+	res, err := r.db.Exec(
+		`INSERT INTO changes (user_id, kind, home, from_home, node_type, size, hash, time)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		change.UserID, change.Kind.String(), change.Home.String(), change.From,
+		change.Type.String(), change.Size, hashStr, change.Time,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("inserting change: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListAfter returns up to limit changes of the user with IDs greater than cursor, oldest first.
+func (r *ChangeRepository) ListAfter(userID int64, cursor int64, limit int) ([]entity.Change, error) {
+	rows, err := r.db.Query(
+		`SELECT id, user_id, kind, home, from_home, node_type, size, hash, time
+		 FROM changes WHERE user_id = ? AND id > ? ORDER BY id ASC LIMIT ?`,
+		userID, cursor, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []entity.Change
+	for rows.Next() {
+		var (
+			c        entity.Change
+			kind     string
+			home     string
+			nodeType string
+			hash     sql.NullString
+		)
+		if err := rows.Scan(&c.ID, &c.UserID, &kind, &home, &c.From, &nodeType, &c.Size, &hash, &c.Time); err != nil {
+			return nil, fmt.Errorf("scanning change: %w", err)
+		}
+		c.Kind, _ = vo.ParseChangeKind(kind)
+		c.Home = vo.NewCloudPath(home)
+		c.Type, _ = vo.ParseNodeType(nodeType)
+		if hash.Valid {
+			c.Hash = vo.MustContentHash(hash.String)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// Latest returns the highest change ID recorded for the user, including pruned ones, or 0.
+func (r *ChangeRepository) Latest(userID int64) (int64, error) {
+	var latest int64
+	err := r.db.QueryRow(
+		`SELECT MAX(
+			COALESCE((SELECT MAX(id) FROM changes WHERE user_id = ?), 0),
+			COALESCE((SELECT pruned_through FROM change_horizons WHERE user_id = ?), 0)
+		)`,
+		userID, userID,
+	).Scan(&latest)
+	if err != nil {
+		return 0, fmt.Errorf("getting latest change: %w", err)
+	}
+	return latest, nil
+}
+
+// Horizon returns the highest change ID pruned from the user's journal, or 0.
+func (r *ChangeRepository) Horizon(userID int64) (int64, error) {
+	var horizon int64
+	err := r.db.QueryRow(
+		`SELECT pruned_through FROM change_horizons WHERE user_id = ?`,
+		userID,
+	).Scan(&horizon)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("getting change horizon: %w", err)
+	}
+	return horizon, nil
+}
+
+// Prune deletes changes recorded before the given Unix time,
+// remembering the highest deleted ID of each user as its horizon.
+func (r *ChangeRepository) Prune(before int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO change_horizons (user_id, pruned_through)
+		 SELECT user_id, MAX(id) FROM changes WHERE time < ? GROUP BY user_id
+		 ON CONFLICT(user_id) DO UPDATE SET pruned_through = MAX(pruned_through, excluded.pruned_through)`,
+		before,
+	)
+	if err != nil {
+		return fmt.Errorf("updating change horizons: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM changes WHERE time < ?`, before); err != nil {
+		return fmt.Errorf("pruning changes: %w", err)
+	}
+	return tx.Commit()
+}
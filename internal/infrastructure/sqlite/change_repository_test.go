@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestChangeRepository_ListAfterAndPrune(t *testing.T) {
+	db := openTestDB(t)
+	userRepo := NewUserRepository(db)
+	repo := NewChangeRepository(db)
+
+	userID, err := userRepo.Create(&entity.User{Email: "a@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	otherID, err := userRepo.Create(&entity.User{Email: "b@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	hash, _ := vo.NewContentHash("0000000000000000000000000000000000000001")
+	insert := func(userID int64, kind vo.ChangeKind, home string, time int64) int64 {
+		t.Helper()
+		id, err := repo.Insert(&entity.Change{
+			UserID: userID, Kind: kind, Home: vo.NewCloudPath(home), Type: vo.NodeTypeFile, Size: 5, Hash: hash, Time: time,
+		})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		return id
+	}
+
+	first := insert(userID, vo.ChangeCreate, "/a.txt", 100)
+	insert(otherID, vo.ChangeCreate, "/other.txt", 100)
+	second := insert(userID, vo.ChangeModify, "/a.txt", 200)
+	third, err := repo.Insert(&entity.Change{
+		UserID: userID, Kind: vo.ChangeMove, Home: vo.NewCloudPath("/b/a.txt"), From: "/a.txt", Type: vo.NodeTypeFile, Time: 300,
+	})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	changes, err := repo.ListAfter(userID, first, 10)
+	if err != nil {
+		t.Fatalf("ListAfter: %v", err)
+	}
+	if len(changes) != 2 || changes[0].ID != second || changes[1].ID != third {
+		t.Fatalf("ListAfter = %+v, want changes %d and %d", changes, second, third)
+	}
+	if changes[0].Kind != vo.ChangeModify || changes[0].Hash != hash || changes[0].Size != 5 {
+		t.Errorf("change = %+v, want modify of a 5-byte file", changes[0])
+	}
+	if changes[1].From != "/a.txt" || changes[1].Home.String() != "/b/a.txt" || !changes[1].Hash.IsZero() {
+		t.Errorf("change = %+v, want move from /a.txt without hash", changes[1])
+	}
+
+	if err := repo.Prune(250); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	horizon, err := repo.Horizon(userID)
+	if err != nil || horizon != second {
+		t.Errorf("Horizon = %d, %v; want %d", horizon, err, second)
+	}
+	changes, _ = repo.ListAfter(userID, 0, 10)
+	if len(changes) != 1 || changes[0].ID != third {
+		t.Errorf("after prune ListAfter = %+v, want only change %d", changes, third)
+	}
+
+	// Pruning everything keeps the latest cursor.
+	if err := repo.Prune(1000); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	latest, err := repo.Latest(userID)
+	if err != nil || latest != third {
+		t.Errorf("Latest = %d, %v; want %d", latest, err, third)
+	}
+	if horizon, _ := repo.Horizon(userID); horizon != third {
+		t.Errorf("Horizon = %d, want %d", horizon, third)
+	}
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// ContentIndexRepository implements repository.ContentIndexRepository using
+// the content_index table and the content_fts full-text index.
+type ContentIndexRepository struct {
+	db *sql.DB
+}
+
+// NewContentIndexRepository creates a ContentIndexRepository from the given database connection.
+func NewContentIndexRepository(db *DB) *ContentIndexRepository {
+	return &ContentIndexRepository{db: db.Conn()}
+}
+
+// ListUnindexed returns up to limit of the contents held by files that have
+// not been indexed yet, smallest first.
+func (r *ContentIndexRepository) ListUnindexed(limit int) ([]repository.UnindexedContent, error) {
+	rows, err := r.db.Query(
+		`SELECT hash, MAX(name), MAX(size) FROM nodes
+		 WHERE node_type = 'file' AND hash IS NOT NULL
+		   AND hash NOT IN (SELECT hash FROM content_index)
+		 GROUP BY hash ORDER BY MAX(size), hash LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing unindexed content: %w", err)
+	}
+	defer rows.Close()
+
+	var contents []repository.UnindexedContent
+	for rows.Next() {
+		var c repository.UnindexedContent
+		var hash string
+		if err := rows.Scan(&hash, &c.Name, &c.Size); err != nil {
+			return nil, fmt.Errorf("scanning unindexed content: %w", err)
+		}
+		if c.Hash, err = vo.NewContentHash(hash); err != nil {
+			return nil, err
+		}
+		contents = append(contents, c)
+	}
+	return contents, rows.Err()
+}
+
+// Store indexes the text of the content, replacing an earlier entry.
+func (r *ContentIndexRepository) Store(hash vo.ContentHash, text string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(
+		`INSERT INTO content_index (hash, indexed_at) VALUES (?, ?)
+		 ON CONFLICT(hash) DO UPDATE SET indexed_at = excluded.indexed_at
+		 RETURNING id`,
+		hash.String(), time.Now().Unix(),
+	).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("storing content index entry: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM content_fts WHERE rowid = ?`, id); err != nil {
+		return fmt.Errorf("clearing content text: %w", err)
+	}
+	if text != "" {
+		if _, err := tx.Exec(`INSERT INTO content_fts (rowid, body) VALUES (?, ?)`, id, text); err != nil {
+			return fmt.Errorf("indexing content text: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Prune drops the index entries of content that is no longer stored.
+// Returns the number of dropped entries.
+func (r *ContentIndexRepository) Prune() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stale := `SELECT id FROM content_index WHERE hash NOT IN (SELECT hash FROM contents)`
+	if _, err := tx.Exec(`DELETE FROM content_fts WHERE rowid IN (` + stale + `)`); err != nil {
+		return 0, fmt.Errorf("pruning content text: %w", err)
+	}
+	res, err := tx.Exec(`DELETE FROM content_index WHERE id IN (` + stale + `)`)
+	if err != nil {
+		return 0, fmt.Errorf("pruning content index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestContentIndexRepository(t *testing.T) {
+	db := openTestDB(t)
+	nodes := NewNodeRepository(db)
+	contents := NewContentRepository(db)
+	index := NewContentIndexRepository(db)
+	search := NewNodeSearchRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodes.CreateRootNode(userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes.CreateFolder(userID, vo.NewCloudPath("/docs")); err != nil {
+		t.Fatal(err)
+	}
+
+	notes := vo.MustContentHash(strings.Repeat("1", 40))
+	photo := vo.MustContentHash(strings.Repeat("2", 40))
+	for _, f := range []struct {
+		path string
+		hash vo.ContentHash
+		size int64
+	}{
+		{"/notes.txt", notes, 30},
+		{"/docs/copy.txt", notes, 30},
+		{"/photo.jpg", photo, 10},
+	} {
+		if _, err := nodes.CreateFile(userID, vo.NewCloudPath(f.path), f.hash, f.size); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := contents.Insert(f.hash, f.size); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unindexed, err := index.ListUnindexed(10)
+	if err != nil {
+		t.Fatalf("ListUnindexed: %v", err)
+	}
+	if len(unindexed) != 2 || unindexed[0].Hash != photo || unindexed[1].Hash != notes {
+		t.Fatalf("ListUnindexed = %+v, want each content once, smallest first", unindexed)
+	}
+
+	if err := index.Store(notes, "Quarterly budget review: the budget is approved."); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if err := index.Store(photo, ""); err != nil {
+		t.Fatalf("Store without text: %v", err)
+	}
+	if unindexed, _ := index.ListUnindexed(10); len(unindexed) != 0 {
+		t.Errorf("ListUnindexed after Store = %+v, want none", unindexed)
+	}
+
+	find := func(q repository.NodeQuery) []repository.NodeMatch {
+		t.Helper()
+		matches, err := search.SearchContent(userID, &q, 10)
+		if err != nil {
+			t.Fatalf("SearchContent(%+v): %v", q, err)
+		}
+		return matches
+	}
+	matches := find(repository.NodeQuery{Text: "budg"})
+	if len(matches) != 2 || matches[0].Node.Home.String() != "/docs/copy.txt" || matches[1].Node.Home.String() != "/notes.txt" {
+		t.Fatalf("SearchContent = %+v, want both files holding the content", matches)
+	}
+	if !strings.Contains(matches[0].Snippet, "<mark>budget</mark>") {
+		t.Errorf("snippet = %q, want the match marked", matches[0].Snippet)
+	}
+	if matches := find(repository.NodeQuery{Text: "budget", Under: vo.NewCloudPath("/docs")}); len(matches) != 1 {
+		t.Errorf("SearchContent under /docs = %+v, want one file", matches)
+	}
+	if matches := find(repository.NodeQuery{Text: "notes"}); len(matches) != 0 {
+		t.Errorf("SearchContent by a file name = %+v, want only content to match", matches)
+	}
+
+	if err := index.Store(notes, "Replaced text"); err != nil {
+		t.Fatalf("Store again: %v", err)
+	}
+	if matches := find(repository.NodeQuery{Text: "budget"}); len(matches) != 0 {
+		t.Errorf("SearchContent after reindexing = %+v, want the old text gone", matches)
+	}
+
+	if _, err := contents.Decrement(photo); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := index.Prune()
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("Prune = %d, want the deleted content dropped", pruned)
+	}
+}
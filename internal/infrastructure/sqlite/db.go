@@ -12,14 +12,19 @@ import (
 
 const schema = `
 CREATE TABLE IF NOT EXISTS users (
-    id               INTEGER PRIMARY KEY AUTOINCREMENT,
-    email            TEXT NOT NULL UNIQUE,
-    password         TEXT NOT NULL,
-    is_admin         INTEGER NOT NULL DEFAULT 0,
-    quota_bytes      INTEGER NOT NULL DEFAULT 17179869184,
-    file_size_limit  INTEGER NOT NULL DEFAULT 0,
-    version_history  INTEGER NOT NULL DEFAULT 0,
-    created          INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    email             TEXT NOT NULL UNIQUE,
+    password          TEXT NOT NULL,
+    is_admin          INTEGER NOT NULL DEFAULT 0,
+    quota_bytes       INTEGER NOT NULL DEFAULT 17179869184,
+    file_size_limit   INTEGER NOT NULL DEFAULT 0,
+    version_history   INTEGER NOT NULL DEFAULT 0,
+    created           INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+    totp_secret       TEXT NOT NULL DEFAULT '',
+    totp_enabled      INTEGER NOT NULL DEFAULT 0,
+    recovery_codes    TEXT NOT NULL DEFAULT '',
+    version_retention TEXT NOT NULL DEFAULT '',
+    trash_retention   INTEGER
 );
 
 CREATE TABLE IF NOT EXISTS nodes (
@@ -37,9 +42,29 @@ CREATE TABLE IF NOT EXISTS nodes (
     tree      TEXT NOT NULL DEFAULT '',
     weblink   TEXT,
     created   INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+    starred   INTEGER NOT NULL DEFAULT 0,
+    tags      TEXT NOT NULL DEFAULT '',
     UNIQUE(user_id, home)
 );
 
+-- Full-text index of node names, kept in step with nodes by the triggers below.
+CREATE VIRTUAL TABLE IF NOT EXISTS nodes_fts USING fts5(
+    name,
+    content = 'nodes',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+CREATE TRIGGER IF NOT EXISTS nodes_fts_insert AFTER INSERT ON nodes BEGIN
+    INSERT INTO nodes_fts (rowid, name) VALUES (new.id, new.name);
+END;
+CREATE TRIGGER IF NOT EXISTS nodes_fts_delete AFTER DELETE ON nodes BEGIN
+    INSERT INTO nodes_fts (nodes_fts, rowid, name) VALUES ('delete', old.id, old.name);
+END;
+CREATE TRIGGER IF NOT EXISTS nodes_fts_rename AFTER UPDATE OF name ON nodes BEGIN
+    INSERT INTO nodes_fts (nodes_fts, rowid, name) VALUES ('delete', old.id, old.name);
+    INSERT INTO nodes_fts (rowid, name) VALUES (new.id, new.name);
+END;
+
 CREATE TABLE IF NOT EXISTS contents (
     hash      TEXT PRIMARY KEY,
     size      INTEGER NOT NULL,
@@ -47,6 +72,36 @@ CREATE TABLE IF NOT EXISTS contents (
     created   INTEGER NOT NULL DEFAULT (strftime('%s','now'))
 );
 
+-- Full-text index of file content, one entry per content hash. The rowid of
+-- content_fts is the id of the content_index entry.
+CREATE TABLE IF NOT EXISTS content_index (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    hash       TEXT NOT NULL UNIQUE,
+    indexed_at INTEGER NOT NULL
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS content_fts USING fts5(
+    body,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+CREATE INDEX IF NOT EXISTS idx_nodes_hash ON nodes(hash);
+
+-- Photo metadata, one entry per content hash. Content that is not a photo has
+-- an entry with is_media = 0, so it is not read again. taken_at is the camera
+-- clock read as UTC; latitude and longitude are NULL without a location.
+CREATE TABLE IF NOT EXISTS media_info (
+    hash        TEXT PRIMARY KEY,
+    is_media    INTEGER NOT NULL,
+    width       INTEGER NOT NULL DEFAULT 0,
+    height      INTEGER NOT NULL DEFAULT 0,
+    orientation INTEGER NOT NULL DEFAULT 0,
+    taken_at    INTEGER NOT NULL DEFAULT 0,
+    make        TEXT NOT NULL DEFAULT '',
+    model       TEXT NOT NULL DEFAULT '',
+    latitude    REAL,
+    longitude   REAL,
+    scanned_at  INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS trash (
     id           INTEGER PRIMARY KEY AUTOINCREMENT,
     user_id      INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
@@ -62,7 +117,22 @@ CREATE TABLE IF NOT EXISTS trash (
     deleted_at   INTEGER NOT NULL DEFAULT (strftime('%s','now')),
     deleted_from TEXT NOT NULL,
     deleted_by   INTEGER NOT NULL,
-    created      INTEGER NOT NULL
+    created      INTEGER NOT NULL,
+    batch_id     INTEGER
+);
+
+-- Extended attributes of nodes, and those kept with trashed nodes.
+CREATE TABLE IF NOT EXISTS node_xattrs (
+    node_id INTEGER NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    name    TEXT NOT NULL,
+    value   TEXT NOT NULL,
+    PRIMARY KEY (node_id, name)
+);
+CREATE TABLE IF NOT EXISTS trash_xattrs (
+    trash_id INTEGER NOT NULL REFERENCES trash(id) ON DELETE CASCADE,
+    name     TEXT NOT NULL,
+    value    TEXT NOT NULL,
+    PRIMARY KEY (trash_id, name)
 );
 
 CREATE TABLE IF NOT EXISTS shares (
@@ -86,20 +156,94 @@ CREATE TABLE IF NOT EXISTS tokens (
     refresh_token TEXT NOT NULL UNIQUE,
     csrf_token    TEXT NOT NULL,
     expires_at    INTEGER NOT NULL,
-    created       INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+    created       INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+    personal      INTEGER NOT NULL DEFAULT 0,
+    name          TEXT NOT NULL DEFAULT '',
+    scopes        TEXT NOT NULL DEFAULT '',
+    path_prefix   TEXT NOT NULL DEFAULT '/',
+    client_id     TEXT NOT NULL DEFAULT '',
+    impersonator  TEXT NOT NULL DEFAULT '',
+    elevated      INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS file_versions (
-    id      INTEGER PRIMARY KEY AUTOINCREMENT,
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    home        TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    hash        TEXT NOT NULL,
+    size        INTEGER NOT NULL,
+    rev         INTEGER NOT NULL,
+    time        INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+    content_ref INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_file_versions_user_home ON file_versions(user_id, home);
+
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name          TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created       INTEGER NOT NULL,
+    last_used     INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_app_passwords_user ON app_passwords(user_id);
+
+CREATE TABLE IF NOT EXISTS access_keys (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id   INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name      TEXT NOT NULL,
+    key_id    TEXT NOT NULL UNIQUE,
+    secret    TEXT NOT NULL,
+    created   INTEGER NOT NULL,
+    last_used INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_access_keys_user ON access_keys(user_id);
+
+CREATE TABLE IF NOT EXISTS ssh_keys (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    public_key  TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    created     INTEGER NOT NULL,
+    last_used   INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (user_id, fingerprint)
+);
+
+CREATE TABLE IF NOT EXISTS multipart_uploads (
+    id      TEXT PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     home    TEXT NOT NULL,
-    name    TEXT NOT NULL,
-    hash    TEXT NOT NULL,
-    size    INTEGER NOT NULL,
-    rev     INTEGER NOT NULL,
-    time    INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+    created INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS multipart_parts (
+    upload_id TEXT NOT NULL REFERENCES multipart_uploads(id) ON DELETE CASCADE,
+    number    INTEGER NOT NULL,
+    hash      TEXT NOT NULL,
+    size      INTEGER NOT NULL,
+    PRIMARY KEY (upload_id, number)
+);
+
+CREATE TABLE IF NOT EXISTS changes (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id   INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind      TEXT NOT NULL,
+    home      TEXT NOT NULL,
+    from_home TEXT NOT NULL DEFAULT '',
+    node_type TEXT NOT NULL CHECK (node_type IN ('file','folder')),
+    size      INTEGER NOT NULL DEFAULT 0,
+    hash      TEXT,
+    time      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_changes_user ON changes(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_changes_time ON changes(time);
+
+CREATE TABLE IF NOT EXISTS change_horizons (
+    user_id        INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    pruned_through INTEGER NOT NULL
 );
-CREATE INDEX IF NOT EXISTS idx_file_versions_user_home ON file_versions(user_id, home);
 `
 
 // DB wraps the SQLite database connection.
@@ -130,11 +274,25 @@ func Open(dbPath string) (*DB, error) {
 		}
 	}
 
+	var indexed int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'nodes_fts'`).Scan(&indexed); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("checking search index: %w", err)
+	}
+
 	if _, err := conn.Exec(schema); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("initializing schema: %w", err)
 	}
 
+	// Index the names of existing nodes when the search index is new.
+	if indexed == 0 {
+		if _, err := conn.Exec(`INSERT INTO nodes_fts (nodes_fts) VALUES ('rebuild')`); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("building search index: %w", err)
+		}
+	}
+
 	// Migrations for existing databases that lack the new columns.
 	migrations := []string{
 		"ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0",
@@ -142,6 +300,23 @@ func Open(dbPath string) (*DB, error) {
 		"ALTER TABLE nodes ADD COLUMN weblink TEXT",
 		"ALTER TABLE users ADD COLUMN file_size_limit INTEGER NOT NULL DEFAULT 0",
 		"ALTER TABLE users ADD COLUMN version_history INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE tokens ADD COLUMN personal INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE tokens ADD COLUMN name TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE tokens ADD COLUMN scopes TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE tokens ADD COLUMN path_prefix TEXT NOT NULL DEFAULT '/'",
+		"ALTER TABLE tokens ADD COLUMN client_id TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE users ADD COLUMN recovery_codes TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE tokens ADD COLUMN impersonator TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE tokens ADD COLUMN elevated INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE file_versions ADD COLUMN content_ref INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE users ADD COLUMN version_retention TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE users ADD COLUMN trash_retention INTEGER",
+		"ALTER TABLE nodes ADD COLUMN starred INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE nodes ADD COLUMN tags TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE trash ADD COLUMN batch_id INTEGER",
+		"CREATE INDEX IF NOT EXISTS idx_trash_batch ON trash(batch_id)",
 	}
 	for _, m := range migrations {
 		// Ignore errors -- column already exists on fresh or previously migrated DBs.
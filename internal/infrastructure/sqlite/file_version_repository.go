@@ -19,23 +19,28 @@ func NewFileVersionRepository(db *DB) *FileVersionRepository {
 	return &FileVersionRepository{db: db.Conn()}
 }
 
-// Insert records a new file version entry.
+// Insert records a new file version entry holding a reference to its content.
+// The entry gets the next revision number of its path; ID, Rev and Time are set on version.
 func (r *FileVersionRepository) Insert(version *entity.FileVersion) error {
 	now := time.Now().Unix()
-	_, err := r.db.Exec(
-		`INSERT INTO file_versions (user_id, home, name, hash, size, rev, time) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		version.UserID, version.Home.String(), version.Name, version.Hash.String(), version.Size, version.Rev, now,
-	)
+	err := r.db.QueryRow(
+		`INSERT INTO file_versions (user_id, home, name, hash, size, rev, time, content_ref)
+		 SELECT ?, ?, ?, ?, ?, COALESCE(MAX(rev), 0) + 1, ?, 1 FROM file_versions WHERE user_id = ? AND home = ?
+		 RETURNING id, rev`,
+		version.UserID, version.Home.String(), version.Name, version.Hash.String(), version.Size, now,
+		version.UserID, version.Home.String(),
+	).Scan(&version.ID, &version.Rev)
 	if err != nil {
 		return fmt.Errorf("inserting file version: %w", err)
 	}
+	version.Time = now
 	return nil
 }
 
 // ListByPath returns all version entries for the given user and path, ordered by time ascending.
 func (r *FileVersionRepository) ListByPath(userID int64, path vo.CloudPath) ([]entity.FileVersion, error) {
 	rows, err := r.db.Query(
-		`SELECT id, user_id, home, name, hash, size, rev, time FROM file_versions WHERE user_id = ? AND home = ? ORDER BY time ASC`,
+		`SELECT `+fileVersionColumns+` FROM file_versions WHERE user_id = ? AND home = ? ORDER BY time ASC, id ASC`,
 		userID, path.String(),
 	)
 	if err != nil {
@@ -45,14 +50,91 @@ func (r *FileVersionRepository) ListByPath(userID int64, path vo.CloudPath) ([]e
 
 	var versions []entity.FileVersion
 	for rows.Next() {
-		var v entity.FileVersion
-		var homePath, hashStr string
-		if err := rows.Scan(&v.ID, &v.UserID, &homePath, &v.Name, &hashStr, &v.Size, &v.Rev, &v.Time); err != nil {
+		v, err := scanFileVersion(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scanning file version: %w", err)
 		}
-		v.Home = vo.NewCloudPath(homePath)
-		v.Hash = vo.MustContentHash(hashStr)
-		versions = append(versions, v)
+		versions = append(versions, *v)
 	}
 	return versions, rows.Err()
 }
+
+// GetByRev returns the version entry with the given revision of a path, or nil if there is none.
+// Entries recorded before revisions were numbered share revision 1; the latest of them is returned.
+func (r *FileVersionRepository) GetByRev(userID int64, path vo.CloudPath, rev int64) (*entity.FileVersion, error) {
+	v, err := scanFileVersion(r.db.QueryRow(
+		`SELECT `+fileVersionColumns+` FROM file_versions WHERE user_id = ? AND home = ? AND rev = ? ORDER BY id DESC LIMIT 1`,
+		userID, path.String(), rev,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting file version: %w", err)
+	}
+	return v, nil
+}
+
+// ListByUser returns all version entries of the user, grouped by path and newest first within a path.
+func (r *FileVersionRepository) ListByUser(userID int64) ([]entity.FileVersion, error) {
+	rows, err := r.db.Query(
+		`SELECT `+fileVersionColumns+` FROM file_versions WHERE user_id = ? ORDER BY home, time DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing file versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []entity.FileVersion
+	for rows.Next() {
+		v, err := scanFileVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning file version: %w", err)
+		}
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+// Delete removes a version entry.
+func (r *FileVersionRepository) Delete(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM file_versions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting file version: %w", err)
+	}
+	return nil
+}
+
+// TotalSize returns the total size of the user's version entries, leaving out
+// those with the same content as the current file at their path.
+func (r *FileVersionRepository) TotalSize(userID int64) (int64, error) {
+	var total int64
+	err := r.db.QueryRow(
+		`SELECT COALESCE(SUM(v.size), 0) FROM file_versions v
+		 WHERE v.user_id = ? AND NOT EXISTS (
+		     SELECT 1 FROM nodes n WHERE n.user_id = v.user_id AND n.home = v.home AND n.hash = v.hash
+		 )`,
+		userID,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("summing file versions: %w", err)
+	}
+	return total, nil
+}
+
+// fileVersionColumns is the standard column list for file version queries.
+const fileVersionColumns = `id, user_id, home, name, hash, size, rev, time, content_ref`
+
+// scanFileVersion scans a file version row into an entity.FileVersion.
+func scanFileVersion(s interface{ Scan(...any) error }) (*entity.FileVersion, error) {
+	var v entity.FileVersion
+	var homePath, hashStr string
+	var contentRef int
+	if err := s.Scan(&v.ID, &v.UserID, &homePath, &v.Name, &hashStr, &v.Size, &v.Rev, &v.Time, &contentRef); err != nil {
+		return nil, err
+	}
+	v.ContentRef = contentRef != 0
+	v.Home = vo.NewCloudPath(homePath)
+	v.Hash = vo.MustContentHash(hashStr)
+	return &v, nil
+}
@@ -199,3 +199,107 @@ func TestFileVersionRepository_FullAddByHashFlow(t *testing.T) {
 		t.Errorf("versions[1].Size = %d, want 2048", versions[1].Size)
 	}
 }
+
+func TestFileVersionRepository_RevisionsAndGetByRev(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewFileVersionRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	hash1, _ := vo.NewContentHash("0000000000000000000000000000000000000001")
+	hash2, _ := vo.NewContentHash("0000000000000000000000000000000000000002")
+	path := vo.NewCloudPath("/a.txt")
+	other := vo.NewCloudPath("/b.txt")
+
+	for _, v := range []*entity.FileVersion{
+		{UserID: userID, Home: path, Name: "a.txt", Hash: hash1, Size: 1},
+		{UserID: userID, Home: other, Name: "b.txt", Hash: hash1, Size: 1},
+		{UserID: userID, Home: path, Name: "a.txt", Hash: hash2, Size: 2},
+	} {
+		if err := repo.Insert(v); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		if v.ID == 0 || v.Time == 0 {
+			t.Errorf("Insert did not set ID and Time: %+v", v)
+		}
+	}
+
+	versions, _ := repo.ListByPath(userID, path)
+	if len(versions) != 2 || versions[0].Rev != 1 || versions[1].Rev != 2 {
+		t.Fatalf("versions = %+v, want revisions 1 and 2", versions)
+	}
+	if others, _ := repo.ListByPath(userID, other); len(others) != 1 || others[0].Rev != 1 {
+		t.Errorf("other path versions = %+v, want revision 1", others)
+	}
+
+	got, err := repo.GetByRev(userID, path, 2)
+	if err != nil || got == nil || got.Hash != hash2 {
+		t.Errorf("GetByRev(2) = %+v, %v, want the second version", got, err)
+	}
+	if got, err := repo.GetByRev(userID, path, 3); err != nil || got != nil {
+		t.Errorf("GetByRev(3) = %+v, %v, want nil", got, err)
+	}
+
+	var refs int
+	_ = db.Conn().QueryRow("SELECT SUM(content_ref) FROM file_versions").Scan(&refs)
+	if refs != 3 {
+		t.Errorf("versions holding content = %d, want 3", refs)
+	}
+}
+
+func TestFileVersionRepository_ListByUserDeleteAndTotalSize(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewFileVersionRepository(db)
+	nodeRepo := NewNodeRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodeRepo.CreateRootNode(userID); err != nil {
+		t.Fatalf("CreateRootNode: %v", err)
+	}
+
+	hash1, _ := vo.NewContentHash("0000000000000000000000000000000000000001")
+	hash2, _ := vo.NewContentHash("0000000000000000000000000000000000000002")
+	a, b := vo.NewCloudPath("/a.txt"), vo.NewCloudPath("/b.txt")
+	for _, v := range []*entity.FileVersion{
+		{UserID: userID, Home: b, Name: "b.txt", Hash: hash1, Size: 10},
+		{UserID: userID, Home: a, Name: "a.txt", Hash: hash1, Size: 100},
+		{UserID: userID, Home: a, Name: "a.txt", Hash: hash2, Size: 1000},
+	} {
+		if err := repo.Insert(v); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	// The current content of /a.txt is its newest version.
+	if _, err := nodeRepo.CreateFile(userID, a, hash2, 1000); err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+
+	versions, err := repo.ListByUser(userID)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(versions) != 3 || versions[0].Home != a || versions[0].Rev != 2 || versions[1].Rev != 1 || versions[2].Home != b {
+		t.Fatalf("versions = %+v, want /a.txt newest first, then /b.txt", versions)
+	}
+	if !versions[0].ContentRef {
+		t.Error("ContentRef = false, want true")
+	}
+
+	if total, _ := repo.TotalSize(userID); total != 110 {
+		t.Errorf("TotalSize = %d, want 110 without the current content", total)
+	}
+
+	if err := repo.Delete(versions[1].ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if total, _ := repo.TotalSize(userID); total != 10 {
+		t.Errorf("TotalSize after Delete = %d, want 10", total)
+	}
+	if got, _ := repo.GetByRev(userID, a, 1); got != nil {
+		t.Errorf("GetByRev(1) = %+v, want nil after Delete", got)
+	}
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// MediaRepository implements repository.MediaRepository using the media_info table.
+type MediaRepository struct {
+	db *sql.DB
+}
+
+// NewMediaRepository creates a MediaRepository from the given database connection.
+func NewMediaRepository(db *DB) *MediaRepository {
+	return &MediaRepository{db: db.Conn()}
+}
+
+// mediaColumns is the column list of media_info scanned by mediaScanner.
+const mediaColumns = `m.width, m.height, m.orientation, m.taken_at, m.make, m.model, m.latitude, m.longitude`
+
+// ListUnscanned returns up to limit of the contents held by files whose
+// metadata has not been extracted yet, smallest first.
+func (r *MediaRepository) ListUnscanned(limit int) ([]repository.UnindexedContent, error) {
+	rows, err := r.db.Query(
+		`SELECT hash, MAX(name), MAX(size) FROM nodes
+		 WHERE node_type = 'file' AND hash IS NOT NULL
+		   AND hash NOT IN (SELECT hash FROM media_info)
+		 GROUP BY hash ORDER BY MAX(size), hash LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing unscanned content: %w", err)
+	}
+	defer rows.Close()
+
+	var contents []repository.UnindexedContent
+	for rows.Next() {
+		var c repository.UnindexedContent
+		var hash string
+		if err := rows.Scan(&hash, &c.Name, &c.Size); err != nil {
+			return nil, fmt.Errorf("scanning unscanned content: %w", err)
+		}
+		if c.Hash, err = vo.NewContentHash(hash); err != nil {
+			return nil, err
+		}
+		contents = append(contents, c)
+	}
+	return contents, rows.Err()
+}
+
+// Store records the metadata of the content, replacing an earlier entry.
+// A nil info marks content that is not a photo as scanned.
+func (r *MediaRepository) Store(hash vo.ContentHash, info *entity.MediaInfo) error {
+	var err error
+	if info == nil {
+		_, err = r.db.Exec(
+			`INSERT OR REPLACE INTO media_info (hash, is_media, scanned_at) VALUES (?, 0, ?)`,
+			hash.String(), time.Now().Unix(),
+		)
+	} else {
+		var lat, lon *float64
+		if info.HasLocation {
+			lat, lon = &info.Latitude, &info.Longitude
+		}
+		_, err = r.db.Exec(
+			`INSERT OR REPLACE INTO media_info
+			 (hash, is_media, width, height, orientation, taken_at, make, model, latitude, longitude, scanned_at)
+			 VALUES (?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			hash.String(), info.Width, info.Height, info.Orientation, info.TakenAt,
+			info.Make, info.Model, lat, lon, time.Now().Unix(),
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("storing media info: %w", err)
+	}
+	return nil
+}
+
+// Get returns the metadata of the content, or nil if it is not a photo or
+// has not been scanned yet.
+func (r *MediaRepository) Get(hash vo.ContentHash) (*entity.MediaInfo, error) {
+	info := entity.MediaInfo{Hash: hash}
+	s := &mediaScanner{info: &info}
+	err := r.db.QueryRow(
+		`SELECT `+mediaColumns+` FROM media_info AS m WHERE m.hash = ? AND m.is_media = 1`,
+		hash.String(),
+	).Scan(s.dest()...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting media info: %w", err)
+	}
+	s.finish()
+	return &info, nil
+}
+
+// Timeline returns up to limit of the user's photos after the first offset,
+// newest first: by capture time, or by modification time for photos without
+// one. Photos taken at the same time are ordered by path.
+func (r *MediaRepository) Timeline(userID int64, offset, limit int) ([]repository.Photo, error) {
+	rows, err := r.db.Query(
+		`SELECT `+nodeColumns+`, `+mediaColumns+`
+		 FROM nodes JOIN (
+		     SELECT hash AS media_hash, width, height, orientation, taken_at, make, model, latitude, longitude
+		     FROM media_info WHERE is_media = 1
+		 ) AS m ON m.media_hash = nodes.hash
+		 WHERE user_id = ? AND node_type = 'file'
+		 ORDER BY CASE WHEN m.taken_at != 0 THEN m.taken_at ELSE mtime END DESC, home
+		 LIMIT ? OFFSET ?`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing photos: %w", err)
+	}
+	defer rows.Close()
+
+	var photos []repository.Photo
+	for rows.Next() {
+		var p repository.Photo
+		s := &mediaScanner{info: &p.Media}
+		n, err := scanNode(photoScanner{rows, s})
+		if err != nil {
+			return nil, fmt.Errorf("scanning photo: %w", err)
+		}
+		p.Node = *n
+		p.Media.Hash = n.Hash
+		s.finish()
+		photos = append(photos, p)
+	}
+	return photos, rows.Err()
+}
+
+// Prune drops the metadata of content that is no longer stored.
+// Returns the number of dropped entries.
+func (r *MediaRepository) Prune() (int, error) {
+	res, err := r.db.Exec(`DELETE FROM media_info WHERE hash NOT IN (SELECT hash FROM contents)`)
+	if err != nil {
+		return 0, fmt.Errorf("pruning media info: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// mediaScanner scans the mediaColumns into a MediaInfo. The location is
+// set by finish, as it is NULL for photos without one.
+type mediaScanner struct {
+	info     *entity.MediaInfo
+	lat, lon sql.NullFloat64
+}
+
+// dest returns the scan destinations of the mediaColumns.
+func (s *mediaScanner) dest() []any {
+	return []any{
+		&s.info.Width, &s.info.Height, &s.info.Orientation, &s.info.TakenAt,
+		&s.info.Make, &s.info.Model, &s.lat, &s.lon,
+	}
+}
+
+// finish sets the scanned location of the info.
+func (s *mediaScanner) finish() {
+	if s.lat.Valid && s.lon.Valid {
+		s.info.HasLocation = true
+		s.info.Latitude, s.info.Longitude = s.lat.Float64, s.lon.Float64
+	}
+}
+
+// photoScanner scans a node row followed by the mediaColumns.
+type photoScanner struct {
+	row   interface{ Scan(...any) error }
+	media *mediaScanner
+}
+
+// Scan scans the node columns into dest and the rest into the media info.
+func (s photoScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.media.dest()...)...)
+}
@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestMediaRepository(t *testing.T) {
+	db := openTestDB(t)
+	nodes := NewNodeRepository(db)
+	contents := NewContentRepository(db)
+	media := NewMediaRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodes.CreateRootNode(userID); err != nil {
+		t.Fatal(err)
+	}
+	for _, folder := range []string{"/Camera", "/Trip"} {
+		if _, err := nodes.CreateFolder(userID, vo.NewCloudPath(folder)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	beach := vo.MustContentHash(strings.Repeat("1", 40))
+	scan := vo.MustContentHash(strings.Repeat("2", 40))
+	notes := vo.MustContentHash(strings.Repeat("3", 40))
+	for _, f := range []struct {
+		path  string
+		hash  vo.ContentHash
+		mtime int64
+	}{
+		{"/Camera/beach.jpg", beach, 1000},
+		{"/Trip/beach.jpg", beach, 1000},
+		{"/Camera/scan.png", scan, 1717000000},
+		{"/notes.txt", notes, 1000},
+	} {
+		if _, err := nodes.CreateFile(userID, vo.NewCloudPath(f.path), f.hash, 10); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodes.SetMTime(userID, vo.NewCloudPath(f.path), f.mtime); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := contents.Insert(f.hash, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unscanned, err := media.ListUnscanned(10)
+	if err != nil {
+		t.Fatalf("ListUnscanned: %v", err)
+	}
+	if len(unscanned) != 3 {
+		t.Fatalf("ListUnscanned = %+v, want each content once", unscanned)
+	}
+
+	beachInfo := &entity.MediaInfo{
+		Width: 4000, Height: 3000, Orientation: 1, TakenAt: 1717100000,
+		Make: "Canon", Model: "EOS R6", HasLocation: true, Latitude: 43.1, Longitude: -5.2,
+	}
+	if err := media.Store(beach, beachInfo); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if err := media.Store(scan, &entity.MediaInfo{Width: 800, Height: 600}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if err := media.Store(notes, nil); err != nil {
+		t.Fatalf("Store without media: %v", err)
+	}
+	if unscanned, _ := media.ListUnscanned(10); len(unscanned) != 0 {
+		t.Errorf("ListUnscanned after Store = %+v, want none", unscanned)
+	}
+
+	got, err := media.Get(beach)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	beachInfo.Hash = beach
+	if got == nil || *got != *beachInfo {
+		t.Errorf("Get = %+v, want %+v", got, beachInfo)
+	}
+	if got, _ := media.Get(scan); got == nil || got.HasLocation {
+		t.Errorf("Get(scan) = %+v, want it without a location", got)
+	}
+	if got, _ := media.Get(notes); got != nil {
+		t.Errorf("Get(notes) = %+v, want nil for content that is not a photo", got)
+	}
+
+	photos, err := media.Timeline(userID, 0, 10)
+	if err != nil {
+		t.Fatalf("Timeline: %v", err)
+	}
+	var paths []string
+	for _, p := range photos {
+		paths = append(paths, p.Node.Home.String())
+	}
+	// The beach photo was taken after the scan was modified.
+	if strings.Join(paths, ",") != "/Camera/beach.jpg,/Trip/beach.jpg,/Camera/scan.png" {
+		t.Errorf("Timeline = %v", paths)
+	}
+	if photos[0].Media.Model != "EOS R6" || !photos[0].Media.HasLocation || photos[0].Media.Hash != beach {
+		t.Errorf("Timeline media = %+v", photos[0].Media)
+	}
+	if page, _ := media.Timeline(userID, 2, 10); len(page) != 1 || page[0].Node.Home.String() != "/Camera/scan.png" {
+		t.Errorf("Timeline from offset 2 = %+v", page)
+	}
+
+	if _, err := contents.Decrement(scan); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := media.Prune(); err != nil || n != 1 {
+		t.Errorf("Prune = %d, %v, want 1", n, err)
+	}
+}
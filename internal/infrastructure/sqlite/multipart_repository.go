@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// MultipartRepository implements repository.MultipartRepository using SQLite.
+type MultipartRepository struct {
+	db *sql.DB
+}
+
+// NewMultipartRepository creates a MultipartRepository from the given database connection.
+func NewMultipartRepository(db *DB) *MultipartRepository {
+	return &MultipartRepository{db: db.Conn()}
+}
+
+// Create stores a new upload and assigns its creation time.
+func (r *MultipartRepository) Create(u *entity.MultipartUpload) error {
+	u.Created = time.Now().Unix()
+	_, err := r.db.Exec(
+		`INSERT INTO multipart_uploads (id, user_id, home, created) VALUES (?, ?, ?, ?)`,
+		u.ID, u.UserID, u.Home.String(), u.Created,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting multipart upload: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves an upload by ID. Returns nil, nil if not found.
+func (r *MultipartRepository) Get(id string) (*entity.MultipartUpload, error) {
+	var (
+		u    entity.MultipartUpload
+		home string
+	)
+	err := r.db.QueryRow(
+		`SELECT id, user_id, home, created FROM multipart_uploads WHERE id = ?`, id,
+	).Scan(&u.ID, &u.UserID, &home, &u.Created)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting multipart upload: %w", err)
+	}
+	u.Home = vo.NewCloudPath(home)
+	return &u, nil
+}
+
+// PutPart stores a part, returning the part it replaced (nil if the number was new).
+func (r *MultipartRepository) PutPart(p *entity.MultipartPart) (*entity.MultipartPart, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		old  *entity.MultipartPart
+		hash string
+		size int64
+	)
+	err = tx.QueryRow(
+		`SELECT hash, size FROM multipart_parts WHERE upload_id = ? AND number = ?`, p.UploadID, p.Number,
+	).Scan(&hash, &size)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, fmt.Errorf("getting multipart part: %w", err)
+	default:
+		old = &entity.MultipartPart{UploadID: p.UploadID, Number: p.Number, Hash: vo.MustContentHash(hash), Size: size}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO multipart_parts (upload_id, number, hash, size) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(upload_id, number) DO UPDATE SET hash = excluded.hash, size = excluded.size`,
+		p.UploadID, p.Number, p.Hash.String(), p.Size,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("storing multipart part: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing multipart part: %w", err)
+	}
+	return old, nil
+}
+
+// ListParts returns the parts of an upload ordered by part number.
+func (r *MultipartRepository) ListParts(uploadID string) ([]entity.MultipartPart, error) {
+	rows, err := r.db.Query(
+		`SELECT upload_id, number, hash, size FROM multipart_parts WHERE upload_id = ? ORDER BY number`,
+		uploadID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing multipart parts: %w", err)
+	}
+	defer rows.Close()
+
+	var parts []entity.MultipartPart
+	for rows.Next() {
+		var (
+			p    entity.MultipartPart
+			hash string
+		)
+		if err := rows.Scan(&p.UploadID, &p.Number, &hash, &p.Size); err != nil {
+			return nil, fmt.Errorf("scanning multipart part: %w", err)
+		}
+		p.Hash = vo.MustContentHash(hash)
+		parts = append(parts, p)
+	}
+	return parts, rows.Err()
+}
+
+// Delete removes an upload together with its parts.
+func (r *MultipartRepository) Delete(id string) error {
+	if _, err := r.db.Exec(`DELETE FROM multipart_parts WHERE upload_id = ?`, id); err != nil {
+		return fmt.Errorf("deleting multipart parts: %w", err)
+	}
+	if _, err := r.db.Exec(`DELETE FROM multipart_uploads WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting multipart upload: %w", err)
+	}
+	return nil
+}
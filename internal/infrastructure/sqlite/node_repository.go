@@ -196,6 +196,18 @@ func (r *NodeRepository) CreateFile(userID int64, path vo.CloudPath, hash vo.Con
 	}, nil
 }
 
+// SetMTime sets the modification time of the node at the given path.
+func (r *NodeRepository) SetMTime(userID int64, path vo.CloudPath, mtime int64) error {
+	_, err := r.db.Exec(
+		"UPDATE nodes SET mtime = ? WHERE user_id = ? AND home = ?",
+		mtime, userID, path.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("setting mtime: %w", err)
+	}
+	return nil
+}
+
 // Delete removes a node at the given path.
 func (r *NodeRepository) Delete(userID int64, path vo.CloudPath) error {
 	_, err := r.db.Exec(
@@ -229,11 +241,10 @@ func (r *NodeRepository) Rename(userID int64, path vo.CloudPath, newName string)
 	return r.Get(userID, newHome)
 }
 
-// Move moves a node from srcPath into the target folder.
-func (r *NodeRepository) Move(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error) {
-	name := srcPath.Name()
-	newHome := targetFolder.Join(name)
-
+// Move moves a node from srcPath to dstPath, whose parent folder must exist.
+// The node takes the name of dstPath.
+func (r *NodeRepository) Move(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error) {
+	targetFolder := dstPath.Parent()
 	newParent, err := r.Get(userID, targetFolder)
 	if err != nil {
 		return nil, err
@@ -244,22 +255,23 @@ func (r *NodeRepository) Move(userID int64, srcPath, targetFolder vo.CloudPath)
 
 	now := time.Now().Unix()
 	_, err = r.db.Exec(
-		`UPDATE nodes SET parent_id = ?, home = ?, mtime = ? WHERE user_id = ? AND home = ?`,
-		newParent.ID, newHome.String(), now, userID, srcPath.String(),
+		`UPDATE nodes SET parent_id = ?, name = ?, home = ?, mtime = ? WHERE user_id = ? AND home = ?`,
+		newParent.ID, dstPath.Name(), dstPath.String(), now, userID, srcPath.String(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("moving node: %w", err)
 	}
 
-	if err := r.updateChildPaths(userID, srcPath, newHome); err != nil {
+	if err := r.updateChildPaths(userID, srcPath, dstPath); err != nil {
 		return nil, err
 	}
 
-	return r.Get(userID, newHome)
+	return r.Get(userID, dstPath)
 }
 
-// Copy duplicates a node (and its children for folders) from srcPath into the target folder.
-func (r *NodeRepository) Copy(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error) {
+// Copy duplicates a node (and its children for folders) from srcPath to dstPath,
+// whose parent folder must exist.
+func (r *NodeRepository) Copy(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error) {
 	src, err := r.Get(userID, srcPath)
 	if err != nil {
 		return nil, err
@@ -268,23 +280,28 @@ func (r *NodeRepository) Copy(userID int64, srcPath, targetFolder vo.CloudPath)
 		return nil, fmt.Errorf("source not found: %s", srcPath)
 	}
 
-	name := srcPath.Name()
-	newHome := targetFolder.Join(name)
-
+	var dst *entity.Node
 	if src.IsFile() {
-		return r.CreateFile(userID, newHome, src.Hash, src.Size)
+		dst, err = r.CreateFile(userID, dstPath, src.Hash, src.Size)
+	} else {
+		dst, err = r.CreateFolder(userID, dstPath)
 	}
-
-	newFolder, err := r.CreateFolder(userID, newHome)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := r.copyChildren(userID, srcPath, newHome); err != nil {
+	if err := r.copyLabels(src, dst); err != nil {
+		return nil, err
+	}
+	if err := copyXAttrs(r.db, src.ID, dst.ID); err != nil {
 		return nil, err
 	}
 
-	return newFolder, nil
+	if src.IsFolder() {
+		if err := r.copyChildren(userID, srcPath, dstPath); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
 }
 
 // GetWithDescendants retrieves a node and all its descendants (for folders).
@@ -302,9 +319,14 @@ func (r *NodeRepository) GetWithDescendants(userID int64, path vo.CloudPath) (*e
 		return node, nil, nil
 	}
 
+	pattern := path.String() + "/%"
+	if path.IsRoot() {
+		// Every node except the root itself.
+		pattern = "/_%"
+	}
 	rows, err := r.db.Query(
 		`SELECT `+nodeColumns+` FROM nodes WHERE user_id = ? AND home LIKE ?`,
-		userID, path.String()+"/%",
+		userID, pattern,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("listing descendants: %w", err)
@@ -389,6 +411,90 @@ func (r *NodeRepository) ListByWeblink(userID int64) ([]entity.Node, error) {
 	return nodes, rows.Err()
 }
 
+// SetStarred stars or unstars the node at the given path.
+func (r *NodeRepository) SetStarred(userID int64, path vo.CloudPath, starred bool) error {
+	_, err := r.db.Exec(
+		`UPDATE nodes SET starred = ? WHERE user_id = ? AND home = ?`,
+		boolToInt(starred), userID, path.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("setting starred: %w", err)
+	}
+	return nil
+}
+
+// SetTags replaces the tags of the node at the given path.
+func (r *NodeRepository) SetTags(userID int64, path vo.CloudPath, tags []vo.Tag) error {
+	_, err := r.db.Exec(
+		`UPDATE nodes SET tags = ? WHERE user_id = ? AND home = ?`,
+		vo.FormatTags(tags), userID, path.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("setting tags: %w", err)
+	}
+	return nil
+}
+
+// ListStarred returns all starred nodes of the given user, ordered by path.
+func (r *NodeRepository) ListStarred(userID int64) ([]entity.Node, error) {
+	return r.list(
+		`SELECT `+nodeColumns+` FROM nodes WHERE user_id = ? AND starred = 1 ORDER BY home ASC`,
+		userID,
+	)
+}
+
+// ListTagged returns all nodes of the given user that have the tag, ordered by path.
+func (r *NodeRepository) ListTagged(userID int64, tag vo.Tag) ([]entity.Node, error) {
+	return r.list(
+		`SELECT `+nodeColumns+` FROM nodes WHERE user_id = ? AND `+tagCondition+` ORDER BY home ASC`,
+		userID, tagArg(tag),
+	)
+}
+
+// tagCondition matches nodes that have the tag given by tagArg.
+const tagCondition = `instr(',' || tags || ',', ?) > 0`
+
+// tagArg returns the argument of tagCondition for the tag.
+func tagArg(tag vo.Tag) string {
+	return "," + tag.String() + ","
+}
+
+// list runs a query selecting nodeColumns and returns the nodes.
+func (r *NodeRepository) list(query string, args ...any) ([]entity.Node, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []entity.Node
+	for rows.Next() {
+		n, err := scanNode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning node: %w", err)
+		}
+		nodes = append(nodes, *n)
+	}
+	return nodes, rows.Err()
+}
+
+// copyLabels gives the copy dst the star and tags of the node src.
+func (r *NodeRepository) copyLabels(src, dst *entity.Node) error {
+	if !src.Starred && len(src.Tags) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(
+		`UPDATE nodes SET starred = ?, tags = ? WHERE id = ?`,
+		boolToInt(src.Starred), vo.FormatTags(src.Tags), dst.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("copying labels: %w", err)
+	}
+	dst.Starred = src.Starred
+	dst.Tags = src.Tags
+	return nil
+}
+
 // Exists checks whether a node exists at the given path for the user.
 func (r *NodeRepository) Exists(userID int64, path vo.CloudPath) (bool, error) {
 	var exists bool
@@ -453,16 +559,25 @@ func (r *NodeRepository) copyChildren(userID int64, srcFolder, dstFolder vo.Clou
 		return err
 	}
 
-	for _, child := range children {
+	for i := range children {
+		child := &children[i]
 		newHome := dstFolder.Join(child.Name)
+		var copied *entity.Node
 		if child.IsFile() {
-			if _, err := r.CreateFile(userID, newHome, child.Hash, child.Size); err != nil {
-				return err
-			}
+			copied, err = r.CreateFile(userID, newHome, child.Hash, child.Size)
 		} else {
-			if _, err := r.CreateFolder(userID, newHome); err != nil {
-				return err
-			}
+			copied, err = r.CreateFolder(userID, newHome)
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.copyLabels(child, copied); err != nil {
+			return err
+		}
+		if err := copyXAttrs(r.db, child.ID, copied.ID); err != nil {
+			return err
+		}
+		if child.IsFolder() {
 			if err := r.copyChildren(userID, child.Home, newHome); err != nil {
 				return err
 			}
@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestNodeRepository_labels(t *testing.T) {
+	db := openTestDB(t)
+	nodes := NewNodeRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodes.CreateRootNode(userID); err != nil {
+		t.Fatal(err)
+	}
+	hash := vo.MustContentHash("0000000000000000000000000000000000000001")
+	for _, folder := range []string{"/work", "/archive"} {
+		if _, err := nodes.CreateFolder(userID, vo.NewCloudPath(folder)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"/work/plan.txt", "/work/notes.txt"} {
+		if _, err := nodes.CreateFile(userID, vo.NewCloudPath(file), hash, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := nodes.SetTags(userID, vo.NewCloudPath("/work/plan.txt"), []vo.Tag{"q3", "urgent"}); err != nil {
+		t.Fatalf("SetTags: %v", err)
+	}
+	if err := nodes.SetTags(userID, vo.NewCloudPath("/work"), []vo.Tag{"urgent-ish"}); err != nil {
+		t.Fatalf("SetTags: %v", err)
+	}
+	if err := nodes.SetStarred(userID, vo.NewCloudPath("/work/plan.txt"), true); err != nil {
+		t.Fatalf("SetStarred: %v", err)
+	}
+
+	homes := func(list []entity.Node, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := make([]string, len(list))
+		for i := range list {
+			result[i] = list[i].Home.String()
+		}
+		return result
+	}
+
+	if got := homes(nodes.ListTagged(userID, "urgent")); !reflect.DeepEqual(got, []string{"/work/plan.txt"}) {
+		t.Errorf("ListTagged(urgent) = %v, want the exact tag only", got)
+	}
+
+	// Labels stay with the node when it is moved and renamed.
+	if _, err := nodes.Move(userID, vo.NewCloudPath("/work"), vo.NewCloudPath("/archive/2024")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes.Rename(userID, vo.NewCloudPath("/archive/2024/plan.txt"), "final.txt"); err != nil {
+		t.Fatal(err)
+	}
+	node, err := nodes.Get(userID, vo.NewCloudPath("/archive/2024/final.txt"))
+	if err != nil || node == nil {
+		t.Fatalf("Get: %v, %v", node, err)
+	}
+	if !node.Starred || !reflect.DeepEqual(node.Tags, []vo.Tag{"q3", "urgent"}) {
+		t.Errorf("moved node starred %v, tags %v, want its labels kept", node.Starred, node.Tags)
+	}
+
+	// Copies take the labels of their sources.
+	if _, err := nodes.Copy(userID, vo.NewCloudPath("/archive/2024"), vo.NewCloudPath("/copy")); err != nil {
+		t.Fatal(err)
+	}
+	if got := homes(nodes.ListTagged(userID, "q3")); !reflect.DeepEqual(got, []string{"/archive/2024/final.txt", "/copy/final.txt"}) {
+		t.Errorf("ListTagged(q3) = %v, want the file and its copy", got)
+	}
+	if got := homes(nodes.ListStarred(userID)); !reflect.DeepEqual(got, []string{"/archive/2024/final.txt", "/copy/final.txt"}) {
+		t.Errorf("ListStarred = %v, want the file and its copy", got)
+	}
+	if got := homes(nodes.ListTagged(userID, "urgent-ish")); !reflect.DeepEqual(got, []string{"/archive/2024", "/copy"}) {
+		t.Errorf("ListTagged(urgent-ish) = %v, want the folder and its copy", got)
+	}
+
+	if err := nodes.SetTags(userID, vo.NewCloudPath("/copy/final.txt"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes.SetStarred(userID, vo.NewCloudPath("/copy/final.txt"), false); err != nil {
+		t.Fatal(err)
+	}
+	if got := homes(nodes.ListStarred(userID)); len(got) != 1 {
+		t.Errorf("ListStarred after unstarring = %v, want one node", got)
+	}
+
+	query := repository.NodeQuery{Text: "final", Tag: "q3"}
+	matches, err := NewNodeSearchRepository(db).Search(userID, &query, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Node.Home.String() != "/archive/2024/final.txt" {
+		t.Errorf("Search by tag = %+v, want the tagged file", matches)
+	}
+}
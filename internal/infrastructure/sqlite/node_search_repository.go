@@ -0,0 +1,178 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pozitronik/tucha/internal/domain/repository"
+)
+
+// NodeSearchRepository implements repository.NodeSearchRepository using the
+// nodes_fts full-text index, which triggers keep in step with the nodes table.
+type NodeSearchRepository struct {
+	db *sql.DB
+}
+
+// NewNodeSearchRepository creates a NodeSearchRepository from the given database connection.
+func NewNodeSearchRepository(db *DB) *NodeSearchRepository {
+	return &NodeSearchRepository{db: db.Conn()}
+}
+
+// Search returns up to limit of the user's nodes matching the query, best match first.
+// Matches are ranked by BM25; without text, nodes are ordered by path.
+func (r *NodeSearchRepository) Search(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+	from := `nodes`
+	order := `home`
+	rank := `0`
+	var args []any
+	if query.Text != "" {
+		match := ftsMatch(query.Text)
+		if match == "" {
+			// Nothing searchable, such as punctuation only.
+			return nil, nil
+		}
+		from = `nodes JOIN (SELECT rowid, rank FROM nodes_fts WHERE nodes_fts MATCH ?) AS m ON m.rowid = nodes.id`
+		order = `m.rank, home`
+		rank = `m.rank`
+		args = append(args, match)
+	}
+
+	where, filterArgs := nodeFilter(userID, query)
+	args = append(append(args, filterArgs...), limit)
+	return r.query(
+		`SELECT `+nodeColumns+`, `+rank+`, '' FROM `+from+`
+		 WHERE `+where+`
+		 ORDER BY `+order+` LIMIT ?`,
+		args...,
+	)
+}
+
+// SearchContent returns up to limit of the user's files whose indexed content
+// contains the words of query.Text, best match first, each with a snippet of
+// the matching text. The other query fields filter the files as in Search.
+func (r *NodeSearchRepository) SearchContent(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+	match := ftsMatch(query.Text)
+	if match == "" {
+		return nil, nil
+	}
+
+	where, filterArgs := nodeFilter(userID, query)
+	args := append([]any{match}, filterArgs...)
+	args = append(args, limit)
+	return r.query(
+		`SELECT `+nodeColumns+`, m.rank, m.snippet FROM nodes JOIN (
+		     SELECT ci.hash AS content_hash, content_fts.rank AS rank,
+		            snippet(content_fts, 0, '<mark>', '</mark>', '…', 16) AS snippet
+		     FROM content_fts JOIN content_index AS ci ON ci.id = content_fts.rowid
+		     WHERE content_fts MATCH ?
+		 ) AS m ON m.content_hash = nodes.hash
+		 WHERE `+where+`
+		 ORDER BY m.rank, home LIMIT ?`,
+		args...,
+	)
+}
+
+// query runs a search query selecting the node columns, the rank and the snippet.
+func (r *NodeSearchRepository) query(q string, args ...any) ([]repository.NodeMatch, error) {
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searching nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []repository.NodeMatch
+	for rows.Next() {
+		var m repository.NodeMatch
+		n, err := scanNode(matchScanner{rows, &m})
+		if err != nil {
+			return nil, fmt.Errorf("scanning search match: %w", err)
+		}
+		m.Node = *n
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// nodeFilter returns the WHERE condition selecting the user's nodes that
+// match the query fields other than Text, and its arguments.
+func nodeFilter(userID int64, query *repository.NodeQuery) (string, []any) {
+	where := []string{`user_id = ?`, `home != '/'`}
+	args := []any{userID}
+	if query.Type != "" {
+		where = append(where, `node_type = ?`)
+		args = append(args, query.Type.String())
+	}
+	if query.MinSize > 0 {
+		where = append(where, `size >= ?`)
+		args = append(args, query.MinSize)
+	}
+	if query.MaxSize > 0 {
+		where = append(where, `size <= ?`)
+		args = append(args, query.MaxSize)
+	}
+	if query.After > 0 {
+		where = append(where, `mtime >= ?`)
+		args = append(args, query.After)
+	}
+	if query.Before > 0 {
+		where = append(where, `mtime <= ?`)
+		args = append(args, query.Before)
+	}
+	if query.Extension != "" {
+		// LIKE keeps the extension match case-insensitive for ASCII.
+		where = append(where, `node_type = 'file' AND name LIKE ? ESCAPE '\'`)
+		args = append(args, "%."+escapeLike(query.Extension))
+	}
+	if under := query.Under.String(); under != "" && under != "/" {
+		// substr counts characters, not bytes.
+		prefix := under + "/"
+		where = append(where, `substr(home, 1, ?) = ?`)
+		args = append(args, utf8.RuneCountInString(prefix), prefix)
+	}
+	if query.Tag != "" {
+		where = append(where, tagCondition)
+		args = append(args, tagArg(query.Tag))
+	}
+	if query.XAttr != "" {
+		cond := `SELECT 1 FROM node_xattrs AS x WHERE x.node_id = nodes.id AND x.name = ?`
+		args = append(args, query.XAttr.String())
+		if query.XAttrValue != "" {
+			cond += ` AND x.value = ?`
+			args = append(args, query.XAttrValue)
+		}
+		where = append(where, `EXISTS (`+cond+`)`)
+	}
+	return strings.Join(where, " AND "), args
+}
+
+// escapeLike escapes the LIKE wildcards in s for a pattern with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ftsMatch turns search text into an FTS5 query that requires every word as
+// a prefix of a word of the name. Returns "" if the text has no words.
+func ftsMatch(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// matchScanner scans a node row followed by its rank and snippet.
+type matchScanner struct {
+	row   interface{ Scan(...any) error }
+	match *repository.NodeMatch
+}
+
+// Scan scans the node columns into dest and the last two columns into the match.
+func (s matchScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, &s.match.Rank, &s.match.Snippet)...)
+}
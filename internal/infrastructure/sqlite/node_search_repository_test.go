@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestNodeSearchRepository_Search(t *testing.T) {
+	db := openTestDB(t)
+	nodes := NewNodeRepository(db)
+	repo := NewNodeSearchRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodes.CreateRootNode(userID); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, _ := vo.NewContentHash("0000000000000000000000000000000000000001")
+	for _, folder := range []string{"/Reports", "/Reports/2024", "/Photos"} {
+		if _, err := nodes.CreateFolder(userID, vo.NewCloudPath(folder)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, size := range map[string]int64{
+		"/Reports/2024/annual_report.pdf": 500,
+		"/Reports/2024/Résumé.docx":       50,
+		"/Photos/report.jpg":              5000,
+		"/notes.txt":                      5,
+	} {
+		if _, err := nodes.CreateFile(userID, vo.NewCloudPath(path), hash, size); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q repository.NodeQuery) []string {
+		t.Helper()
+		matches, err := repo.Search(userID, &q, 100)
+		if err != nil {
+			t.Fatalf("Search(%+v): %v", q, err)
+		}
+		homes := make([]string, len(matches))
+		for i, m := range matches {
+			homes[i] = m.Node.Home.String()
+		}
+		return homes
+	}
+	expect := func(name string, got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+
+	expect("prefix", search(repository.NodeQuery{Text: "rep"}), "/Reports", "/Photos/report.jpg", "/Reports/2024/annual_report.pdf")
+	expect("all words", search(repository.NodeQuery{Text: "annual rep"}), "/Reports/2024/annual_report.pdf")
+	expect("diacritics", search(repository.NodeQuery{Text: "resume"}), "/Reports/2024/Résumé.docx")
+	expect("punctuation only", search(repository.NodeQuery{Text: "*.*"}))
+	expect("type", search(repository.NodeQuery{Text: "report", Type: vo.NodeTypeFolder}), "/Reports")
+	expect("size", search(repository.NodeQuery{MinSize: 10, MaxSize: 1000}), "/Reports/2024/Résumé.docx", "/Reports/2024/annual_report.pdf")
+	expect("extension", search(repository.NodeQuery{Extension: "PDF"}), "/Reports/2024/annual_report.pdf")
+	expect("subtree", search(repository.NodeQuery{Text: "report", Under: vo.NewCloudPath("/Photos")}), "/Photos/report.jpg")
+	expect("subtree wildcard", search(repository.NodeQuery{Text: "report", Under: vo.NewCloudPath("/Photo_")}))
+	expect("subtree case", search(repository.NodeQuery{Text: "report", Under: vo.NewCloudPath("/photos")}))
+	expect("extension wildcard", search(repository.NodeQuery{Extension: "p_f"}))
+	expect("mtime", search(repository.NodeQuery{Text: "notes", Before: 1}))
+
+	xattrs := NewXAttrRepository(db)
+	for path, source := range map[string]string{"/Reports/2024/annual_report.pdf": "erp", "/Photos/report.jpg": "camera"} {
+		node, _ := nodes.Get(userID, vo.NewCloudPath(path))
+		if err := xattrs.Set(node.ID, "source", source); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect("xattr", search(repository.NodeQuery{XAttr: "source"}), "/Photos/report.jpg", "/Reports/2024/annual_report.pdf")
+	expect("xattr value", search(repository.NodeQuery{Text: "report", XAttr: "source", XAttrValue: "erp"}), "/Reports/2024/annual_report.pdf")
+	expect("xattr name", search(repository.NodeQuery{XAttr: "Source"}))
+
+	t.Run("index follows renames and deletes", func(t *testing.T) {
+		if _, err := nodes.Rename(userID, vo.NewCloudPath("/notes.txt"), "minutes.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodes.Delete(userID, vo.NewCloudPath("/Reports")); err != nil {
+			t.Fatal(err)
+		}
+		expect("old name", search(repository.NodeQuery{Text: "notes"}))
+		expect("new name", search(repository.NodeQuery{Text: "minutes"}), "/minutes.txt")
+		expect("deleted subtree", search(repository.NodeQuery{Text: "report"}), "/Photos/report.jpg")
+	})
+}
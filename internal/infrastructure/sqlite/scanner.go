@@ -8,7 +8,7 @@ import (
 )
 
 // scanNode scans a node row into an entity.Node.
-// The column order must match: id, user_id, parent_id, name, home, node_type, size, hash, mtime, rev, grev, tree, created.
+// The column order must match nodeColumns.
 func scanNode(s interface{ Scan(...any) error }) (*entity.Node, error) {
 	var (
 		n        entity.Node
@@ -17,6 +17,8 @@ func scanNode(s interface{ Scan(...any) error }) (*entity.Node, error) {
 		weblink  sql.NullString
 		home     string
 		nodeType string
+		starred  int
+		tags     string
 	)
 
 	err := s.Scan(
@@ -24,6 +26,7 @@ func scanNode(s interface{ Scan(...any) error }) (*entity.Node, error) {
 		&n.Name, &home, &nodeType,
 		&n.Size, &hash,
 		&n.MTime, &n.Rev, &n.GRev, &n.Tree, &weblink, &n.Created,
+		&starred, &tags,
 	)
 	if err != nil {
 		return nil, err
@@ -40,9 +43,12 @@ func scanNode(s interface{ Scan(...any) error }) (*entity.Node, error) {
 	if weblink.Valid {
 		n.Weblink = weblink.String
 	}
+	n.Starred = starred != 0
+	// Stored tags were validated when set.
+	n.Tags, _ = vo.ParseTags(tags)
 
 	return &n, nil
 }
 
 // nodeColumns is the standard column list for node queries.
-const nodeColumns = `id, user_id, parent_id, name, home, node_type, size, hash, mtime, rev, grev, tree, weblink, created`
+const nodeColumns = `id, user_id, parent_id, name, home, node_type, size, hash, mtime, rev, grev, tree, weblink, created, starred, tags`
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/vo"
@@ -81,7 +82,7 @@ func (r *ShareRepository) ListByOwnerPathPrefix(ownerID int64, path vo.CloudPath
 	pathStr := path.String()
 	rows, err := r.db.Query(
 		`SELECT `+shareColumns+` FROM shares WHERE owner_id = ? AND (home = ? OR home LIKE ? || '/%') ORDER BY created DESC`,
-		ownerID, pathStr, pathStr,
+		ownerID, pathStr, strings.TrimSuffix(pathStr, "/"), // The root is a prefix of every path.
 	)
 	if err != nil {
 		return nil, fmt.Errorf("listing shares by path prefix: %w", err)
@@ -184,6 +185,19 @@ func (r *ShareRepository) ListMountedByUser(userID int64) ([]entity.Share, error
 	return scanShares(rows)
 }
 
+// ListMountedByOwner returns all accepted shares of the given owner that are mounted.
+func (r *ShareRepository) ListMountedByOwner(ownerID int64) ([]entity.Share, error) {
+	rows, err := r.db.Query(
+		`SELECT `+shareColumns+` FROM shares WHERE owner_id = ? AND status = 'accepted' AND mount_user_id IS NOT NULL ORDER BY home ASC`,
+		ownerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing mounted shares: %w", err)
+	}
+	defer rows.Close()
+	return scanShares(rows)
+}
+
 // scanShare scans a single share row into an entity.Share.
 func scanShare(s interface{ Scan(...any) error }) (*entity.Share, error) {
 	var (
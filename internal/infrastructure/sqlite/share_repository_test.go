@@ -238,6 +238,13 @@ func TestShareLifecycle_ResolveMountAfterAccept(t *testing.T) {
 	if len(mounted) != 1 {
 		t.Fatalf("expected 1 mounted share, got %d", len(mounted))
 	}
+	owned, err := shareRepo.ListMountedByOwner(ownerID)
+	if err != nil {
+		t.Fatalf("ListMountedByOwner: %v", err)
+	}
+	if len(owned) != 1 || owned[0].ID != mounted[0].ID {
+		t.Fatalf("ListMountedByOwner = %+v, want the mounted share", owned)
+	}
 	t.Logf("MountHome = %q, MountUserID = %v, Status = %q, Access = %q",
 		mounted[0].MountHome, mounted[0].MountUserID, mounted[0].Status, mounted[0].Access)
 
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// sshKeyColumns is the column list matching scanSSHKey.
+const sshKeyColumns = `id, user_id, name, public_key, fingerprint, created, last_used`
+
+// SSHKeyRepository implements repository.SSHKeyRepository using SQLite.
+type SSHKeyRepository struct {
+	db *sql.DB
+}
+
+// NewSSHKeyRepository creates an SSHKeyRepository from the given database connection.
+func NewSSHKeyRepository(db *DB) *SSHKeyRepository {
+	return &SSHKeyRepository{db: db.Conn()}
+}
+
+// Create stores a new SSH key and assigns its ID and creation time.
+func (r *SSHKeyRepository) Create(k *entity.SSHKey) error {
+	k.Created = time.Now().Unix()
+	res, err := r.db.Exec(
+		`INSERT INTO ssh_keys (user_id, name, public_key, fingerprint, created) VALUES (?, ?, ?, ?, ?)`,
+		k.UserID, k.Name, k.PublicKey, k.Fingerprint, k.Created,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting SSH key: %w", err)
+	}
+	k.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves an SSH key by ID. Returns nil, nil if not found.
+func (r *SSHKeyRepository) GetByID(id int64) (*entity.SSHKey, error) {
+	return r.getOne(`SELECT `+sshKeyColumns+` FROM ssh_keys WHERE id = ?`, id)
+}
+
+// GetByFingerprint retrieves a user's SSH key by fingerprint. Returns nil, nil if not found.
+func (r *SSHKeyRepository) GetByFingerprint(userID int64, fingerprint string) (*entity.SSHKey, error) {
+	return r.getOne(`SELECT `+sshKeyColumns+` FROM ssh_keys WHERE user_id = ? AND fingerprint = ?`, userID, fingerprint)
+}
+
+// ListByUser returns all SSH keys of the given user, newest first.
+func (r *SSHKeyRepository) ListByUser(userID int64) ([]entity.SSHKey, error) {
+	rows, err := r.db.Query(
+		`SELECT `+sshKeyColumns+` FROM ssh_keys WHERE user_id = ? ORDER BY id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing SSH keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []entity.SSHKey
+	for rows.Next() {
+		k, err := scanSSHKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning SSH key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// MarkUsed records the time an SSH key was last used.
+func (r *SSHKeyRepository) MarkUsed(id int64, ts int64) error {
+	if _, err := r.db.Exec(`UPDATE ssh_keys SET last_used = ? WHERE id = ?`, ts, id); err != nil {
+		return fmt.Errorf("marking SSH key used: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an SSH key by ID.
+func (r *SSHKeyRepository) Delete(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM ssh_keys WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting SSH key: %w", err)
+	}
+	return nil
+}
+
+// getOne runs a single-row SSH key query. Returns nil, nil if no row matches.
+func (r *SSHKeyRepository) getOne(query string, args ...any) (*entity.SSHKey, error) {
+	k, err := scanSSHKey(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting SSH key: %w", err)
+	}
+	return k, nil
+}
+
+// scanSSHKey scans a row selected with sshKeyColumns.
+func scanSSHKey(s interface{ Scan(...any) error }) (*entity.SSHKey, error) {
+	var k entity.SSHKey
+	if err := s.Scan(&k.ID, &k.UserID, &k.Name, &k.PublicKey, &k.Fingerprint, &k.Created, &k.LastUsed); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // TokenRepository implements repository.TokenRepository using SQLite.
@@ -20,57 +21,91 @@ func NewTokenRepository(db *DB) *TokenRepository {
 	return &TokenRepository{db: db.Conn()}
 }
 
-// Create generates a new token set for the given user and stores it.
-func (r *TokenRepository) Create(userID int64, ttlSeconds int) (*entity.Token, error) {
-	accessToken, err := randomHex(32)
+// Create generates a new session token set for the given user, issued to clientID, and stores it.
+func (r *TokenRepository) Create(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+	t, err := newTokenSet(userID)
 	if err != nil {
-		return nil, fmt.Errorf("generating access token: %w", err)
+		return nil, err
 	}
+	t.ExpiresAt = t.Created + int64(ttlSeconds)
+	t.ClientID = clientID
+	t.PathPrefix = vo.NewCloudPath("/")
 
-	refreshToken, err := randomHex(32)
+	if err := r.insert(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreatePersonal generates a personal access token restricted to the given
+// scopes and path prefix. A ttlSeconds of 0 creates a token that never expires.
+func (r *TokenRepository) CreatePersonal(userID int64, name string, scopes []vo.TokenScope, pathPrefix vo.CloudPath, ttlSeconds int) (*entity.Token, error) {
+	t, err := newTokenSet(userID)
 	if err != nil {
-		return nil, fmt.Errorf("generating refresh token: %w", err)
+		return nil, err
+	}
+	if ttlSeconds > 0 {
+		t.ExpiresAt = t.Created + int64(ttlSeconds)
 	}
+	t.Personal = true
+	t.Name = name
+	t.Scopes = scopes
+	t.PathPrefix = pathPrefix
 
-	csrfToken, err := randomHex(16)
+	if err := r.insert(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateImpersonation generates a short-lived support token that lets the
+// impersonator act as the user. Unless elevated, it only grants read access.
+func (r *TokenRepository) CreateImpersonation(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error) {
+	t, err := newTokenSet(userID)
 	if err != nil {
-		return nil, fmt.Errorf("generating CSRF token: %w", err)
+		return nil, err
 	}
+	t.ExpiresAt = t.Created + int64(ttlSeconds)
+	t.Personal = true
+	t.Name = "support: " + impersonator
+	t.Scopes = []vo.TokenScope{vo.ScopeRead}
+	if elevated {
+		t.Scopes = []vo.TokenScope{vo.ScopeWrite, vo.ScopePublish, vo.ScopeShare, vo.ScopeTrash}
+	}
+	t.PathPrefix = vo.NewCloudPath("/")
+	t.Impersonator = impersonator
+	t.Elevated = elevated
 
-	now := time.Now().Unix()
-	expiresAt := now + int64(ttlSeconds)
+	if err := r.insert(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
 
+// insert stores a fully populated token and assigns its ID.
+func (r *TokenRepository) insert(t *entity.Token) error {
 	res, err := r.db.Exec(
-		`INSERT INTO tokens (user_id, access_token, refresh_token, csrf_token, expires_at, created)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		userID, accessToken, refreshToken, csrfToken, expiresAt, now,
+		`INSERT INTO tokens (user_id, access_token, refresh_token, csrf_token, expires_at, created, client_id, personal, name, scopes, path_prefix, impersonator, elevated)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.AccessToken, t.RefreshToken, t.CSRFToken, t.ExpiresAt, t.Created,
+		t.ClientID, boolToInt(t.Personal), t.Name, vo.FormatTokenScopes(t.Scopes), t.PathPrefix.String(),
+		t.Impersonator, boolToInt(t.Elevated),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("inserting token: %w", err)
+		return fmt.Errorf("inserting token: %w", err)
 	}
 
-	id, _ := res.LastInsertId()
-
-	return &entity.Token{
-		ID:           id,
-		UserID:       userID,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		CSRFToken:    csrfToken,
-		ExpiresAt:    expiresAt,
-		Created:      now,
-	}, nil
+	t.ID, _ = res.LastInsertId()
+	return nil
 }
 
 // LookupAccess finds a token by its access_token value.
 // Returns nil, nil if not found. Does NOT check expiration.
 func (r *TokenRepository) LookupAccess(accessToken string) (*entity.Token, error) {
-	t := &entity.Token{}
-	err := r.db.QueryRow(
-		`SELECT id, user_id, access_token, refresh_token, csrf_token, expires_at, created
-		 FROM tokens WHERE access_token = ?`,
+	t, err := scanToken(r.db.QueryRow(
+		`SELECT `+tokenColumns+` FROM tokens WHERE access_token = ?`,
 		accessToken,
-	).Scan(&t.ID, &t.UserID, &t.AccessToken, &t.RefreshToken, &t.CSRFToken, &t.ExpiresAt, &t.Created)
+	))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -80,6 +115,69 @@ func (r *TokenRepository) LookupAccess(accessToken string) (*entity.Token, error
 	return t, nil
 }
 
+// LookupRefresh finds a session token by its refresh_token value.
+// Returns nil, nil if not found.
+func (r *TokenRepository) LookupRefresh(refreshToken string) (*entity.Token, error) {
+	t, err := scanToken(r.db.QueryRow(
+		`SELECT `+tokenColumns+` FROM tokens WHERE refresh_token = ? AND personal = 0`,
+		refreshToken,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("looking up refresh token: %w", err)
+	}
+	return t, nil
+}
+
+// GetByID retrieves a token by its ID. Returns nil, nil if not found.
+func (r *TokenRepository) GetByID(id int64) (*entity.Token, error) {
+	t, err := scanToken(r.db.QueryRow(
+		`SELECT `+tokenColumns+` FROM tokens WHERE id = ?`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting token by id: %w", err)
+	}
+	return t, nil
+}
+
+// ListPersonal returns all personal access tokens of the given user, newest first.
+func (r *TokenRepository) ListPersonal(userID int64) ([]entity.Token, error) {
+	return r.list(userID, true)
+}
+
+// ListSessions returns all session (non-personal) tokens of the given user, newest first.
+func (r *TokenRepository) ListSessions(userID int64) ([]entity.Token, error) {
+	return r.list(userID, false)
+}
+
+// list returns the user's tokens of one kind, newest first.
+func (r *TokenRepository) list(userID int64, personal bool) ([]entity.Token, error) {
+	rows, err := r.db.Query(
+		`SELECT `+tokenColumns+` FROM tokens WHERE user_id = ? AND personal = ? ORDER BY id DESC`,
+		userID, boolToInt(personal),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []entity.Token
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning token: %w", err)
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
 // Delete removes a token by its ID.
 func (r *TokenRepository) Delete(id int64) error {
 	_, err := r.db.Exec(`DELETE FROM tokens WHERE id = ?`, id)
@@ -89,6 +187,78 @@ func (r *TokenRepository) Delete(id int64) error {
 	return nil
 }
 
+// DeleteSessionsByClient removes all session tokens the given client holds for the user.
+// Returns the number of tokens removed.
+func (r *TokenRepository) DeleteSessionsByClient(userID int64, clientID string) (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM tokens WHERE user_id = ? AND client_id = ? AND personal = 0`,
+		userID, clientID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("deleting client sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// newTokenSet generates the random access, refresh, and CSRF values for a new token.
+func newTokenSet(userID int64) (*entity.Token, error) {
+	accessToken, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("generating access token: %w", err)
+	}
+
+	refreshToken, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	csrfToken, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("generating CSRF token: %w", err)
+	}
+
+	return &entity.Token{
+		UserID:       userID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		CSRFToken:    csrfToken,
+		Created:      time.Now().Unix(),
+	}, nil
+}
+
+// tokenColumns is the standard column list for token queries.
+const tokenColumns = `id, user_id, access_token, refresh_token, csrf_token, expires_at, created, client_id, personal, name, scopes, path_prefix, impersonator, elevated`
+
+// scanToken scans a token row into an entity.Token.
+func scanToken(s interface{ Scan(...any) error }) (*entity.Token, error) {
+	var (
+		t          entity.Token
+		personal   int
+		scopes     string
+		pathPrefix string
+		elevated   int
+	)
+
+	err := s.Scan(
+		&t.ID, &t.UserID, &t.AccessToken, &t.RefreshToken, &t.CSRFToken,
+		&t.ExpiresAt, &t.Created, &t.ClientID, &personal, &t.Name, &scopes, &pathPrefix,
+		&t.Impersonator, &elevated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Personal = personal != 0
+	t.Elevated = elevated != 0
+	if scopes != "" {
+		// Stored values were validated on creation; an unparsable list grants nothing.
+		t.Scopes, _ = vo.ParseTokenScopes(scopes)
+	}
+	t.PathPrefix = vo.NewCloudPath(pathPrefix)
+
+	return &t, nil
+}
+
 // randomHex generates n random bytes and returns them as a hex string.
 func randomHex(n int) (string, error) {
 	b := make([]byte, n)
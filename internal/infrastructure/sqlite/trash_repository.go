@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/vo"
@@ -20,18 +21,20 @@ func NewTrashRepository(db *DB) *TrashRepository {
 }
 
 // Insert copies a node and its descendants into the trash table.
+// All of them are marked with the batch ID of the node, its own item ID.
 func (r *TrashRepository) Insert(userID int64, node *entity.Node, descendants []entity.Node, deletedBy int64) error {
 	now := time.Now().Unix()
 	deletedFrom := node.Home.Parent().String()
 
 	// Insert the root node being trashed.
-	if err := r.insertOne(userID, node, deletedFrom, deletedBy, now); err != nil {
+	batchID, err := r.insertOne(userID, node, deletedFrom, deletedBy, now, 0)
+	if err != nil {
 		return err
 	}
 
 	// Insert all descendants.
 	for i := range descendants {
-		if err := r.insertOne(userID, &descendants[i], deletedFrom, deletedBy, now); err != nil {
+		if _, err := r.insertOne(userID, &descendants[i], deletedFrom, deletedBy, now, batchID); err != nil {
 			return err
 		}
 	}
@@ -39,23 +42,57 @@ func (r *TrashRepository) Insert(userID int64, node *entity.Node, descendants []
 	return nil
 }
 
-// insertOne inserts a single node into the trash table.
-func (r *TrashRepository) insertOne(userID int64, node *entity.Node, deletedFrom string, deletedBy int64, deletedAt int64) error {
+// insertOne inserts a single node into the trash table, with its extended
+// attributes, and returns its item ID. A zero batchID starts a new batch
+// identified by the item itself.
+func (r *TrashRepository) insertOne(userID int64, node *entity.Node, deletedFrom string, deletedBy int64, deletedAt int64, batchID int64) (int64, error) {
 	var hashStr *string
 	if !node.Hash.IsZero() {
 		s := node.Hash.String()
 		hashStr = &s
 	}
 
-	_, err := r.db.Exec(
-		`INSERT INTO trash (user_id, name, home, node_type, size, hash, mtime, rev, grev, tree, deleted_at, deleted_from, deleted_by, created)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	result, err := r.db.Exec(
+		`INSERT INTO trash (user_id, name, home, node_type, size, hash, mtime, rev, grev, tree, deleted_at, deleted_from, deleted_by, created, batch_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, 0))`,
 		userID, node.Name, node.Home.String(), node.Type.String(),
 		node.Size, hashStr, node.MTime, node.Rev, node.GRev, node.Tree,
-		deletedAt, deletedFrom, deletedBy, node.Created,
+		deletedAt, deletedFrom, deletedBy, node.Created, batchID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("inserting trash item: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("inserting trash item: %w", err)
+	}
+	if batchID == 0 {
+		if _, err := r.db.Exec("UPDATE trash SET batch_id = id WHERE id = ?", id); err != nil {
+			return 0, fmt.Errorf("inserting trash item: %w", err)
+		}
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO trash_xattrs (trash_id, name, value)
+		 SELECT ?, name, value FROM node_xattrs WHERE node_id = ?`,
+		id, node.ID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("keeping trash item attributes: %w", err)
+	}
+	return id, nil
+}
+
+// RestoreXAttrs gives the node nodeID the extended attributes kept with the
+// trash item itemID.
+func (r *TrashRepository) RestoreXAttrs(itemID, nodeID int64) error {
+	_, err := r.db.Exec(
+		`INSERT OR REPLACE INTO node_xattrs (node_id, name, value)
+		 SELECT ?, name, value FROM trash_xattrs WHERE trash_id = ?`,
+		nodeID, itemID,
 	)
 	if err != nil {
-		return fmt.Errorf("inserting trash item: %w", err)
+		return fmt.Errorf("restoring attributes: %w", err)
 	}
 	return nil
 }
@@ -98,6 +135,45 @@ func (r *TrashRepository) GetByPathAndRev(userID int64, path vo.CloudPath, rev i
 	return item, nil
 }
 
+// ListDescendants returns the items trashed together with a folder item that
+// were inside it: those of its batch under its path. Items trashed by earlier
+// releases have no batch and are matched by their deletion time and origin.
+// Paths are compared case-sensitively.
+func (r *TrashRepository) ListDescendants(item *entity.TrashItem) ([]entity.TrashItem, error) {
+	prefix := item.Home.String() + "/"
+	if item.Home.IsRoot() {
+		prefix = "/"
+	}
+	batch := `batch_id = ?`
+	args := []any{item.UserID, item.BatchID}
+	if item.BatchID == 0 {
+		batch = `batch_id IS NULL AND deleted_at = ? AND deleted_from = ?`
+		args = []any{item.UserID, item.DeletedAt, item.DeletedFrom}
+	}
+	// substr counts characters, not bytes.
+	args = append(args, utf8.RuneCountInString(prefix), prefix, item.ID)
+	rows, err := r.db.Query(
+		`SELECT `+trashColumns+` FROM trash
+		 WHERE user_id = ? AND `+batch+` AND substr(home, 1, ?) = ? AND id != ?
+		 ORDER BY home`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing trash descendants: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.TrashItem
+	for rows.Next() {
+		d, err := scanTrashItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning trash item: %w", err)
+		}
+		items = append(items, *d)
+	}
+	return items, rows.Err()
+}
+
 // Delete removes a single trash item by ID.
 func (r *TrashRepository) Delete(id int64) error {
 	_, err := r.db.Exec("DELETE FROM trash WHERE id = ?", id)
@@ -124,8 +200,44 @@ func (r *TrashRepository) DeleteAll(userID int64) ([]entity.TrashItem, error) {
 	return items, nil
 }
 
+// DeleteOlderThan removes a user's trash items deleted before the given
+// Unix time and returns the deleted items.
+func (r *TrashRepository) DeleteOlderThan(userID int64, before int64) ([]entity.TrashItem, error) {
+	rows, err := r.db.Query(
+		`DELETE FROM trash WHERE user_id = ? AND deleted_at < ? RETURNING `+trashColumns,
+		userID, before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("purging trash: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.TrashItem
+	for rows.Next() {
+		item, err := scanTrashItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning trash item: %w", err)
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// TotalSize returns the total size of the files in a user's trash.
+func (r *TrashRepository) TotalSize(userID int64) (int64, error) {
+	var total int64
+	err := r.db.QueryRow(
+		"SELECT COALESCE(SUM(size), 0) FROM trash WHERE user_id = ? AND node_type = 'file'",
+		userID,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("calculating trash size: %w", err)
+	}
+	return total, nil
+}
+
 // trashColumns is the standard column list for trash queries.
-const trashColumns = `id, user_id, name, home, node_type, size, hash, mtime, rev, grev, tree, deleted_at, deleted_from, deleted_by, created`
+const trashColumns = `id, user_id, name, home, node_type, size, hash, mtime, rev, grev, tree, deleted_at, deleted_from, deleted_by, created, COALESCE(batch_id, 0)`
 
 // scanTrashItem scans a trash row into an entity.TrashItem.
 func scanTrashItem(s interface{ Scan(...any) error }) (*entity.TrashItem, error) {
@@ -139,7 +251,7 @@ func scanTrashItem(s interface{ Scan(...any) error }) (*entity.TrashItem, error)
 	err := s.Scan(
 		&item.ID, &item.UserID, &item.Name, &home, &nodeType,
 		&item.Size, &hash, &item.MTime, &item.Rev, &item.GRev, &item.Tree,
-		&item.DeletedAt, &item.DeletedFrom, &item.DeletedBy, &item.Created,
+		&item.DeletedAt, &item.DeletedFrom, &item.DeletedBy, &item.Created, &item.BatchID,
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestTrashRepository_DeleteOlderThanAndTotalSize(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTrashRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	hash, _ := vo.NewContentHash("0000000000000000000000000000000000000001")
+	folder := &entity.Node{Name: "old", Home: vo.NewCloudPath("/old"), Type: vo.NodeTypeFolder}
+	inside := []entity.Node{{Name: "a.txt", Home: vo.NewCloudPath("/old/a.txt"), Type: vo.NodeTypeFile, Hash: hash, Size: 100}}
+	recent := &entity.Node{Name: "b.txt", Home: vo.NewCloudPath("/b.txt"), Type: vo.NodeTypeFile, Hash: hash, Size: 10}
+	if err := repo.Insert(userID, folder, inside, userID); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := repo.Insert(userID, recent, nil, userID); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if _, err := db.Conn().Exec("UPDATE trash SET deleted_at = 1000 WHERE home LIKE '/old%'"); err != nil {
+		t.Fatal(err)
+	}
+
+	if total, _ := repo.TotalSize(userID); total != 110 {
+		t.Errorf("TotalSize = %d, want 110", total)
+	}
+
+	purged, err := repo.DeleteOlderThan(userID, 2000)
+	if err != nil {
+		t.Fatalf("DeleteOlderThan: %v", err)
+	}
+	if len(purged) != 2 {
+		t.Fatalf("purged %d items, want the folder and its file", len(purged))
+	}
+	for _, item := range purged {
+		if item.Home.String() != "/old" && item.Home.String() != "/old/a.txt" {
+			t.Errorf("purged %s", item.Home)
+		}
+	}
+
+	if total, _ := repo.TotalSize(userID); total != 10 {
+		t.Errorf("TotalSize after purge = %d, want 10", total)
+	}
+	if items, _ := repo.List(userID); len(items) != 1 || items[0].Home.String() != "/b.txt" {
+		t.Errorf("remaining items = %+v, want /b.txt", items)
+	}
+}
+
+func TestTrashRepository_ListDescendants(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTrashRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	folder := &entity.Node{Name: "docs", Home: vo.NewCloudPath("/docs"), Type: vo.NodeTypeFolder}
+	inside := []entity.Node{
+		{Name: "b.txt", Home: vo.NewCloudPath("/docs/sub/b.txt"), Type: vo.NodeTypeFile},
+		{Name: "sub", Home: vo.NewCloudPath("/docs/sub"), Type: vo.NodeTypeFolder},
+	}
+	if err := repo.Insert(userID, folder, inside, userID); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	// A sibling with a common name prefix, trashed separately.
+	sibling := &entity.Node{Name: "docs2", Home: vo.NewCloudPath("/docs2"), Type: vo.NodeTypeFolder}
+	if err := repo.Insert(userID, sibling, nil, userID); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	root, _ := repo.GetByPathAndRev(userID, folder.Home, 0)
+	if root == nil {
+		t.Fatal("trashed folder not found")
+	}
+	descendants, err := repo.ListDescendants(root)
+	if err != nil {
+		t.Fatalf("ListDescendants: %v", err)
+	}
+	if len(descendants) != 2 || descendants[0].Home.String() != "/docs/sub" || descendants[1].Home.String() != "/docs/sub/b.txt" {
+		t.Errorf("descendants = %+v, want /docs/sub then /docs/sub/b.txt", descendants)
+	}
+}
+
+func TestTrashRepository_ListDescendants_separatesBatches(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTrashRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	// Folders trashed in the same second whose paths a LIKE pattern or a
+	// case-insensitive comparison would mix up.
+	for _, name := range []string{"a_b", "axb", "Docs", "docs"} {
+		folder := &entity.Node{Name: name, Home: vo.NewCloudPath("/" + name), Type: vo.NodeTypeFolder}
+		inside := []entity.Node{{Name: "f.txt", Home: vo.NewCloudPath("/" + name + "/f.txt"), Type: vo.NodeTypeFile}}
+		if err := repo.Insert(userID, folder, inside, userID); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	for _, name := range []string{"a_b", "Docs"} {
+		root, _ := repo.GetByPathAndRev(userID, vo.NewCloudPath("/"+name), 0)
+		if root == nil {
+			t.Fatalf("trashed folder /%s not found", name)
+		}
+		descendants, err := repo.ListDescendants(root)
+		if err != nil {
+			t.Fatalf("ListDescendants: %v", err)
+		}
+		if len(descendants) != 1 || descendants[0].Home.String() != "/"+name+"/f.txt" {
+			t.Errorf("descendants of /%s = %+v, want only its own file", name, descendants)
+		}
+	}
+}
@@ -3,9 +3,11 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // UserRepository implements repository.UserRepository using SQLite.
@@ -64,45 +66,31 @@ func (r *UserRepository) Create(user *entity.User) (int64, error) {
 
 // GetByID retrieves a user by ID. Returns nil, nil if not found.
 func (r *UserRepository) GetByID(id int64) (*entity.User, error) {
-	u := &entity.User{}
-	var isAdmin, versionHistory int
-	err := r.db.QueryRow(
-		"SELECT id, email, password, is_admin, quota_bytes, file_size_limit, version_history, created FROM users WHERE id = ?",
-		id,
-	).Scan(&u.ID, &u.Email, &u.Password, &isAdmin, &u.QuotaBytes, &u.FileSizeLimit, &versionHistory, &u.Created)
+	u, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("getting user by id: %w", err)
 	}
-	u.IsAdmin = isAdmin != 0
-	u.VersionHistory = versionHistory != 0
 	return u, nil
 }
 
 // GetByEmail retrieves a user by email. Returns nil, nil if not found.
 func (r *UserRepository) GetByEmail(email string) (*entity.User, error) {
-	u := &entity.User{}
-	var isAdmin, versionHistory int
-	err := r.db.QueryRow(
-		"SELECT id, email, password, is_admin, quota_bytes, file_size_limit, version_history, created FROM users WHERE email = ?",
-		email,
-	).Scan(&u.ID, &u.Email, &u.Password, &isAdmin, &u.QuotaBytes, &u.FileSizeLimit, &versionHistory, &u.Created)
+	u, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = ?", email))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("getting user by email: %w", err)
 	}
-	u.IsAdmin = isAdmin != 0
-	u.VersionHistory = versionHistory != 0
 	return u, nil
 }
 
 // List returns all users.
 func (r *UserRepository) List() ([]entity.User, error) {
-	rows, err := r.db.Query("SELECT id, email, password, is_admin, quota_bytes, file_size_limit, version_history, created FROM users ORDER BY id")
+	rows, err := r.db.Query("SELECT " + userColumns + " FROM users ORDER BY id")
 	if err != nil {
 		return nil, fmt.Errorf("listing users: %w", err)
 	}
@@ -110,23 +98,22 @@ func (r *UserRepository) List() ([]entity.User, error) {
 
 	var users []entity.User
 	for rows.Next() {
-		var u entity.User
-		var isAdmin, versionHistory int
-		if err := rows.Scan(&u.ID, &u.Email, &u.Password, &isAdmin, &u.QuotaBytes, &u.FileSizeLimit, &versionHistory, &u.Created); err != nil {
+		u, err := scanUser(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scanning user: %w", err)
 		}
-		u.IsAdmin = isAdmin != 0
-		u.VersionHistory = versionHistory != 0
-		users = append(users, u)
+		users = append(users, *u)
 	}
 	return users, rows.Err()
 }
 
-// Update modifies an existing user's fields.
+// Update modifies an existing user's fields, including two-factor state.
 func (r *UserRepository) Update(user *entity.User) error {
 	res, err := r.db.Exec(
-		`UPDATE users SET email = ?, password = ?, is_admin = ?, quota_bytes = ?, file_size_limit = ?, version_history = ? WHERE id = ?`,
-		user.Email, user.Password, boolToInt(user.IsAdmin), user.QuotaBytes, user.FileSizeLimit, boolToInt(user.VersionHistory), user.ID,
+		`UPDATE users SET email = ?, password = ?, is_admin = ?, quota_bytes = ?, file_size_limit = ?, version_history = ?,
+		 totp_secret = ?, totp_enabled = ?, recovery_codes = ?, version_retention = ?, trash_retention = ? WHERE id = ?`,
+		user.Email, user.Password, boolToInt(user.IsAdmin), user.QuotaBytes, user.FileSizeLimit, boolToInt(user.VersionHistory),
+		user.TOTPSecret, boolToInt(user.TOTPEnabled), strings.Join(user.RecoveryCodes, ","), retentionToString(user.VersionRetention), user.TrashRetentionDays, user.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("updating user: %w", err)
@@ -159,6 +146,52 @@ func (r *UserRepository) Delete(id int64) error {
 	return nil
 }
 
+// userColumns is the standard column list for user queries.
+const userColumns = `id, email, password, is_admin, quota_bytes, file_size_limit, version_history, created, totp_secret, totp_enabled, recovery_codes, version_retention, trash_retention`
+
+// scanUser scans a user row into an entity.User.
+func scanUser(s interface{ Scan(...any) error }) (*entity.User, error) {
+	var (
+		u                                    entity.User
+		isAdmin, versionHistory, totpEnabled int
+		recoveryCodes, versionRetention      string
+		trashRetention                       sql.NullInt64
+	)
+
+	err := s.Scan(
+		&u.ID, &u.Email, &u.Password, &isAdmin, &u.QuotaBytes, &u.FileSizeLimit, &versionHistory, &u.Created,
+		&u.TOTPSecret, &totpEnabled, &recoveryCodes, &versionRetention, &trashRetention,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	u.IsAdmin = isAdmin != 0
+	u.VersionHistory = versionHistory != 0
+	u.TOTPEnabled = totpEnabled != 0
+	if recoveryCodes != "" {
+		u.RecoveryCodes = strings.Split(recoveryCodes, ",")
+	}
+	if versionRetention != "" {
+		if policy, err := vo.ParseRetentionPolicy(versionRetention); err == nil {
+			u.VersionRetention = &policy
+		}
+	}
+	if trashRetention.Valid {
+		days := int(trashRetention.Int64)
+		u.TrashRetentionDays = &days
+	}
+	return &u, nil
+}
+
+// retentionToString converts an optional retention policy for SQLite storage; nil is stored as "".
+func retentionToString(policy *vo.RetentionPolicy) string {
+	if policy == nil {
+		return ""
+	}
+	return policy.String()
+}
+
 // boolToInt converts a boolean to an integer for SQLite storage.
 func boolToInt(b bool) int {
 	if b {
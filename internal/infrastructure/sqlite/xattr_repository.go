@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// XAttrRepository implements repository.XAttrRepository using the node_xattrs table.
+type XAttrRepository struct {
+	db *sql.DB
+}
+
+// NewXAttrRepository creates an XAttrRepository from the given database connection.
+func NewXAttrRepository(db *DB) *XAttrRepository {
+	return &XAttrRepository{db: db.Conn()}
+}
+
+// List returns the attributes of the node, ordered by name.
+func (r *XAttrRepository) List(nodeID int64) ([]entity.XAttr, error) {
+	rows, err := r.db.Query(
+		`SELECT name, value FROM node_xattrs WHERE node_id = ? ORDER BY name`,
+		nodeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing attributes: %w", err)
+	}
+	defer rows.Close()
+
+	var attrs []entity.XAttr
+	for rows.Next() {
+		var attr entity.XAttr
+		var name string
+		if err := rows.Scan(&name, &attr.Value); err != nil {
+			return nil, fmt.Errorf("scanning attribute: %w", err)
+		}
+		attr.Name = vo.XAttrName(name)
+		attrs = append(attrs, attr)
+	}
+	return attrs, rows.Err()
+}
+
+// Get returns the named attribute of the node, or nil if it is not set.
+func (r *XAttrRepository) Get(nodeID int64, name vo.XAttrName) (*entity.XAttr, error) {
+	attr := entity.XAttr{Name: name}
+	err := r.db.QueryRow(
+		`SELECT value FROM node_xattrs WHERE node_id = ? AND name = ?`,
+		nodeID, name.String(),
+	).Scan(&attr.Value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting attribute: %w", err)
+	}
+	return &attr, nil
+}
+
+// Set creates or replaces the named attribute of the node.
+func (r *XAttrRepository) Set(nodeID int64, name vo.XAttrName, value string) error {
+	_, err := r.db.Exec(
+		`INSERT INTO node_xattrs (node_id, name, value) VALUES (?, ?, ?)
+		 ON CONFLICT (node_id, name) DO UPDATE SET value = excluded.value`,
+		nodeID, name.String(), value,
+	)
+	if err != nil {
+		return fmt.Errorf("setting attribute: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the named attribute of the node. Returns false if it was not set.
+func (r *XAttrRepository) Delete(nodeID int64, name vo.XAttrName) (bool, error) {
+	result, err := r.db.Exec(
+		`DELETE FROM node_xattrs WHERE node_id = ? AND name = ?`,
+		nodeID, name.String(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("deleting attribute: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Copy gives the node dstNodeID all attributes of the node srcNodeID.
+func (r *XAttrRepository) Copy(srcNodeID, dstNodeID int64) error {
+	return copyXAttrs(r.db, srcNodeID, dstNodeID)
+}
+
+// copyXAttrs gives the node dst all attributes of the node src, replacing
+// those it already has under the same names.
+func copyXAttrs(db *sql.DB, src, dst int64) error {
+	_, err := db.Exec(
+		`INSERT OR REPLACE INTO node_xattrs (node_id, name, value)
+		 SELECT ?, name, value FROM node_xattrs WHERE node_id = ?`,
+		dst, src,
+	)
+	if err != nil {
+		return fmt.Errorf("copying attributes: %w", err)
+	}
+	return nil
+}
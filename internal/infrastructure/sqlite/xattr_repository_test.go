@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+func TestXAttrRepository(t *testing.T) {
+	db := openTestDB(t)
+	nodes := NewNodeRepository(db)
+	repo := NewXAttrRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodes.CreateRootNode(userID); err != nil {
+		t.Fatal(err)
+	}
+	file, err := nodes.CreateFile(userID, vo.NewCloudPath("/report.pdf"), vo.MustContentHash("0000000000000000000000000000000000000001"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Set(file.ID, "source", "crm"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := repo.Set(file.ID, "checksum", "abc"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := repo.Set(file.ID, "source", "erp"); err != nil {
+		t.Fatalf("Set replacing: %v", err)
+	}
+
+	list, err := repo.List(file.ID)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []entity.XAttr{{Name: "checksum", Value: "abc"}, {Name: "source", Value: "erp"}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("List = %+v, want %+v", list, want)
+	}
+
+	if attr, err := repo.Get(file.ID, "source"); err != nil || attr == nil || attr.Value != "erp" {
+		t.Errorf("Get(source) = %+v, %v, want erp", attr, err)
+	}
+	if attr, err := repo.Get(file.ID, "missing"); err != nil || attr != nil {
+		t.Errorf("Get(missing) = %+v, %v, want nil", attr, err)
+	}
+
+	if deleted, err := repo.Delete(file.ID, "checksum"); err != nil || !deleted {
+		t.Errorf("Delete(checksum) = %v, %v, want true", deleted, err)
+	}
+	if deleted, err := repo.Delete(file.ID, "checksum"); err != nil || deleted {
+		t.Errorf("Delete(checksum) again = %v, %v, want false", deleted, err)
+	}
+
+	t.Run("copied with nodes", func(t *testing.T) {
+		if _, err := nodes.CreateFolder(userID, vo.NewCloudPath("/docs")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := nodes.Move(userID, vo.NewCloudPath("/report.pdf"), vo.NewCloudPath("/docs/report.pdf")); err != nil {
+			t.Fatal(err)
+		}
+		copied, err := nodes.Copy(userID, vo.NewCloudPath("/docs"), vo.NewCloudPath("/docs2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if copied == nil {
+			t.Fatal("Copy returned no node")
+		}
+		dst, _ := nodes.Get(userID, vo.NewCloudPath("/docs2/report.pdf"))
+		if attr, _ := repo.Get(dst.ID, "source"); attr == nil || attr.Value != "erp" {
+			t.Errorf("copied file attribute = %+v, want erp", attr)
+		}
+	})
+
+	t.Run("removed with the node", func(t *testing.T) {
+		if err := nodes.Delete(userID, vo.NewCloudPath("/docs")); err != nil {
+			t.Fatal(err)
+		}
+		if list, _ := repo.List(file.ID); len(list) != 0 {
+			t.Errorf("attributes of deleted node = %+v, want none", list)
+		}
+	})
+}
+
+func TestTrashRepository_xattrs(t *testing.T) {
+	db := openTestDB(t)
+	nodes := NewNodeRepository(db)
+	trash := NewTrashRepository(db)
+	xattrs := NewXAttrRepository(db)
+	userID, err := NewUserRepository(db).Create(&entity.User{Email: "test@example.com", Password: "pass"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	if _, err := nodes.CreateRootNode(userID); err != nil {
+		t.Fatal(err)
+	}
+	path := vo.NewCloudPath("/report.pdf")
+	file, err := nodes.CreateFile(userID, path, vo.MustContentHash("0000000000000000000000000000000000000001"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xattrs.Set(file.ID, "source", "crm"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trash.Insert(userID, file, nil, userID); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := nodes.Delete(userID, path); err != nil {
+		t.Fatal(err)
+	}
+	item, err := trash.GetByPathAndRev(userID, path, file.Rev)
+	if err != nil || item == nil {
+		t.Fatalf("GetByPathAndRev = %+v, %v", item, err)
+	}
+
+	restored, err := nodes.CreateFile(userID, path, file.Hash, file.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := trash.RestoreXAttrs(item.ID, restored.ID); err != nil {
+		t.Fatalf("RestoreXAttrs: %v", err)
+	}
+	if attr, _ := xattrs.Get(restored.ID, "source"); attr == nil || attr.Value != "crm" {
+		t.Errorf("restored attribute = %+v, want crm", attr)
+	}
+
+	if err := trash.Delete(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	var kept int
+	if err := db.Conn().QueryRow(`SELECT COUNT(*) FROM trash_xattrs`).Scan(&kept); err != nil {
+		t.Fatal(err)
+	}
+	if kept != 0 {
+		t.Errorf("%d attributes kept after the trash item was deleted, want 0", kept)
+	}
+}
@@ -0,0 +1,30 @@
+// Package sshkey parses SSH public keys in authorized_keys format.
+package sshkey
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Parser implements port.PublicKeyParser.
+type Parser struct{}
+
+// NewParser creates a new Parser.
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Parse reads a single authorized_keys line. Options before the key type are not accepted.
+func (p *Parser) Parse(authorizedKey string) (key, fingerprint, comment string, err error) {
+	pub, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return "", "", "", fmt.Errorf("parsing public key: %w", err)
+	}
+	if len(options) > 0 || len(bytes.TrimSpace(rest)) > 0 {
+		return "", "", "", fmt.Errorf("expected a single key without options")
+	}
+	key = string(bytes.TrimSpace(ssh.MarshalAuthorizedKey(pub)))
+	return key, ssh.FingerprintSHA256(pub), comment, nil
+}
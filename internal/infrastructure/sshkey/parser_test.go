@@ -0,0 +1,48 @@
+package sshkey
+
+import "testing"
+
+// testKey is an ed25519 public key; its fingerprint was taken from ssh-keygen -l.
+const (
+	testKey         = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAOY2Qb5tPg/qT2+YhiwnF1rtTpfGKL3txYYUtgGD2T4"
+	testFingerprint = "SHA256:pv/7khc4jq8jnbXgHr09n4AjMDD4CWr9zhPUEVy1KRQ"
+)
+
+func TestParser_Parse(t *testing.T) {
+	p := NewParser()
+
+	key, fp, comment, err := p.Parse(testKey + " backup@server\n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if key != testKey {
+		t.Errorf("key = %q, want %q", key, testKey)
+	}
+	if fp != testFingerprint {
+		t.Errorf("fingerprint = %q, want %q", fp, testFingerprint)
+	}
+	if comment != "backup@server" {
+		t.Errorf("comment = %q, want backup@server", comment)
+	}
+
+	_, fp2, _, err := p.Parse(testKey)
+	if err != nil || fp2 != fp {
+		t.Errorf("same key without comment: fingerprint = %q, %v; want %q", fp2, err, fp)
+	}
+}
+
+func TestParser_Parse_invalid(t *testing.T) {
+	p := NewParser()
+	tests := []string{
+		"",
+		"not a key",
+		"ssh-ed25519 AAAA",
+		`command="ls" ` + testKey,
+		testKey + "\n" + testKey,
+	}
+	for _, in := range tests {
+		if _, _, _, err := p.Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package textextract
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ooxmlFormat names the parts that hold the text of an Office Open XML format.
+type ooxmlFormat struct {
+	ext     string // File name extension.
+	pattern string // Pattern matching the text part names.
+}
+
+// maxPartSize caps how much of one document part is read.
+const maxPartSize = 64 << 20
+
+// ooxmlFormats lists the supported Office Open XML formats.
+var ooxmlFormats = []ooxmlFormat{
+	{".docx", "word/document.xml"},
+	{".xlsx", "xl/sharedStrings.xml"},
+	{".pptx", "ppt/slides/slide*.xml"},
+}
+
+// OOXML extracts the text of Word, Excel and PowerPoint documents in the
+// Office Open XML formats: document paragraphs, spreadsheet cell strings and
+// slide text.
+type OOXML struct{}
+
+// Supports reports whether the name is a .docx, .xlsx or .pptx file.
+func (OOXML) Supports(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, format := range ooxmlFormats {
+		if format.ext == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Extract returns the text of the document parts, one paragraph per line.
+// The format is recognized by the parts the archive holds. Parts are no
+// longer read once limit bytes of text are extracted.
+func (OOXML) Extract(r io.ReaderAt, size int64, limit int) (string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []*zip.File
+	for _, format := range ooxmlFormats {
+		for _, f := range archive.File {
+			if ok, _ := path.Match(format.pattern, f.Name); ok {
+				parts = append(parts, f)
+			}
+		}
+		if len(parts) > 0 {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return "", errors.New("no document text parts")
+	}
+	// Slides in order: slide2.xml before slide10.xml.
+	sort.Slice(parts, func(i, j int) bool { return partNumber(parts[i].Name) < partNumber(parts[j].Name) })
+
+	var text strings.Builder
+	for _, f := range parts {
+		if text.Len() >= limit {
+			break
+		}
+		if err := extractPart(f, &text, limit); err != nil {
+			return "", err
+		}
+	}
+	return truncate(text.String(), limit), nil
+}
+
+// extractPart appends the character data of the part's text elements (w:t,
+// a:t, t) to text, ending paragraphs (w:p, a:p) and shared strings (si) with
+// a line break. It stops once text holds limit bytes.
+func extractPart(f *zip.File, text *strings.Builder, limit int) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(io.LimitReader(rc, maxPartSize))
+	inText := false
+	for text.Len() < limit {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			inText = t.Name.Local == "t"
+		case xml.EndElement:
+			inText = false
+			if t.Name.Local == "p" || t.Name.Local == "si" {
+				text.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+	return nil
+}
+
+// partNumber returns the number at the end of a part name such as
+// "ppt/slides/slide12.xml", or 0.
+func partNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	i := len(base)
+	for i > 0 && base[i-1] >= '0' && base[i-1] <= '9' {
+		i--
+	}
+	n, _ := strconv.Atoi(base[i:])
+	return n
+}
@@ -0,0 +1,96 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// buildZip returns a zip archive of the given parts.
+func buildZip(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestOOXML_Extract(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts map[string]string
+		want  string
+	}{
+		{
+			"docx",
+			map[string]string{
+				"[Content_Types].xml": `<Types/>`,
+				"word/document.xml": `<w:document xmlns:w="w"><w:body>` +
+					`<w:p><w:r><w:t>Hello, </w:t></w:r><w:r><w:t>world</w:t></w:r></w:p>` +
+					`<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Second &amp; last</w:t></w:r></w:p>` +
+					`</w:body></w:document>`,
+			},
+			"Hello, world\nSecond & last\n",
+		},
+		{
+			"xlsx",
+			map[string]string{
+				"xl/workbook.xml":      `<workbook/>`,
+				"xl/sharedStrings.xml": `<sst><si><t>Budget</t></si><si><r><t>Q</t></r><r><t>1</t></r></si></sst>`,
+			},
+			"Budget\nQ1\n",
+		},
+		{
+			"pptx",
+			map[string]string{
+				"ppt/slides/slide10.xml":           `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Tenth</a:t></a:r></a:p></p:sld>`,
+				"ppt/slides/slide2.xml":            `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Second</a:t></a:r></a:p></p:sld>`,
+				"ppt/slides/_rels/slide2.xml.rels": `<Relationships/>`,
+			},
+			"Second\nTenth\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildZip(t, tt.parts)
+			got, err := OOXML{}.Extract(bytes.NewReader(data), int64(len(data)), 1<<20)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOOXML_Extract_notDocument(t *testing.T) {
+	data := buildZip(t, map[string]string{"readme.txt": "hello"})
+	if _, err := (OOXML{}).Extract(bytes.NewReader(data), int64(len(data)), 1<<20); err == nil {
+		t.Error("Extract of a plain zip succeeded")
+	}
+	if _, err := (OOXML{}).Extract(bytes.NewReader([]byte("not a zip")), 9, 1<<20); err == nil {
+		t.Error("Extract of a non-zip succeeded")
+	}
+}
+
+func TestOOXML_Extract_limit(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"ppt/slides/slide1.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Café</a:t></a:r></a:p></p:sld>`,
+		"ppt/slides/slide2.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Second</a:t></a:r></a:p></p:sld>`,
+	})
+	// The limit cuts "é" in two, which is dropped; the second slide is not read.
+	got, err := OOXML{}.Extract(bytes.NewReader(data), int64(len(data)), 4)
+	if err != nil || got != "Caf" {
+		t.Errorf("Extract = %q, %v, want %q", got, err, "Caf")
+	}
+}
@@ -0,0 +1,251 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPDFSize is the largest PDF file that is read.
+const maxPDFSize = 64 << 20
+
+// maxStreamSize caps the decompressed size of one PDF stream.
+const maxStreamSize = 16 << 20
+
+// PDF extracts the text layer of PDF documents: the strings shown by the
+// text operators of uncompressed and Flate-compressed content streams.
+// Text in fonts with custom encodings, as well as scanned pages, is skipped.
+type PDF struct{}
+
+// Supports reports whether the name is a .pdf file.
+func (PDF) Supports(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".pdf")
+}
+
+// Extract returns the text of the content streams, one text object per line.
+// Streams are no longer read once limit bytes of text are extracted.
+func (PDF) Extract(r io.ReaderAt, size int64, limit int) (string, error) {
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, min(size, maxPDFSize)))
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for rest := data; text.Len() < limit; {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		dict := rest[:start]
+		if i := bytes.LastIndex(dict, []byte("<<")); i >= 0 {
+			dict = dict[i:]
+		}
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+
+		if content, ok := decodeStream(dict, body[:end]); ok {
+			showText(content, &text, limit)
+		}
+	}
+	return truncate(text.String(), limit), nil
+}
+
+// decodeStream returns the content of a stream that may hold page text:
+// uncompressed or Flate-compressed, and not an image or a font.
+func decodeStream(dict, body []byte) ([]byte, bool) {
+	for _, skip := range []string{"/Image", "/FontFile", "/Length1", "/DCTDecode", "/JPXDecode", "/CCITTFaxDecode", "/JBIG2Decode"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return nil, false
+		}
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) {
+		return body, !bytes.Contains(dict, []byte("/Filter"))
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, false
+	}
+	defer zr.Close()
+	// A truncated stream still yields the text before the damage.
+	content, _ := io.ReadAll(io.LimitReader(zr, maxStreamSize))
+	return content, len(content) > 0
+}
+
+// showText appends the strings shown by the text operators of a content
+// stream to text: Tj, TJ, ' and ". Line and text object operators break lines.
+// It stops once text holds limit bytes.
+func showText(content []byte, text *strings.Builder, limit int) {
+	var shown strings.Builder
+	for i := 0; i < len(content) && text.Len() < limit; {
+		c := content[i]
+		switch {
+		case c == '(':
+			var s []byte
+			s, i = literalString(content, i+1)
+			shown.Write(s)
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			var s []byte
+			s, i = hexString(content, i+1)
+			shown.Write(s)
+		case c == '-' || c == '.' || c >= '0' && c <= '9':
+			// A wide negative gap between the strings of a TJ array separates words.
+			start := i
+			for i++; i < len(content) && (content[i] == '.' || content[i] >= '0' && content[i] <= '9'); i++ {
+			}
+			if n, err := strconv.ParseFloat(string(content[start:i]), 64); err == nil && n < -200 {
+				shown.WriteByte(' ')
+			}
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isOperatorChar(c):
+			start := i
+			for i < len(content) && isOperatorChar(content[i]) {
+				i++
+			}
+			switch string(content[start:i]) {
+			case "Tj", "TJ", "'", `"`:
+				if s := printable(shown.String()); s != "" {
+					if text.Len() > 0 && !strings.HasSuffix(text.String(), "\n") {
+						text.WriteByte(' ')
+					}
+					text.WriteString(s)
+				}
+			case "Td", "TD", "T*", "ET":
+				if text.Len() > 0 && !strings.HasSuffix(text.String(), "\n") {
+					text.WriteByte('\n')
+				}
+			}
+			shown.Reset()
+		default:
+			i++
+		}
+	}
+}
+
+// literalString reads a (string) starting after its opening parenthesis.
+// Returns the string and the position after its closing parenthesis.
+func literalString(content []byte, i int) ([]byte, int) {
+	var s []byte
+	depth := 1
+	for i < len(content) {
+		c := content[i]
+		i++
+		switch c {
+		case '\\':
+			if i >= len(content) {
+				return s, i
+			}
+			e := content[i]
+			i++
+			switch e {
+			case 'n':
+				s = append(s, '\n')
+			case 'r', 't', 'b', 'f':
+				s = append(s, ' ')
+			case '\r', '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for k := 0; k < 2 && i < len(content) && content[i] >= '0' && content[i] <= '7'; k++ {
+						n = n*8 + int(content[i]-'0')
+						i++
+					}
+					s = append(s, byte(n))
+				} else {
+					s = append(s, e)
+				}
+			}
+		case '(':
+			depth++
+			s = append(s, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return s, i
+			}
+			s = append(s, c)
+		default:
+			s = append(s, c)
+		}
+	}
+	return s, i
+}
+
+// hexString reads a <hex string> starting after its opening bracket.
+// Returns the string and the position after its closing bracket.
+func hexString(content []byte, i int) ([]byte, int) {
+	var s []byte
+	var digits []byte
+	for i < len(content) && content[i] != '>' {
+		if v, ok := hexValue(content[i]); ok {
+			digits = append(digits, v)
+		}
+		i++
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, 0)
+	}
+	for k := 0; k < len(digits); k += 2 {
+		s = append(s, digits[k]<<4|digits[k+1])
+	}
+	return s, i + 1
+}
+
+// hexValue returns the value of a hexadecimal digit.
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// isOperatorChar reports whether c can be part of a content stream operator.
+func isOperatorChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '*' || c == '\'' || c == '"'
+}
+
+// printable decodes shown bytes, which are UTF-16 with a byte order mark
+// or a single-byte encoding, and returns them if they read as text.
+// Glyph IDs of fonts with custom encodings come out as control characters.
+func printable(shown string) string {
+	var runes []rune
+	if strings.HasPrefix(shown, "\xfe\xff") {
+		for k := 2; k+1 < len(shown); k += 2 {
+			runes = append(runes, rune(shown[k])<<8|rune(shown[k+1]))
+		}
+	} else {
+		// PDFDocEncoding and WinAnsiEncoding match Latin-1 for text characters.
+		for k := 0; k < len(shown); k++ {
+			runes = append(runes, rune(shown[k]))
+		}
+	}
+
+	controls := 0
+	for _, r := range runes {
+		if r < ' ' && r != '\n' && r != '\t' || r == utf8.RuneError {
+			controls++
+		}
+	}
+	if controls*4 > len(runes) {
+		return ""
+	}
+	return strings.TrimSpace(string(runes))
+}
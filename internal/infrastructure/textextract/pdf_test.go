@@ -0,0 +1,69 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF returns a minimal PDF with the given page content streams,
+// the first uncompressed and the others Flate-compressed.
+func buildPDF(t *testing.T, pages ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")
+	for i, page := range pages {
+		if i == 0 {
+			fmt.Fprintf(&b, "%d 0 obj << /Length %d >>\nstream\n%s\nendstream\nendobj\n", i+2, len(page), page)
+			continue
+		}
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write([]byte(page))
+		zw.Close()
+		fmt.Fprintf(&b, "%d 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", i+2, z.Len())
+		b.Write(z.Bytes())
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	b.WriteString("%%EOF\n")
+	return b.Bytes()
+}
+
+func TestPDF_Extract(t *testing.T) {
+	data := buildPDF(t,
+		"BT /F1 12 Tf 72 712 Td (Quarterly \\(Q3\\) report) Tj 0 -14 Td [(Reve) 20 (nue) -300 (grew)] TJ ET",
+		"BT /F1 12 Tf <FEFF0041006E006E00E9006500200032> Tj T* (caf\\351) Tj ET",
+	)
+	data = append(data, []byte("5 0 obj << /Subtype /Image /Length 6 >>\nstream\n(Hidden) Tj\nendstream\nendobj\n")...)
+
+	got, err := PDF{}.Extract(bytes.NewReader(data), int64(len(data)), 1<<20)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	want := "Quarterly (Q3) report\nRevenue grew\nAnnée 2\ncafé\n"
+	if got != want {
+		t.Errorf("Extract = %q, want %q", got, want)
+	}
+	if strings.Contains(got, "Hidden") {
+		t.Error("Extract read the text of an image stream")
+	}
+}
+
+func TestPDF_Extract_glyphIDs(t *testing.T) {
+	// Fonts with custom encodings show glyph IDs, which are not text.
+	data := buildPDF(t, "BT <0003000400050006> Tj ET")
+	got, err := PDF{}.Extract(bytes.NewReader(data), int64(len(data)), 1<<20)
+	if err != nil || got != "" {
+		t.Errorf("Extract = %q, %v, want no text", got, err)
+	}
+}
+
+func TestPDF_Extract_limit(t *testing.T) {
+	data := buildPDF(t, "BT (First page) Tj ET", "BT (Second page) Tj ET")
+	got, err := PDF{}.Extract(bytes.NewReader(data), int64(len(data)), 8)
+	if err != nil || got != "First pa" {
+		t.Errorf("Extract = %q, %v, want the first 8 bytes", got, err)
+	}
+}
@@ -0,0 +1,64 @@
+// Package textextract provides text extractors for content search.
+package textextract
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// maxPlainSize is how much of a plain text file is read.
+const maxPlainSize = 4 << 20
+
+// plainExtensions lists the extensions of plain text, Markdown and source code files.
+var plainExtensions = map[string]bool{
+	".txt": true, ".text": true, ".log": true, ".csv": true, ".tsv": true,
+	".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".tex": true,
+	".json": true, ".xml": true, ".yaml": true, ".yml": true, ".toml": true,
+	".ini": true, ".conf": true, ".cfg": true, ".env": true, ".properties": true,
+	".html": true, ".htm": true, ".css": true, ".scss": true, ".svg": true,
+	".go": true, ".py": true, ".rb": true, ".php": true, ".pl": true, ".lua": true,
+	".js": true, ".mjs": true, ".ts": true, ".jsx": true, ".tsx": true, ".vue": true,
+	".java": true, ".kt": true, ".scala": true, ".groovy": true, ".cs": true, ".fs": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".m": true,
+	".rs": true, ".swift": true, ".dart": true, ".zig": true, ".hs": true, ".ex": true, ".exs": true,
+	".sh": true, ".bash": true, ".zsh": true, ".ps1": true, ".bat": true, ".cmd": true,
+	".sql": true, ".graphql": true, ".proto": true, ".tf": true, ".gradle": true, ".cmake": true,
+}
+
+// plainNames lists extensionless file names that hold plain text.
+var plainNames = map[string]bool{
+	"readme": true, "license": true, "changelog": true, "makefile": true, "dockerfile": true,
+}
+
+// Plain extracts plain text, Markdown and source code files as they are.
+type Plain struct{}
+
+// Supports reports whether the name has a plain text extension.
+func (Plain) Supports(name string) bool {
+	name = strings.ToLower(name)
+	return plainExtensions[filepath.Ext(name)] || plainNames[name]
+}
+
+// Extract returns the text of the first 4 MB of the content, up to limit bytes.
+// Content with NUL bytes is binary and has no text.
+func (Plain) Extract(r io.ReaderAt, size int64, limit int) (string, error) {
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, min(size, maxPlainSize, int64(limit))))
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", nil
+	}
+	// Drop invalid sequences, such as a character cut by the size limit.
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// truncate cuts text to at most limit bytes, dropping a character cut in two.
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[:limit], "")
+}
@@ -0,0 +1,49 @@
+package textextract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+)
+
+var (
+	_ port.TextExtractor = Plain{}
+	_ port.TextExtractor = PDF{}
+	_ port.TextExtractor = OOXML{}
+)
+
+func TestPlain_Supports(t *testing.T) {
+	for name, want := range map[string]bool{
+		"notes.txt":  true,
+		"README.md":  true,
+		"main.GO":    true,
+		"Makefile":   true,
+		"photo.jpg":  false,
+		"report.pdf": false,
+		"archive":    false,
+	} {
+		if got := (Plain{}).Supports(name); got != want {
+			t.Errorf("Supports(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPlain_Extract(t *testing.T) {
+	text := "# Título\n\nSome *Markdown* text."
+	got, err := Plain{}.Extract(strings.NewReader(text), int64(len(text)), 1<<20)
+	if err != nil || got != text {
+		t.Errorf("Extract = %q, %v, want the text as is", got, err)
+	}
+
+	binary := "text\x00more"
+	if got, err := (Plain{}).Extract(strings.NewReader(binary), int64(len(binary)), 1<<20); err != nil || got != "" {
+		t.Errorf("Extract(binary) = %q, %v, want no text", got, err)
+	}
+
+	// A character cut by the size is dropped.
+	cut := "naïve"
+	if got, err := (Plain{}).Extract(strings.NewReader(cut), 3, 1<<20); err != nil || got != "na" {
+		t.Errorf("Extract(cut) = %q, %v, want %q", got, err, "na")
+	}
+}
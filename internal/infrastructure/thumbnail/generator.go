@@ -14,6 +14,8 @@ import (
 	"sync"
 
 	"golang.org/x/image/draw"
+
+	"github.com/pozitronik/tucha/internal/infrastructure/exif"
 )
 
 // Generator creates and caches image thumbnails.
@@ -40,6 +42,7 @@ type Result struct {
 }
 
 // Generate creates a thumbnail for the given image file at the specified preset size.
+// JPEG thumbnails are turned upright according to the EXIF orientation of the image.
 // Returns the thumbnail data and content type.
 func (g *Generator) Generate(srcFile *os.File, hash string, preset Preset) (*Result, error) {
 	// Check cache first
@@ -73,16 +76,32 @@ func (g *Generator) Generate(srcFile *os.File, hash string, preset Preset) (*Res
 		}, nil
 	}
 
-	// Calculate target dimensions maintaining aspect ratio
+	// Cameras store photos as shot and record how to turn them upright
+	orientation := 0
+	if format == "jpeg" {
+		if meta, err := exif.Read(reader, reader.Size()); err == nil {
+			orientation = meta.Orientation
+		}
+	}
+
+	// Calculate target dimensions maintaining the upright aspect ratio
 	srcBounds := img.Bounds()
 	srcWidth := srcBounds.Dx()
 	srcHeight := srcBounds.Dy()
 
+	transposed := exif.Transposed(orientation)
+	if transposed {
+		srcWidth, srcHeight = srcHeight, srcWidth
+	}
 	targetWidth, targetHeight := fitWithinBounds(srcWidth, srcHeight, preset.Width, preset.Height)
+	if transposed {
+		targetWidth, targetHeight = targetHeight, targetWidth
+	}
 
-	// Create scaled image
+	// Create scaled image, then turn it upright
 	scaled := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
 	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, srcBounds, draw.Over, nil)
+	scaled = orient(scaled, orientation)
 
 	// Encode the result
 	var buf bytes.Buffer
@@ -1,6 +1,7 @@
 package thumbnail
 
 import (
+	"bytes"
 	"image"
 	"image/color"
 	"image/jpeg"
@@ -144,6 +145,41 @@ func TestGenerator_Generate(t *testing.T) {
 		}
 	})
 
+	t.Run("turns JPEG thumbnail upright", func(t *testing.T) {
+		var img bytes.Buffer
+		if err := jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 800, 400)), nil); err != nil {
+			t.Fatal(err)
+		}
+		// EXIF block with a single entry: orientation 6, a quarter turn clockwise.
+		exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08" +
+			"\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+		data := append([]byte{0xff, 0xd8, 0xff, 0xe1, 0x00, byte(len(exif) + 2)}, exif...)
+		data = append(data, img.Bytes()[2:]...)
+
+		path := filepath.Join(t.TempDir(), "rotated.jpg")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		gen, _ := NewGenerator("")
+		result, err := gen.Generate(f, "testhash_rotated", Preset{Width: 160, Height: 160})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		thumb, err := jpeg.Decode(bytes.NewReader(result.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := thumb.Bounds(); b.Dx() != 80 || b.Dy() != 160 {
+			t.Errorf("thumbnail = %dx%d, want the upright 80x160", b.Dx(), b.Dy())
+		}
+	})
+
 	t.Run("generates PNG thumbnail", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		gen, err := NewGenerator(filepath.Join(tmpDir, "cache"))
@@ -0,0 +1,46 @@
+package thumbnail
+
+import (
+	"image"
+)
+
+// orient returns the image turned upright according to its EXIF orientation:
+// 2 to 4 mirror or rotate it by half a turn, 5 to 8 transpose it. Other
+// orientations return the image as it is.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// Source pixel of the upright pixel (x, y).
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirrored horizontally.
+				sx, sy = w-1-x, y
+			case 3: // Rotated by 180 degrees.
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically.
+				sx, sy = x, h-1-y
+			case 5: // Mirrored along the main diagonal.
+				sx, sy = y, x
+			case 6: // Needs a quarter turn clockwise.
+				sx, sy = y, h-1-x
+			case 7: // Mirrored along the other diagonal.
+				sx, sy = w-1-y, h-1-x
+			case 8: // Needs a quarter turn counterclockwise.
+				sx, sy = w-1-y, x
+			}
+			dst.SetRGBA(x, y, img.RGBAAt(img.Rect.Min.X+sx, img.Rect.Min.Y+sy))
+		}
+	}
+	return dst
+}
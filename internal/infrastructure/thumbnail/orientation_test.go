@@ -0,0 +1,45 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image with distinct pixels; each orientation maps the top row
+	// of the stored image to a known edge of the upright one.
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+	pixel := func(x, y int) color.RGBA { return color.RGBA{R: uint8(x), G: uint8(y), A: 255} }
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		topLeft     color.RGBA
+		topRight    color.RGBA
+	}{
+		{1, 3, 2, pixel(0, 0), pixel(2, 0)},
+		{2, 3, 2, pixel(2, 0), pixel(0, 0)},
+		{3, 3, 2, pixel(2, 1), pixel(0, 1)},
+		{4, 3, 2, pixel(0, 1), pixel(2, 1)},
+		{5, 2, 3, pixel(0, 0), pixel(0, 1)},
+		{6, 2, 3, pixel(0, 1), pixel(0, 0)},
+		{7, 2, 3, pixel(2, 1), pixel(2, 0)},
+		{8, 2, 3, pixel(2, 0), pixel(2, 1)},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		if b := got.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if tl, tr := got.RGBAAt(0, 0), got.RGBAAt(tt.w-1, 0); tl != tt.topLeft || tr != tt.topRight {
+			t.Errorf("orientation %d: top corners %v %v, want %v %v", tt.orientation, tl, tr, tt.topLeft, tt.topRight)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 Appendix B ("12345678901234567890").
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_rfc6238Vectors(t *testing.T) {
+	// RFC 6238 lists 8-digit codes; the 6-digit codes are their last six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, tt.unix/stepSecs)
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestGenerator_Verify(t *testing.T) {
+	g := &Generator{now: func() time.Time { return time.Unix(1111111111, 0) }}
+	step := int64(1111111111 / stepSecs)
+
+	if got, ok := g.Verify(rfcSecret, "050471"); !ok || got != step {
+		t.Errorf("Verify(current code) = %d, %v, want %d, true", got, ok, step)
+	}
+	if got, ok := g.Verify(rfcSecret, "081804"); !ok || got != step-1 {
+		t.Errorf("Verify(previous step) = %d, %v, want %d, true (drift window)", got, ok, step-1)
+	}
+	if _, ok := g.Verify(rfcSecret, "005924"); ok {
+		t.Error("Verify(code from another time) = true, want false")
+	}
+	for _, code := range []string{"", "12345"} {
+		if _, ok := g.Verify(rfcSecret, code); ok {
+			t.Errorf("Verify(%q) = true, want false", code)
+		}
+	}
+	if _, ok := g.Verify("not base32!", "050471"); ok {
+		t.Error("Verify(invalid secret) = true, want false")
+	}
+}
+
+func TestGenerator_GenerateSecret(t *testing.T) {
+	g := NewGenerator()
+	a, err := g.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	b, _ := g.GenerateSecret()
+	if a == b {
+		t.Error("GenerateSecret returned the same secret twice")
+	}
+	if len(a) != 32 {
+		t.Errorf("len(secret) = %d, want 32 base32 chars", len(a))
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Errorf("generated secret is not decodable: %v", err)
+	}
+}
+
+func TestGenerator_ProvisioningURI(t *testing.T) {
+	uri := NewGenerator().ProvisioningURI("Tucha", "user@example.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Tucha:user@example.com?") {
+		t.Errorf("unexpected URI prefix: %s", uri)
+	}
+	for _, part := range []string{"secret=ABC", "issuer=Tucha", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("URI %s does not contain %s", uri, part)
+		}
+	}
+}
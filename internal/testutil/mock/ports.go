@@ -1,9 +1,11 @@
 package mock
 
 import (
+	"errors"
 	"io"
 	"os"
 
+	"github.com/pozitronik/tucha/internal/domain/entity"
 	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
@@ -107,3 +109,98 @@ func (m *LoggerMock) Error(msg string, args ...any) {
 		m.ErrorFunc(msg, args...)
 	}
 }
+
+// TOTPMock is a test double for port.TOTP.
+// By default it issues FixedSecret and accepts only ValidCode, at time step
+// Step or, if Step is zero, at a new step on every call.
+type TOTPMock struct {
+	GenerateSecretFunc func() (string, error)
+	VerifyFunc         func(secret, code string) (int64, bool)
+	FixedSecret        string
+	ValidCode          string
+	Step               int64
+
+	calls int64
+}
+
+func (m *TOTPMock) GenerateSecret() (string, error) {
+	if m.GenerateSecretFunc != nil {
+		return m.GenerateSecretFunc()
+	}
+	return m.FixedSecret, nil
+}
+
+func (m *TOTPMock) Verify(secret, code string) (int64, bool) {
+	if m.VerifyFunc != nil {
+		return m.VerifyFunc(secret, code)
+	}
+	m.calls++
+	step := m.Step
+	if step == 0 {
+		step = m.calls
+	}
+	return step, secret != "" && code != "" && code == m.ValidCode
+}
+
+func (m *TOTPMock) ProvisioningURI(issuer, account, secret string) string {
+	return "otpauth://totp/" + issuer + ":" + account + "?secret=" + secret
+}
+
+// PublicKeyParserMock is a test double for port.PublicKeyParser.
+// By default it accepts any non-empty key and uses "SHA256:" + key as its fingerprint.
+type PublicKeyParserMock struct {
+	ParseFunc func(authorizedKey string) (key, fingerprint, comment string, err error)
+}
+
+func (m *PublicKeyParserMock) Parse(authorizedKey string) (string, string, string, error) {
+	if m.ParseFunc != nil {
+		return m.ParseFunc(authorizedKey)
+	}
+	if authorizedKey == "" {
+		return "", "", "", errors.New("empty key")
+	}
+	return authorizedKey, "SHA256:" + authorizedKey, "", nil
+}
+
+// TextExtractorMock is a test double for port.TextExtractor.
+// By default it supports every file and extracts its whole content.
+type TextExtractorMock struct {
+	SupportsFunc func(name string) bool
+	ExtractFunc  func(r io.ReaderAt, size int64, limit int) (string, error)
+}
+
+func (m *TextExtractorMock) Supports(name string) bool {
+	if m.SupportsFunc != nil {
+		return m.SupportsFunc(name)
+	}
+	return true
+}
+
+func (m *TextExtractorMock) Extract(r io.ReaderAt, size int64, limit int) (string, error) {
+	if m.ExtractFunc != nil {
+		return m.ExtractFunc(r, size, limit)
+	}
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, min(size, int64(limit))))
+	return string(data), err
+}
+
+// MediaExtractorMock is a test double for port.MediaExtractor.
+// By default it supports every file and returns empty metadata.
+type MediaExtractorMock struct {
+	SupportsFunc func(name string) bool
+	ExtractFunc  func(r io.ReaderAt, size int64) (*entity.MediaInfo, error)
+}
+
+func (m *MediaExtractorMock) Supports(name string) bool {
+	if m.SupportsFunc != nil {
+		return m.SupportsFunc(name)
+	}
+	return true
+}
+
+func (m *MediaExtractorMock) Extract(r io.ReaderAt, size int64) (*entity.MediaInfo, error) {
+	if m.ExtractFunc != nil {
+		return m.ExtractFunc(r, size)
+	}
+	return &entity.MediaInfo{}, nil
+}
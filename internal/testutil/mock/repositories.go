@@ -3,6 +3,7 @@ package mock
 
 import (
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
 	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
@@ -78,16 +79,21 @@ type NodeRepositoryMock struct {
 	CreateRootNodeFunc     func(userID int64) (*entity.Node, error)
 	CreateFolderFunc       func(userID int64, path vo.CloudPath) (*entity.Node, error)
 	CreateFileFunc         func(userID int64, path vo.CloudPath, hash vo.ContentHash, size int64) (*entity.Node, error)
+	SetMTimeFunc           func(userID int64, path vo.CloudPath, mtime int64) error
 	DeleteFunc             func(userID int64, path vo.CloudPath) error
 	RenameFunc             func(userID int64, path vo.CloudPath, newName string) (*entity.Node, error)
-	MoveFunc               func(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error)
-	CopyFunc               func(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error)
+	MoveFunc               func(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error)
+	CopyFunc               func(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error)
 	EnsurePathFunc         func(userID int64, path vo.CloudPath) error
 	GetWithDescendantsFunc func(userID int64, path vo.CloudPath) (*entity.Node, []entity.Node, error)
 	TotalSizeFunc          func(userID int64) (int64, error)
 	SetWeblinkFunc         func(userID int64, path vo.CloudPath, weblink string) error
 	GetByWeblinkFunc       func(weblink string) (*entity.Node, error)
 	ListByWeblinkFunc      func(userID int64) ([]entity.Node, error)
+	SetStarredFunc         func(userID int64, path vo.CloudPath, starred bool) error
+	SetTagsFunc            func(userID int64, path vo.CloudPath, tags []vo.Tag) error
+	ListStarredFunc        func(userID int64) ([]entity.Node, error)
+	ListTaggedFunc         func(userID int64, tag vo.Tag) ([]entity.Node, error)
 	ExistsFunc             func(userID int64, path vo.CloudPath) (bool, error)
 }
 
@@ -133,6 +139,13 @@ func (m *NodeRepositoryMock) CreateFile(userID int64, path vo.CloudPath, hash vo
 	return &entity.Node{ID: 1, UserID: userID, Home: path, Name: path.Name(), Type: vo.NodeTypeFile, Hash: hash, Size: size}, nil
 }
 
+func (m *NodeRepositoryMock) SetMTime(userID int64, path vo.CloudPath, mtime int64) error {
+	if m.SetMTimeFunc != nil {
+		return m.SetMTimeFunc(userID, path, mtime)
+	}
+	return nil
+}
+
 func (m *NodeRepositoryMock) Delete(userID int64, path vo.CloudPath) error {
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(userID, path)
@@ -147,16 +160,16 @@ func (m *NodeRepositoryMock) Rename(userID int64, path vo.CloudPath, newName str
 	return nil, nil
 }
 
-func (m *NodeRepositoryMock) Move(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error) {
+func (m *NodeRepositoryMock) Move(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error) {
 	if m.MoveFunc != nil {
-		return m.MoveFunc(userID, srcPath, targetFolder)
+		return m.MoveFunc(userID, srcPath, dstPath)
 	}
 	return nil, nil
 }
 
-func (m *NodeRepositoryMock) Copy(userID int64, srcPath, targetFolder vo.CloudPath) (*entity.Node, error) {
+func (m *NodeRepositoryMock) Copy(userID int64, srcPath, dstPath vo.CloudPath) (*entity.Node, error) {
 	if m.CopyFunc != nil {
-		return m.CopyFunc(userID, srcPath, targetFolder)
+		return m.CopyFunc(userID, srcPath, dstPath)
 	}
 	return nil, nil
 }
@@ -203,6 +216,34 @@ func (m *NodeRepositoryMock) ListByWeblink(userID int64) ([]entity.Node, error)
 	return nil, nil
 }
 
+func (m *NodeRepositoryMock) SetStarred(userID int64, path vo.CloudPath, starred bool) error {
+	if m.SetStarredFunc != nil {
+		return m.SetStarredFunc(userID, path, starred)
+	}
+	return nil
+}
+
+func (m *NodeRepositoryMock) SetTags(userID int64, path vo.CloudPath, tags []vo.Tag) error {
+	if m.SetTagsFunc != nil {
+		return m.SetTagsFunc(userID, path, tags)
+	}
+	return nil
+}
+
+func (m *NodeRepositoryMock) ListStarred(userID int64) ([]entity.Node, error) {
+	if m.ListStarredFunc != nil {
+		return m.ListStarredFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *NodeRepositoryMock) ListTagged(userID int64, tag vo.Tag) ([]entity.Node, error) {
+	if m.ListTaggedFunc != nil {
+		return m.ListTaggedFunc(userID, tag)
+	}
+	return nil, nil
+}
+
 func (m *NodeRepositoryMock) Exists(userID int64, path vo.CloudPath) (bool, error) {
 	if m.ExistsFunc != nil {
 		return m.ExistsFunc(userID, path)
@@ -214,16 +255,37 @@ func (m *NodeRepositoryMock) Exists(userID int64, path vo.CloudPath) (bool, erro
 
 // TokenRepositoryMock is a test double for repository.TokenRepository.
 type TokenRepositoryMock struct {
-	CreateFunc       func(userID int64, ttlSeconds int) (*entity.Token, error)
-	LookupAccessFunc func(accessToken string) (*entity.Token, error)
-	DeleteFunc       func(id int64) error
+	CreateFunc                 func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error)
+	CreatePersonalFunc         func(userID int64, name string, scopes []vo.TokenScope, pathPrefix vo.CloudPath, ttlSeconds int) (*entity.Token, error)
+	CreateImpersonationFunc    func(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error)
+	LookupAccessFunc           func(accessToken string) (*entity.Token, error)
+	LookupRefreshFunc          func(refreshToken string) (*entity.Token, error)
+	GetByIDFunc                func(id int64) (*entity.Token, error)
+	ListPersonalFunc           func(userID int64) ([]entity.Token, error)
+	ListSessionsFunc           func(userID int64) ([]entity.Token, error)
+	DeleteFunc                 func(id int64) error
+	DeleteSessionsByClientFunc func(userID int64, clientID string) (int64, error)
+}
+
+func (m *TokenRepositoryMock) Create(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(userID, clientID, ttlSeconds)
+	}
+	return &entity.Token{ID: 1, UserID: userID, AccessToken: "test-access", RefreshToken: "test-refresh", ClientID: clientID}, nil
 }
 
-func (m *TokenRepositoryMock) Create(userID int64, ttlSeconds int) (*entity.Token, error) {
-	if m.CreateFunc != nil {
-		return m.CreateFunc(userID, ttlSeconds)
+func (m *TokenRepositoryMock) CreatePersonal(userID int64, name string, scopes []vo.TokenScope, pathPrefix vo.CloudPath, ttlSeconds int) (*entity.Token, error) {
+	if m.CreatePersonalFunc != nil {
+		return m.CreatePersonalFunc(userID, name, scopes, pathPrefix, ttlSeconds)
+	}
+	return &entity.Token{ID: 1, UserID: userID, AccessToken: "test-personal", Personal: true, Name: name, Scopes: scopes, PathPrefix: pathPrefix}, nil
+}
+
+func (m *TokenRepositoryMock) CreateImpersonation(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error) {
+	if m.CreateImpersonationFunc != nil {
+		return m.CreateImpersonationFunc(userID, impersonator, elevated, ttlSeconds)
 	}
-	return &entity.Token{ID: 1, UserID: userID, AccessToken: "test-access", RefreshToken: "test-refresh"}, nil
+	return &entity.Token{ID: 1, UserID: userID, AccessToken: "test-support", Personal: true, Impersonator: impersonator, Elevated: elevated}, nil
 }
 
 func (m *TokenRepositoryMock) LookupAccess(accessToken string) (*entity.Token, error) {
@@ -233,6 +295,41 @@ func (m *TokenRepositoryMock) LookupAccess(accessToken string) (*entity.Token, e
 	return nil, nil
 }
 
+func (m *TokenRepositoryMock) LookupRefresh(refreshToken string) (*entity.Token, error) {
+	if m.LookupRefreshFunc != nil {
+		return m.LookupRefreshFunc(refreshToken)
+	}
+	return nil, nil
+}
+
+func (m *TokenRepositoryMock) GetByID(id int64) (*entity.Token, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *TokenRepositoryMock) ListPersonal(userID int64) ([]entity.Token, error) {
+	if m.ListPersonalFunc != nil {
+		return m.ListPersonalFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *TokenRepositoryMock) ListSessions(userID int64) ([]entity.Token, error) {
+	if m.ListSessionsFunc != nil {
+		return m.ListSessionsFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *TokenRepositoryMock) DeleteSessionsByClient(userID int64, clientID string) (int64, error) {
+	if m.DeleteSessionsByClientFunc != nil {
+		return m.DeleteSessionsByClientFunc(userID, clientID)
+	}
+	return 0, nil
+}
+
 func (m *TokenRepositoryMock) Delete(id int64) error {
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(id)
@@ -277,8 +374,12 @@ type TrashRepositoryMock struct {
 	InsertFunc          func(userID int64, node *entity.Node, descendants []entity.Node, deletedBy int64) error
 	ListFunc            func(userID int64) ([]entity.TrashItem, error)
 	GetByPathAndRevFunc func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error)
+	ListDescendantsFunc func(item *entity.TrashItem) ([]entity.TrashItem, error)
+	RestoreXAttrsFunc   func(itemID, nodeID int64) error
 	DeleteFunc          func(id int64) error
 	DeleteAllFunc       func(userID int64) ([]entity.TrashItem, error)
+	DeleteOlderThanFunc func(userID int64, before int64) ([]entity.TrashItem, error)
+	TotalSizeFunc       func(userID int64) (int64, error)
 }
 
 func (m *TrashRepositoryMock) Insert(userID int64, node *entity.Node, descendants []entity.Node, deletedBy int64) error {
@@ -302,6 +403,20 @@ func (m *TrashRepositoryMock) GetByPathAndRev(userID int64, path vo.CloudPath, r
 	return nil, nil
 }
 
+func (m *TrashRepositoryMock) ListDescendants(item *entity.TrashItem) ([]entity.TrashItem, error) {
+	if m.ListDescendantsFunc != nil {
+		return m.ListDescendantsFunc(item)
+	}
+	return nil, nil
+}
+
+func (m *TrashRepositoryMock) RestoreXAttrs(itemID, nodeID int64) error {
+	if m.RestoreXAttrsFunc != nil {
+		return m.RestoreXAttrsFunc(itemID, nodeID)
+	}
+	return nil
+}
+
 func (m *TrashRepositoryMock) Delete(id int64) error {
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(id)
@@ -316,12 +431,30 @@ func (m *TrashRepositoryMock) DeleteAll(userID int64) ([]entity.TrashItem, error
 	return nil, nil
 }
 
+func (m *TrashRepositoryMock) DeleteOlderThan(userID int64, before int64) ([]entity.TrashItem, error) {
+	if m.DeleteOlderThanFunc != nil {
+		return m.DeleteOlderThanFunc(userID, before)
+	}
+	return nil, nil
+}
+
+func (m *TrashRepositoryMock) TotalSize(userID int64) (int64, error) {
+	if m.TotalSizeFunc != nil {
+		return m.TotalSizeFunc(userID)
+	}
+	return 0, nil
+}
+
 // -- FileVersionRepositoryMock --
 
 // FileVersionRepositoryMock is a test double for repository.FileVersionRepository.
 type FileVersionRepositoryMock struct {
 	InsertFunc     func(version *entity.FileVersion) error
 	ListByPathFunc func(userID int64, path vo.CloudPath) ([]entity.FileVersion, error)
+	GetByRevFunc   func(userID int64, path vo.CloudPath, rev int64) (*entity.FileVersion, error)
+	ListByUserFunc func(userID int64) ([]entity.FileVersion, error)
+	DeleteFunc     func(id int64) error
+	TotalSizeFunc  func(userID int64) (int64, error)
 }
 
 func (m *FileVersionRepositoryMock) Insert(version *entity.FileVersion) error {
@@ -338,6 +471,34 @@ func (m *FileVersionRepositoryMock) ListByPath(userID int64, path vo.CloudPath)
 	return nil, nil
 }
 
+func (m *FileVersionRepositoryMock) GetByRev(userID int64, path vo.CloudPath, rev int64) (*entity.FileVersion, error) {
+	if m.GetByRevFunc != nil {
+		return m.GetByRevFunc(userID, path, rev)
+	}
+	return nil, nil
+}
+
+func (m *FileVersionRepositoryMock) ListByUser(userID int64) ([]entity.FileVersion, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *FileVersionRepositoryMock) Delete(id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
+
+func (m *FileVersionRepositoryMock) TotalSize(userID int64) (int64, error) {
+	if m.TotalSizeFunc != nil {
+		return m.TotalSizeFunc(userID)
+	}
+	return 0, nil
+}
+
 // -- ShareRepositoryMock --
 
 // ShareRepositoryMock is a test double for repository.ShareRepository.
@@ -355,6 +516,7 @@ type ShareRepositoryMock struct {
 	UnmountFunc               func(userID int64, mountHome string) (*entity.Share, error)
 	GetByMountPathFunc        func(userID int64, mountHome string) (*entity.Share, error)
 	ListMountedByUserFunc     func(userID int64) ([]entity.Share, error)
+	ListMountedByOwnerFunc    func(ownerID int64) ([]entity.Share, error)
 }
 
 func (m *ShareRepositoryMock) Create(share *entity.Share) (int64, error) {
@@ -447,3 +609,403 @@ func (m *ShareRepositoryMock) ListMountedByUser(userID int64) ([]entity.Share, e
 	}
 	return nil, nil
 }
+
+func (m *ShareRepositoryMock) ListMountedByOwner(ownerID int64) ([]entity.Share, error) {
+	if m.ListMountedByOwnerFunc != nil {
+		return m.ListMountedByOwnerFunc(ownerID)
+	}
+	return nil, nil
+}
+
+// -- AppPasswordRepositoryMock --
+
+// AppPasswordRepositoryMock is a test double for repository.AppPasswordRepository.
+type AppPasswordRepositoryMock struct {
+	CreateFunc     func(p *entity.AppPassword) error
+	GetByIDFunc    func(id int64) (*entity.AppPassword, error)
+	ListByUserFunc func(userID int64) ([]entity.AppPassword, error)
+	MarkUsedFunc   func(id int64, ts int64) error
+	DeleteFunc     func(id int64) error
+}
+
+func (m *AppPasswordRepositoryMock) Create(p *entity.AppPassword) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(p)
+	}
+	p.ID = 1
+	return nil
+}
+
+func (m *AppPasswordRepositoryMock) GetByID(id int64) (*entity.AppPassword, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *AppPasswordRepositoryMock) ListByUser(userID int64) ([]entity.AppPassword, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *AppPasswordRepositoryMock) MarkUsed(id int64, ts int64) error {
+	if m.MarkUsedFunc != nil {
+		return m.MarkUsedFunc(id, ts)
+	}
+	return nil
+}
+
+func (m *AppPasswordRepositoryMock) Delete(id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
+
+// -- AccessKeyRepositoryMock --
+
+// AccessKeyRepositoryMock is a test double for repository.AccessKeyRepository.
+type AccessKeyRepositoryMock struct {
+	CreateFunc     func(k *entity.AccessKey) error
+	GetByIDFunc    func(id int64) (*entity.AccessKey, error)
+	GetByKeyIDFunc func(keyID string) (*entity.AccessKey, error)
+	ListByUserFunc func(userID int64) ([]entity.AccessKey, error)
+	MarkUsedFunc   func(id int64, ts int64) error
+	DeleteFunc     func(id int64) error
+}
+
+func (m *AccessKeyRepositoryMock) Create(k *entity.AccessKey) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(k)
+	}
+	k.ID = 1
+	return nil
+}
+
+func (m *AccessKeyRepositoryMock) GetByID(id int64) (*entity.AccessKey, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *AccessKeyRepositoryMock) GetByKeyID(keyID string) (*entity.AccessKey, error) {
+	if m.GetByKeyIDFunc != nil {
+		return m.GetByKeyIDFunc(keyID)
+	}
+	return nil, nil
+}
+
+func (m *AccessKeyRepositoryMock) ListByUser(userID int64) ([]entity.AccessKey, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *AccessKeyRepositoryMock) MarkUsed(id int64, ts int64) error {
+	if m.MarkUsedFunc != nil {
+		return m.MarkUsedFunc(id, ts)
+	}
+	return nil
+}
+
+func (m *AccessKeyRepositoryMock) Delete(id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
+
+// -- MultipartRepositoryMock --
+
+// MultipartRepositoryMock is a test double for repository.MultipartRepository.
+type MultipartRepositoryMock struct {
+	CreateFunc    func(u *entity.MultipartUpload) error
+	GetFunc       func(id string) (*entity.MultipartUpload, error)
+	PutPartFunc   func(p *entity.MultipartPart) (*entity.MultipartPart, error)
+	ListPartsFunc func(uploadID string) ([]entity.MultipartPart, error)
+	DeleteFunc    func(id string) error
+}
+
+func (m *MultipartRepositoryMock) Create(u *entity.MultipartUpload) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(u)
+	}
+	return nil
+}
+
+func (m *MultipartRepositoryMock) Get(id string) (*entity.MultipartUpload, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *MultipartRepositoryMock) PutPart(p *entity.MultipartPart) (*entity.MultipartPart, error) {
+	if m.PutPartFunc != nil {
+		return m.PutPartFunc(p)
+	}
+	return nil, nil
+}
+
+func (m *MultipartRepositoryMock) ListParts(uploadID string) ([]entity.MultipartPart, error) {
+	if m.ListPartsFunc != nil {
+		return m.ListPartsFunc(uploadID)
+	}
+	return nil, nil
+}
+
+func (m *MultipartRepositoryMock) Delete(id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
+
+// -- SSHKeyRepositoryMock --
+
+// SSHKeyRepositoryMock is a test double for repository.SSHKeyRepository.
+type SSHKeyRepositoryMock struct {
+	CreateFunc           func(k *entity.SSHKey) error
+	GetByIDFunc          func(id int64) (*entity.SSHKey, error)
+	GetByFingerprintFunc func(userID int64, fingerprint string) (*entity.SSHKey, error)
+	ListByUserFunc       func(userID int64) ([]entity.SSHKey, error)
+	MarkUsedFunc         func(id int64, ts int64) error
+	DeleteFunc           func(id int64) error
+}
+
+func (m *SSHKeyRepositoryMock) Create(k *entity.SSHKey) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(k)
+	}
+	k.ID = 1
+	return nil
+}
+
+func (m *SSHKeyRepositoryMock) GetByID(id int64) (*entity.SSHKey, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	return nil, nil
+}
+
+func (m *SSHKeyRepositoryMock) GetByFingerprint(userID int64, fingerprint string) (*entity.SSHKey, error) {
+	if m.GetByFingerprintFunc != nil {
+		return m.GetByFingerprintFunc(userID, fingerprint)
+	}
+	return nil, nil
+}
+
+func (m *SSHKeyRepositoryMock) ListByUser(userID int64) ([]entity.SSHKey, error) {
+	if m.ListByUserFunc != nil {
+		return m.ListByUserFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *SSHKeyRepositoryMock) MarkUsed(id int64, ts int64) error {
+	if m.MarkUsedFunc != nil {
+		return m.MarkUsedFunc(id, ts)
+	}
+	return nil
+}
+
+func (m *SSHKeyRepositoryMock) Delete(id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(id)
+	}
+	return nil
+}
+
+// -- ChangeRepositoryMock --
+
+// ChangeRepositoryMock is a test double for repository.ChangeRepository.
+type ChangeRepositoryMock struct {
+	InsertFunc    func(change *entity.Change) (int64, error)
+	ListAfterFunc func(userID int64, cursor int64, limit int) ([]entity.Change, error)
+	LatestFunc    func(userID int64) (int64, error)
+	HorizonFunc   func(userID int64) (int64, error)
+	PruneFunc     func(before int64) error
+}
+
+func (m *ChangeRepositoryMock) Insert(change *entity.Change) (int64, error) {
+	if m.InsertFunc != nil {
+		return m.InsertFunc(change)
+	}
+	return 1, nil
+}
+
+func (m *ChangeRepositoryMock) ListAfter(userID int64, cursor int64, limit int) ([]entity.Change, error) {
+	if m.ListAfterFunc != nil {
+		return m.ListAfterFunc(userID, cursor, limit)
+	}
+	return nil, nil
+}
+
+func (m *ChangeRepositoryMock) Latest(userID int64) (int64, error) {
+	if m.LatestFunc != nil {
+		return m.LatestFunc(userID)
+	}
+	return 0, nil
+}
+
+func (m *ChangeRepositoryMock) Horizon(userID int64) (int64, error) {
+	if m.HorizonFunc != nil {
+		return m.HorizonFunc(userID)
+	}
+	return 0, nil
+}
+
+func (m *ChangeRepositoryMock) Prune(before int64) error {
+	if m.PruneFunc != nil {
+		return m.PruneFunc(before)
+	}
+	return nil
+}
+
+// -- NodeSearchRepositoryMock --
+
+// NodeSearchRepositoryMock is a test double for repository.NodeSearchRepository.
+type NodeSearchRepositoryMock struct {
+	SearchFunc        func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error)
+	SearchContentFunc func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error)
+}
+
+func (m *NodeSearchRepositoryMock) Search(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+	if m.SearchFunc != nil {
+		return m.SearchFunc(userID, query, limit)
+	}
+	return nil, nil
+}
+
+func (m *NodeSearchRepositoryMock) SearchContent(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+	if m.SearchContentFunc != nil {
+		return m.SearchContentFunc(userID, query, limit)
+	}
+	return nil, nil
+}
+
+// -- ContentIndexRepositoryMock --
+
+// ContentIndexRepositoryMock is a test double for repository.ContentIndexRepository.
+type ContentIndexRepositoryMock struct {
+	ListUnindexedFunc func(limit int) ([]repository.UnindexedContent, error)
+	StoreFunc         func(hash vo.ContentHash, text string) error
+	PruneFunc         func() (int, error)
+}
+
+func (m *ContentIndexRepositoryMock) ListUnindexed(limit int) ([]repository.UnindexedContent, error) {
+	if m.ListUnindexedFunc != nil {
+		return m.ListUnindexedFunc(limit)
+	}
+	return nil, nil
+}
+
+func (m *ContentIndexRepositoryMock) Store(hash vo.ContentHash, text string) error {
+	if m.StoreFunc != nil {
+		return m.StoreFunc(hash, text)
+	}
+	return nil
+}
+
+func (m *ContentIndexRepositoryMock) Prune() (int, error) {
+	if m.PruneFunc != nil {
+		return m.PruneFunc()
+	}
+	return 0, nil
+}
+
+// -- XAttrRepositoryMock --
+
+// XAttrRepositoryMock is a test double for repository.XAttrRepository.
+type XAttrRepositoryMock struct {
+	ListFunc   func(nodeID int64) ([]entity.XAttr, error)
+	GetFunc    func(nodeID int64, name vo.XAttrName) (*entity.XAttr, error)
+	SetFunc    func(nodeID int64, name vo.XAttrName, value string) error
+	DeleteFunc func(nodeID int64, name vo.XAttrName) (bool, error)
+	CopyFunc   func(srcNodeID, dstNodeID int64) error
+}
+
+func (m *XAttrRepositoryMock) List(nodeID int64) ([]entity.XAttr, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(nodeID)
+	}
+	return nil, nil
+}
+
+func (m *XAttrRepositoryMock) Get(nodeID int64, name vo.XAttrName) (*entity.XAttr, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(nodeID, name)
+	}
+	return nil, nil
+}
+
+func (m *XAttrRepositoryMock) Set(nodeID int64, name vo.XAttrName, value string) error {
+	if m.SetFunc != nil {
+		return m.SetFunc(nodeID, name, value)
+	}
+	return nil
+}
+
+func (m *XAttrRepositoryMock) Delete(nodeID int64, name vo.XAttrName) (bool, error) {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(nodeID, name)
+	}
+	return false, nil
+}
+
+func (m *XAttrRepositoryMock) Copy(srcNodeID, dstNodeID int64) error {
+	if m.CopyFunc != nil {
+		return m.CopyFunc(srcNodeID, dstNodeID)
+	}
+	return nil
+}
+
+// -- MediaRepositoryMock --
+
+// MediaRepositoryMock is a test double for repository.MediaRepository.
+type MediaRepositoryMock struct {
+	ListUnscannedFunc func(limit int) ([]repository.UnindexedContent, error)
+	StoreFunc         func(hash vo.ContentHash, info *entity.MediaInfo) error
+	GetFunc           func(hash vo.ContentHash) (*entity.MediaInfo, error)
+	TimelineFunc      func(userID int64, offset, limit int) ([]repository.Photo, error)
+	PruneFunc         func() (int, error)
+}
+
+func (m *MediaRepositoryMock) ListUnscanned(limit int) ([]repository.UnindexedContent, error) {
+	if m.ListUnscannedFunc != nil {
+		return m.ListUnscannedFunc(limit)
+	}
+	return nil, nil
+}
+
+func (m *MediaRepositoryMock) Store(hash vo.ContentHash, info *entity.MediaInfo) error {
+	if m.StoreFunc != nil {
+		return m.StoreFunc(hash, info)
+	}
+	return nil
+}
+
+func (m *MediaRepositoryMock) Get(hash vo.ContentHash) (*entity.MediaInfo, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(hash)
+	}
+	return nil, nil
+}
+
+func (m *MediaRepositoryMock) Timeline(userID int64, offset, limit int) ([]repository.Photo, error) {
+	if m.TimelineFunc != nil {
+		return m.TimelineFunc(userID, offset, limit)
+	}
+	return nil, nil
+}
+
+func (m *MediaRepositoryMock) Prune() (int, error) {
+	if m.PruneFunc != nil {
+		return m.PruneFunc()
+	}
+	return 0, nil
+}
@@ -1,19 +1,142 @@
 package httpapi
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
-// authenticate validates the access_token query parameter and returns the authenticated user.
+// webSessionCookie names the cookie holding the session token of the web file browser.
+const webSessionCookie = "tucha_session"
+
+// csrfHeader carries the session's CSRF token on requests authenticated by the web session cookie.
+const csrfHeader = "X-CSRF-Token"
+
+// requestToken returns the token from an "Authorization: Bearer" header,
+// falling back to the given query parameter used by legacy clients.
+func requestToken(r *http.Request, param string) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+	return r.URL.Query().Get(param)
+}
+
+// authenticate validates the bearer token (or the access_token query parameter)
+// and returns the authenticated user.
 // If authentication fails, it writes a 403 error response and returns nil.
 // Callers should return immediately when nil is returned.
 func authenticate(w http.ResponseWriter, r *http.Request, auth *service.AuthService) *service.AuthenticatedUser {
-	authed, err := auth.Validate(r.URL.Query().Get("access_token"))
+	authed, err := validateRequest(r, auth, "access_token")
 	if err != nil || authed == nil {
 		writeAuthError(w)
 		return nil
 	}
 	return authed
 }
+
+// validateRequest resolves the caller from the bearer token or the given query parameter.
+// Without either, the web session cookie is accepted when the request also carries
+// the session's CSRF token, so that other sites cannot act on the user's behalf.
+// Returns nil, nil if no valid credentials are present.
+func validateRequest(r *http.Request, auth *service.AuthService, param string) (*service.AuthenticatedUser, error) {
+	if token := requestToken(r, param); token != "" {
+		return auth.Validate(token)
+	}
+
+	authed, err := webSession(r, auth)
+	if err != nil || authed == nil {
+		return nil, err
+	}
+	csrf := r.Header.Get(csrfHeader)
+	if authed.CSRFToken == "" || subtle.ConstantTimeCompare([]byte(csrf), []byte(authed.CSRFToken)) != 1 {
+		return nil, nil
+	}
+	return authed, nil
+}
+
+// webSession resolves the caller from the web session cookie alone.
+// Only safe for requests that do not modify anything: the cookie is SameSite=Strict,
+// but the request carries no CSRF token.
+func webSession(r *http.Request, auth *service.AuthService) (*service.AuthenticatedUser, error) {
+	cookie, err := r.Cookie(webSessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+	return auth.Validate(cookie.Value)
+}
+
+// authenticateContent authenticates a /get/ or /thumb/ request for path.
+// A request carrying a signature is checked as a signed URL; otherwise the
+// bearer token (or the token query parameter) is validated.
+// Returns nil if authentication fails.
+func authenticateContent(r *http.Request, auth *service.AuthService, signer *service.URLSigner, path vo.CloudPath) *service.AuthenticatedUser {
+	q := r.URL.Query()
+	if value := q.Get("signature"); value != "" {
+		userID, err := strconv.ParseInt(q.Get("uid"), 10, 64)
+		if err != nil {
+			return nil
+		}
+		expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+		if err != nil {
+			return nil
+		}
+		if signer.Verify(path, service.Signature{UserID: userID, Expires: expires, Value: value}) != nil {
+			return nil
+		}
+		authed, err := auth.ResolveUser(userID)
+		if err != nil {
+			return nil
+		}
+		return authed
+	}
+
+	authed, err := auth.Validate(requestToken(r, "token"))
+	if err != nil {
+		return nil
+	}
+	return authed
+}
+
+// isSigned reports whether the request uses a signed URL rather than a token.
+func isSigned(r *http.Request) bool {
+	return r.URL.Query().Get("signature") != ""
+}
+
+// signedQuery encodes a signature as URL query parameters.
+func signedQuery(sig service.Signature) string {
+	return url.Values{
+		"uid":       {strconv.FormatInt(sig.UserID, 10)},
+		"expires":   {strconv.FormatInt(sig.Expires, 10)},
+		"signature": {sig.Value},
+	}.Encode()
+}
+
+// authenticateSession authenticates the caller and rejects personal tokens,
+// so that a scoped token cannot be used to mint broader credentials.
+func authenticateSession(w http.ResponseWriter, r *http.Request, auth *service.AuthService) *service.AuthenticatedUser {
+	authed := authenticate(w, r, auth)
+	if authed == nil {
+		return nil
+	}
+	if authed.Personal {
+		writeHomeError(w, authed.Email, 403, "forbidden")
+		return nil
+	}
+	return authed
+}
+
+// authorize checks that the token grants scope on every given path.
+// If it does not, it writes a 403 error response and returns false.
+// Callers should return immediately when false is returned.
+func authorize(w http.ResponseWriter, authed *service.AuthenticatedUser, scope vo.TokenScope, paths ...vo.CloudPath) bool {
+	if authed.Allows(scope, paths...) {
+		return true
+	}
+	writeHomeError(w, authed.Email, 403, "forbidden")
+	return false
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pozitronik/tucha/internal/application/service"
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 	"github.com/pozitronik/tucha/internal/testutil/mock"
 )
 
@@ -160,3 +161,111 @@ func TestAuthenticate(t *testing.T) {
 		}
 	})
 }
+
+func TestAuthorize(t *testing.T) {
+	authed := &service.AuthenticatedUser{
+		UserID:     1,
+		Email:      "user@example.com",
+		Personal:   true,
+		Scopes:     []vo.TokenScope{vo.ScopeWrite},
+		PathPrefix: vo.NewCloudPath("/backups"),
+	}
+
+	t.Run("allows path within prefix", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if !authorize(w, authed, vo.ScopeWrite, vo.NewCloudPath("/backups/db.sql")) {
+			t.Error("authorize() = false, want true")
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("unexpected response body: %s", w.Body.String())
+		}
+	})
+
+	t.Run("rejects listing root with 403", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if authorize(w, authed, vo.ScopeRead, vo.NewCloudPath("/")) {
+			t.Fatal("authorize() = true, want false")
+		}
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Response status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+}
+
+func TestRequestToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   string
+	}{
+		{"bearer header", "/x", "Bearer header-token", "header-token"},
+		{"header wins over query", "/x?access_token=query-token", "Bearer header-token", "header-token"},
+		{"query fallback", "/x?access_token=query-token", "", "query-token"},
+		{"non-bearer header ignored", "/x?access_token=query-token", "Basic abc", "query-token"},
+		{"none", "/x", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if got := requestToken(req, "access_token"); got != tt.want {
+				t.Errorf("requestToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateContent(t *testing.T) {
+	testUser := mock.NewTestUser(1, "user@example.com")
+	authSvc := service.NewAuthService(
+		&mock.TokenRepositoryMock{
+			LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+				if accessToken == "valid-token" {
+					return mock.NewTestToken(1, time.Now().Add(time.Hour)), nil
+				}
+				return nil, nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				if id == testUser.ID {
+					return testUser, nil
+				}
+				return nil, nil
+			},
+		},
+	)
+	signer := service.NewURLSigner([]byte("key"), time.Minute)
+	path := vo.NewCloudPath("/docs/a.txt")
+	signed := signedQuery(signer.Sign(1, path))
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		path   vo.CloudPath
+		want   bool
+	}{
+		{"token query", "/get/docs/a.txt?token=valid-token", "", path, true},
+		{"bearer header", "/get/docs/a.txt", "Bearer valid-token", path, true},
+		{"signed URL", "/get/docs/a.txt?" + signed, "", path, true},
+		{"signed URL for other path", "/get/docs/b.txt?" + signed, "", vo.NewCloudPath("/docs/b.txt"), false},
+		{"invalid token", "/get/docs/a.txt?token=bad", "", path, false},
+		{"no credentials", "/get/docs/a.txt", "", path, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			authed := authenticateContent(req, authSvc, signer, tt.path)
+			if (authed != nil) != tt.want {
+				t.Errorf("authenticateContent() = %v, want authenticated=%v", authed, tt.want)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ type FolderItem struct {
 	Tree      string       `json:"tree,omitempty"`
 	Count     *FolderCount `json:"count,omitempty"`
 	VirusScan string       `json:"virus_scan,omitempty"`
+	Starred   bool         `json:"starred,omitempty"`
+	Tags      []string     `json:"tags,omitempty"`
 }
 
 // FolderListing represents the response body for a folder listing.
@@ -60,6 +62,96 @@ type SpaceInfo struct {
 	Overquota  bool  `json:"overquota"`
 	BytesTotal int64 `json:"bytes_total"`
 	BytesUsed  int64 `json:"bytes_used"`
+	// BytesVersions is the storage held by file version history, not counted in BytesUsed.
+	BytesVersions int64 `json:"bytes_versions"`
+	// BytesTrash is the size of the trash, counted in BytesUsed only if the server counts trash toward the quota.
+	BytesTrash int64 `json:"bytes_trash"`
+}
+
+// JobStatus represents the progress of a background job.
+type JobStatus struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	State    string `json:"state"`
+	Target   string `json:"target"`
+	Total    int    `json:"total"`
+	Done     int    `json:"done"`
+	Bytes    int64  `json:"bytes"`
+	Error    string `json:"error,omitempty"`
+	Started  int64  `json:"started"`
+	Finished int64  `json:"finished,omitempty"`
+}
+
+// ChangeItem represents one entry of the change feed.
+type ChangeItem struct {
+	Cursor int64  `json:"cursor"`
+	Kind   string `json:"kind"`
+	Home   string `json:"home"`
+	From   string `json:"from,omitempty"`
+	Type   string `json:"type"`
+	Size   int64  `json:"size"`
+	Hash   string `json:"hash,omitempty"`
+	Time   int64  `json:"time"`
+}
+
+// ChangeListing represents one page of the change feed.
+type ChangeListing struct {
+	Cursor  int64        `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+	Changes []ChangeItem `json:"changes"`
+}
+
+// SearchResult represents one page of search results.
+type SearchResult struct {
+	HasMore bool         `json:"has_more"`
+	List    []SearchItem `json:"list"`
+}
+
+// SearchItem represents a search result: the node and, for content search,
+// the matching text with the matches in <mark> tags.
+type SearchItem struct {
+	FolderItem
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// MediaItem represents the metadata of a photo. Width and height are as
+// displayed; taken is the capture time shown by the camera clock, in Unix
+// seconds read as UTC.
+type MediaItem struct {
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	Orientation int      `json:"orientation,omitempty"`
+	Taken       int64    `json:"taken,omitempty"`
+	Make        string   `json:"make,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+}
+
+// TimelineResult represents one page of the photo timeline.
+type TimelineResult struct {
+	HasMore bool          `json:"has_more"`
+	Days    []TimelineDay `json:"days"`
+}
+
+// TimelineDay represents the photos of one day of the timeline.
+type TimelineDay struct {
+	Date string      `json:"date"`
+	List []PhotoItem `json:"list"`
+}
+
+// PhotoItem represents a photo in the timeline: the file, its metadata and
+// a signed URL of its thumbnail.
+type PhotoItem struct {
+	FolderItem
+	Media     MediaItem `json:"media"`
+	Thumbnail string    `json:"thumbnail"`
+}
+
+// XAttrItem represents an extended attribute of a node.
+type XAttrItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 // TrashFolderItem represents a trashed item in the trashbin listing response.
@@ -105,7 +197,41 @@ type UserInfo struct {
 	BytesUsed      int64  `json:"bytes_used"`
 	FileSizeLimit  int64  `json:"file_size_limit"`
 	VersionHistory bool   `json:"version_history"`
-	Created        int64  `json:"created"`
+	// VersionRetention is the user's own retention policy, empty for the server default.
+	VersionRetention string `json:"version_retention"`
+	// TrashRetentionDays is the user's own trash retention period, null for the server default.
+	TrashRetentionDays *int  `json:"trash_retention_days"`
+	TwoFactor          bool  `json:"two_factor"`
+	Created            int64 `json:"created"`
+}
+
+// PersonalTokenInfo represents a personal access token in API responses.
+// AccessToken is only populated in the response that creates the token.
+type PersonalTokenInfo struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Scopes       string `json:"scopes"`
+	Path         string `json:"path"`
+	ExpiresAt    int64  `json:"expires_at"`
+	Created      int64  `json:"created"`
+	AccessToken  string `json:"access_token,omitempty"`
+	Impersonator string `json:"impersonator,omitempty"` // set on admin support tokens
+	Elevated     bool   `json:"elevated,omitempty"`
+}
+
+// SessionInfo represents an OAuth session token in admin API responses.
+type SessionInfo struct {
+	ID        int64  `json:"id"`
+	ClientID  string `json:"client_id"`
+	ExpiresAt int64  `json:"expires_at"`
+	Created   int64  `json:"created"`
+}
+
+// ClientSessions groups a user's sessions by the client that issued them.
+type ClientSessions struct {
+	ClientID   string        `json:"client_id"`
+	Registered bool          `json:"registered"`
+	Sessions   []SessionInfo `json:"sessions"`
 }
 
 // FileVersionItem represents a single entry in a file version history response.
@@ -117,3 +243,54 @@ type FileVersionItem struct {
 	Rev  int64  `json:"rev,omitempty"`
 	Time int64  `json:"time"`
 }
+
+// TwoFactorStatus represents the caller's two-factor authentication state.
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Pending           bool `json:"pending"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollment carries the secret for registering an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// AppPasswordInfo represents an app password in API responses.
+// Password is only populated in the response that creates it.
+type AppPasswordInfo struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Created  int64  `json:"created"`
+	LastUsed int64  `json:"last_used"`
+	Password string `json:"password,omitempty"`
+}
+
+// AccessKeyInfo represents an S3 access key in API responses.
+// Secret is only populated in the response that creates the key.
+type AccessKeyInfo struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	KeyID    string `json:"access_key_id"`
+	Created  int64  `json:"created"`
+	LastUsed int64  `json:"last_used"`
+	Secret   string `json:"secret_access_key,omitempty"`
+}
+
+// SSHKeyInfo represents an authorized SFTP public key in API responses.
+type SSHKeyInfo struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	PublicKey   string `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+	Created     int64  `json:"created"`
+	LastUsed    int64  `json:"last_used"`
+}
+
+// SignedURLs holds short-lived signed content URLs issued by the dispatcher.
+type SignedURLs struct {
+	Get       string `json:"get"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Expires   int64  `json:"expires"`
+}
@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// AccessKeyHandler manages the caller's S3 access keys at /api/v2/user/access-keys*.
+type AccessKeyHandler struct {
+	auth *service.AuthService
+	keys *service.AccessKeyService
+}
+
+// NewAccessKeyHandler creates a new AccessKeyHandler.
+func NewAccessKeyHandler(auth *service.AuthService, keys *service.AccessKeyService) *AccessKeyHandler {
+	return &AccessKeyHandler{auth: auth, keys: keys}
+}
+
+// HandleList handles GET /api/v2/user/access-keys - list the caller's access keys.
+func (h *AccessKeyHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	keys, err := h.keys.List(authed.UserID)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	infos := make([]AccessKeyInfo, 0, len(keys))
+	for i := range keys {
+		info := newAccessKeyInfo(&keys[i])
+		info.Secret = ""
+		infos = append(infos, info)
+	}
+	writeSuccess(w, authed.Email, infos)
+}
+
+// HandleAdd handles POST /api/v2/user/access-keys/add - issue an access key.
+// The secret is returned only in this response.
+func (h *AccessKeyHandler) HandleAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	name := r.FormValue("name")
+	if name == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	key, err := h.keys.Create(authed.UserID, name)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, newAccessKeyInfo(key))
+}
+
+// HandleRemove handles POST /api/v2/user/access-keys/remove - revoke an access key.
+func (h *AccessKeyHandler) HandleRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	if err := h.keys.Revoke(authed.UserID, id); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, "ok")
+}
+
+// newAccessKeyInfo converts an access key to a DTO, including its secret.
+func newAccessKeyInfo(k *entity.AccessKey) AccessKeyInfo {
+	return AccessKeyInfo{
+		ID:       k.ID,
+		Name:     k.Name,
+		KeyID:    k.KeyID,
+		Created:  k.Created,
+		LastUsed: k.LastUsed,
+		Secret:   k.Secret,
+	}
+}
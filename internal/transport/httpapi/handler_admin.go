@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	_ "embed"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -45,7 +46,21 @@ func (h *AdminHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	login := r.FormValue("login")
 	password := r.FormValue("password")
 
-	token, err := h.adminAuth.Login(login, password)
+	token, err := h.adminAuth.Login(login, password, r.FormValue("otp"))
+	if errors.Is(err, service.ErrSecondFactorRequired) {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"status": 403,
+			"body":   "otp_required",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrTooManyAttempts) {
+		writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+			"status": 429,
+			"body":   "too_many_attempts",
+		})
+		return
+	}
 	if err != nil {
 		writeJSON(w, http.StatusForbidden, map[string]interface{}{
 			"status": 403,
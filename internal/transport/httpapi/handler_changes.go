@@ -0,0 +1,251 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+const (
+	// defaultChangeLimit is the page size of the change feed when none is given.
+	defaultChangeLimit = 500
+
+	// maxChangeLimit caps the page size of the change feed.
+	maxChangeLimit = 5000
+
+	// eventHeartbeat is how often an idle event stream sends a comment line,
+	// so that clients and proxies can tell it is still alive.
+	eventHeartbeat = 30 * time.Second
+)
+
+// ChangeHandler serves the change feed used for incremental sync, and its
+// live counterpart as a stream of server-sent events.
+type ChangeHandler struct {
+	auth      *service.AuthService
+	changes   *service.ChangeService
+	heartbeat time.Duration
+}
+
+// NewChangeHandler creates a new ChangeHandler.
+func NewChangeHandler(auth *service.AuthService, changes *service.ChangeService) *ChangeHandler {
+	return &ChangeHandler{
+		auth:      auth,
+		changes:   changes,
+		heartbeat: eventHeartbeat,
+	}
+}
+
+// HandleChanges handles GET /api/v2/changes - changes made after a cursor.
+// Without a cursor it returns the current cursor and no changes, so a client
+// that has just listed its tree can follow changes from that point.
+// An expired cursor is answered with 410 and the client must list its tree again.
+func (h *ChangeHandler) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
+
+	q := r.URL.Query()
+	limit := defaultChangeLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, authed.Email, 400, "limit", "invalid")
+			return
+		}
+		limit = min(n, maxChangeLimit)
+	}
+
+	if q.Get("cursor") == "" {
+		latest, err := h.changes.Latest(authed.UserID)
+		if err != nil {
+			writeHomeError(w, authed.Email, 500, "unknown")
+			return
+		}
+		writeSuccess(w, authed.Email, ChangeListing{Cursor: latest, Changes: []ChangeItem{}})
+		return
+	}
+
+	cursor, err := strconv.ParseInt(q.Get("cursor"), 10, 64)
+	if err != nil || cursor < 0 {
+		writeError(w, authed.Email, 400, "cursor", "invalid")
+		return
+	}
+
+	page, err := h.changes.List(authed.UserID, cursor, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrCursorExpired) {
+			writeError(w, authed.Email, 410, "cursor", "expired")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	listing := ChangeListing{
+		Cursor:  page.Cursor,
+		HasMore: page.HasMore,
+		Changes: make([]ChangeItem, 0, len(page.Changes)),
+	}
+	for _, c := range page.Changes {
+		if changeVisible(authed, c) {
+			listing.Changes = append(listing.Changes, toChangeItem(c))
+		}
+	}
+
+	writeSuccess(w, authed.Email, listing)
+}
+
+// HandleEvents handles GET /api/v2/changes/events - a stream of server-sent
+// events, one "change" event per change as it is recorded. Each event ID is the
+// change's cursor. A client that reconnects with a Last-Event-ID header (or a
+// cursor parameter) first receives the changes it missed. A new stream without
+// one starts with a "ready" event carrying the current cursor.
+// An expired cursor is answered with 410 before the stream starts.
+func (h *ChangeHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
+
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("cursor")
+	}
+	cursor := int64(-1)
+	if last != "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			writeError(w, authed.Email, 400, "cursor", "invalid")
+			return
+		}
+		cursor = n
+	}
+
+	// Subscribe before reading the journal, so that no change recorded in
+	// between is lost. Changes already sent from the journal are skipped.
+	sub := h.changes.Subscribe(authed.UserID)
+	defer sub.Close()
+
+	var page *service.ChangePage
+	if cursor >= 0 {
+		var err error
+		page, err = h.changes.List(authed.UserID, cursor, maxChangeLimit)
+		if err != nil {
+			if errors.Is(err, service.ErrCursorExpired) {
+				writeError(w, authed.Email, 410, "cursor", "expired")
+				return
+			}
+			writeHomeError(w, authed.Email, 500, "unknown")
+			return
+		}
+	} else {
+		latest, err := h.changes.Latest(authed.UserID)
+		if err != nil {
+			writeHomeError(w, authed.Email, 500, "unknown")
+			return
+		}
+		cursor = latest
+	}
+
+	// The stream outlives the server's write timeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if page == nil {
+		fmt.Fprintf(w, "id: %d\nevent: ready\ndata: {\"cursor\":%d}\n\n", cursor, cursor)
+	}
+	for page != nil {
+		for _, c := range page.Changes {
+			writeChangeEvent(w, authed, c)
+		}
+		cursor = page.Cursor
+		if !page.HasMore {
+			break
+		}
+		var err error
+		if page, err = h.changes.List(authed.UserID, cursor, maxChangeLimit); err != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case c, ok := <-sub.C:
+			if !ok {
+				// Fell behind; the client reconnects and resumes from its last event.
+				return
+			}
+			if c.ID <= cursor {
+				continue
+			}
+			cursor = c.ID
+			writeChangeEvent(w, authed, c)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeChangeEvent writes a change as a server-sent event, unless the token may not see it.
+func writeChangeEvent(w http.ResponseWriter, authed *service.AuthenticatedUser, c entity.Change) {
+	if !changeVisible(authed, c) {
+		return
+	}
+	data, _ := json.Marshal(toChangeItem(c))
+	fmt.Fprintf(w, "id: %d\nevent: change\ndata: %s\n\n", c.ID, data)
+}
+
+// changeVisible reports whether the token may see a change. Tokens limited to
+// a path prefix only see changes under it.
+func changeVisible(authed *service.AuthenticatedUser, c entity.Change) bool {
+	if authed.Allows(vo.ScopeRead, c.Home) {
+		return true
+	}
+	return c.From != "" && authed.Allows(vo.ScopeRead, vo.NewCloudPath(c.From))
+}
+
+// toChangeItem converts a journal entry to its API representation.
+func toChangeItem(c entity.Change) ChangeItem {
+	item := ChangeItem{
+		Cursor: c.ID,
+		Kind:   c.Kind.String(),
+		Home:   c.Home.String(),
+		From:   c.From,
+		Type:   c.Type.String(),
+		Size:   c.Size,
+		Time:   c.Time,
+	}
+	if !c.Hash.IsZero() {
+		item.Hash = c.Hash.String()
+	}
+	return item
+}
@@ -0,0 +1,215 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newTestChangeHandler(token *entity.Token) *ChangeHandler {
+	user := mock.NewTestUser(1, "user@example.com")
+	tokens := &mock.TokenRepositoryMock{
+		LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+			if accessToken == token.AccessToken {
+				return token, nil
+			}
+			return nil, nil
+		},
+	}
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) { return user, nil },
+	}
+	changes := &mock.ChangeRepositoryMock{
+		LatestFunc:  func(userID int64) (int64, error) { return 42, nil },
+		HorizonFunc: func(userID int64) (int64, error) { return 10, nil },
+		ListAfterFunc: func(userID int64, cursor int64, limit int) ([]entity.Change, error) {
+			return []entity.Change{
+				{ID: 20, Kind: vo.ChangeMove, Home: vo.NewCloudPath("/docs/a.txt"), From: "/a.txt", Type: vo.NodeTypeFile, Hash: mock.ValidHash()},
+				{ID: 21, Kind: vo.ChangeCreate, Home: vo.NewCloudPath("/photos"), Type: vo.NodeTypeFolder},
+			}, nil
+		},
+	}
+	return NewChangeHandler(service.NewAuthService(tokens, users), service.NewChangeService(changes))
+}
+
+func getChanges(h *ChangeHandler, query string) (*httptest.ResponseRecorder, ChangeListing) {
+	w := httptest.NewRecorder()
+	h.HandleChanges(w, httptest.NewRequest(http.MethodGet, "/api/v2/changes?access_token=access-token-123"+query, nil))
+
+	var resp struct {
+		Body ChangeListing `json:"body"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	return w, resp.Body
+}
+
+func TestChangeHandler_HandleChanges(t *testing.T) {
+	token := mock.NewTestToken(1, time.Now().Add(time.Hour))
+
+	t.Run("without cursor returns the latest one", func(t *testing.T) {
+		w, listing := getChanges(newTestChangeHandler(token), "")
+
+		if w.Code != http.StatusOK || listing.Cursor != 42 || len(listing.Changes) != 0 {
+			t.Errorf("got %d %+v, want cursor 42 without changes", w.Code, listing)
+		}
+	})
+
+	t.Run("lists changes after the cursor", func(t *testing.T) {
+		w, listing := getChanges(newTestChangeHandler(token), "&cursor=15")
+
+		if w.Code != http.StatusOK || listing.Cursor != 21 || len(listing.Changes) != 2 {
+			t.Fatalf("got %d %+v, want two changes up to 21", w.Code, listing)
+		}
+		if c := listing.Changes[0]; c.Kind != "move" || c.From != "/a.txt" || c.Hash == "" {
+			t.Errorf("change = %+v, want move from /a.txt", c)
+		}
+	})
+
+	t.Run("expired cursor", func(t *testing.T) {
+		w, _ := getChanges(newTestChangeHandler(token), "&cursor=5")
+
+		if w.Code != http.StatusGone {
+			t.Errorf("status = %d, want 410", w.Code)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		w, _ := getChanges(newTestChangeHandler(token), "&cursor=abc")
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("path-limited token sees changes under its prefix", func(t *testing.T) {
+		limited := mock.NewTestToken(1, time.Now().Add(time.Hour))
+		limited.Personal = true
+		limited.Scopes = []vo.TokenScope{vo.ScopeRead}
+		limited.PathPrefix = vo.NewCloudPath("/docs")
+
+		_, listing := getChanges(newTestChangeHandler(limited), "&cursor=15")
+
+		if len(listing.Changes) != 1 || listing.Changes[0].Home != "/docs/a.txt" {
+			t.Errorf("changes = %+v, want only /docs/a.txt", listing.Changes)
+		}
+		if listing.Cursor != 21 {
+			t.Errorf("cursor = %d, want 21 even when changes are filtered", listing.Cursor)
+		}
+	})
+}
+
+// readEvent reads the next server-sent event, skipping comments, and returns its fields.
+func readEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	event := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(event) > 0 {
+				return event
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		event[field] = value
+	}
+}
+
+func TestChangeHandler_HandleEvents(t *testing.T) {
+	token := mock.NewTestToken(1, time.Now().Add(time.Hour))
+
+	openStream := func(t *testing.T, h *ChangeHandler, lastEventID string) *http.Response {
+		t.Helper()
+		srv := httptest.NewServer(http.HandlerFunc(h.HandleEvents))
+		t.Cleanup(srv.Close)
+
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v2/changes/events?access_token=access-token-123", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("delivers recorded changes", func(t *testing.T) {
+		h := newTestChangeHandler(token)
+		h.changes = service.NewChangeService(&mock.ChangeRepositoryMock{
+			LatestFunc: func(userID int64) (int64, error) { return 42, nil },
+			InsertFunc: func(change *entity.Change) (int64, error) { return 43, nil },
+		})
+		resp := openStream(t, h, "")
+		if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+			t.Fatalf("got %d %s, want an event stream", resp.StatusCode, ct)
+		}
+		r := bufio.NewReader(resp.Body)
+
+		if ev := readEvent(t, r); ev["event"] != "ready" || ev["id"] != "42" {
+			t.Fatalf("first event = %v, want ready at 42", ev)
+		}
+
+		h.changes.RecordFolder(1, vo.ChangeCreate, vo.NewCloudPath("/new"))
+
+		ev := readEvent(t, r)
+		var item ChangeItem
+		_ = json.Unmarshal([]byte(ev["data"]), &item)
+		if ev["event"] != "change" || ev["id"] != "43" || item.Kind != "create" || item.Home != "/new" {
+			t.Errorf("event = %v, want creation of /new at 43", ev)
+		}
+	})
+
+	t.Run("resumes after the last event", func(t *testing.T) {
+		r := bufio.NewReader(openStream(t, newTestChangeHandler(token), "15").Body)
+
+		if ev := readEvent(t, r); ev["id"] != "20" || ev["event"] != "change" {
+			t.Errorf("first event = %v, want change 20", ev)
+		}
+		if ev := readEvent(t, r); ev["id"] != "21" {
+			t.Errorf("second event = %v, want change 21", ev)
+		}
+	})
+
+	t.Run("sends heartbeats", func(t *testing.T) {
+		h := newTestChangeHandler(token)
+		h.heartbeat = 10 * time.Millisecond
+		r := bufio.NewReader(openStream(t, h, "").Body)
+
+		readEvent(t, r)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading: %v", err)
+			}
+			if line == ": heartbeat\n" {
+				return
+			}
+		}
+	})
+
+	t.Run("expired cursor", func(t *testing.T) {
+		resp := openStream(t, newTestChangeHandler(token), "5")
+
+		if resp.StatusCode != http.StatusGone {
+			t.Errorf("status = %d, want 410", resp.StatusCode)
+		}
+	})
+}
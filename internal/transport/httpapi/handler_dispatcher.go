@@ -3,21 +3,25 @@ package httpapi
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
-// DispatchHandler handles shard endpoint discovery.
+// DispatchHandler handles shard endpoint discovery and signed URL generation.
 type DispatchHandler struct {
 	auth        *service.AuthService
+	signer      *service.URLSigner
 	externalURL string
 }
 
 // NewDispatchHandler creates a new DispatchHandler.
-func NewDispatchHandler(auth *service.AuthService, externalURL string) *DispatchHandler {
+func NewDispatchHandler(auth *service.AuthService, signer *service.URLSigner, externalURL string) *DispatchHandler {
 	return &DispatchHandler{
 		auth:        auth,
+		signer:      signer,
 		externalURL: strings.TrimRight(externalURL, "/"),
 	}
 }
@@ -50,7 +54,7 @@ func (h *DispatchHandler) HandleDispatcher(w http.ResponseWriter, r *http.Reques
 
 // HandleOAuthDispatcher handles GET /d and GET /u (OAuth dispatcher).
 func (h *DispatchHandler) HandleOAuthDispatcher(w http.ResponseWriter, r *http.Request) {
-	authed, err := h.auth.Validate(r.URL.Query().Get("token"))
+	authed, err := h.auth.Validate(requestToken(r, "token"))
 	if err != nil || authed == nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -72,3 +76,37 @@ func (h *DispatchHandler) HandleOAuthDispatcher(w http.ResponseWriter, r *http.R
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	fmt.Fprintf(w, "%s 127.0.0.1 1", shardURL)
 }
+
+// HandleSign handles GET /api/v2/dispatcher/sign?home=<path>[&preset=<preset>].
+// Returns short-lived signed URLs for downloading the file and, when a preset
+// is given, for its thumbnail. The URLs carry no access token.
+func (h *DispatchHandler) HandleSign(w http.ResponseWriter, r *http.Request) {
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	home := r.URL.Query().Get("home")
+	if home == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+	path := vo.NewCloudPath(home)
+	if !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
+
+	sig := h.signer.Sign(authed.UserID, path)
+	escaped := (&url.URL{Path: path.String()}).EscapedPath()
+	query := signedQuery(sig)
+
+	body := SignedURLs{
+		Get:     h.externalURL + "/get" + escaped + "?" + query,
+		Expires: sig.Expires,
+	}
+	if preset := r.URL.Query().Get("preset"); preset != "" {
+		body.Thumbnail = h.externalURL + "/thumb/" + url.PathEscape(preset) + escaped + "?" + query
+	}
+
+	writeSuccess(w, authed.Email, body)
+}
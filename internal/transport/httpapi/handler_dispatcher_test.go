@@ -15,14 +15,14 @@ import (
 
 func TestNewDispatchHandler(t *testing.T) {
 	t.Run("trims trailing slash from external URL", func(t *testing.T) {
-		handler := NewDispatchHandler(nil, "http://example.com/")
+		handler := NewDispatchHandler(nil, nil, "http://example.com/")
 		if handler.externalURL != "http://example.com" {
 			t.Errorf("externalURL = %q, want %q", handler.externalURL, "http://example.com")
 		}
 	})
 
 	t.Run("preserves URL without trailing slash", func(t *testing.T) {
-		handler := NewDispatchHandler(nil, "http://example.com")
+		handler := NewDispatchHandler(nil, nil, "http://example.com")
 		if handler.externalURL != "http://example.com" {
 			t.Errorf("externalURL = %q, want %q", handler.externalURL, "http://example.com")
 		}
@@ -52,7 +52,7 @@ func TestDispatchHandler_HandleDispatcher(t *testing.T) {
 
 	t.Run("returns shard URLs for authenticated user", func(t *testing.T) {
 		authSvc, _, testUser := setupAuth()
-		handler := NewDispatchHandler(authSvc, "http://localhost:8080")
+		handler := NewDispatchHandler(authSvc, nil, "http://localhost:8080")
 
 		req := httptest.NewRequest(http.MethodPost, "/api/v2/dispatcher/?access_token=valid-token", nil)
 		w := httptest.NewRecorder()
@@ -103,7 +103,7 @@ func TestDispatchHandler_HandleDispatcher(t *testing.T) {
 		tokenRepo := &mock.TokenRepositoryMock{}
 		userRepo := &mock.UserRepositoryMock{}
 		authSvc := service.NewAuthService(tokenRepo, userRepo)
-		handler := NewDispatchHandler(authSvc, "http://localhost:8080")
+		handler := NewDispatchHandler(authSvc, nil, "http://localhost:8080")
 
 		req := httptest.NewRequest(http.MethodPost, "/api/v2/dispatcher/", nil)
 		w := httptest.NewRecorder()
@@ -139,7 +139,7 @@ func TestDispatchHandler_HandleOAuthDispatcher(t *testing.T) {
 
 	t.Run("returns download shard for /d endpoint", func(t *testing.T) {
 		authSvc := setupAuth()
-		handler := NewDispatchHandler(authSvc, "http://localhost:8080")
+		handler := NewDispatchHandler(authSvc, nil, "http://localhost:8080")
 
 		req := httptest.NewRequest(http.MethodGet, "/d?token=valid-token", nil)
 		w := httptest.NewRecorder()
@@ -161,7 +161,7 @@ func TestDispatchHandler_HandleOAuthDispatcher(t *testing.T) {
 
 	t.Run("returns upload shard for /u endpoint", func(t *testing.T) {
 		authSvc := setupAuth()
-		handler := NewDispatchHandler(authSvc, "http://localhost:8080")
+		handler := NewDispatchHandler(authSvc, nil, "http://localhost:8080")
 
 		req := httptest.NewRequest(http.MethodGet, "/u?token=valid-token", nil)
 		w := httptest.NewRecorder()
@@ -182,7 +182,7 @@ func TestDispatchHandler_HandleOAuthDispatcher(t *testing.T) {
 		tokenRepo := &mock.TokenRepositoryMock{}
 		userRepo := &mock.UserRepositoryMock{}
 		authSvc := service.NewAuthService(tokenRepo, userRepo)
-		handler := NewDispatchHandler(authSvc, "http://localhost:8080")
+		handler := NewDispatchHandler(authSvc, nil, "http://localhost:8080")
 
 		req := httptest.NewRequest(http.MethodGet, "/d", nil)
 		w := httptest.NewRecorder()
@@ -196,7 +196,7 @@ func TestDispatchHandler_HandleOAuthDispatcher(t *testing.T) {
 
 	t.Run("returns 404 for unknown path", func(t *testing.T) {
 		authSvc := setupAuth()
-		handler := NewDispatchHandler(authSvc, "http://localhost:8080")
+		handler := NewDispatchHandler(authSvc, nil, "http://localhost:8080")
 
 		req := httptest.NewRequest(http.MethodGet, "/x?token=valid-token", nil)
 		w := httptest.NewRecorder()
@@ -208,3 +208,65 @@ func TestDispatchHandler_HandleOAuthDispatcher(t *testing.T) {
 		}
 	})
 }
+
+func TestDispatchHandler_HandleSign(t *testing.T) {
+	testUser := mock.NewTestUser(1, "user@example.com")
+	authSvc := service.NewAuthService(
+		&mock.TokenRepositoryMock{
+			LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+				if accessToken == "valid-token" {
+					return mock.NewTestToken(1, time.Now().Add(time.Hour)), nil
+				}
+				return nil, nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				return testUser, nil
+			},
+		},
+	)
+	signer := service.NewURLSigner([]byte("key"), time.Minute)
+	handler := NewDispatchHandler(authSvc, signer, "http://localhost:8080")
+
+	t.Run("returns signed URLs without the access token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/dispatcher/sign?home=/My%20Docs/a.txt&preset=xw1", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		w := httptest.NewRecorder()
+
+		handler.HandleSign(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+		}
+		var env struct {
+			Body SignedURLs `json:"body"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !strings.HasPrefix(env.Body.Get, "http://localhost:8080/get/My%20Docs/a.txt?") {
+			t.Errorf("get URL = %q", env.Body.Get)
+		}
+		if !strings.HasPrefix(env.Body.Thumbnail, "http://localhost:8080/thumb/xw1/My%20Docs/a.txt?") {
+			t.Errorf("thumbnail URL = %q", env.Body.Thumbnail)
+		}
+		if strings.Contains(env.Body.Get, "valid-token") || !strings.Contains(env.Body.Get, "signature=") {
+			t.Errorf("get URL must be signed and carry no token: %q", env.Body.Get)
+		}
+		if env.Body.Expires == 0 {
+			t.Error("expires not set")
+		}
+	})
+
+	t.Run("requires home", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/dispatcher/sign?access_token=valid-token", nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleSign(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -11,23 +12,30 @@ import (
 )
 
 // DownloadHandler handles binary downloads.
+// Folders are downloaded as ZIP archives streamed on the fly.
 type DownloadHandler struct {
 	auth      *service.AuthService
 	downloads *service.DownloadService
+	archives  *service.ArchiveService
 	shares    *service.ShareService
+	signer    *service.URLSigner
 }
 
 // NewDownloadHandler creates a new DownloadHandler.
-func NewDownloadHandler(auth *service.AuthService, downloads *service.DownloadService, shares *service.ShareService) *DownloadHandler {
+func NewDownloadHandler(auth *service.AuthService, downloads *service.DownloadService, archives *service.ArchiveService, shares *service.ShareService, signer *service.URLSigner) *DownloadHandler {
 	return &DownloadHandler{
 		auth:      auth,
 		downloads: downloads,
+		archives:  archives,
 		shares:    shares,
+		signer:    signer,
 	}
 }
 
 // HandleDownload handles GET /get/{path...} - download binary.
-// Blocks browser-like User-Agents and serves with Range support.
+// Authenticates by token or signed URL. Token requests from browser-like
+// User-Agents are blocked; signed URLs are meant to be opened anywhere.
+// Serves files as attachments with Range support and folders as ZIP archives.
 func (h *DownloadHandler) HandleDownload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -35,17 +43,11 @@ func (h *DownloadHandler) HandleDownload(w http.ResponseWriter, r *http.Request)
 	}
 
 	ua := r.Header.Get("User-Agent")
-	if strings.Contains(ua, "Mozilla") {
+	if strings.Contains(ua, "Mozilla") && !isSigned(r) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
 
-	authed, err := h.auth.Validate(r.URL.Query().Get("token"))
-	if err != nil || authed == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
 	rawPath := strings.TrimPrefix(r.URL.Path, "/get")
 	if rawPath == "" || rawPath == "/" {
 		http.Error(w, "Not found", http.StatusNotFound)
@@ -59,21 +61,186 @@ func (h *DownloadHandler) HandleDownload(w http.ResponseWriter, r *http.Request)
 	}
 
 	path := vo.NewCloudPath(cloudPath)
-	result, err := h.downloads.Resolve(authed.UserID, path)
+	authed := authenticateContent(r, h.auth, h.signer, path)
+	if authed == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !authed.Allows(vo.ScopeRead, path) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	result := h.resolve(authed.UserID, path)
+	if result == nil {
+		h.serveArchive(w, r, authed.UserID, []vo.CloudPath{path}, "")
+		return
+	}
+	defer result.File.Close()
+
+	serveAttachment(w, r, result)
+}
+
+// HandleWebDownload handles GET /web/get/{path...} - download for the web file browser.
+// Authenticates by the web session cookie, so browsers can follow plain links,
+// and serves the file as an attachment with Range support, or a folder as a ZIP archive.
+func (h *DownloadHandler) HandleWebDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed, err := webSession(r, h.auth)
+	if err != nil || authed == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := vo.NewCloudPath(strings.TrimPrefix(r.URL.Path, "/web/get"))
+	if path.IsRoot() {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	result := h.resolve(authed.UserID, path)
+	if result == nil {
+		h.serveArchive(w, r, authed.UserID, []vo.CloudPath{path}, "")
+		return
+	}
+	defer result.File.Close()
+
+	serveAttachment(w, r, result)
+}
+
+// serveAttachment serves a file with Range support as a download. Uploaded content
+// must never run as a page of this origin, so browsers are told to save it, not
+// to guess its type, and to sandbox it should they display it anyway.
+func serveAttachment(w http.ResponseWriter, r *http.Request, result *service.DownloadResult) {
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": result.Node.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	http.ServeContent(w, r, result.Node.Name, time.Unix(result.Node.MTime, 0), result.File)
+}
+
+// resolve opens the file at path in the user's tree or, failing that, in a mounted share.
+// Returns nil if there is no file at the path.
+func (h *DownloadHandler) resolve(userID int64, path vo.CloudPath) *service.DownloadResult {
+	result, err := h.downloads.Resolve(userID, path)
+	if err == nil {
+		return result
+	}
+
+	resolution, err := h.shares.ResolveMount(userID, path)
+	if err != nil || resolution == nil {
+		return nil
+	}
+	result, err = h.downloads.Resolve(resolution.Share.OwnerID, resolution.OwnerPath)
 	if err != nil {
-		// Path not found in user's own tree -- try mounted shares.
-		resolution, mErr := h.shares.ResolveMount(authed.UserID, path)
-		if mErr != nil || resolution == nil {
-			http.Error(w, "Not found", http.StatusNotFound)
+		return nil
+	}
+	return result
+}
+
+// HandleZip handles GET /zip?home={path}[&home=...][&name=...] - download several
+// files and folders as one ZIP archive. Authenticates by bearer token or the
+// token query parameter. As on /get/, browser-like User-Agents are blocked, so
+// tokens do not leak through links and history; the web file browser uses
+// /web/zip. The optional name sets the archive's file name.
+func (h *DownloadHandler) HandleZip(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("User-Agent"), "Mozilla") {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	authed, err := h.auth.Validate(requestToken(r, "token"))
+	if err != nil || authed == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.serveSelection(w, r, authed)
+}
+
+// HandleWebZip handles GET /web/zip?home={path}[&home=...][&name=...] - the
+// multi-select download of the web file browser, authenticated by the session cookie.
+func (h *DownloadHandler) HandleWebZip(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed, err := webSession(r, h.auth)
+	if err != nil || authed == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.serveSelection(w, r, authed)
+}
+
+// serveSelection archives the paths given in the home query parameters.
+func (h *DownloadHandler) serveSelection(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	homes := r.URL.Query()["home"]
+	if len(homes) == 0 {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	paths := make([]vo.CloudPath, 0, len(homes))
+	for _, home := range homes {
+		path := vo.NewCloudPath(home)
+		if !authed.Allows(vo.ScopeRead, path) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
 		}
-		result, err = h.downloads.Resolve(resolution.Share.OwnerID, resolution.OwnerPath)
-		if err != nil {
-			http.Error(w, "Not found", http.StatusNotFound)
-			return
+		paths = append(paths, path)
+	}
+
+	h.serveArchive(w, r, authed.UserID, paths, r.URL.Query().Get("name"))
+}
+
+// serveArchive streams the paths, as the user sees them, as a ZIP attachment.
+// Paths inside mounted shares are read from the owner's tree. Without a name,
+// a single item's archive is named after it.
+func (h *DownloadHandler) serveArchive(w http.ResponseWriter, r *http.Request, userID int64, paths []vo.CloudPath, name string) {
+	sources := make([]service.ArchiveSource, 0, len(paths))
+	for _, path := range paths {
+		source := service.ArchiveSource{UserID: userID, Path: path}
+		if resolution, err := h.shares.ResolveMount(userID, path); err == nil && resolution != nil {
+			source = service.ArchiveSource{UserID: resolution.Share.OwnerID, Path: resolution.OwnerPath, Name: path.Name()}
 		}
+		sources = append(sources, source)
 	}
-	defer result.File.Close()
 
-	http.ServeContent(w, r, result.Node.Name, time.Unix(result.Node.MTime, 0), result.File)
+	archive, err := h.archives.Prepare(sources)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if name == "" && len(paths) == 1 && !paths[0].IsRoot() {
+		name = paths[0].Name()
+	}
+	writeArchive(w, r, archive, name)
+}
+
+// writeArchive sends the archive as a ZIP attachment named name (default "archive").
+// The length is not known up front, so the response is chunked and cannot be resumed.
+func writeArchive(w http.ResponseWriter, r *http.Request, archive *service.Archive, name string) {
+	name = strings.TrimSuffix(strings.ReplaceAll(name, "/", "_"), ".zip")
+	if name == "" {
+		name = "archive"
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".zip"}))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = archive.Write(w)
 }
@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// ExtractHandler handles server-side archive extraction and background job status.
+type ExtractHandler struct {
+	auth    *service.AuthService
+	extract *service.ExtractService
+	jobs    *service.JobRegistry
+	shares  *service.ShareService
+}
+
+// NewExtractHandler creates a new ExtractHandler.
+func NewExtractHandler(
+	auth *service.AuthService,
+	extract *service.ExtractService,
+	jobs *service.JobRegistry,
+	shares *service.ShareService,
+) *ExtractHandler {
+	return &ExtractHandler{
+		auth:    auth,
+		extract: extract,
+		jobs:    jobs,
+		shares:  shares,
+	}
+}
+
+// HandleExtract handles POST /api/v2/file/extract - unpack an archive into a folder.
+// The archive is extracted in the background; the response describes the started job.
+func (h *ExtractHandler) HandleExtract(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	homePath := r.FormValue("home")
+	if homePath == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	conflict, err := vo.ParseConflictMode(r.FormValue("conflict"))
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	archive := vo.NewCloudPath(homePath)
+	target := service.DefaultExtractTarget(archive)
+	if folder := r.FormValue("folder"); folder != "" {
+		target = vo.NewCloudPath(folder)
+	}
+
+	if !authorize(w, authed, vo.ScopeRead, archive) || !authorize(w, authed, vo.ScopeWrite, target) {
+		return
+	}
+
+	// Extraction works within the user's own tree only.
+	for _, p := range []vo.CloudPath{archive, target} {
+		resolution, err := h.shares.ResolveMount(authed.UserID, p)
+		if err != nil {
+			writeHomeError(w, authed.Email, 500, "unknown")
+			return
+		}
+		if resolution != nil {
+			writeHomeError(w, authed.Email, 400, "invalid")
+			return
+		}
+	}
+
+	job, err := h.extract.Start(service.ExtractRequest{
+		UserID:        authed.UserID,
+		Archive:       archive,
+		Target:        target,
+		Conflict:      conflict,
+		FileSizeLimit: authed.FileSizeLimit,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			writeHomeError(w, authed.Email, 404, "not_exists")
+		case errors.Is(err, service.ErrUnsupportedArchive):
+			writeHomeError(w, authed.Email, 400, "unsupported")
+		case errors.Is(err, service.ErrAlreadyExists):
+			writeHomeError(w, authed.Email, 400, "exists")
+		case errors.Is(err, service.ErrBusy):
+			writeHomeError(w, authed.Email, 409, "busy")
+		default:
+			writeHomeError(w, authed.Email, 500, "unknown")
+		}
+		return
+	}
+
+	writeSuccess(w, authed.Email, jobStatus(job))
+}
+
+// HandleJobStatus handles GET /api/v2/jobs/status - progress of a background job.
+func (h *ExtractHandler) HandleJobStatus(w http.ResponseWriter, r *http.Request) {
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	job, err := h.jobs.Get(authed.UserID, id)
+	if err != nil {
+		writeHomeError(w, authed.Email, 404, "not_exists")
+		return
+	}
+
+	writeSuccess(w, authed.Email, jobStatus(job))
+}
+
+// jobStatus converts a job snapshot to its response form.
+func jobStatus(job service.Job) JobStatus {
+	return JobStatus{
+		ID:       job.ID,
+		Kind:     job.Kind,
+		State:    string(job.State),
+		Target:   job.Target,
+		Total:    job.Total,
+		Done:     job.Done,
+		Bytes:    job.Bytes,
+		Error:    job.Error,
+		Started:  job.Started,
+		Finished: job.Finished,
+	}
+}
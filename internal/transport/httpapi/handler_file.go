@@ -2,9 +2,11 @@ package httpapi
 
 import (
 	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pozitronik/tucha/internal/application/service"
 	"github.com/pozitronik/tucha/internal/domain/vo"
@@ -53,6 +55,10 @@ func (h *FileHandler) HandleFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
+
 	node, err := h.files.Get(authed.UserID, path)
 	if err != nil {
 		writeHomeError(w, authed.Email, 500, "unknown")
@@ -164,6 +170,10 @@ func (h *FileHandler) HandleFileAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeWrite, path) {
+		return
+	}
+
 	targetUserID := authed.UserID
 	targetPath := path
 
@@ -236,6 +246,10 @@ func (h *FileHandler) HandleFileRemove(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeTrash, path) {
+		return
+	}
+
 	_ = h.trash.Trash(authed.UserID, path, authed.UserID)
 
 	writeSuccess(w, authed.Email, path.String())
@@ -272,6 +286,10 @@ func (h *FileHandler) HandleFileRename(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeWrite, path) {
+		return
+	}
+
 	node, err := h.files.Rename(authed.UserID, path, newName)
 	if err != nil {
 		writeHomeError(w, authed.Email, 400, "not_exists")
@@ -314,11 +332,21 @@ func (h *FileHandler) HandleFileMove(w http.ResponseWriter, r *http.Request) {
 		folder = "/" + folder
 	}
 
+	conflict, err := vo.ParseConflictMode(r.FormValue("conflict"))
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
 	srcPath := vo.NewCloudPath(homePath)
 	targetFolder := vo.NewCloudPath(folder)
-	node, err := h.files.Move(authed.UserID, srcPath, targetFolder)
+	if !authorize(w, authed, vo.ScopeWrite, srcPath, targetFolder) {
+		return
+	}
+
+	node, err := h.files.Move(authed.UserID, srcPath, targetFolder, conflict)
 	if err != nil {
-		writeHomeError(w, authed.Email, 400, "not_exists")
+		writeTransferError(w, authed.Email, err)
 		return
 	}
 
@@ -339,6 +367,9 @@ func (h *FileHandler) HandleFileHistory(w http.ResponseWriter, r *http.Request)
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
 
 	// Verify the file exists before returning history.
 	node, err := h.files.Get(authed.UserID, path)
@@ -375,6 +406,107 @@ func (h *FileHandler) HandleFileHistory(w http.ResponseWriter, r *http.Request)
 	writeSuccess(w, authed.Email, items)
 }
 
+// HandleFileHistoryRestore handles POST /api/v2/file/history/restore - make an
+// earlier revision of a file current. Requires version history on the account.
+func (h *FileHandler) HandleFileHistoryRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	path, rev, ok := historyParams(w, authed, r.FormValue("home"), r.FormValue("rev"))
+	if !ok || !authorize(w, authed, vo.ScopeWrite, path) {
+		return
+	}
+
+	node, err := h.files.RestoreVersion(authed.UserID, path, rev)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound), errors.Is(err, service.ErrContentNotFound):
+			writeHomeError(w, authed.Email, 404, "not_exists")
+		case errors.Is(err, service.ErrOverQuota):
+			writeHomeError(w, authed.Email, 507, "overquota")
+		default:
+			writeHomeError(w, authed.Email, 500, "unknown")
+		}
+		return
+	}
+
+	writeSuccess(w, authed.Email, node.Home.String())
+}
+
+// HandleFileHistoryDownload handles GET /api/v2/file/history/download - download
+// an earlier revision of a file, with Range support. Requires version history on the account.
+func (h *FileHandler) HandleFileHistoryDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	q := r.URL.Query()
+	path, rev, ok := historyParams(w, authed, q.Get("home"), q.Get("rev"))
+	if !ok || !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
+
+	version, f, err := h.files.OpenVersion(authed.UserID, path, rev)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrContentNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+		} else {
+			writeHomeError(w, authed.Email, 500, "unknown")
+		}
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": version.Name}))
+	http.ServeContent(w, r, version.Name, time.Unix(version.Time, 0), f)
+}
+
+// historyParams validates the home and rev parameters of a revision request and
+// checks that the account keeps version history. On failure it writes the error response.
+func historyParams(w http.ResponseWriter, authed *service.AuthenticatedUser, home, revStr string) (vo.CloudPath, int64, bool) {
+	if home == "" || revStr == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return vo.CloudPath{}, 0, false
+	}
+	rev, err := strconv.ParseInt(revStr, 10, 64)
+	if err != nil || rev <= 0 {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return vo.CloudPath{}, 0, false
+	}
+	if !authed.VersionHistory {
+		writeHomeError(w, authed.Email, 403, "forbidden")
+		return vo.CloudPath{}, 0, false
+	}
+	return vo.NewCloudPath(home), rev, true
+}
+
+// writeTransferError writes the error of a move or copy.
+func writeTransferError(w http.ResponseWriter, email string, err error) {
+	if errors.Is(err, service.ErrAlreadyExists) {
+		writeHomeError(w, email, 400, "exists")
+		return
+	}
+	writeHomeError(w, email, 400, "not_exists")
+}
+
 // HandleFileCopy handles POST /api/v2/file/copy.
 // Note: both home and folder have explicit leading "/" from client.
 func (h *FileHandler) HandleFileCopy(w http.ResponseWriter, r *http.Request) {
@@ -400,11 +532,21 @@ func (h *FileHandler) HandleFileCopy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	conflict, err := vo.ParseConflictMode(r.FormValue("conflict"))
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
 	srcPath := vo.NewCloudPath(homePath)
 	targetFolder := vo.NewCloudPath(folder)
-	node, err := h.files.Copy(authed.UserID, srcPath, targetFolder)
+	if !authorize(w, authed, vo.ScopeWrite, srcPath, targetFolder) {
+		return
+	}
+
+	node, err := h.files.Copy(authed.UserID, srcPath, targetFolder, conflict)
 	if err != nil {
-		writeHomeError(w, authed.Email, 400, "not_exists")
+		writeTransferError(w, authed.Email, err)
 		return
 	}
 
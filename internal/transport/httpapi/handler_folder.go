@@ -56,6 +56,9 @@ func (h *FolderHandler) HandleFolder(w http.ResponseWriter, r *http.Request) {
 		homePath = "/"
 	}
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
 
 	offsetStr := r.URL.Query().Get("offset")
 	limitStr := r.URL.Query().Get("limit")
@@ -288,6 +291,10 @@ func (h *FolderHandler) HandleFolderAdd(w http.ResponseWriter, r *http.Request)
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeWrite, path) {
+		return
+	}
+
 	node, err := h.folders.CreateFolder(authed.UserID, path)
 	if err != nil {
 		if err == service.ErrAlreadyExists {
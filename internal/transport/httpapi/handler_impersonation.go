@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+)
+
+// ImpersonationHandler lets the admin obtain a support token to see what a user sees.
+type ImpersonationHandler struct {
+	adminAuth     *service.AdminAuthService
+	impersonation *service.ImpersonationService
+}
+
+// NewImpersonationHandler creates a new ImpersonationHandler.
+func NewImpersonationHandler(adminAuth *service.AdminAuthService, impersonation *service.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{adminAuth: adminAuth, impersonation: impersonation}
+}
+
+// HandleImpersonate handles POST /admin/user/impersonate - issue a support token for a user.
+// Form fields: user_id, optional elevated (true to allow changes) and ttl_seconds.
+func (h *ImpersonationHandler) HandleImpersonate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.adminAuth.Validate(extractAdminToken(r)) {
+		writeEnvelope(w, "", 403, "forbidden")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	elevated := false
+	if v := r.FormValue("elevated"); v != "" {
+		elevated, err = strconv.ParseBool(v)
+		if err != nil {
+			writeEnvelope(w, "", 400, "invalid")
+			return
+		}
+	}
+
+	ttl := 0
+	if v := r.FormValue("ttl_seconds"); v != "" {
+		ttl, err = strconv.Atoi(v)
+		if err != nil || ttl < 0 {
+			writeEnvelope(w, "", 400, "invalid")
+			return
+		}
+	}
+
+	token, err := h.impersonation.Start(userID, h.adminAuth.Identity(), elevated, ttl)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeEnvelope(w, "", 404, "not_found")
+			return
+		}
+		writeEnvelope(w, "", 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, "", newPersonalTokenInfo(token))
+}
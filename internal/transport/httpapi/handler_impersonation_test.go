@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestImpersonationHandler_HandleImpersonate(t *testing.T) {
+	adminAuth := service.NewAdminAuthService("admin", "secret")
+	adminToken, err := adminAuth.Login("admin", "secret", "")
+	if err != nil {
+		t.Fatalf("admin login: %v", err)
+	}
+
+	var gotImpersonator string
+	var gotElevated bool
+	h := NewImpersonationHandler(adminAuth, service.NewImpersonationService(
+		&mock.TokenRepositoryMock{
+			CreateImpersonationFunc: func(userID int64, impersonator string, elevated bool, ttlSeconds int) (*entity.Token, error) {
+				gotImpersonator, gotElevated = impersonator, elevated
+				return &entity.Token{ID: 4, UserID: userID, AccessToken: "support", Personal: true, ExpiresAt: 100, Impersonator: impersonator, Elevated: elevated}, nil
+			},
+		},
+		&mock.UserRepositoryMock{
+			GetByIDFunc: func(id int64) (*entity.User, error) {
+				if id == 1 {
+					return mock.NewTestUser(1, "user@example.com"), nil
+				}
+				return nil, nil
+			},
+		},
+		&mock.LoggerMock{},
+	))
+
+	t.Run("issues read-only support token", func(t *testing.T) {
+		req := postForm("/admin/user/impersonate", url.Values{"user_id": {"1"}})
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		h.HandleImpersonate(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+		}
+		var env struct {
+			Body PersonalTokenInfo `json:"body"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if env.Body.AccessToken != "support" || env.Body.Impersonator != "admin" || env.Body.Elevated {
+			t.Errorf("body = %+v", env.Body)
+		}
+		if gotImpersonator != "admin" || gotElevated {
+			t.Errorf("impersonator = %q, elevated = %v", gotImpersonator, gotElevated)
+		}
+	})
+
+	t.Run("elevated on request", func(t *testing.T) {
+		req := postForm("/admin/user/impersonate", url.Values{"user_id": {"1"}, "elevated": {"true"}})
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		h.HandleImpersonate(w, req)
+
+		if w.Code != http.StatusOK || !gotElevated {
+			t.Errorf("status = %d, elevated = %v", w.Code, gotElevated)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		req := postForm("/admin/user/impersonate", url.Values{"user_id": {"2"}})
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		h.HandleImpersonate(w, req)
+
+		if !strings.Contains(w.Body.String(), "not_found") {
+			t.Errorf("body = %s, want not_found", w.Body.String())
+		}
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		h.HandleImpersonate(w, postForm("/admin/user/impersonate", url.Values{"user_id": {"1"}}))
+
+		if !strings.Contains(w.Body.String(), "forbidden") {
+			t.Errorf("body = %s, want forbidden", w.Body.String())
+		}
+	})
+}
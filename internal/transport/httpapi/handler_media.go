@@ -0,0 +1,177 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/infrastructure/thumbnail"
+)
+
+const (
+	// defaultTimelineLimit is the page size of the photo timeline when the
+	// request gives none.
+	defaultTimelineLimit = 100
+
+	// maxTimelineLimit caps the page size of the photo timeline.
+	maxTimelineLimit = 1000
+
+	// defaultTimelinePreset is the thumbnail preset of timeline photos when
+	// the request gives none.
+	defaultTimelinePreset = "xw14"
+)
+
+// MediaHandler handles photo metadata and the photo timeline.
+type MediaHandler struct {
+	auth        *service.AuthService
+	media       *service.MediaService
+	presenter   *Presenter
+	signer      *service.URLSigner
+	externalURL string
+}
+
+// NewMediaHandler creates a new MediaHandler. Timeline thumbnails are signed
+// URLs below externalURL.
+func NewMediaHandler(auth *service.AuthService, media *service.MediaService, presenter *Presenter, signer *service.URLSigner, externalURL string) *MediaHandler {
+	return &MediaHandler{
+		auth:        auth,
+		media:       media,
+		presenter:   presenter,
+		signer:      signer,
+		externalURL: strings.TrimRight(externalURL, "/"),
+	}
+}
+
+// HandleInfo handles GET /api/v2/file/media - the metadata of a photo.
+func (h *MediaHandler) HandleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	homePath := r.URL.Query().Get("home")
+	if homePath == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
+
+	info, err := h.media.Info(authed.UserID, path)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+	if info == nil {
+		writeHomeError(w, authed.Email, 404, "no_media")
+		return
+	}
+	writeSuccess(w, authed.Email, mediaToItem(info))
+}
+
+// HandleTimeline handles GET /api/v2/folder/timeline - the user's photos
+// across folders, newest first and grouped by the day they were taken.
+// Each photo carries a signed URL of its thumbnail in the preset parameter.
+func (h *MediaHandler) HandleTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
+
+	q := r.URL.Query()
+	preset := defaultTimelinePreset
+	if v := q.Get("preset"); v != "" {
+		if thumbnail.GetPreset(v) == nil {
+			writeError(w, authed.Email, 400, "preset", "invalid")
+			return
+		}
+		preset = v
+	}
+	offset, err := strconv.Atoi(q.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit := defaultTimelineLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, authed.Email, 400, "limit", "invalid")
+			return
+		}
+		limit = min(n, maxTimelineLimit)
+	}
+
+	days, more, err := h.media.Timeline(authed.UserID, offset, limit)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	result := TimelineResult{HasMore: more, Days: make([]TimelineDay, 0, len(days))}
+	for _, day := range days {
+		items := make([]PhotoItem, 0, len(day.Photos))
+		for i := range day.Photos {
+			photo := &day.Photos[i]
+			if !authed.Allows(vo.ScopeRead, photo.Node.Home) {
+				continue
+			}
+			items = append(items, PhotoItem{
+				FolderItem: h.presenter.NodeToFolderItem(&photo.Node, nil),
+				Media:      mediaToItem(&photo.Media),
+				Thumbnail:  h.thumbnailURL(authed.UserID, photo.Node.Home, preset),
+			})
+		}
+		if len(items) > 0 {
+			result.Days = append(result.Days, TimelineDay{Date: day.Date, List: items})
+		}
+	}
+	writeSuccess(w, authed.Email, result)
+}
+
+// thumbnailURL returns a signed URL of the thumbnail of the user's file.
+func (h *MediaHandler) thumbnailURL(userID int64, path vo.CloudPath, preset string) string {
+	sig := h.signer.Sign(userID, path)
+	escaped := (&url.URL{Path: path.String()}).EscapedPath()
+	return h.externalURL + "/thumb/" + url.PathEscape(preset) + escaped + "?" + signedQuery(sig)
+}
+
+// mediaToItem converts photo metadata to its response form.
+func mediaToItem(info *entity.MediaInfo) MediaItem {
+	item := MediaItem{
+		Width:       info.Width,
+		Height:      info.Height,
+		Orientation: info.Orientation,
+		Taken:       info.TakenAt,
+		Make:        info.Make,
+		Model:       info.Model,
+	}
+	if info.HasLocation {
+		item.Latitude, item.Longitude = &info.Latitude, &info.Longitude
+	}
+	return item
+}
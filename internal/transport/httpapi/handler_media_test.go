@@ -0,0 +1,126 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newTestMediaHandler(media *mock.MediaRepositoryMock) *MediaHandler {
+	auth := setupHandlerAuth()
+	nodes := &mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			switch path.String() {
+			case "/beach.jpg", "/notes.txt":
+				return mock.NewTestFileNode(1, path.String(), mock.ValidHash(), 10), nil
+			}
+			return nil, nil
+		},
+	}
+	signer := service.NewURLSigner([]byte("key"), time.Minute)
+	return NewMediaHandler(auth, service.NewMediaService(nodes, media), NewPresenter(), signer, "https://cloud.example.com/")
+}
+
+func TestMediaHandler_HandleTimeline(t *testing.T) {
+	taken := time.Date(2024, 5, 31, 18, 0, 0, 0, time.UTC).Unix()
+	h := newTestMediaHandler(&mock.MediaRepositoryMock{
+		TimelineFunc: func(userID int64, offset, limit int) ([]repository.Photo, error) {
+			return []repository.Photo{{
+				Node:  *mock.NewTestFileNode(1, "/Trip 2024/beach.jpg", mock.ValidHash(), 10),
+				Media: entity.MediaInfo{Width: 4000, Height: 3000, TakenAt: taken, Model: "EOS R6", HasLocation: true, Latitude: 43.5},
+			}}, nil
+		},
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v2/folder/timeline?access_token=access-token-123&"+query, nil)
+		w := httptest.NewRecorder()
+		h.HandleTimeline(w, r)
+		return w
+	}
+
+	w := get("preset=xw2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Body TimelineResult `json:"body"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Body.Days) != 1 || resp.Body.Days[0].Date != "2024-05-31" || len(resp.Body.Days[0].List) != 1 {
+		t.Fatalf("days = %+v, want one photo on 2024-05-31", resp.Body.Days)
+	}
+	photo := resp.Body.Days[0].List[0]
+	if photo.Home != "/Trip 2024/beach.jpg" || photo.Media.Model != "EOS R6" || photo.Media.Taken != taken {
+		t.Errorf("photo = %+v", photo)
+	}
+	if photo.Media.Latitude == nil || *photo.Media.Latitude != 43.5 {
+		t.Errorf("latitude = %v, want 43.5", photo.Media.Latitude)
+	}
+	if !strings.HasPrefix(photo.Thumbnail, "https://cloud.example.com/thumb/xw2/Trip%202024/beach.jpg?") ||
+		!strings.Contains(photo.Thumbnail, "signature=") {
+		t.Errorf("thumbnail = %q, want a signed xw2 thumbnail URL", photo.Thumbnail)
+	}
+
+	for _, query := range []string{"preset=xw99", "limit=0"} {
+		if w := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestMediaHandler_HandleInfo(t *testing.T) {
+	h := newTestMediaHandler(&mock.MediaRepositoryMock{
+		GetFunc: func(hash vo.ContentHash) (*entity.MediaInfo, error) {
+			return &entity.MediaInfo{Hash: hash, Width: 30, Height: 40, Orientation: 6}, nil
+		},
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v2/file/media?access_token=access-token-123&"+query, nil)
+		w := httptest.NewRecorder()
+		h.HandleInfo(w, r)
+		return w
+	}
+
+	w := get("home=/beach.jpg")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Body MediaItem `json:"body"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Body.Width != 30 || resp.Body.Height != 40 || resp.Body.Orientation != 6 || resp.Body.Latitude != nil {
+		t.Errorf("media = %+v", resp.Body)
+	}
+
+	if w := get("home=/missing.jpg"); w.Code != http.StatusNotFound {
+		t.Errorf("missing file: status = %d, want 404", w.Code)
+	}
+	if w := get(""); w.Code != http.StatusBadRequest {
+		t.Errorf("no home: status = %d, want 400", w.Code)
+	}
+}
+
+func TestMediaHandler_HandleInfo_notPhoto(t *testing.T) {
+	h := newTestMediaHandler(&mock.MediaRepositoryMock{})
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/file/media?access_token=access-token-123&home=/notes.txt", nil)
+	w := httptest.NewRecorder()
+	h.HandleInfo(w, r)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "no_media") {
+		t.Errorf("status = %d, body %s, want 404 no_media", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,257 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// PersonalTokenHandler manages scoped personal access tokens,
+// both for the token owner (/api/v2/tokens/personal*) and for the admin panel (/admin/user/tokens*).
+type PersonalTokenHandler struct {
+	auth      *service.AuthService
+	adminAuth *service.AdminAuthService
+	tokens    *service.TokenService
+}
+
+// NewPersonalTokenHandler creates a new PersonalTokenHandler.
+func NewPersonalTokenHandler(auth *service.AuthService, adminAuth *service.AdminAuthService, tokens *service.TokenService) *PersonalTokenHandler {
+	return &PersonalTokenHandler{auth: auth, adminAuth: adminAuth, tokens: tokens}
+}
+
+// HandleList handles GET /api/v2/tokens/personal - list the caller's personal tokens.
+func (h *PersonalTokenHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	tokens, err := h.tokens.ListPersonal(authed.UserID)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, personalTokensToInfo(tokens))
+}
+
+// HandleAdd handles POST /api/v2/tokens/personal/add - issue a personal token for the caller.
+func (h *PersonalTokenHandler) HandleAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	token, status, errBody := h.create(r, authed.UserID)
+	if token == nil {
+		writeHomeError(w, authed.Email, status, errBody)
+		return
+	}
+
+	writeSuccess(w, authed.Email, newPersonalTokenInfo(token))
+}
+
+// HandleRemove handles POST /api/v2/tokens/personal/remove - revoke one of the caller's personal tokens.
+func (h *PersonalTokenHandler) HandleRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	if err := h.tokens.RevokePersonal(authed.UserID, tokenID); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, "ok")
+}
+
+// HandleAdminList handles GET /admin/user/tokens?id=<user_id> - list a user's personal tokens.
+func (h *PersonalTokenHandler) HandleAdminList(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuth.Validate(extractAdminToken(r)) {
+		writeEnvelope(w, "", 403, "forbidden")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	tokens, err := h.tokens.ListPersonal(userID)
+	if err != nil {
+		writeEnvelope(w, "", 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, "", personalTokensToInfo(tokens))
+}
+
+// HandleAdminAdd handles POST /admin/user/tokens/add - issue a personal token for a user.
+func (h *PersonalTokenHandler) HandleAdminAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.adminAuth.Validate(extractAdminToken(r)) {
+		writeEnvelope(w, "", 403, "forbidden")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	token, status, errBody := h.create(r, userID)
+	if token == nil {
+		writeEnvelope(w, "", status, errBody)
+		return
+	}
+
+	writeSuccess(w, "", newPersonalTokenInfo(token))
+}
+
+// HandleAdminRemove handles POST /admin/user/tokens/remove - revoke a user's personal token.
+func (h *PersonalTokenHandler) HandleAdminRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.adminAuth.Validate(extractAdminToken(r)) {
+		writeEnvelope(w, "", 403, "forbidden")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+	tokenID, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	if err := h.tokens.RevokePersonal(userID, tokenID); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeEnvelope(w, "", 404, "not_found")
+			return
+		}
+		writeEnvelope(w, "", 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, "", "ok")
+}
+
+// create parses the name, scopes, path and ttl form values and issues the token.
+// On failure it returns a nil token together with the status and error body to report.
+func (h *PersonalTokenHandler) create(r *http.Request, userID int64) (*entity.Token, int, string) {
+	name := r.FormValue("name")
+	if name == "" {
+		return nil, 400, "required"
+	}
+
+	scopes, err := vo.ParseTokenScopes(r.FormValue("scopes"))
+	if err != nil {
+		return nil, 400, "invalid"
+	}
+
+	pathPrefix := vo.NewCloudPath("/")
+	if p := r.FormValue("path"); p != "" {
+		pathPrefix = vo.NewCloudPath(p)
+	}
+
+	ttl := 0
+	if ttlStr := r.FormValue("ttl_seconds"); ttlStr != "" {
+		ttl, err = strconv.Atoi(ttlStr)
+		if err != nil || ttl < 0 {
+			return nil, 400, "invalid"
+		}
+	}
+
+	token, err := h.tokens.CreatePersonal(userID, name, scopes, pathPrefix, ttl)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return nil, 404, "not_found"
+		}
+		return nil, 500, "unknown"
+	}
+	return token, 0, ""
+}
+
+// personalTokensToInfo converts tokens to DTOs without their secret values.
+func personalTokensToInfo(tokens []entity.Token) []PersonalTokenInfo {
+	infos := make([]PersonalTokenInfo, 0, len(tokens))
+	for i := range tokens {
+		info := newPersonalTokenInfo(&tokens[i])
+		info.AccessToken = ""
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// newPersonalTokenInfo converts a token to a DTO, including its access token value.
+func newPersonalTokenInfo(t *entity.Token) PersonalTokenInfo {
+	return PersonalTokenInfo{
+		ID:           t.ID,
+		Name:         t.Name,
+		Scopes:       vo.FormatTokenScopes(t.Scopes),
+		Path:         t.PathPrefix.String(),
+		ExpiresAt:    t.ExpiresAt,
+		Created:      t.Created,
+		AccessToken:  t.AccessToken,
+		Impersonator: t.Impersonator,
+		Elevated:     t.Elevated,
+	}
+}
@@ -0,0 +1,184 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// newPersonalTokenTestHandler builds a handler where "session" is a regular
+// login token and "personal" is a scoped personal token of the same user.
+func newPersonalTokenTestHandler(tokenRepo *mock.TokenRepositoryMock) (*PersonalTokenHandler, *service.AdminAuthService) {
+	testUser := mock.NewTestUser(1, "user@example.com")
+	tokenRepo.LookupAccessFunc = func(accessToken string) (*entity.Token, error) {
+		switch accessToken {
+		case "session":
+			return mock.NewTestToken(1, time.Now().Add(time.Hour)), nil
+		case "personal":
+			tok := mock.NewTestToken(1, time.Time{})
+			tok.Personal = true
+			tok.ExpiresAt = 0
+			tok.Scopes = []vo.TokenScope{vo.ScopeWrite}
+			return tok, nil
+		}
+		return nil, nil
+	}
+	userRepo := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			if id == testUser.ID {
+				return testUser, nil
+			}
+			return nil, nil
+		},
+	}
+	adminAuth := service.NewAdminAuthService("admin", "secret")
+	h := NewPersonalTokenHandler(
+		service.NewAuthService(tokenRepo, userRepo),
+		adminAuth,
+		service.NewTokenService(tokenRepo, userRepo, &mock.AppPasswordRepositoryMock{}),
+	)
+	return h, adminAuth
+}
+
+func TestPersonalTokenHandler_HandleAdd(t *testing.T) {
+	t.Run("creates scoped token and returns its value", func(t *testing.T) {
+		var gotScopes []vo.TokenScope
+		var gotPath vo.CloudPath
+		var gotTTL int
+		h, _ := newPersonalTokenTestHandler(&mock.TokenRepositoryMock{
+			CreatePersonalFunc: func(userID int64, name string, scopes []vo.TokenScope, pathPrefix vo.CloudPath, ttlSeconds int) (*entity.Token, error) {
+				gotScopes, gotPath, gotTTL = scopes, pathPrefix, ttlSeconds
+				return &entity.Token{ID: 7, UserID: userID, AccessToken: "secret", Personal: true, Name: name, Scopes: scopes, PathPrefix: pathPrefix}, nil
+			},
+		})
+
+		form := url.Values{"name": {"backup"}, "scopes": {"write,trash"}, "path": {"/backups"}, "ttl_seconds": {"3600"}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/tokens/personal/add?access_token=session", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		h.HandleAdd(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+		}
+		var env struct {
+			Body PersonalTokenInfo `json:"body"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if env.Body.AccessToken != "secret" || env.Body.Scopes != "write,trash" || env.Body.Path != "/backups" {
+			t.Errorf("body = %+v", env.Body)
+		}
+		if len(gotScopes) != 2 || gotPath.String() != "/backups" || gotTTL != 3600 {
+			t.Errorf("CreatePersonal got scopes=%v path=%q ttl=%d", gotScopes, gotPath, gotTTL)
+		}
+	})
+
+	t.Run("rejects unknown scope", func(t *testing.T) {
+		h, _ := newPersonalTokenTestHandler(&mock.TokenRepositoryMock{})
+
+		form := url.Values{"name": {"x"}, "scopes": {"admin"}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/tokens/personal/add?access_token=session", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		h.HandleAdd(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("personal token cannot mint tokens", func(t *testing.T) {
+		h, _ := newPersonalTokenTestHandler(&mock.TokenRepositoryMock{})
+
+		form := url.Values{"name": {"x"}, "scopes": {"read"}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/tokens/personal/add?access_token=personal", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		h.HandleAdd(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", w.Code)
+		}
+	})
+}
+
+func TestPersonalTokenHandler_HandleList_hidesSecrets(t *testing.T) {
+	h, _ := newPersonalTokenTestHandler(&mock.TokenRepositoryMock{
+		ListPersonalFunc: func(userID int64) ([]entity.Token, error) {
+			return []entity.Token{{ID: 1, UserID: userID, AccessToken: "secret", Personal: true, Name: "ci", Scopes: []vo.TokenScope{vo.ScopeRead}, PathPrefix: vo.NewCloudPath("/")}}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/tokens/personal?access_token=session", nil)
+	w := httptest.NewRecorder()
+
+	h.HandleList(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("list response leaks token value: %s", w.Body.String())
+	}
+}
+
+func TestPersonalTokenHandler_HandleAdminRemove(t *testing.T) {
+	var deleted int64
+	h, adminAuth := newPersonalTokenTestHandler(&mock.TokenRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.Token, error) {
+			return &entity.Token{ID: id, UserID: 1, Personal: true}, nil
+		},
+		DeleteFunc: func(id int64) error {
+			deleted = id
+			return nil
+		},
+	})
+
+	form := url.Values{"user_id": {"1"}, "id": {"5"}}
+
+	t.Run("requires admin token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/user/tokens/remove", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		h.HandleAdminRemove(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", w.Code)
+		}
+	})
+
+	t.Run("revokes token", func(t *testing.T) {
+		adminToken, err := adminAuth.Login("admin", "secret", "")
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/admin/user/tokens/remove", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		h.HandleAdminRemove(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+		}
+		if deleted != 5 {
+			t.Errorf("deleted = %d, want 5", deleted)
+		}
+	})
+}
@@ -52,6 +52,10 @@ func (h *PublishHandler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopePublish, path) {
+		return
+	}
+
 	weblink, err := h.publish.Publish(authed.UserID, path)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
@@ -77,6 +81,10 @@ func (h *PublishHandler) HandleUnpublish(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !authorize(w, authed, vo.ScopePublish) {
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
 		return
@@ -115,6 +123,10 @@ func (h *PublishHandler) HandleSharedLinks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !authorize(w, authed, vo.ScopePublish) {
+		return
+	}
+
 	nodes, err := h.publish.ListPublished(authed.UserID)
 	if err != nil {
 		writeHomeError(w, authed.Email, 500, "unknown")
@@ -160,6 +172,10 @@ func (h *PublishHandler) HandleClone(w http.ResponseWriter, r *http.Request) {
 	}
 
 	targetFolder := vo.NewCloudPath(folder)
+	if !authorize(w, authed, vo.ScopeWrite, targetFolder) {
+		return
+	}
+
 	node, err := h.publish.Clone(authed.UserID, weblinkID, targetFolder, conflict)
 	if err != nil {
 		switch {
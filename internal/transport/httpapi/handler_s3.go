@@ -0,0 +1,848 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// s3Prefix is the URL prefix the S3-compatible API is served under.
+const s3Prefix = "/s3"
+
+// s3MaxKeys is the default and maximum page size of ListObjects.
+const s3MaxKeys = 1000
+
+// s3MaxPartNumber is the highest part number accepted by UploadPart.
+const s3MaxPartNumber = 10000
+
+// s3MaxBodySize caps request payloads for users without a file size limit,
+// matching the largest object Amazon S3 accepts in a single PUT.
+const s3MaxBodySize = 5 << 30
+
+// s3MaxXMLBodySize caps XML request bodies, which are read into memory.
+// A DeleteObjects request of 1000 keys or a CompleteMultipartUpload of
+// 10000 parts stays well below it.
+const s3MaxXMLBodySize = 2 << 20
+
+// s3Subresources are bucket and object subresources that are recognized but not supported.
+var s3Subresources = []string{
+	"acl", "analytics", "cors", "encryption", "intelligent-tiering", "inventory",
+	"legal-hold", "lifecycle", "logging", "metrics", "notification", "object-lock",
+	"ownershipControls", "policy", "publicAccessBlock", "replication", "requestPayment",
+	"restore", "retention", "select", "tagging", "torrent", "versioning", "versions", "website",
+}
+
+// S3Handler serves a path-style S3-compatible API at /s3/.
+// Buckets are the caller's top-level folders and object keys are paths inside them.
+// Requests are authenticated with AWS Signature Version 4 using per-user access keys.
+type S3Handler struct {
+	auth      *service.AuthService
+	keys      *service.AccessKeyService
+	folders   *service.FolderService
+	files     *service.FileService
+	uploads   *service.UploadService
+	downloads *service.DownloadService
+	trash     *service.TrashService
+	multipart *service.MultipartService
+	logger    port.Logger
+}
+
+// NewS3Handler creates a new S3Handler.
+func NewS3Handler(
+	auth *service.AuthService,
+	keys *service.AccessKeyService,
+	folders *service.FolderService,
+	files *service.FileService,
+	uploads *service.UploadService,
+	downloads *service.DownloadService,
+	trash *service.TrashService,
+	multipart *service.MultipartService,
+	logger port.Logger,
+) *S3Handler {
+	return &S3Handler{
+		auth:      auth,
+		keys:      keys,
+		folders:   folders,
+		files:     files,
+		uploads:   uploads,
+		downloads: downloads,
+		trash:     trash,
+		multipart: multipart,
+		logger:    logger,
+	}
+}
+
+// s3Request is an authenticated S3 request split into bucket and key.
+type s3Request struct {
+	authed      *service.AuthenticatedUser
+	bucket      string
+	key         string
+	payloadHash string
+}
+
+// bucketPath returns the cloud path of the bucket folder.
+func (req *s3Request) bucketPath() vo.CloudPath {
+	return vo.NewCloudPath("/" + req.bucket)
+}
+
+// objectPath returns the cloud path of the object key.
+func (req *s3Request) objectPath() vo.CloudPath {
+	return vo.NewCloudPath("/" + req.bucket + "/" + req.key)
+}
+
+// HandleS3 dispatches all requests under /s3/.
+func (h *S3Handler) HandleS3(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, s3Prefix), "/")
+	req.bucket, req.key, _ = strings.Cut(rest, "/")
+
+	if req.bucket == "" {
+		if r.Method != http.MethodGet {
+			writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+			return
+		}
+		h.listBuckets(w, r, req)
+		return
+	}
+	if !validS3Path(req) {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid bucket name or object key.")
+		return
+	}
+
+	q := r.URL.Query()
+	for _, sub := range s3Subresources {
+		if q.Has(sub) {
+			writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", "The "+sub+" subresource is not supported.")
+			return
+		}
+	}
+
+	if req.key == "" {
+		h.serveBucket(w, r, req)
+		return
+	}
+	h.serveObject(w, r, req)
+}
+
+// authenticate verifies the SigV4 signature and resolves the key owner.
+// Writes the S3 error and returns false on failure.
+func (h *S3Handler) authenticate(w http.ResponseWriter, r *http.Request) (*s3Request, bool) {
+	sig, err := parseSigV4(r)
+	if err != nil {
+		writeS3Error(w, r, http.StatusForbidden, "AccessDenied", "Requests must be signed with AWS Signature Version 4.")
+		return nil, false
+	}
+
+	key, err := h.keys.Lookup(sig.KeyID)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeS3Error(w, r, http.StatusForbidden, "InvalidAccessKeyId", "The access key ID you provided does not exist in our records.")
+			return nil, false
+		}
+		h.internalError(w, r, err)
+		return nil, false
+	}
+
+	if err := sig.verify(r, key.Secret, time.Now()); err != nil {
+		if errors.Is(err, errRequestTime) {
+			writeS3Error(w, r, http.StatusForbidden, "RequestTimeTooSkewed", "The request time is outside the allowed window.")
+			return nil, false
+		}
+		writeS3Error(w, r, http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.")
+		return nil, false
+	}
+
+	if err := h.keys.RecordUse(key); err != nil {
+		h.logger.Warn("Failed to record access key use: key=%s err=%v", key.KeyID, err)
+	}
+
+	authed, err := h.auth.ResolveUser(key.UserID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return nil, false
+	}
+	if authed == nil {
+		writeS3Error(w, r, http.StatusForbidden, "InvalidAccessKeyId", "The access key ID you provided does not exist in our records.")
+		return nil, false
+	}
+	return &s3Request{authed: authed, payloadHash: sig.PayloadHash}, true
+}
+
+// validS3Path rejects bucket names and keys that would resolve outside the bucket,
+// such as keys containing ".." segments.
+func validS3Path(req *s3Request) bool {
+	bucket := req.bucketPath()
+	if !bucket.Parent().IsRoot() || bucket.Name() != req.bucket {
+		return false
+	}
+	if req.key == "" {
+		return true
+	}
+	return strings.HasPrefix(req.objectPath().String(), bucket.String()+"/")
+}
+
+// serveBucket handles requests addressed to a bucket.
+func (h *S3Handler) serveBucket(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPut:
+		h.createBucket(w, r, req)
+		return
+	case r.Method == http.MethodPost && q.Has("delete"):
+		// DeleteObjects checks the bucket itself.
+	case r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodDelete:
+		writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+		return
+	}
+
+	if !h.requireBucket(w, r, req) {
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		h.deleteBucket(w, r, req)
+	case r.Method == http.MethodPost:
+		h.deleteObjects(w, r, req)
+	case q.Has("location"):
+		writeS3XML(w, http.StatusOK, S3LocationConstraint{Xmlns: s3Namespace})
+	case q.Has("uploads"):
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", "Listing multipart uploads is not supported.")
+	default:
+		h.listObjects(w, r, req)
+	}
+}
+
+// serveObject handles requests addressed to an object key.
+func (h *S3Handler) serveObject(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	if !h.requireBucket(w, r, req) {
+		return
+	}
+
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.getObject(w, r, req)
+	case http.MethodPut:
+		switch {
+		case q.Has("uploadId"):
+			h.uploadPart(w, r, req)
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			h.copyObject(w, r, req)
+		default:
+			h.putObject(w, r, req)
+		}
+	case http.MethodPost:
+		switch {
+		case q.Has("uploads"):
+			h.createMultipartUpload(w, r, req)
+		case q.Has("uploadId"):
+			h.completeMultipartUpload(w, r, req)
+		default:
+			writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+		}
+	case http.MethodDelete:
+		if q.Has("uploadId") {
+			h.abortMultipartUpload(w, r, req)
+			return
+		}
+		h.deleteObject(w, r, req)
+	default:
+		writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	}
+}
+
+// requireBucket writes NoSuchBucket and returns false unless the bucket folder exists.
+func (h *S3Handler) requireBucket(w http.ResponseWriter, r *http.Request, req *s3Request) bool {
+	node, err := h.folders.Get(req.authed.UserID, req.bucketPath())
+	if err != nil {
+		h.internalError(w, r, err)
+		return false
+	}
+	if node == nil || !node.IsFolder() {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return false
+	}
+	return true
+}
+
+// listBuckets returns the caller's top-level folders.
+func (h *S3Handler) listBuckets(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	result := S3ListAllMyBucketsResult{
+		Xmlns:   s3Namespace,
+		Owner:   S3Owner{ID: strconv.FormatInt(req.authed.UserID, 10), DisplayName: req.authed.Email},
+		Buckets: []S3Bucket{},
+	}
+
+	children, err := h.folders.ListChildren(req.authed.UserID, vo.NewCloudPath("/"), 0, davListLimit)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	for _, n := range children {
+		if n.IsFolder() {
+			result.Buckets = append(result.Buckets, S3Bucket{Name: n.Name, CreationDate: s3Time(n.Created)})
+		}
+	}
+	writeS3XML(w, http.StatusOK, result)
+}
+
+// createBucket creates a top-level folder.
+func (h *S3Handler) createBucket(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	if _, err := h.folders.CreateFolder(req.authed.UserID, req.bucketPath()); err != nil {
+		if errors.Is(err, service.ErrAlreadyExists) {
+			writeS3Error(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou", "The bucket you tried to create already exists.")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/"+req.bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteBucket moves an empty bucket folder to the trash.
+func (h *S3Handler) deleteBucket(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	folders, files, err := h.folders.CountChildren(req.authed.UserID, req.bucketPath())
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	if folders+files > 0 {
+		writeS3Error(w, r, http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty.")
+		return
+	}
+	if err := h.trash.Trash(req.authed.UserID, req.bucketPath(), req.authed.UserID); err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listObjects implements ListObjects (v1) and ListObjectsV2 (list-type=2).
+func (h *S3Handler) listObjects(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	q := r.URL.Query()
+	v2 := q.Get("list-type") == "2"
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	encode := q.Get("encoding-type") == "url"
+
+	maxKeys := s3MaxKeys
+	if v := q.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "max-keys must be a non-negative integer.")
+			return
+		}
+		maxKeys = min(n, s3MaxKeys)
+	}
+
+	after := q.Get("marker")
+	if v2 {
+		after = q.Get("start-after")
+		if token := q.Get("continuation-token"); token != "" {
+			key, ok := decodeS3Token(token)
+			if !ok {
+				writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect.")
+				return
+			}
+			after = max(after, key)
+		}
+	}
+
+	nodes, err := h.folders.ListTree(req.authed.UserID, req.bucketPath())
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	root := req.bucketPath().String() + "/"
+	entries := make([]s3Entry, 0, len(nodes))
+	for _, n := range nodes {
+		key := strings.TrimPrefix(n.Home.String(), root)
+		if key == n.Home.String() {
+			continue
+		}
+		if n.IsFolder() {
+			entries = append(entries, s3Entry{Key: key + "/", Folder: true})
+			continue
+		}
+		entries = append(entries, s3Entry{Key: key, Object: S3Object{
+			Key:          s3EncodeKey(key, encode),
+			LastModified: s3Time(n.MTime),
+			ETag:         s3ETag(n.Hash.String()),
+			Size:         n.Size,
+			StorageClass: "STANDARD",
+		}})
+	}
+	page := listS3Entries(entries, prefix, delimiter, after, maxKeys)
+
+	result := S3ListBucketResult{
+		Xmlns:       s3Namespace,
+		Name:        req.bucket,
+		Prefix:      s3EncodeKey(prefix, encode),
+		Delimiter:   s3EncodeKey(delimiter, encode),
+		MaxKeys:     maxKeys,
+		IsTruncated: page.Truncated,
+		Contents:    page.Objects,
+	}
+	if encode {
+		result.EncodingType = "url"
+	}
+	for _, p := range page.Prefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, S3CommonPrefix{Prefix: s3EncodeKey(p, encode)})
+	}
+
+	if v2 {
+		keyCount := len(page.Objects) + len(page.Prefixes)
+		result.KeyCount = &keyCount
+		result.StartAfter = s3EncodeKey(q.Get("start-after"), encode)
+		result.ContinuationToken = q.Get("continuation-token")
+		if page.Truncated {
+			result.NextContinuationToken = encodeS3Token(page.Next)
+		}
+	} else {
+		marker := s3EncodeKey(q.Get("marker"), encode)
+		result.Marker = &marker
+		if page.Truncated {
+			result.NextMarker = s3EncodeKey(page.Next, encode)
+		}
+	}
+	writeS3XML(w, http.StatusOK, result)
+}
+
+// getObject serves GetObject and HeadObject, including range and conditional requests.
+func (h *S3Handler) getObject(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	result, err := h.downloads.Resolve(req.authed.UserID, req.objectPath())
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+	defer result.File.Close()
+
+	ct := mime.TypeByExtension(path.Ext(result.Node.Name))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("ETag", s3ETag(result.Node.Hash.String()))
+	w.Header().Set("Accept-Ranges", "bytes")
+	http.ServeContent(w, r, result.Node.Name, time.Unix(result.Node.MTime, 0), result.File)
+}
+
+// putObject stores the request body at the key, replacing an existing file.
+// A zero-length object whose key ends with "/" creates a folder instead.
+func (h *S3Handler) putObject(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	body, ok := h.spoolBody(w, r, req)
+	if !ok {
+		return
+	}
+	defer body.Close()
+	p := req.objectPath()
+
+	if strings.HasSuffix(req.key, "/") {
+		if body.size > 0 {
+			writeS3Error(w, r, http.StatusBadRequest, "InvalidRequest", "Keys ending with a slash denote folders and cannot have content.")
+			return
+		}
+		if _, err := h.folders.CreateFolder(req.authed.UserID, p); err != nil && !errors.Is(err, service.ErrAlreadyExists) {
+			h.internalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if md5sum := r.Header.Get("Content-MD5"); md5sum != "" {
+		if md5sum != base64.StdEncoding.EncodeToString(body.md5) {
+			writeS3Error(w, r, http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+			return
+		}
+	}
+	if !h.requireFileTarget(w, r, req) {
+		return
+	}
+
+	hash, err := h.uploads.UploadFile(body.file, body.size)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	if _, err := h.files.AddByHash(req.authed.UserID, p, hash, body.size, vo.ConflictReplace); err != nil {
+		h.writeStoreError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", s3ETag(hash.String()))
+	w.WriteHeader(http.StatusOK)
+}
+
+// copyObject registers the content of another object at the key without copying data.
+func (h *S3Handler) copyObject(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source.")
+		return
+	}
+	source, _, _ = strings.Cut(source, "?versionId=")
+	src := &s3Request{authed: req.authed}
+	src.bucket, src.key, _ = strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	if src.bucket == "" || src.key == "" || !validS3Path(src) {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source.")
+		return
+	}
+
+	node, err := h.files.Get(req.authed.UserID, src.objectPath())
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	if node == nil || !node.HasContent() {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", "The specified copy source does not exist.")
+		return
+	}
+	if !h.requireFileTarget(w, r, req) {
+		return
+	}
+
+	copied, err := h.files.AddByHash(req.authed.UserID, req.objectPath(), node.Hash, node.Size, vo.ConflictReplace)
+	if err != nil {
+		h.writeStoreError(w, r, err)
+		return
+	}
+	writeS3XML(w, http.StatusOK, S3CopyObjectResult{
+		ETag:         s3ETag(copied.Hash.String()),
+		LastModified: s3Time(copied.MTime),
+	})
+}
+
+// deleteObject moves the object to the trash. A key ending with "/" removes the
+// folder only when it is empty. Missing keys are not an error, as in S3.
+func (h *S3Handler) deleteObject(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	if err := h.removeKey(req); err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteObjects implements the batch DeleteObjects request.
+func (h *S3Handler) deleteObjects(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	var body S3Delete
+	if !h.readXMLBody(w, r, req, &body) {
+		return
+	}
+
+	result := S3DeleteResult{Xmlns: s3Namespace}
+	for _, obj := range body.Objects {
+		target := &s3Request{authed: req.authed, bucket: req.bucket, key: obj.Key}
+		if obj.Key == "" || !validS3Path(target) {
+			result.Errors = append(result.Errors, S3DeleteError{Key: obj.Key, Code: "InvalidArgument", Message: "Invalid object key."})
+			continue
+		}
+		if err := h.removeKey(target); err != nil {
+			h.logger.Error("S3 delete failed: user=%q key=%q err=%v", req.authed.Email, obj.Key, err)
+			result.Errors = append(result.Errors, S3DeleteError{Key: obj.Key, Code: "InternalError", Message: "We encountered an internal error."})
+			continue
+		}
+		if !body.Quiet {
+			result.Deleted = append(result.Deleted, S3DeletedObject{Key: obj.Key})
+		}
+	}
+	writeS3XML(w, http.StatusOK, result)
+}
+
+// removeKey trashes the file at the key, or the empty folder for keys ending with "/".
+func (h *S3Handler) removeKey(req *s3Request) error {
+	p := req.objectPath()
+	node, err := h.files.Get(req.authed.UserID, p)
+	if err != nil || node == nil {
+		return err
+	}
+
+	if strings.HasSuffix(req.key, "/") {
+		if !node.IsFolder() {
+			return nil
+		}
+		folders, files, err := h.folders.CountChildren(req.authed.UserID, p)
+		if err != nil || folders+files > 0 {
+			return err
+		}
+	} else if !node.IsFile() {
+		return nil
+	}
+	return h.trash.Trash(req.authed.UserID, p, req.authed.UserID)
+}
+
+// createMultipartUpload starts a multipart upload to the key.
+func (h *S3Handler) createMultipartUpload(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	if !h.requireFileTarget(w, r, req) {
+		return
+	}
+	upload, err := h.multipart.Start(req.authed.UserID, req.objectPath())
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	writeS3XML(w, http.StatusOK, S3InitiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		UploadID: upload.ID,
+	})
+}
+
+// uploadPart stores one part of a multipart upload.
+func (h *S3Handler) uploadPart(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	q := r.URL.Query()
+	number, err := strconv.Atoi(q.Get("partNumber"))
+	if err != nil || number < 1 || number > s3MaxPartNumber {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000.")
+		return
+	}
+	if _, ok := h.requireUpload(w, r, req); !ok {
+		return
+	}
+
+	body, ok := h.spoolBody(w, r, req)
+	if !ok {
+		return
+	}
+	defer body.Close()
+	part, err := h.multipart.UploadPart(req.authed.UserID, q.Get("uploadId"), number, body.file, body.size)
+	if err != nil {
+		h.writeUploadError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", s3ETag(part.Hash.String()))
+	w.WriteHeader(http.StatusOK)
+}
+
+// completeMultipartUpload assembles the listed parts into the object.
+func (h *S3Handler) completeMultipartUpload(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	upload, ok := h.requireUpload(w, r, req)
+	if !ok {
+		return
+	}
+
+	var body S3CompleteMultipartUpload
+	if !h.readXMLBody(w, r, req, &body) {
+		return
+	}
+
+	parts, err := h.multipart.Parts(req.authed.UserID, upload.ID)
+	if err != nil {
+		h.writeUploadError(w, r, err)
+		return
+	}
+	byNumber := make(map[int]entity.MultipartPart, len(parts))
+	for _, p := range parts {
+		byNumber[p.Number] = p
+	}
+
+	// Validate the listed ETags and the assembled size before any content is concatenated.
+	numbers := make([]int, 0, len(body.Parts))
+	var size int64
+	for _, p := range body.Parts {
+		part, ok := byNumber[p.PartNumber]
+		etag := strings.Trim(p.ETag, `"`)
+		if !ok || (etag != "" && etag != part.Hash.String()) {
+			writeS3Error(w, r, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found.")
+			return
+		}
+		numbers = append(numbers, p.PartNumber)
+		size += part.Size
+	}
+	if limit := req.authed.FileSizeLimit; limit > 0 && size > limit {
+		writeS3Error(w, r, http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed size.")
+		return
+	}
+
+	node, err := h.multipart.Complete(req.authed.UserID, upload.ID, numbers)
+	if err != nil {
+		h.writeUploadError(w, r, err)
+		return
+	}
+	writeS3XML(w, http.StatusOK, S3CompleteMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: s3Prefix + "/" + req.bucket + "/" + req.key,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		ETag:     s3ETag(node.Hash.String()),
+	})
+}
+
+// abortMultipartUpload discards a multipart upload and its parts.
+func (h *S3Handler) abortMultipartUpload(w http.ResponseWriter, r *http.Request, req *s3Request) {
+	upload, ok := h.requireUpload(w, r, req)
+	if !ok {
+		return
+	}
+	if err := h.multipart.Abort(req.authed.UserID, upload.ID); err != nil {
+		h.writeUploadError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireUpload loads the upload named by the uploadId parameter and checks
+// that it targets the request key. Writes NoSuchUpload and returns false otherwise.
+func (h *S3Handler) requireUpload(w http.ResponseWriter, r *http.Request, req *s3Request) (*entity.MultipartUpload, bool) {
+	upload, err := h.multipart.Get(req.authed.UserID, r.URL.Query().Get("uploadId"))
+	if err != nil {
+		h.writeUploadError(w, r, err)
+		return nil, false
+	}
+	if upload.Home.String() != req.objectPath().String() {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist.")
+		return nil, false
+	}
+	return upload, true
+}
+
+// requireFileTarget refuses to write an object over an existing folder,
+// which a replacing upload would otherwise delete with all its contents.
+func (h *S3Handler) requireFileTarget(w http.ResponseWriter, r *http.Request, req *s3Request) bool {
+	node, err := h.files.Get(req.authed.UserID, req.objectPath())
+	if err != nil {
+		h.internalError(w, r, err)
+		return false
+	}
+	if node != nil && node.IsFolder() {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidRequest", "A folder already exists at the specified key.")
+		return false
+	}
+	return true
+}
+
+// s3Body is a request payload spooled to a temporary file.
+type s3Body struct {
+	file *os.File
+	size int64
+	md5  []byte // MD5 digest of the payload, for Content-MD5 checks.
+}
+
+// Close removes the temporary file.
+func (b *s3Body) Close() {
+	b.file.Close()
+	os.Remove(b.file.Name())
+}
+
+// spoolBody streams the request payload to a temporary file, enforcing the
+// payload hash and the user's file size limit. Payloads are capped at
+// s3MaxBodySize even for users without a limit. The caller must Close the body.
+func (h *S3Handler) spoolBody(w http.ResponseWriter, r *http.Request, req *s3Request) (*s3Body, bool) {
+	limit := int64(s3MaxBodySize)
+	if l := req.authed.FileSizeLimit; l > 0 && l < limit {
+		limit = l
+	}
+
+	f, err := os.CreateTemp("", "tucha-s3-*")
+	if err != nil {
+		h.internalError(w, r, err)
+		return nil, false
+	}
+	body := &s3Body{file: f}
+	sum := md5.New()
+	size, ok := h.copyBody(w, r, req, io.MultiWriter(f, sum), limit)
+	if !ok {
+		body.Close()
+		return nil, false
+	}
+	body.size, body.md5 = size, sum.Sum(nil)
+	return body, true
+}
+
+// readXMLBody reads a request payload of at most s3MaxXMLBodySize and decodes it into v.
+func (h *S3Handler) readXMLBody(w http.ResponseWriter, r *http.Request, req *s3Request, v any) bool {
+	var buf bytes.Buffer
+	if _, ok := h.copyBody(w, r, req, &buf, s3MaxXMLBodySize); !ok {
+		return false
+	}
+	if err := xml.Unmarshal(buf.Bytes(), v); err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed.")
+		return false
+	}
+	return true
+}
+
+// copyBody copies the request payload of at most limit bytes to dst and
+// returns its size. On failure it writes the error response.
+func (h *S3Handler) copyBody(w http.ResponseWriter, r *http.Request, req *s3Request, dst io.Writer, limit int64) (int64, bool) {
+	streaming := strings.HasPrefix(req.payloadHash, sigV4StreamingBody)
+	if r.ContentLength > limit && !streaming {
+		writeS3Error(w, r, http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed size.")
+		return 0, false
+	}
+
+	// aws-chunked framing adds a signed header of under 100 bytes to chunks
+	// of at least 8 KiB, so the wire size allows for a small overhead.
+	wireLimit := limit
+	if streaming {
+		wireLimit += limit/64 + awsChunkHeaderMax
+	}
+	n, err := copySigV4Body(dst, http.MaxBytesReader(w, r.Body, wireLimit), req.payloadHash, limit)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, errSignature):
+			writeS3Error(w, r, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided x-amz-content-sha256 header does not match what was computed.")
+		case errors.Is(err, errBodyTooLarge), errors.As(err, &tooLarge):
+			writeS3Error(w, r, http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed size.")
+		default:
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody", "The request body could not be read.")
+		}
+		return 0, false
+	}
+	return n, true
+}
+
+// writeStoreError maps errors from storing an object.
+func (h *S3Handler) writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrOverQuota) {
+		writeS3Error(w, r, http.StatusForbidden, "QuotaExceeded", "The storage quota would be exceeded.")
+		return
+	}
+	h.internalError(w, r, err)
+}
+
+// writeUploadError maps errors from multipart upload operations.
+func (h *S3Handler) writeUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist.")
+	case errors.Is(err, service.ErrInvalidPart):
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found.")
+	default:
+		h.writeStoreError(w, r, err)
+	}
+}
+
+// internalError logs the error and writes a generic S3 InternalError.
+func (h *S3Handler) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error("S3 %s %s failed: %v", r.Method, r.URL.Path, err)
+	writeS3Error(w, r, http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again.")
+}
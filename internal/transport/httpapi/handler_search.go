@@ -0,0 +1,153 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+const (
+	// defaultSearchLimit is the page size of search results when none is given.
+	defaultSearchLimit = 100
+
+	// maxSearchLimit caps the page size of search results.
+	maxSearchLimit = 1000
+)
+
+// SearchHandler serves the search over node names, metadata and file content.
+type SearchHandler struct {
+	auth      *service.AuthService
+	search    *service.SearchService
+	presenter *Presenter
+}
+
+// NewSearchHandler creates a new SearchHandler.
+func NewSearchHandler(auth *service.AuthService, search *service.SearchService, presenter *Presenter) *SearchHandler {
+	return &SearchHandler{
+		auth:      auth,
+		search:    search,
+		presenter: presenter,
+	}
+}
+
+// HandleFind handles GET /api/v2/folder/find - nodes below home whose names
+// contain the words of q, filtered by type, extension, tag, extended attribute,
+// size and modification time.
+// Results are ranked by relevance, or ordered by path without q.
+// With content=1, q is searched in the indexed text of files instead, and
+// each result carries a snippet of the matching text.
+func (h *SearchHandler) HandleFind(w http.ResponseWriter, r *http.Request) {
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	q := r.URL.Query()
+	home := q.Get("home")
+	if home == "" {
+		home = "/"
+	}
+	query := repository.NodeQuery{
+		Text:      q.Get("q"),
+		Extension: strings.TrimPrefix(q.Get("ext"), "."),
+		Under:     vo.NewCloudPath(home),
+	}
+	if !authorize(w, authed, vo.ScopeRead, query.Under) {
+		return
+	}
+
+	content, _ := strconv.ParseBool(q.Get("content"))
+	if content && query.Text == "" {
+		writeError(w, authed.Email, 400, "q", "required")
+		return
+	}
+
+	if v := q.Get("tag"); v != "" {
+		tag, err := vo.ParseTag(v)
+		if err != nil {
+			writeError(w, authed.Email, 400, "tag", "invalid")
+			return
+		}
+		query.Tag = tag
+	}
+	if v := q.Get("xattr"); v != "" {
+		raw, value, _ := strings.Cut(v, ":")
+		name, err := vo.ParseXAttrName(raw)
+		if err != nil {
+			writeError(w, authed.Email, 400, "xattr", "invalid")
+			return
+		}
+		query.XAttr = name
+		query.XAttrValue = value
+	}
+	if v := q.Get("type"); v != "" {
+		t, err := vo.ParseNodeType(v)
+		if err != nil {
+			writeError(w, authed.Email, 400, "type", "invalid")
+			return
+		}
+		query.Type = t
+	}
+	for _, bound := range []struct {
+		param string
+		dst   *int64
+	}{
+		{"size_min", &query.MinSize},
+		{"size_max", &query.MaxSize},
+		{"mtime_from", &query.After},
+		{"mtime_to", &query.Before},
+	} {
+		if v := q.Get(bound.param); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				writeError(w, authed.Email, 400, bound.param, "invalid")
+				return
+			}
+			*bound.dst = n
+		}
+	}
+
+	offset, err := strconv.Atoi(q.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit := defaultSearchLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, authed.Email, 400, "limit", "invalid")
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	var matches []repository.NodeMatch
+	var more bool
+	if content {
+		matches, more, err = h.search.FindContent(authed.UserID, query, offset, limit)
+	} else {
+		var nodes []entity.Node
+		nodes, more, err = h.search.Find(authed.UserID, query, offset, limit)
+		for i := range nodes {
+			matches = append(matches, repository.NodeMatch{Node: nodes[i]})
+		}
+	}
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	result := SearchResult{HasMore: more, List: make([]SearchItem, 0, len(matches))}
+	for i := range matches {
+		result.List = append(result.List, SearchItem{
+			FolderItem: h.presenter.NodeToFolderItem(&matches[i].Node, nil),
+			Snippet:    matches[i].Snippet,
+		})
+	}
+	writeSuccess(w, authed.Email, result)
+}
@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/repository"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// setupHandlerAuth returns an AuthService that accepts the access token of
+// mock.NewTestToken for the test user with ID 1.
+func setupHandlerAuth() *service.AuthService {
+	testUser := mock.NewTestUser(1, "user@example.com")
+	testToken := mock.NewTestToken(1, time.Now().Add(time.Hour))
+
+	tokenRepo := &mock.TokenRepositoryMock{
+		LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+			if accessToken == testToken.AccessToken {
+				return testToken, nil
+			}
+			return nil, nil
+		},
+	}
+	userRepo := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			return testUser, nil
+		},
+	}
+	return service.NewAuthService(tokenRepo, userRepo)
+}
+
+func TestSearchHandler_HandleFind(t *testing.T) {
+	var got repository.NodeQuery
+	search := &mock.NodeSearchRepositoryMock{
+		SearchFunc: func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+			got = *query
+			return []repository.NodeMatch{
+				{Node: *mock.NewTestFileNode(1, "/docs/report.pdf", mock.ValidHash(), 100)},
+				{Node: *mock.NewTestFileNode(1, "/docs/old/report.pdf", mock.ValidHash(), 200)},
+			}, nil
+		},
+		SearchContentFunc: func(userID int64, query *repository.NodeQuery, limit int) ([]repository.NodeMatch, error) {
+			got = *query
+			return []repository.NodeMatch{
+				{Node: *mock.NewTestFileNode(1, "/notes.txt", mock.ValidHash(), 10), Snippet: "the <mark>budget</mark>"},
+			}, nil
+		},
+	}
+	auth := setupHandlerAuth()
+	shares := service.NewShareService(&mock.ShareRepositoryMock{}, &mock.NodeRepositoryMock{}, &mock.ContentRepositoryMock{}, &mock.UserRepositoryMock{})
+	h := NewSearchHandler(auth, service.NewSearchService(search, shares), NewPresenter())
+
+	find := func(query string) (*httptest.ResponseRecorder, SearchResult) {
+		w := httptest.NewRecorder()
+		h.HandleFind(w, httptest.NewRequest(http.MethodGet, "/api/v2/folder/find?access_token=access-token-123"+query, nil))
+		var resp struct {
+			Body SearchResult `json:"body"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp.Body
+	}
+
+	t.Run("passes the filters and pages the results", func(t *testing.T) {
+		w, result := find("&q=report&home=/docs&type=file&ext=.pdf&tag=Work&size_min=10&size_max=500&mtime_from=1&mtime_to=2&limit=1")
+
+		if w.Code != http.StatusOK || len(result.List) != 1 || !result.HasMore || result.List[0].Home != "/docs/old/report.pdf" {
+			t.Fatalf("got %d %+v, want the first of two matches", w.Code, result)
+		}
+		want := repository.NodeQuery{Text: "report", Type: vo.NodeTypeFile, MinSize: 10, MaxSize: 500, After: 1, Before: 2, Extension: "pdf", Under: vo.NewCloudPath("/docs"), Tag: "work"}
+		if got != want {
+			t.Errorf("query = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("content search", func(t *testing.T) {
+		w, result := find("&q=budget&content=1&ext=txt")
+
+		if w.Code != http.StatusOK || len(result.List) != 1 || result.List[0].Home != "/notes.txt" || result.List[0].Snippet != "the <mark>budget</mark>" {
+			t.Fatalf("got %d %+v, want the file with its snippet", w.Code, result)
+		}
+		if got.Text != "budget" || got.Extension != "txt" {
+			t.Errorf("query = %+v, want the text and filters passed", got)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		for _, query := range []string{"&type=link", "&size_min=-1", "&mtime_to=soon", "&limit=0", "&content=1", "&tag=a,b"} {
+			if w, _ := find(query); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400", query, w.Code)
+			}
+		}
+	})
+}
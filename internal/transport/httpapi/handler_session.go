@@ -0,0 +1,98 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+)
+
+// SessionHandler lets the admin audit and revoke a user's OAuth sessions per client.
+type SessionHandler struct {
+	adminAuth *service.AdminAuthService
+	tokens    *service.TokenService
+	clients   *service.ClientRegistry
+}
+
+// NewSessionHandler creates a new SessionHandler.
+func NewSessionHandler(adminAuth *service.AdminAuthService, tokens *service.TokenService, clients *service.ClientRegistry) *SessionHandler {
+	return &SessionHandler{adminAuth: adminAuth, tokens: tokens, clients: clients}
+}
+
+// HandleList handles GET /admin/user/sessions?id=<user_id> - list sessions grouped by client.
+// Registered clients are always listed; sessions of clients that were removed from
+// the configuration are listed after them so they can still be revoked.
+func (h *SessionHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuth.Validate(extractAdminToken(r)) {
+		writeEnvelope(w, "", 403, "forbidden")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	sessions, err := h.tokens.ListSessions(userID)
+	if err != nil {
+		writeEnvelope(w, "", 500, "unknown")
+		return
+	}
+
+	groups := make([]ClientSessions, 0)
+	index := make(map[string]int)
+	for _, id := range h.clients.IDs() {
+		index[id] = len(groups)
+		groups = append(groups, ClientSessions{ClientID: id, Registered: true, Sessions: []SessionInfo{}})
+	}
+	for _, t := range sessions {
+		i, ok := index[t.ClientID]
+		if !ok {
+			i = len(groups)
+			index[t.ClientID] = i
+			groups = append(groups, ClientSessions{ClientID: t.ClientID, Sessions: []SessionInfo{}})
+		}
+		groups[i].Sessions = append(groups[i].Sessions, SessionInfo{
+			ID:        t.ID,
+			ClientID:  t.ClientID,
+			ExpiresAt: t.ExpiresAt,
+			Created:   t.Created,
+		})
+	}
+
+	writeSuccess(w, "", groups)
+}
+
+// HandleRevoke handles POST /admin/user/sessions/revoke - revoke all of a user's sessions for one client.
+func (h *SessionHandler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.adminAuth.Validate(extractAdminToken(r)) {
+		writeEnvelope(w, "", 403, "forbidden")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
+	// An empty client_id is valid: it addresses sessions issued before clients were recorded.
+	revoked, err := h.tokens.RevokeClientSessions(userID, r.FormValue("client_id"))
+	if err != nil {
+		writeEnvelope(w, "", 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, "", map[string]int64{"revoked": revoked})
+}
@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestSessionHandler_HandleList_groupsByClient(t *testing.T) {
+	tokenRepo := &mock.TokenRepositoryMock{
+		ListSessionsFunc: func(userID int64) ([]entity.Token, error) {
+			return []entity.Token{
+				{ID: 3, UserID: userID, ClientID: "cloud-win"},
+				{ID: 2, UserID: userID, ClientID: "retired-tool"},
+				{ID: 1, UserID: userID, ClientID: "cloud-win"},
+			}, nil
+		},
+	}
+	adminAuth := service.NewAdminAuthService("admin", "secret")
+	adminToken, err := adminAuth.Login("admin", "secret", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	h := NewSessionHandler(adminAuth, service.NewTokenService(tokenRepo, &mock.UserRepositoryMock{}, &mock.AppPasswordRepositoryMock{}), newTestClientRegistry())
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/user/sessions?id=1", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+
+	h.HandleList(w, req)
+
+	var env struct {
+		Status int              `json:"status"`
+		Body   []ClientSessions `json:"body"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.Status != 200 {
+		t.Fatalf("status = %d, want 200", env.Status)
+	}
+
+	got := make(map[string]ClientSessions)
+	for _, g := range env.Body {
+		got[g.ClientID] = g
+	}
+	if len(env.Body) != 3 {
+		t.Fatalf("groups = %d, want 3 (two registered + one retired)", len(env.Body))
+	}
+	if n := len(got["cloud-win"].Sessions); n != 2 {
+		t.Errorf("cloud-win sessions = %d, want 2", n)
+	}
+	if g := got["backup-tool"]; !g.Registered || len(g.Sessions) != 0 {
+		t.Errorf("backup-tool = %+v, want registered with no sessions", g)
+	}
+	if g := got["retired-tool"]; g.Registered || len(g.Sessions) != 1 {
+		t.Errorf("retired-tool = %+v, want unregistered with one session", g)
+	}
+}
@@ -42,6 +42,9 @@ func (h *ShareHandler) HandleShare(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
@@ -76,6 +79,10 @@ func (h *ShareHandler) HandleShare(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeShare, path) {
+		return
+	}
+
 	share, err := h.shares.Share(authed.UserID, path, invite.Email, access)
 	if err != nil {
 		switch {
@@ -103,6 +110,9 @@ func (h *ShareHandler) HandleUnshare(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
@@ -130,6 +140,10 @@ func (h *ShareHandler) HandleUnshare(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeShare, path) {
+		return
+	}
+
 	if err := h.shares.Unshare(authed.UserID, path, invite.Email); err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			writeHomeError(w, authed.Email, 404, "not_exists")
@@ -153,6 +167,9 @@ func (h *ShareHandler) HandleSharedInfo(w http.ResponseWriter, r *http.Request)
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	homePath := r.URL.Query().Get("home")
 	if homePath == "" {
@@ -161,6 +178,10 @@ func (h *ShareHandler) HandleSharedInfo(w http.ResponseWriter, r *http.Request)
 	}
 
 	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeShare, path) {
+		return
+	}
+
 	shares, err := h.shares.GetShareInfo(authed.UserID, path)
 	if err != nil {
 		writeHomeError(w, authed.Email, 500, "unknown")
@@ -186,6 +207,9 @@ func (h *ShareHandler) HandleIncoming(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	shares, err := h.shares.ListIncoming(authed.Email)
 	if err != nil {
@@ -219,6 +243,9 @@ func (h *ShareHandler) HandleMount(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
@@ -238,6 +265,10 @@ func (h *ShareHandler) HandleMount(w http.ResponseWriter, r *http.Request) {
 		conflict = vo.ConflictRename
 	}
 
+	if !authorize(w, authed, vo.ScopeShare, vo.NewCloudPath(home)) {
+		return
+	}
+
 	if err := h.shares.Mount(authed.UserID, home, inviteToken, conflict); err != nil {
 		switch {
 		case errors.Is(err, service.ErrNotFound):
@@ -266,6 +297,9 @@ func (h *ShareHandler) HandleUnmount(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
@@ -278,6 +312,10 @@ func (h *ShareHandler) HandleUnmount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, authed, vo.ScopeShare, vo.NewCloudPath(homePath)) {
+		return
+	}
+
 	cloneCopy := r.FormValue("clone_copy") == "true"
 
 	if err := h.shares.Unmount(authed.UserID, homePath, cloneCopy); err != nil {
@@ -303,6 +341,9 @@ func (h *ShareHandler) HandleReject(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeShare) {
+		return
+	}
 
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // SpaceHandler handles storage quota information.
@@ -26,6 +27,9 @@ func (h *SpaceHandler) HandleSpace(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
 
 	usage, err := h.quota.GetUsage(authed.UserID)
 	if err != nil {
@@ -34,9 +38,11 @@ func (h *SpaceHandler) HandleSpace(w http.ResponseWriter, r *http.Request) {
 	}
 
 	space := SpaceInfo{
-		Overquota:  usage.Overquota,
-		BytesTotal: usage.BytesTotal,
-		BytesUsed:  usage.BytesUsed,
+		Overquota:     usage.Overquota,
+		BytesTotal:    usage.BytesTotal,
+		BytesUsed:     usage.BytesUsed,
+		BytesVersions: usage.BytesVersions,
+		BytesTrash:    usage.BytesTrash,
 	}
 
 	writeSuccess(w, authed.Email, space)
@@ -0,0 +1,126 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// SSHKeyHandler manages the caller's SFTP public keys at /api/v2/user/ssh-keys*.
+type SSHKeyHandler struct {
+	auth *service.AuthService
+	keys *service.SSHKeyService
+}
+
+// NewSSHKeyHandler creates a new SSHKeyHandler.
+func NewSSHKeyHandler(auth *service.AuthService, keys *service.SSHKeyService) *SSHKeyHandler {
+	return &SSHKeyHandler{auth: auth, keys: keys}
+}
+
+// HandleList handles GET /api/v2/user/ssh-keys - list the caller's public keys.
+func (h *SSHKeyHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	keys, err := h.keys.List(authed.UserID)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	infos := make([]SSHKeyInfo, 0, len(keys))
+	for i := range keys {
+		infos = append(infos, newSSHKeyInfo(&keys[i]))
+	}
+	writeSuccess(w, authed.Email, infos)
+}
+
+// HandleAdd handles POST /api/v2/user/ssh-keys/add - authorize a public key
+// given in authorized_keys format. The name defaults to the key comment.
+func (h *SSHKeyHandler) HandleAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	key := r.FormValue("key")
+	if key == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	k, err := h.keys.Add(authed.UserID, r.FormValue("name"), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidKey):
+			writeHomeError(w, authed.Email, 400, "invalid")
+		case errors.Is(err, service.ErrAlreadyExists):
+			writeHomeError(w, authed.Email, 400, "exists")
+		default:
+			writeHomeError(w, authed.Email, 500, "unknown")
+		}
+		return
+	}
+
+	writeSuccess(w, authed.Email, newSSHKeyInfo(k))
+}
+
+// HandleRemove handles POST /api/v2/user/ssh-keys/remove - revoke a public key.
+func (h *SSHKeyHandler) HandleRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	if err := h.keys.Revoke(authed.UserID, id); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, "ok")
+}
+
+// newSSHKeyInfo converts a public key to a DTO.
+func newSSHKeyInfo(k *entity.SSHKey) SSHKeyInfo {
+	return SSHKeyInfo{
+		ID:          k.ID,
+		Name:        k.Name,
+		PublicKey:   k.PublicKey,
+		Fingerprint: k.Fingerprint,
+		Created:     k.Created,
+		LastUsed:    k.LastUsed,
+	}
+}
@@ -0,0 +1,191 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// TagHandler handles tagging and starring nodes, and listing tagged and starred nodes.
+type TagHandler struct {
+	auth      *service.AuthService
+	tags      *service.TagService
+	presenter *Presenter
+}
+
+// NewTagHandler creates a new TagHandler.
+func NewTagHandler(auth *service.AuthService, tags *service.TagService, presenter *Presenter) *TagHandler {
+	return &TagHandler{
+		auth:      auth,
+		tags:      tags,
+		presenter: presenter,
+	}
+}
+
+// HandleTag handles POST /api/v2/file/tag - add tags to a node.
+func (h *TagHandler) HandleTag(w http.ResponseWriter, r *http.Request) {
+	h.handleTags(w, r, h.tags.AddTags)
+}
+
+// HandleUntag handles POST /api/v2/file/untag - remove tags from a node.
+func (h *TagHandler) HandleUntag(w http.ResponseWriter, r *http.Request) {
+	h.handleTags(w, r, h.tags.RemoveTags)
+}
+
+// handleTags changes the tags of the node at home with update. The tags are
+// given in repeated or comma-separated tag parameters.
+func (h *TagHandler) handleTags(w http.ResponseWriter, r *http.Request, update func(int64, vo.CloudPath, []vo.Tag) (*entity.Node, error)) {
+	path, authed := h.parseNodeForm(w, r)
+	if authed == nil {
+		return
+	}
+
+	tags, err := vo.ParseTags(strings.Join(r.Form["tag"], ","))
+	if err != nil {
+		writeError(w, authed.Email, 400, "tag", "invalid")
+		return
+	}
+	if len(tags) == 0 {
+		writeError(w, authed.Email, 400, "tag", "required")
+		return
+	}
+
+	node, err := update(authed.UserID, path, tags)
+	h.writeNode(w, authed, node, err)
+}
+
+// HandleStar handles POST /api/v2/file/star - star a node.
+func (h *TagHandler) HandleStar(w http.ResponseWriter, r *http.Request) {
+	h.handleStarred(w, r, true)
+}
+
+// HandleUnstar handles POST /api/v2/file/unstar - unstar a node.
+func (h *TagHandler) HandleUnstar(w http.ResponseWriter, r *http.Request) {
+	h.handleStarred(w, r, false)
+}
+
+// handleStarred stars or unstars the node at home.
+func (h *TagHandler) handleStarred(w http.ResponseWriter, r *http.Request, starred bool) {
+	path, authed := h.parseNodeForm(w, r)
+	if authed == nil {
+		return
+	}
+
+	node, err := h.tags.SetStarred(authed.UserID, path, starred)
+	h.writeNode(w, authed, node, err)
+}
+
+// parseNodeForm checks a labeling request and returns the node path from the
+// home parameter. Returns a nil user if the request was rejected.
+func (h *TagHandler) parseNodeForm(w http.ResponseWriter, r *http.Request) (vo.CloudPath, *service.AuthenticatedUser) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return vo.CloudPath{}, nil
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return vo.CloudPath{}, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return vo.CloudPath{}, nil
+	}
+
+	homePath := r.FormValue("home")
+	if homePath == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return vo.CloudPath{}, nil
+	}
+
+	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeWrite, path) {
+		return vo.CloudPath{}, nil
+	}
+	return path, authed
+}
+
+// writeNode writes the labeled node, or the error of labeling it.
+func (h *TagHandler) writeNode(w http.ResponseWriter, authed *service.AuthenticatedUser, node *entity.Node, err error) {
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+	writeSuccess(w, authed.Email, h.presenter.NodeToFolderItem(node, nil))
+}
+
+// HandleTagged handles GET /api/v2/folder/tagged - list the nodes with a tag.
+func (h *TagHandler) HandleTagged(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
+
+	raw := r.URL.Query().Get("tag")
+	if raw == "" {
+		writeError(w, authed.Email, 400, "tag", "required")
+		return
+	}
+	tag, err := vo.ParseTag(raw)
+	if err != nil {
+		writeError(w, authed.Email, 400, "tag", "invalid")
+		return
+	}
+
+	nodes, err := h.tags.ListTagged(authed.UserID, tag)
+	h.writeList(w, authed, nodes, err)
+}
+
+// HandleStarred handles GET /api/v2/folder/starred - list the starred nodes.
+func (h *TagHandler) HandleStarred(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if !authorize(w, authed, vo.ScopeRead) {
+		return
+	}
+
+	nodes, err := h.tags.ListStarred(authed.UserID)
+	h.writeList(w, authed, nodes, err)
+}
+
+// writeList writes the listed nodes the token may read, or the error of listing them.
+func (h *TagHandler) writeList(w http.ResponseWriter, authed *service.AuthenticatedUser, nodes []entity.Node, err error) {
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	items := make([]FolderItem, 0, len(nodes))
+	for i := range nodes {
+		if authed.Allows(vo.ScopeRead, nodes[i].Home) {
+			items = append(items, h.presenter.NodeToFolderItem(&nodes[i], nil))
+		}
+	}
+	writeSuccess(w, authed.Email, map[string]interface{}{"list": items})
+}
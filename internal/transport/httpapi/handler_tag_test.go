@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newTestTagHandler(nodes *mock.NodeRepositoryMock) *TagHandler {
+	auth := setupHandlerAuth()
+	return NewTagHandler(auth, service.NewTagService(nodes), NewPresenter())
+}
+
+func TestTagHandler_HandleTag(t *testing.T) {
+	var stored []vo.Tag
+	h := newTestTagHandler(&mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			if path.String() != "/plan.txt" {
+				return nil, nil
+			}
+			node := mock.NewTestFileNode(1, "/plan.txt", mock.ValidHash(), 10)
+			node.Tags = []vo.Tag{"old"}
+			return node, nil
+		},
+		SetTagsFunc: func(userID int64, path vo.CloudPath, tags []vo.Tag) error {
+			stored = tags
+			return nil
+		},
+	})
+
+	post := func(form url.Values) (*httptest.ResponseRecorder, FolderItem) {
+		r := httptest.NewRequest(http.MethodPost, "/api/v2/file/tag?access_token=access-token-123", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.HandleTag(w, r)
+		var resp struct {
+			Body FolderItem `json:"body"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp.Body
+	}
+
+	w, item := post(url.Values{"home": {"/plan.txt"}, "tag": {"Work,q3", "work"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if want := []vo.Tag{"old", "q3", "work"}; len(stored) != 3 || stored[0] != want[0] || stored[1] != want[1] || stored[2] != want[2] {
+		t.Errorf("stored %v, want %v", stored, want)
+	}
+	if len(item.Tags) != 3 || item.Home != "/plan.txt" {
+		t.Errorf("item = %+v, want the tagged file", item)
+	}
+
+	for _, tt := range []struct {
+		form url.Values
+		want int
+	}{
+		{url.Values{"tag": {"work"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/plan.txt"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/plan.txt"}, "tag": {"a\x01b"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/missing.txt"}, "tag": {"work"}}, http.StatusNotFound},
+	} {
+		if w, _ := post(tt.form); w.Code != tt.want {
+			t.Errorf("%v: status = %d, want %d", tt.form, w.Code, tt.want)
+		}
+	}
+}
+
+func TestTagHandler_HandleStarred(t *testing.T) {
+	h := newTestTagHandler(&mock.NodeRepositoryMock{
+		ListStarredFunc: func(userID int64) ([]entity.Node, error) {
+			node := mock.NewTestFileNode(1, "/plan.txt", mock.ValidHash(), 10)
+			node.Starred = true
+			return []entity.Node{*node}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	h.HandleStarred(w, httptest.NewRequest(http.MethodGet, "/api/v2/folder/starred?access_token=access-token-123", nil))
+	var resp struct {
+		Body struct {
+			List []FolderItem `json:"list"`
+		} `json:"body"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || len(resp.Body.List) != 1 || !resp.Body.List[0].Starred {
+		t.Errorf("got %d %+v, want the starred file", w.Code, resp.Body.List)
+	}
+}
+
+func TestTagHandler_HandleTagged(t *testing.T) {
+	var listed vo.Tag
+	h := newTestTagHandler(&mock.NodeRepositoryMock{
+		ListTaggedFunc: func(userID int64, tag vo.Tag) ([]entity.Node, error) {
+			listed = tag
+			return nil, nil
+		},
+	})
+
+	get := func(query string) int {
+		w := httptest.NewRecorder()
+		h.HandleTagged(w, httptest.NewRequest(http.MethodGet, "/api/v2/folder/tagged?access_token=access-token-123"+query, nil))
+		return w.Code
+	}
+	if code := get("&tag=Work"); code != http.StatusOK || listed != "work" {
+		t.Errorf("status = %d, listed %q, want 200 and the normalized tag", code, listed)
+	}
+	if code := get(""); code != http.StatusBadRequest {
+		t.Errorf("status without a tag = %d, want 400", code)
+	}
+}
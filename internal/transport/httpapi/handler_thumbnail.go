@@ -14,31 +14,27 @@ import (
 type ThumbnailHandler struct {
 	auth       *service.AuthService
 	thumbnails *service.ThumbnailService
+	signer     *service.URLSigner
 }
 
 // NewThumbnailHandler creates a new ThumbnailHandler.
-func NewThumbnailHandler(auth *service.AuthService, thumbnails *service.ThumbnailService) *ThumbnailHandler {
+func NewThumbnailHandler(auth *service.AuthService, thumbnails *service.ThumbnailService, signer *service.URLSigner) *ThumbnailHandler {
 	return &ThumbnailHandler{
 		auth:       auth,
 		thumbnails: thumbnails,
+		signer:     signer,
 	}
 }
 
 // HandleThumbnail handles GET /thumb/{preset}/{path...}.
-// URL format: /thumb/<preset>/<cloud_path>?client_id=cloud-win&token=<access_token>
+// URL format: /thumb/<preset>/<cloud_path>?client_id=cloud-win&token=<access_token>,
+// or a signed URL: /thumb/<preset>/<cloud_path>?uid=<id>&expires=<unix>&signature=<sig>
 func (h *ThumbnailHandler) HandleThumbnail(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Authenticate
-	authed, err := h.auth.Validate(r.URL.Query().Get("token"))
-	if err != nil || authed == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
 	// Parse path: /thumb/<preset>/<cloud_path>
 	rawPath := strings.TrimPrefix(r.URL.Path, "/thumb/")
 	if rawPath == "" || rawPath == "/" {
@@ -63,6 +59,15 @@ func (h *ThumbnailHandler) HandleThumbnail(w http.ResponseWriter, r *http.Reques
 	}
 
 	path := vo.NewCloudPath(cloudPath)
+	authed := authenticateContent(r, h.auth, h.signer, path)
+	if authed == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !authed.Allows(vo.ScopeRead, path) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
 
 	result, err := h.thumbnails.Generate(authed.UserID, path, preset)
 	if err != nil {
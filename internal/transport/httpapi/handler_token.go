@@ -5,18 +5,20 @@ import (
 
 	"github.com/pozitronik/tucha/internal/application/port"
 	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
-// TokenHandler handles OAuth2 password grant authentication.
+// TokenHandler handles the OAuth2 token endpoint (password and refresh_token grants).
 type TokenHandler struct {
-	tokens          *service.TokenService
-	tokenTTLSeconds int
-	logger          port.Logger
+	tokens  *service.TokenService
+	clients *service.ClientRegistry
+	logger  port.Logger
 }
 
 // NewTokenHandler creates a new TokenHandler.
-func NewTokenHandler(tokens *service.TokenService, tokenTTLSeconds int, logger port.Logger) *TokenHandler {
-	return &TokenHandler{tokens: tokens, tokenTTLSeconds: tokenTTLSeconds, logger: logger}
+func NewTokenHandler(tokens *service.TokenService, clients *service.ClientRegistry, logger port.Logger) *TokenHandler {
+	return &TokenHandler{tokens: tokens, clients: clients, logger: logger}
 }
 
 // HandleToken handles POST /token.
@@ -36,11 +38,9 @@ func (h *TokenHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	clientID := r.FormValue("client_id")
-	grantType := r.FormValue("grant_type")
-	username := r.FormValue("username")
-	password := r.FormValue("password")
-
-	if clientID != "cloud-win" {
+	client := h.clients.Lookup(clientID)
+	if client == nil || !client.CheckSecret(r.FormValue("client_secret")) {
+		h.logger.Warn("Token request rejected: client_id=%q", clientID)
 		writeJSON(w, http.StatusOK, OAuthToken{
 			Error:            "invalid_client",
 			ErrorCode:        2,
@@ -49,20 +49,34 @@ func (h *TokenHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if grantType != "password" {
+	grantType, err := vo.ParseGrantType(r.FormValue("grant_type"))
+	if err != nil || !client.AllowsGrant(grantType) {
 		writeJSON(w, http.StatusOK, OAuthToken{
 			Error:            "unsupported_grant_type",
 			ErrorCode:        3,
-			ErrorDescription: "Only password grant is supported",
+			ErrorDescription: "Grant type is not allowed for this client",
 		})
 		return
 	}
 
-	h.logger.Info("Auth attempt: email=%q password_len=%d", username, len(password))
+	var token *entity.Token
+	switch grantType {
+	case vo.GrantPassword:
+		username := r.FormValue("username")
+		password := r.FormValue("password")
+		h.logger.Info("Auth attempt: email=%q client_id=%q password_len=%d", username, clientID, len(password))
 
-	token, err := h.tokens.Authenticate(username, password, h.tokenTTLSeconds)
+		token, err = h.tokens.Authenticate(username, password, clientID, client.TokenTTLSeconds)
+		if err != nil {
+			h.logger.Warn("Auth failed: email=%q err=%v", username, err)
+		}
+	case vo.GrantRefreshToken:
+		token, err = h.tokens.Refresh(r.FormValue("refresh_token"), clientID, client.TokenTTLSeconds)
+		if err != nil {
+			h.logger.Warn("Token refresh failed: client_id=%q err=%v", clientID, err)
+		}
+	}
 	if err != nil {
-		h.logger.Warn("Auth failed: email=%q err=%v", username, err)
 		writeJSON(w, http.StatusOK, OAuthToken{
 			Error:            "invalid_grant",
 			ErrorCode:        4,
@@ -72,7 +86,7 @@ func (h *TokenHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusOK, OAuthToken{
-		ExpiresIn:        h.tokenTTLSeconds,
+		ExpiresIn:        client.TokenTTLSeconds,
 		RefreshToken:     token.RefreshToken,
 		AccessToken:      token.AccessToken,
 		Error:            "",
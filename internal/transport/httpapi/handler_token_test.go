@@ -11,29 +11,39 @@ import (
 
 	"github.com/pozitronik/tucha/internal/application/service"
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 	"github.com/pozitronik/tucha/internal/testutil/mock"
 )
 
 func TestNewTokenHandler(t *testing.T) {
 	tokenRepo := &mock.TokenRepositoryMock{}
 	userRepo := &mock.UserRepositoryMock{}
-	tokenSvc := service.NewTokenService(tokenRepo, userRepo)
+	tokenSvc := service.NewTokenService(tokenRepo, userRepo, &mock.AppPasswordRepositoryMock{})
 	logger := &mock.LoggerMock{}
 
-	handler := NewTokenHandler(tokenSvc, 3600, logger)
+	handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), logger)
 
 	if handler == nil {
 		t.Fatal("NewTokenHandler() returned nil")
 	}
-	if handler.tokenTTLSeconds != 3600 {
-		t.Errorf("tokenTTLSeconds = %d, want 3600", handler.tokenTTLSeconds)
+	if handler.clients.Lookup("cloud-win") == nil {
+		t.Error("handler.clients does not contain cloud-win")
 	}
 }
 
+// newTestClientRegistry returns the public desktop client "cloud-win" (password and
+// refresh_token grants) and a confidential "backup-tool" client (password grant only).
+func newTestClientRegistry() *service.ClientRegistry {
+	return service.NewClientRegistry([]entity.OAuthClient{
+		{ID: "cloud-win", GrantTypes: []vo.GrantType{vo.GrantPassword, vo.GrantRefreshToken}, TokenTTLSeconds: 3600},
+		{ID: "backup-tool", Secret: "s3cret", GrantTypes: []vo.GrantType{vo.GrantPassword}, TokenTTLSeconds: 600},
+	})
+}
+
 func TestTokenHandler_HandleToken(t *testing.T) {
 	t.Run("returns 405 for non-POST methods", func(t *testing.T) {
-		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{})
-		handler := NewTokenHandler(tokenSvc, 3600, &mock.LoggerMock{})
+		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{}, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), &mock.LoggerMock{})
 
 		methods := []string{http.MethodGet, http.MethodPut, http.MethodDelete}
 		for _, method := range methods {
@@ -49,8 +59,8 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 	})
 
 	t.Run("returns error for invalid client_id", func(t *testing.T) {
-		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{})
-		handler := NewTokenHandler(tokenSvc, 3600, &mock.LoggerMock{})
+		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{}, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), &mock.LoggerMock{})
 
 		form := url.Values{}
 		form.Set("client_id", "wrong-client")
@@ -82,8 +92,8 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 	})
 
 	t.Run("returns error for unsupported grant_type", func(t *testing.T) {
-		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{})
-		handler := NewTokenHandler(tokenSvc, 3600, &mock.LoggerMock{})
+		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, &mock.UserRepositoryMock{}, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), &mock.LoggerMock{})
 
 		form := url.Values{}
 		form.Set("client_id", "cloud-win")
@@ -116,8 +126,8 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 				return nil, nil // User not found
 			},
 		}
-		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo)
-		handler := NewTokenHandler(tokenSvc, 3600, &mock.LoggerMock{})
+		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), &mock.LoggerMock{})
 
 		form := url.Values{}
 		form.Set("client_id", "cloud-win")
@@ -167,12 +177,12 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 			},
 		}
 		tokenRepo := &mock.TokenRepositoryMock{
-			CreateFunc: func(userID int64, ttlSeconds int) (*entity.Token, error) {
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
 				return testToken, nil
 			},
 		}
-		tokenSvc := service.NewTokenService(tokenRepo, userRepo)
-		handler := NewTokenHandler(tokenSvc, 3600, &mock.LoggerMock{})
+		tokenSvc := service.NewTokenService(tokenRepo, userRepo, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), &mock.LoggerMock{})
 
 		form := url.Values{}
 		form.Set("client_id", "cloud-win")
@@ -224,8 +234,8 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 				return nil, nil
 			},
 		}
-		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo)
-		handler := NewTokenHandler(tokenSvc, 3600, &mock.LoggerMock{})
+		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), &mock.LoggerMock{})
 
 		form := url.Values{}
 		form.Set("client_id", "cloud-win")
@@ -256,8 +266,8 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 				return nil, nil
 			},
 		}
-		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo)
-		handler := NewTokenHandler(tokenSvc, 3600, logger)
+		tokenSvc := service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo, &mock.AppPasswordRepositoryMock{})
+		handler := NewTokenHandler(tokenSvc, newTestClientRegistry(), logger)
 
 		form := url.Values{}
 		form.Set("client_id", "cloud-win")
@@ -291,3 +301,103 @@ func TestTokenHandler_HandleToken(t *testing.T) {
 		}
 	})
 }
+
+func TestTokenHandler_HandleToken_clients(t *testing.T) {
+	testUser := &entity.User{ID: 1, Email: "user@example.com", Password: "pass"}
+	userRepo := &mock.UserRepositoryMock{
+		GetByEmailFunc: func(email string) (*entity.User, error) {
+			if email == testUser.Email {
+				return testUser, nil
+			}
+			return nil, nil
+		},
+	}
+
+	post := func(handler *TokenHandler, form url.Values) OAuthToken {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.HandleToken(w, req)
+
+		var resp OAuthToken
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("confidential client requires secret", func(t *testing.T) {
+		handler := NewTokenHandler(service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo, &mock.AppPasswordRepositoryMock{}), newTestClientRegistry(), &mock.LoggerMock{})
+
+		resp := post(handler, url.Values{"client_id": {"backup-tool"}, "grant_type": {"password"}, "username": {"user@example.com"}, "password": {"pass"}})
+		if resp.Error != "invalid_client" {
+			t.Errorf("resp.Error = %q, want %q", resp.Error, "invalid_client")
+		}
+	})
+
+	t.Run("issues token with client TTL and records client", func(t *testing.T) {
+		var gotClient string
+		var gotTTL int
+		tokenRepo := &mock.TokenRepositoryMock{
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+				gotClient, gotTTL = clientID, ttlSeconds
+				return &entity.Token{ID: 1, UserID: userID, AccessToken: "at", ClientID: clientID}, nil
+			},
+		}
+		handler := NewTokenHandler(service.NewTokenService(tokenRepo, userRepo, &mock.AppPasswordRepositoryMock{}), newTestClientRegistry(), &mock.LoggerMock{})
+
+		resp := post(handler, url.Values{"client_id": {"backup-tool"}, "client_secret": {"s3cret"}, "grant_type": {"password"}, "username": {"user@example.com"}, "password": {"pass"}})
+		if resp.Error != "" {
+			t.Fatalf("resp.Error = %q, want empty", resp.Error)
+		}
+		if resp.ExpiresIn != 600 || gotTTL != 600 {
+			t.Errorf("ExpiresIn = %d, repo ttl = %d, want 600", resp.ExpiresIn, gotTTL)
+		}
+		if gotClient != "backup-tool" {
+			t.Errorf("token client = %q, want %q", gotClient, "backup-tool")
+		}
+	})
+
+	t.Run("rejects grant type not allowed for client", func(t *testing.T) {
+		handler := NewTokenHandler(service.NewTokenService(&mock.TokenRepositoryMock{}, userRepo, &mock.AppPasswordRepositoryMock{}), newTestClientRegistry(), &mock.LoggerMock{})
+
+		resp := post(handler, url.Values{"client_id": {"backup-tool"}, "client_secret": {"s3cret"}, "grant_type": {"refresh_token"}, "refresh_token": {"rt"}})
+		if resp.Error != "unsupported_grant_type" {
+			t.Errorf("resp.Error = %q, want %q", resp.Error, "unsupported_grant_type")
+		}
+	})
+
+	t.Run("refresh_token grant rotates the session", func(t *testing.T) {
+		var deleted int64
+		tokenRepo := &mock.TokenRepositoryMock{
+			LookupRefreshFunc: func(refreshToken string) (*entity.Token, error) {
+				if refreshToken == "rt" {
+					return &entity.Token{ID: 7, UserID: 1, RefreshToken: "rt", ClientID: "cloud-win"}, nil
+				}
+				return nil, nil
+			},
+			CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+				return &entity.Token{ID: 8, UserID: userID, AccessToken: "new-at", RefreshToken: "new-rt", ClientID: clientID}, nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = id
+				return nil
+			},
+		}
+		handler := NewTokenHandler(service.NewTokenService(tokenRepo, userRepo, &mock.AppPasswordRepositoryMock{}), newTestClientRegistry(), &mock.LoggerMock{})
+
+		resp := post(handler, url.Values{"client_id": {"cloud-win"}, "grant_type": {"refresh_token"}, "refresh_token": {"rt"}})
+		if resp.Error != "" || resp.AccessToken != "new-at" || resp.RefreshToken != "new-rt" {
+			t.Errorf("resp = %+v", resp)
+		}
+		if deleted != 7 {
+			t.Errorf("deleted = %d, want 7 (old session)", deleted)
+		}
+
+		resp = post(handler, url.Values{"client_id": {"cloud-win"}, "grant_type": {"refresh_token"}, "refresh_token": {"unknown"}})
+		if resp.Error != "invalid_grant" {
+			t.Errorf("resp.Error = %q, want %q", resp.Error, "invalid_grant")
+		}
+	})
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
-// TrashHandler handles trashbin operations: listing, restoring, and emptying.
+// TrashHandler handles trashbin operations: listing, restoring, deleting, and emptying.
 type TrashHandler struct {
 	auth      *service.AuthService
 	trash     *service.TrashService
@@ -40,6 +40,9 @@ func (h *TrashHandler) HandleTrashList(w http.ResponseWriter, r *http.Request) {
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeTrash) {
+		return
+	}
 
 	items, err := h.trash.List(authed.UserID)
 	if err != nil {
@@ -49,6 +52,10 @@ func (h *TrashHandler) HandleTrashList(w http.ResponseWriter, r *http.Request) {
 
 	dtoItems := make([]TrashFolderItem, 0, len(items))
 	for i := range items {
+		// Path-restricted tokens only see items deleted from within their prefix.
+		if !authed.CanAccess(items[i].Home) {
+			continue
+		}
 		dtoItems = append(dtoItems, h.presenter.TrashItemToDTO(&items[i]))
 	}
 
@@ -56,7 +63,7 @@ func (h *TrashHandler) HandleTrashList(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleTrashRestore handles POST /api/v2/trashbin/restore - restore a trashed item.
-// Body: path=<url_encoded_original_path>&restore_revision=<rev>&conflict=<mode>
+// Body: path=<url_encoded_original_path>&restore_revision=<rev>&conflict=<mode>[&folder=<target_folder>]
 func (h *TrashHandler) HandleTrashRestore(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -67,6 +74,9 @@ func (h *TrashHandler) HandleTrashRestore(w http.ResponseWriter, r *http.Request
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeTrash) {
+		return
+	}
 
 	if err := r.ParseForm(); err != nil {
 		writeHomeError(w, authed.Email, 400, "invalid")
@@ -93,7 +103,21 @@ func (h *TrashHandler) HandleTrashRestore(w http.ResponseWriter, r *http.Request
 	}
 
 	path := vo.NewCloudPath(pathStr)
-	if err := h.trash.Restore(authed.UserID, path, rev, conflict); err != nil {
+	if !authorize(w, authed, vo.ScopeTrash, path) {
+		return
+	}
+
+	// Restore into the original folder unless another one is given.
+	folder := path.Parent()
+	if f := r.FormValue("folder"); f != "" {
+		folder = vo.NewCloudPath(f)
+		if !authorize(w, authed, vo.ScopeTrash, folder) {
+			return
+		}
+	}
+
+	node, err := h.trash.RestoreTo(authed.UserID, path, rev, folder, conflict)
+	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNotFound):
 			writeHomeError(w, authed.Email, 404, "not_exists")
@@ -105,6 +129,57 @@ func (h *TrashHandler) HandleTrashRestore(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	writeSuccess(w, authed.Email, node.Home.String())
+}
+
+// HandleTrashDelete handles POST /api/v2/trashbin/delete - permanently delete a trashed item.
+// Body: path=<url_encoded_original_path>&restore_revision=<rev>
+func (h *TrashHandler) HandleTrashDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+	if !authorize(w, authed, vo.ScopeTrash) {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	pathStr := r.FormValue("path")
+	revStr := r.FormValue("restore_revision")
+	if pathStr == "" || revStr == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	rev, err := strconv.ParseInt(revStr, 10, 64)
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	path := vo.NewCloudPath(pathStr)
+	if !authorize(w, authed, vo.ScopeTrash, path) {
+		return
+	}
+
+	if err := h.trash.Remove(authed.UserID, path, rev); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
 	writeSuccess(w, authed.Email, path.String())
 }
 
@@ -119,6 +194,9 @@ func (h *TrashHandler) HandleTrashEmpty(w http.ResponseWriter, r *http.Request)
 	if authed == nil {
 		return
 	}
+	if !authorize(w, authed, vo.ScopeTrash) {
+		return
+	}
 
 	if err := h.trash.Empty(authed.UserID); err != nil {
 		writeHomeError(w, authed.Email, 500, "unknown")
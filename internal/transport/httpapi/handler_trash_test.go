@@ -308,6 +308,51 @@ func TestTrashHandler_HandleTrashRestore(t *testing.T) {
 	})
 }
 
+func TestTrashHandler_HandleTrashDelete(t *testing.T) {
+	authSvc, _, _ := setupTrashHandlerAuth()
+	item := mock.NewTestTrashItem(1, "/deleted.txt", vo.NodeTypeFile)
+	var deleted []int64
+	trashSvc := service.NewTrashService(
+		&mock.NodeRepositoryMock{},
+		&mock.TrashRepositoryMock{
+			GetByPathAndRevFunc: func(userID int64, path vo.CloudPath, rev int64) (*entity.TrashItem, error) {
+				if path.String() == "/deleted.txt" {
+					return item, nil
+				}
+				return nil, nil
+			},
+			DeleteFunc: func(id int64) error {
+				deleted = append(deleted, id)
+				return nil
+			},
+		},
+		&mock.ContentRepositoryMock{},
+		&mock.ContentStorageMock{},
+		&mock.ShareRepositoryMock{},
+	)
+	handler := NewTrashHandler(authSvc, trashSvc, NewPresenter())
+
+	post := func(path string) Envelope {
+		form := url.Values{"path": {path}, "restore_revision": {"1"}}
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/trashbin/delete?access_token=valid-token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.HandleTrashDelete(w, req)
+		var env Envelope
+		if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return env
+	}
+
+	if env := post("/deleted.txt"); env.Status != 200 || len(deleted) != 1 {
+		t.Errorf("env.Status = %d, deleted %v, want 200 and the item deleted", env.Status, deleted)
+	}
+	if env := post("/missing.txt"); env.Status != 404 {
+		t.Errorf("env.Status = %d, want 404", env.Status)
+	}
+}
+
 func TestTrashHandler_HandleTrashEmpty(t *testing.T) {
 	t.Run("returns 405 for non-POST methods", func(t *testing.T) {
 		authSvc, _, _ := setupTrashHandlerAuth()
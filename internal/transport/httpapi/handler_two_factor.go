@@ -0,0 +1,241 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+)
+
+// TwoFactorHandler lets a user manage TOTP two-factor authentication and app passwords.
+// All endpoints require a session token; personal tokens are rejected.
+type TwoFactorHandler struct {
+	auth      *service.AuthService
+	twoFactor *service.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler.
+func NewTwoFactorHandler(auth *service.AuthService, twoFactor *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{auth: auth, twoFactor: twoFactor}
+}
+
+// HandleStatus handles GET /api/v2/user/2fa - report the caller's 2FA state.
+func (h *TwoFactorHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	state, err := h.twoFactor.Status(authed.UserID)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, TwoFactorStatus{
+		Enabled:           state.Enabled,
+		Pending:           state.Pending,
+		RecoveryCodesLeft: state.RecoveryCodesLeft,
+	})
+}
+
+// HandleEnroll handles POST /api/v2/user/2fa/enroll - issue a new TOTP secret.
+func (h *TwoFactorHandler) HandleEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	enrollment, err := h.twoFactor.Enroll(authed.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrAlreadyExists) {
+			writeHomeError(w, authed.Email, 400, "exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, TwoFactorEnrollment{Secret: enrollment.Secret, URI: enrollment.URI})
+}
+
+// HandleConfirm handles POST /api/v2/user/2fa/confirm - enable 2FA with a first code.
+// Responds with the recovery codes, which are shown only once.
+func (h *TwoFactorHandler) HandleConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	code := r.FormValue("code")
+	if code == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	codes, err := h.twoFactor.Confirm(authed.UserID, code)
+	if err != nil {
+		writeTwoFactorError(w, authed.Email, err)
+		return
+	}
+
+	writeSuccess(w, authed.Email, codes)
+}
+
+// HandleDisable handles POST /api/v2/user/2fa/disable - turn 2FA off with a TOTP or recovery code.
+func (h *TwoFactorHandler) HandleDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	code := r.FormValue("code")
+	if code == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	if err := h.twoFactor.Disable(authed.UserID, code); err != nil {
+		writeTwoFactorError(w, authed.Email, err)
+		return
+	}
+
+	writeSuccess(w, authed.Email, "ok")
+}
+
+// HandleAppPasswords handles GET /api/v2/user/app-passwords - list the caller's app passwords.
+func (h *TwoFactorHandler) HandleAppPasswords(w http.ResponseWriter, r *http.Request) {
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	passwords, err := h.twoFactor.ListAppPasswords(authed.UserID)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	infos := make([]AppPasswordInfo, 0, len(passwords))
+	for i := range passwords {
+		infos = append(infos, newAppPasswordInfo(&passwords[i], ""))
+	}
+	writeSuccess(w, authed.Email, infos)
+}
+
+// HandleAppPasswordAdd handles POST /api/v2/user/app-passwords/add - issue an app password.
+func (h *TwoFactorHandler) HandleAppPasswordAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	name := r.FormValue("name")
+	if name == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+
+	p, plain, err := h.twoFactor.CreateAppPassword(authed.UserID, name)
+	if err != nil {
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, newAppPasswordInfo(p, plain))
+}
+
+// HandleAppPasswordRemove handles POST /api/v2/user/app-passwords/remove - revoke an app password.
+func (h *TwoFactorHandler) HandleAppPasswordRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticateSession(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return
+	}
+
+	if err := h.twoFactor.RevokeAppPassword(authed.UserID, id); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeHomeError(w, authed.Email, 404, "not_exists")
+			return
+		}
+		writeHomeError(w, authed.Email, 500, "unknown")
+		return
+	}
+
+	writeSuccess(w, authed.Email, "ok")
+}
+
+// writeTwoFactorError maps confirm/disable errors to home error responses.
+func writeTwoFactorError(w http.ResponseWriter, email string, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		writeHomeError(w, email, 403, "invalid_code")
+	case errors.Is(err, service.ErrTooManyAttempts):
+		writeHomeError(w, email, 429, "too_many_attempts")
+	case errors.Is(err, service.ErrNotFound):
+		writeHomeError(w, email, 404, "not_exists")
+	case errors.Is(err, service.ErrAlreadyExists):
+		writeHomeError(w, email, 400, "exists")
+	default:
+		writeHomeError(w, email, 500, "unknown")
+	}
+}
+
+// newAppPasswordInfo converts an app password to a DTO; plain is set only on creation.
+func newAppPasswordInfo(p *entity.AppPassword, plain string) AppPasswordInfo {
+	return AppPasswordInfo{
+		ID:       p.ID,
+		Name:     p.Name,
+		Created:  p.Created,
+		LastUsed: p.LastUsed,
+		Password: plain,
+	}
+}
@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// newTwoFactorTestHandler builds a handler where "session" is a regular login
+// token and "personal" is a personal token of the same user.
+func newTwoFactorTestHandler(user *entity.User, appPasswords *mock.AppPasswordRepositoryMock) *TwoFactorHandler {
+	tokenRepo := &mock.TokenRepositoryMock{
+		LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+			switch accessToken {
+			case "session":
+				return mock.NewTestToken(user.ID, time.Now().Add(time.Hour)), nil
+			case "personal":
+				tok := mock.NewTestToken(user.ID, time.Time{})
+				tok.Personal = true
+				tok.ExpiresAt = 0
+				return tok, nil
+			}
+			return nil, nil
+		},
+	}
+	userRepo := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			copied := *user
+			return &copied, nil
+		},
+		UpdateFunc: func(u *entity.User) error {
+			*user = *u
+			return nil
+		},
+	}
+	totp := &mock.TOTPMock{FixedSecret: "JBSWY3DPEHPK3PXP", ValidCode: "123456"}
+	return NewTwoFactorHandler(
+		service.NewAuthService(tokenRepo, userRepo),
+		service.NewTwoFactorService(userRepo, appPasswords, totp, "Tucha"),
+	)
+}
+
+func postForm(target string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestTwoFactorHandler_EnrollAndConfirm(t *testing.T) {
+	user := &entity.User{ID: 1, Email: "user@example.com"}
+	h := newTwoFactorTestHandler(user, &mock.AppPasswordRepositoryMock{})
+
+	w := httptest.NewRecorder()
+	h.HandleEnroll(w, postForm("/api/v2/user/2fa/enroll?access_token=session", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("enroll status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleConfirm(w, postForm("/api/v2/user/2fa/confirm?access_token=session", url.Values{"code": {"000000"}}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("confirm with wrong code status = %d, want 403", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleConfirm(w, postForm("/api/v2/user/2fa/confirm?access_token=session", url.Values{"code": {"123456"}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirm status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var env struct {
+		Body []string `json:"body"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(env.Body) == 0 || !user.TOTPEnabled {
+		t.Errorf("recovery codes = %v, enabled = %v", env.Body, user.TOTPEnabled)
+	}
+}
+
+func TestTwoFactorHandler_rejectsPersonalToken(t *testing.T) {
+	h := newTwoFactorTestHandler(&entity.User{ID: 1, Email: "user@example.com"}, &mock.AppPasswordRepositoryMock{})
+
+	w := httptest.NewRecorder()
+	h.HandleAppPasswordAdd(w, postForm("/api/v2/user/app-passwords/add?access_token=personal", url.Values{"name": {"x"}}))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", w.Code)
+	}
+}
+
+func TestTwoFactorHandler_AppPasswords(t *testing.T) {
+	var stored *entity.AppPassword
+	appPasswords := &mock.AppPasswordRepositoryMock{
+		CreateFunc: func(p *entity.AppPassword) error {
+			p.ID = 3
+			stored = p
+			return nil
+		},
+		ListByUserFunc: func(userID int64) ([]entity.AppPassword, error) {
+			return []entity.AppPassword{*stored}, nil
+		},
+	}
+	h := newTwoFactorTestHandler(&entity.User{ID: 1, Email: "user@example.com"}, appPasswords)
+
+	w := httptest.NewRecorder()
+	h.HandleAppPasswordAdd(w, postForm("/api/v2/user/app-passwords/add?access_token=session", url.Values{"name": {"desktop"}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("add status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var env struct {
+		Body AppPasswordInfo `json:"body"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.Body.Password == "" || env.Body.Name != "desktop" {
+		t.Errorf("body = %+v", env.Body)
+	}
+	if stored.PasswordHash == env.Body.Password {
+		t.Error("app password must be stored hashed")
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleAppPasswords(w, httptest.NewRequest(http.MethodGet, "/api/v2/user/app-passwords?access_token=session", nil))
+	if strings.Contains(w.Body.String(), env.Body.Password) {
+		t.Errorf("list response leaks password: %s", w.Body.String())
+	}
+}
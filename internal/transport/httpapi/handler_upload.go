@@ -34,12 +34,23 @@ func (h *UploadHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authed, err := h.auth.Validate(r.URL.Query().Get("token"))
+	authed, err := validateRequest(r, h.auth, "token")
 	if err != nil || authed == nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
+	// Content-only uploads are placed later via file/add, which checks the path itself.
+	homePath := parseUploadHome(r.URL.Path)
+	allowed := authed.Can(vo.ScopeWrite)
+	if homePath != "" {
+		allowed = authed.Allows(vo.ScopeWrite, vo.NewCloudPath(homePath))
+	}
+	if !allowed {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	// Fast reject via Content-Length header if file size limit is set.
 	if authed.FileSizeLimit > 0 && r.ContentLength > 0 && r.ContentLength > authed.FileSizeLimit {
 		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
@@ -67,7 +78,7 @@ func (h *UploadHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
 
 	// If the URL path contains a home parameter, also register the file node.
 	// This matches the real API where PUT /upload/home=/path stores AND registers.
-	if homePath := parseUploadHome(r.URL.Path); homePath != "" {
+	if homePath != "" {
 		path := vo.NewCloudPath(homePath)
 		targetUserID := authed.UserID
 		targetPath := path
@@ -2,11 +2,13 @@ package httpapi
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/pozitronik/tucha/internal/application/service"
 	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
 )
 
 // UserHandler handles admin user management CRUD operations.
@@ -65,6 +67,17 @@ func (h *UserHandler) HandleUserAdd(w http.ResponseWriter, r *http.Request) {
 
 	versionHistory := r.FormValue("version_history") == "true" || r.FormValue("version_history") == "1"
 
+	retention, err := parseVersionRetention(r.FormValue("version_retention"))
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+	trashDays, err := parseTrashRetention(r.FormValue("trash_retention_days"))
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+
 	user, err := h.users.Create(email, password, false, quotaBytes)
 	if err != nil {
 		if errors.Is(err, service.ErrAlreadyExists) {
@@ -84,6 +97,20 @@ func (h *UserHandler) HandleUserAdd(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if retention != nil {
+		if err := h.users.SetVersionRetention(user.ID, retention); err != nil {
+			writeEnvelope(w, "", 500, "unknown")
+			return
+		}
+		user.VersionRetention = retention
+	}
+	if trashDays != nil {
+		if err := h.users.SetTrashRetention(user.ID, trashDays); err != nil {
+			writeEnvelope(w, "", 500, "unknown")
+			return
+		}
+		user.TrashRetentionDays = trashDays
+	}
 
 	writeSuccess(w, "", userToInfo(user))
 }
@@ -104,14 +131,17 @@ func (h *UserHandler) HandleUserList(w http.ResponseWriter, r *http.Request) {
 	infos := make([]UserInfo, 0, len(users))
 	for _, u := range users {
 		infos = append(infos, UserInfo{
-			ID:             u.ID,
-			Email:          u.Email,
-			Password:       u.Password,
-			QuotaBytes:     u.QuotaBytes,
-			BytesUsed:      u.BytesUsed,
-			FileSizeLimit:  u.FileSizeLimit,
-			VersionHistory: u.VersionHistory,
-			Created:        u.Created,
+			ID:                 u.ID,
+			Email:              u.Email,
+			Password:           u.Password,
+			QuotaBytes:         u.QuotaBytes,
+			BytesUsed:          u.BytesUsed,
+			FileSizeLimit:      u.FileSizeLimit,
+			VersionHistory:     u.VersionHistory,
+			VersionRetention:   retentionToString(u.VersionRetention),
+			TrashRetentionDays: u.TrashRetentionDays,
+			TwoFactor:          u.TOTPEnabled,
+			Created:            u.Created,
 		})
 	}
 
@@ -172,6 +202,18 @@ func (h *UserHandler) HandleUserEdit(w http.ResponseWriter, r *http.Request) {
 	if v := r.FormValue("version_history"); v != "" {
 		user.VersionHistory = v == "true" || v == "1"
 	}
+	_, setRetention := r.Form["version_retention"]
+	retention, err := parseVersionRetention(r.FormValue("version_retention"))
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
+	_, setTrashDays := r.Form["trash_retention_days"]
+	trashDays, err := parseTrashRetention(r.FormValue("trash_retention_days"))
+	if err != nil {
+		writeEnvelope(w, "", 400, "invalid")
+		return
+	}
 
 	if err := h.users.Update(user); err != nil {
 		if errors.Is(err, service.ErrNotFound) {
@@ -181,6 +223,18 @@ func (h *UserHandler) HandleUserEdit(w http.ResponseWriter, r *http.Request) {
 		writeEnvelope(w, "", 500, "unknown")
 		return
 	}
+	if setRetention {
+		if err := h.users.SetVersionRetention(id, retention); err != nil {
+			writeEnvelope(w, "", 500, "unknown")
+			return
+		}
+	}
+	if setTrashDays {
+		if err := h.users.SetTrashRetention(id, trashDays); err != nil {
+			writeEnvelope(w, "", 500, "unknown")
+			return
+		}
+	}
 
 	writeSuccess(w, "", "ok")
 }
@@ -229,12 +283,49 @@ func (h *UserHandler) HandleUserRemove(w http.ResponseWriter, r *http.Request) {
 // userToInfo converts a User pointer to a UserInfo DTO.
 func userToInfo(u *entity.User) UserInfo {
 	return UserInfo{
-		ID:             u.ID,
-		Email:          u.Email,
-		Password:       u.Password,
-		QuotaBytes:     u.QuotaBytes,
-		FileSizeLimit:  u.FileSizeLimit,
-		VersionHistory: u.VersionHistory,
-		Created:        u.Created,
+		ID:                 u.ID,
+		Email:              u.Email,
+		Password:           u.Password,
+		QuotaBytes:         u.QuotaBytes,
+		FileSizeLimit:      u.FileSizeLimit,
+		VersionHistory:     u.VersionHistory,
+		VersionRetention:   retentionToString(u.VersionRetention),
+		TrashRetentionDays: u.TrashRetentionDays,
+		TwoFactor:          u.TOTPEnabled,
+		Created:            u.Created,
+	}
+}
+
+// parseVersionRetention parses a version_retention parameter. An empty value
+// or "default" selects the server default, returned as nil.
+func parseVersionRetention(raw string) (*vo.RetentionPolicy, error) {
+	if raw == "" || raw == "default" {
+		return nil, nil
+	}
+	policy, err := vo.ParseRetentionPolicy(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// parseTrashRetention parses a trash_retention_days parameter. An empty value
+// or "default" selects the server default, returned as nil.
+func parseTrashRetention(raw string) (*int, error) {
+	if raw == "" || raw == "default" {
+		return nil, nil
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 0 {
+		return nil, fmt.Errorf("invalid trash retention: %q", raw)
+	}
+	return &days, nil
+}
+
+// retentionToString formats an optional retention policy, "" for the server default.
+func retentionToString(policy *vo.RetentionPolicy) string {
+	if policy == nil {
+		return ""
 	}
+	return policy.String()
 }
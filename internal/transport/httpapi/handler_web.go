@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	_ "embed"
+	"errors"
+	"net/http"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+)
+
+//go:embed web.html
+var webHTML []byte
+
+// webClientID is the client that web file browser sessions are issued to.
+const webClientID = "web"
+
+// WebHandler serves the end-user web file browser and its cookie-based login.
+// The browser app itself calls the regular /api/v2 endpoints, authenticated by
+// the session cookie plus the session's CSRF token in the X-CSRF-Token header.
+type WebHandler struct {
+	auth       *service.AuthService
+	tokens     *service.TokenService
+	twoFactor  *service.TwoFactorService
+	ttlSeconds int
+	secure     bool
+}
+
+// NewWebHandler creates a new WebHandler issuing sessions that last ttlSeconds.
+// Session cookies are marked Secure, sent over HTTPS only, unless secure is false.
+func NewWebHandler(auth *service.AuthService, tokens *service.TokenService, twoFactor *service.TwoFactorService, ttlSeconds int, secure bool) *WebHandler {
+	return &WebHandler{auth: auth, tokens: tokens, twoFactor: twoFactor, ttlSeconds: ttlSeconds, secure: secure}
+}
+
+// HandleWeb serves the web file browser SPA.
+func (h *WebHandler) HandleWeb(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(webHTML)
+}
+
+// HandleLogin handles POST /web/login - check the user's password (and second
+// factor when enabled) and start a session held in an HttpOnly cookie.
+// The response carries the session's CSRF token for the app's API calls;
+// it is not handed out anywhere else.
+func (h *WebHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"status": 400,
+			"body":   "invalid",
+		})
+		return
+	}
+
+	user, err := h.twoFactor.CheckLogin(r.FormValue("email"), r.FormValue("password"), r.FormValue("otp"))
+	if errors.Is(err, service.ErrSecondFactorRequired) {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"status": 403,
+			"body":   "otp_required",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrTooManyAttempts) {
+		writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+			"status": 429,
+			"body":   "too_many_attempts",
+		})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"status": 403,
+			"body":   "forbidden",
+		})
+		return
+	}
+
+	token, err := h.tokens.Create(user.ID, webClientID, h.ttlSeconds)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"status": 500,
+			"body":   "unknown",
+		})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     webSessionCookie,
+		Value:    token.AccessToken,
+		Path:     "/",
+		MaxAge:   h.ttlSeconds,
+		HttpOnly: true,
+		Secure:   h.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	writeSuccess(w, user.Email, map[string]string{"csrf_token": token.CSRFToken})
+}
+
+// HandleLogout handles POST /web/logout - end the session and clear the cookie.
+func (h *WebHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if cookie, err := r.Cookie(webSessionCookie); err == nil {
+		_ = h.tokens.Revoke(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     webSessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": 200,
+		"body":   "ok",
+	})
+}
@@ -0,0 +1,175 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newTestWebHandler(totpEnabled bool) *WebHandler {
+	user := mock.NewTestUser(1, "user@example.com")
+	if totpEnabled {
+		user.TOTPEnabled = true
+		user.TOTPSecret = "secret"
+	}
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			if id == user.ID {
+				return user, nil
+			}
+			return nil, nil
+		},
+		GetByEmailFunc: func(email string) (*entity.User, error) {
+			if email == user.Email {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	session := mock.NewTestToken(user.ID, time.Now().Add(time.Hour))
+	session.ClientID = webClientID
+	tokens := &mock.TokenRepositoryMock{
+		CreateFunc: func(userID int64, clientID string, ttlSeconds int) (*entity.Token, error) {
+			return session, nil
+		},
+		LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+			if accessToken == session.AccessToken {
+				return session, nil
+			}
+			return nil, nil
+		},
+	}
+	totp := &mock.TOTPMock{ValidCode: "123456"}
+
+	return NewWebHandler(
+		service.NewAuthService(tokens, users),
+		service.NewTokenService(tokens, users, &mock.AppPasswordRepositoryMock{}),
+		service.NewTwoFactorService(users, &mock.AppPasswordRepositoryMock{}, totp, "Tucha"),
+		3600,
+		true,
+	)
+}
+
+func postWebLogin(h *WebHandler, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/web/login", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.HandleLogin(w, r)
+	return w
+}
+
+func TestWebHandler_HandleLogin(t *testing.T) {
+	t.Run("sets session cookie and returns CSRF token", func(t *testing.T) {
+		h := newTestWebHandler(false)
+
+		w := postWebLogin(h, url.Values{"email": {"user@example.com"}, "password": {"password"}})
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != webSessionCookie || cookies[0].Value != "access-token-123" {
+			t.Fatalf("cookies = %v, want %s=access-token-123", cookies, webSessionCookie)
+		}
+		if !cookies[0].HttpOnly || !cookies[0].Secure || cookies[0].SameSite != http.SameSiteStrictMode {
+			t.Error("session cookie must be HttpOnly, Secure and SameSite=Strict")
+		}
+
+		var resp struct {
+			Body struct {
+				CSRFToken string `json:"csrf_token"`
+			} `json:"body"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.Body.CSRFToken != "csrf-token-789" {
+			t.Errorf("csrf_token = %q, want %q", resp.Body.CSRFToken, "csrf-token-789")
+		}
+	})
+
+	t.Run("rejects wrong password", func(t *testing.T) {
+		h := newTestWebHandler(false)
+
+		w := postWebLogin(h, url.Values{"email": {"user@example.com"}, "password": {"nope"}})
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", w.Code)
+		}
+		if len(w.Result().Cookies()) != 0 {
+			t.Error("no cookie should be set on failed login")
+		}
+	})
+
+	t.Run("asks for the second factor", func(t *testing.T) {
+		h := newTestWebHandler(true)
+
+		w := postWebLogin(h, url.Values{"email": {"user@example.com"}, "password": {"password"}})
+
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "otp_required") {
+			t.Errorf("got %d %s, want 403 otp_required", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("accepts a valid second factor", func(t *testing.T) {
+		h := newTestWebHandler(true)
+
+		w := postWebLogin(h, url.Values{"email": {"user@example.com"}, "password": {"password"}, "otp": {"123456"}})
+
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", w.Code)
+		}
+	})
+}
+
+func TestValidateRequest_WebSession(t *testing.T) {
+	h := newTestWebHandler(false)
+
+	tests := []struct {
+		name     string
+		cookie   string
+		csrf     string
+		wantUser bool
+	}{
+		{"cookie with CSRF token", "access-token-123", "csrf-token-789", true},
+		{"cookie without CSRF token", "access-token-123", "", false},
+		{"cookie with wrong CSRF token", "access-token-123", "other", false},
+		{"unknown cookie", "unknown", "csrf-token-789", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v2/folder/add", nil)
+			r.AddCookie(&http.Cookie{Name: webSessionCookie, Value: tt.cookie})
+			if tt.csrf != "" {
+				r.Header.Set(csrfHeader, tt.csrf)
+			}
+
+			authed, _ := validateRequest(r, h.auth, "access_token")
+			if (authed != nil) != tt.wantUser {
+				t.Errorf("authenticated = %v, want %v", authed != nil, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestDownloadHandler_HandleWebDownload_RequiresSession(t *testing.T) {
+	h := newTestWebHandler(false)
+	downloads := NewDownloadHandler(h.auth, nil, nil, nil, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/web/get/file.txt", nil)
+	r.Header.Set("Authorization", "Bearer access-token-123")
+	w := httptest.NewRecorder()
+	downloads.HandleWebDownload(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
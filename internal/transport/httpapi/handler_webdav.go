@@ -0,0 +1,208 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// davPrefix is the URL prefix the WebDAV tree is served under.
+const davPrefix = "/dav"
+
+// davRealm is the HTTP basic auth realm announced to WebDAV clients.
+const davRealm = "Tucha"
+
+// WebDAVHandler serves each user's node tree over WebDAV at /dav/.
+// Clients authenticate with HTTP basic auth (email and password, or an app
+// password for two-factor accounts); a personal access token is accepted as the
+// password or as a bearer token and keeps its scope and path restrictions.
+type WebDAVHandler struct {
+	auth      *service.AuthService
+	tokens    *service.TokenService
+	folders   *service.FolderService
+	files     *service.FileService
+	uploads   *service.UploadService
+	downloads *service.DownloadService
+	trash     *service.TrashService
+	shares    *service.ShareService
+	logger    port.Logger
+
+	mu    sync.Mutex
+	locks map[int64]webdav.LockSystem
+}
+
+// NewWebDAVHandler creates a new WebDAVHandler.
+func NewWebDAVHandler(
+	auth *service.AuthService,
+	tokens *service.TokenService,
+	folders *service.FolderService,
+	files *service.FileService,
+	uploads *service.UploadService,
+	downloads *service.DownloadService,
+	trash *service.TrashService,
+	shares *service.ShareService,
+	logger port.Logger,
+) *WebDAVHandler {
+	return &WebDAVHandler{
+		auth:      auth,
+		tokens:    tokens,
+		folders:   folders,
+		files:     files,
+		uploads:   uploads,
+		downloads: downloads,
+		trash:     trash,
+		shares:    shares,
+		logger:    logger,
+		locks:     make(map[int64]webdav.LockSystem),
+	}
+}
+
+// HandleDAV handles all WebDAV methods under /dav/.
+func (h *WebDAVHandler) HandleDAV(w http.ResponseWriter, r *http.Request) {
+	authed := h.authenticate(r)
+	if authed == nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+davRealm+`", charset="UTF-8"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !h.authorize(r, authed) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPut && authed.FileSizeLimit > 0 && r.ContentLength > authed.FileSizeLimit {
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	dav := &webdav.Handler{
+		Prefix: davPrefix,
+		FileSystem: &davFileSystem{
+			authed:    authed,
+			folders:   h.folders,
+			files:     h.files,
+			uploads:   h.uploads,
+			downloads: h.downloads,
+			trash:     h.trash,
+			shares:    h.shares,
+		},
+		LockSystem: h.lockSystem(authed.UserID),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				h.logger.Warn("WebDAV %s %s failed: user=%q err=%v", r.Method, r.URL.Path, authed.Email, err)
+			}
+		},
+	}
+	dav.ServeHTTP(w, r)
+}
+
+// authenticate resolves the caller from a bearer token or basic auth credentials.
+// Returns nil if the credentials are missing or invalid.
+func (h *WebDAVHandler) authenticate(r *http.Request) *service.AuthenticatedUser {
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			return nil
+		}
+		authed, err := h.auth.Validate(requestToken(r, ""))
+		if err != nil {
+			return nil
+		}
+		return authed
+	}
+
+	if user, err := h.tokens.Verify(email, password); err == nil {
+		authed, err := h.auth.ResolveUser(user.ID)
+		if err != nil {
+			return nil
+		}
+		return authed
+	}
+
+	// Fall back to a personal access token supplied as the password.
+	authed, err := h.auth.Validate(password)
+	if err != nil || authed == nil || !strings.EqualFold(authed.Email, email) {
+		return nil
+	}
+	return authed
+}
+
+// authorize checks the token scope required by the method on the request path
+// and, for COPY and MOVE, on the destination. Modifications inside read-only
+// mounts are refused here so that clients get 403 rather than a generic failure.
+func (h *WebDAVHandler) authorize(r *http.Request, authed *service.AuthenticatedUser) bool {
+	var scope vo.TokenScope
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND":
+		scope = vo.ScopeRead
+	case http.MethodDelete:
+		scope = vo.ScopeTrash
+	default:
+		scope = vo.ScopeWrite
+	}
+
+	paths := []vo.CloudPath{davPath(r.URL.Path)}
+	if dest := r.Header.Get("Destination"); dest != "" {
+		u, err := url.Parse(dest)
+		if err != nil {
+			return false
+		}
+		paths = append(paths, davPath(u.Path))
+	}
+	if !authed.Allows(scope, paths...) {
+		return false
+	}
+	if scope == vo.ScopeRead {
+		return true
+	}
+
+	// COPY only modifies its destination.
+	modified := paths
+	if r.Method == "COPY" {
+		modified = paths[1:]
+	}
+	for _, p := range modified {
+		resolution, err := h.shares.ResolveMount(authed.UserID, p)
+		if err != nil {
+			return false
+		}
+		if resolution == nil || resolution.Share.Access != vo.AccessReadOnly {
+			continue
+		}
+		// Deleting the mount point itself only unmounts the share.
+		if r.Method == http.MethodDelete && resolution.OwnerPath.String() == resolution.Share.Home.String() {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// lockSystem returns the lock table of the given user, creating it on first use.
+// Each user has a separate table because every user sees their own tree under /dav/.
+func (h *WebDAVHandler) lockSystem(userID int64) webdav.LockSystem {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ls, ok := h.locks[userID]
+	if !ok {
+		ls = webdav.NewMemLS()
+		h.locks[userID] = ls
+	}
+	return ls
+}
+
+// davPath converts a /dav/ URL path to a cloud path.
+func davPath(urlPath string) vo.CloudPath {
+	return vo.NewCloudPath(strings.TrimPrefix(urlPath, davPrefix))
+}
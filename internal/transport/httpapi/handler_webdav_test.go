@@ -0,0 +1,127 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newTestWebDAVHandler(shares []entity.Share) *WebDAVHandler {
+	user := mock.NewTestUser(1, "user@example.com")
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) {
+			if id == 1 {
+				return user, nil
+			}
+			return nil, nil
+		},
+		GetByEmailFunc: func(email string) (*entity.User, error) {
+			if email == user.Email {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	tokens := &mock.TokenRepositoryMock{
+		LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+			if accessToken == "pat" {
+				return &entity.Token{
+					ID: 2, UserID: 1, AccessToken: "pat", Personal: true,
+					Scopes: []vo.TokenScope{vo.ScopeRead}, PathPrefix: vo.NewCloudPath("/docs"),
+				}, nil
+			}
+			return nil, nil
+		},
+	}
+	shareRepo := &mock.ShareRepositoryMock{
+		ListMountedByUserFunc: func(userID int64) ([]entity.Share, error) {
+			return shares, nil
+		},
+	}
+
+	return NewWebDAVHandler(
+		service.NewAuthService(tokens, users),
+		service.NewTokenService(tokens, users, &mock.AppPasswordRepositoryMock{}),
+		nil, nil, nil, nil, nil,
+		service.NewShareService(shareRepo, nil, nil, users),
+		&mock.LoggerMock{},
+	)
+}
+
+func TestWebDAVHandler_authenticate(t *testing.T) {
+	h := newTestWebDAVHandler(nil)
+
+	tests := []struct {
+		name     string
+		setup    func(r *http.Request)
+		wantUser bool
+		personal bool
+	}{
+		{"no credentials", func(r *http.Request) {}, false, false},
+		{"password", func(r *http.Request) { r.SetBasicAuth("user@example.com", "password") }, true, false},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("user@example.com", "nope") }, false, false},
+		{"token as password", func(r *http.Request) { r.SetBasicAuth("user@example.com", "pat") }, true, true},
+		{"token of another login", func(r *http.Request) { r.SetBasicAuth("other@example.com", "pat") }, false, false},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer pat") }, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PROPFIND", "/dav/", nil)
+			tt.setup(r)
+
+			authed := h.authenticate(r)
+			if (authed != nil) != tt.wantUser {
+				t.Fatalf("authenticated = %v, want %v", authed != nil, tt.wantUser)
+			}
+			if authed != nil && authed.Personal != tt.personal {
+				t.Errorf("Personal = %v, want %v", authed.Personal, tt.personal)
+			}
+		})
+	}
+}
+
+func TestWebDAVHandler_authorize(t *testing.T) {
+	h := newTestWebDAVHandler([]entity.Share{
+		{OwnerID: 2, Home: vo.NewCloudPath("/public"), Access: vo.AccessReadOnly, MountHome: "/ro"},
+	})
+	session := &service.AuthenticatedUser{UserID: 1}
+	readToken := &service.AuthenticatedUser{
+		UserID: 1, Personal: true, Scopes: []vo.TokenScope{vo.ScopeRead}, PathPrefix: vo.NewCloudPath("/docs"),
+	}
+
+	tests := []struct {
+		name   string
+		authed *service.AuthenticatedUser
+		method string
+		path   string
+		dest   string
+		want   bool
+	}{
+		{"session put", session, http.MethodPut, "/dav/a.txt", "", true},
+		{"token read in prefix", readToken, "PROPFIND", "/dav/docs/a.txt", "", true},
+		{"token read outside prefix", readToken, http.MethodGet, "/dav/other.txt", "", false},
+		{"token write", readToken, http.MethodPut, "/dav/docs/a.txt", "", false},
+		{"put into read-only mount", session, http.MethodPut, "/dav/ro/a.txt", "", false},
+		{"delete inside read-only mount", session, http.MethodDelete, "/dav/ro/a.txt", "", false},
+		{"unmount read-only mount", session, http.MethodDelete, "/dav/ro", "", true},
+		{"copy out of read-only mount", session, "COPY", "/dav/ro/a.txt", "http://host/dav/a.txt", true},
+		{"move out of read-only mount", session, "MOVE", "/dav/ro/a.txt", "http://host/dav/a.txt", false},
+		{"copy into read-only mount", session, "COPY", "/dav/a.txt", "http://host/dav/ro/a.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.dest != "" {
+				r.Header.Set("Destination", tt.dest)
+			}
+			if got := h.authorize(r, tt.authed); got != tt.want {
+				t.Errorf("authorize = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
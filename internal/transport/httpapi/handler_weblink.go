@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"mime"
 	"net/http"
 	"strings"
 	"time"
@@ -11,9 +12,11 @@ import (
 
 // WeblinkDownloadHandler serves public (unauthenticated) access to published nodes.
 // Files are served as binary downloads; folders return a JSON listing with shard config.
+// Browsers asking for HTML get a landing page instead.
 type WeblinkDownloadHandler struct {
 	publish     *service.PublishService
 	downloads   *service.DownloadService
+	archives    *service.ArchiveService
 	folders     *service.FolderService
 	presenter   *Presenter
 	externalURL string
@@ -23,6 +26,7 @@ type WeblinkDownloadHandler struct {
 func NewWeblinkDownloadHandler(
 	publish *service.PublishService,
 	downloads *service.DownloadService,
+	archives *service.ArchiveService,
 	folders *service.FolderService,
 	presenter *Presenter,
 	externalURL string,
@@ -30,6 +34,7 @@ func NewWeblinkDownloadHandler(
 	return &WeblinkDownloadHandler{
 		publish:     publish,
 		downloads:   downloads,
+		archives:    archives,
 		folders:     folders,
 		presenter:   presenter,
 		externalURL: strings.TrimRight(externalURL, "/"),
@@ -40,6 +45,10 @@ func NewWeblinkDownloadHandler(
 // The weblink ID is composed of two path segments: "{seg1}/{seg2}".
 // An optional subpath after the weblink ID resolves items within a published folder.
 // Files are served as binary; folders return a JSON listing with relative paths.
+// Requests accepting text/html get an HTML page: a folder listing, or a file preview
+// with a download button. The download query parameter forces the raw response;
+// for folders it returns a ZIP archive, limited to the children given in name
+// query parameters if there are any.
 // No authentication is required.
 func (h *WeblinkDownloadHandler) HandleWeblinkDownload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -67,6 +76,23 @@ func (h *WeblinkDownloadHandler) HandleWeblinkDownload(w http.ResponseWriter, r
 		return
 	}
 
+	if wantsHTML(r) {
+		root, err := h.publish.ResolveWeblink(weblinkID, "")
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(node.Home.String(), root.Home.String()), "/")
+		h.servePage(w, weblinkID, root, node, rel)
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+
+	if node.IsFolder() && r.URL.Query().Get("download") != "" {
+		h.serveArchive(w, r, node)
+		return
+	}
+
 	if node.IsFile() {
 		h.serveFile(w, r, node)
 		return
@@ -76,6 +102,7 @@ func (h *WeblinkDownloadHandler) HandleWeblinkDownload(w http.ResponseWriter, r
 }
 
 // serveFile streams the file content as a binary download.
+// With the download query parameter, the browser is told to save it as a file.
 func (h *WeblinkDownloadHandler) serveFile(w http.ResponseWriter, r *http.Request, node *entity.Node) {
 	result, err := h.downloads.ResolveByNode(node)
 	if err != nil {
@@ -84,9 +111,37 @@ func (h *WeblinkDownloadHandler) serveFile(w http.ResponseWriter, r *http.Reques
 	}
 	defer result.File.Close()
 
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": result.Node.Name}))
+	}
+
 	http.ServeContent(w, r, result.Node.Name, time.Unix(result.Node.MTime, 0), result.File)
 }
 
+// serveArchive streams a published folder, or the children of it named in the
+// query, as a ZIP archive.
+func (h *WeblinkDownloadHandler) serveArchive(w http.ResponseWriter, r *http.Request, node *entity.Node) {
+	names := r.URL.Query()["name"]
+	sources := []service.ArchiveSource{{UserID: node.UserID, Path: node.Home}}
+	if len(names) > 0 {
+		sources = make([]service.ArchiveSource, 0, len(names))
+		for _, name := range names {
+			if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			sources = append(sources, service.ArchiveSource{UserID: node.UserID, Path: node.Home.Join(name)})
+		}
+	}
+
+	archive, err := h.archives.Prepare(sources)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	writeArchive(w, r, archive, node.Name)
+}
+
 // serveFolderListing returns a JSON listing of the folder's children with relative paths.
 func (h *WeblinkDownloadHandler) serveFolderListing(w http.ResponseWriter, node *entity.Node) {
 	children, err := h.folders.ListChildren(node.UserID, node.Home, 0, 65535)
@@ -0,0 +1,255 @@
+package httpapi
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/infrastructure/thumbnail"
+)
+
+//go:embed weblink.html
+var weblinkHTML string
+
+var weblinkPage = template.Must(template.New("weblink").Parse(weblinkHTML))
+
+const (
+	// weblinkIconPreset is the thumbnail preset shown next to images in folder pages.
+	weblinkIconPreset = "xw28"
+
+	// weblinkPreviewPreset is the thumbnail preset used to preview an image file.
+	weblinkPreviewPreset = "xw2"
+
+	// weblinkTextLimit caps how much of a text file is rendered on its page.
+	weblinkTextLimit = 256 << 10
+)
+
+// Preview kinds of a file page, chosen by file extension.
+const (
+	previewImage = "image"
+	previewVideo = "video"
+	previewAudio = "audio"
+	previewText  = "text"
+)
+
+var previewExtensions = map[string]string{
+	".mp4": previewVideo, ".m4v": previewVideo, ".webm": previewVideo, ".ogv": previewVideo, ".mov": previewVideo,
+	".mp3": previewAudio, ".m4a": previewAudio, ".ogg": previewAudio, ".oga": previewAudio, ".wav": previewAudio, ".flac": previewAudio,
+	".txt": previewText, ".md": previewText, ".log": previewText, ".csv": previewText, ".json": previewText,
+	".xml": previewText, ".yaml": previewText, ".yml": previewText, ".toml": previewText, ".ini": previewText,
+	".conf": previewText, ".cfg": previewText, ".sh": previewText, ".go": previewText, ".py": previewText,
+	".js": previewText, ".ts": previewText, ".css": previewText, ".html": previewText, ".sql": previewText,
+	".c": previewText, ".h": previewText, ".cpp": previewText, ".java": previewText, ".rs": previewText,
+}
+
+// weblinkPageData is rendered by the weblink.html template.
+type weblinkPageData struct {
+	Title       string
+	Crumbs      []weblinkCrumb
+	URL         string
+	DownloadURL string
+
+	// Folder pages.
+	Folder bool
+	Items  []weblinkPageItem
+
+	// File pages.
+	Size          string
+	Modified      string
+	Preview       string
+	PreviewURL    string
+	Text          string
+	TextTruncated bool
+}
+
+// weblinkCrumb is one breadcrumb; the last one has no URL.
+type weblinkCrumb struct {
+	Name string
+	URL  string
+}
+
+// weblinkPageItem is one row of a folder page.
+type weblinkPageItem struct {
+	Name     string
+	URL      string
+	ThumbURL string
+	Folder   bool
+	Size     string
+	Modified string
+}
+
+// wantsHTML reports whether the request comes from a browser navigating to the link.
+// Clients that do not ask for HTML, and explicit downloads, get the raw responses.
+func wantsHTML(r *http.Request) bool {
+	if r.URL.Query().Get("download") != "" {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// servePage renders the HTML landing page for a published node.
+// rel is the node's path relative to the published root ("" for the root itself).
+func (h *WeblinkDownloadHandler) servePage(w http.ResponseWriter, weblinkID string, root, node *entity.Node, rel string) {
+	base := "/public/" + weblinkID
+	pageURL := base
+	if rel != "" {
+		pageURL += "/" + escapeRelPath(rel)
+	}
+	data := weblinkPageData{
+		Title:       node.Name,
+		Crumbs:      weblinkCrumbs(base, root.Name, rel),
+		URL:         pageURL,
+		DownloadURL: pageURL + "?download=1",
+	}
+
+	if node.IsFolder() {
+		children, err := h.folders.ListChildren(node.UserID, node.Home, 0, 65535)
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		data.Folder = true
+		data.Items = make([]weblinkPageItem, 0, len(children))
+		for i := range children {
+			child := &children[i]
+			childRel := escapeRelPath(path.Join(rel, child.Name))
+			item := weblinkPageItem{
+				Name:   child.Name,
+				URL:    base + "/" + childRel,
+				Folder: child.IsFolder(),
+			}
+			if child.IsFile() {
+				item.Size = formatSize(child.Size)
+				item.Modified = formatModified(child.MTime)
+				if thumbnail.IsSupportedFormat(child.Name) {
+					item.ThumbURL = "/public/thumb/" + weblinkIconPreset + "/" + weblinkID + "/" + childRel
+				}
+			}
+			data.Items = append(data.Items, item)
+		}
+	} else {
+		data.Size = formatSize(node.Size)
+		data.Modified = formatModified(node.MTime)
+		data.Preview, data.PreviewURL = previewOf(node.Name, weblinkID, rel, pageURL)
+
+		if data.Preview == previewText {
+			text, truncated, ok := h.readText(node)
+			if ok {
+				data.Text, data.TextTruncated = text, truncated
+			} else {
+				data.Preview = ""
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Vary", "Accept")
+	_ = weblinkPage.Execute(w, data)
+}
+
+// readText reads the beginning of a file for rendering on its page.
+// Returns false if the content is not valid UTF-8 text.
+func (h *WeblinkDownloadHandler) readText(node *entity.Node) (string, bool, bool) {
+	result, err := h.downloads.ResolveByNode(node)
+	if err != nil {
+		return "", false, false
+	}
+	defer result.File.Close()
+
+	data, err := io.ReadAll(io.LimitReader(result.File, weblinkTextLimit+1))
+	if err != nil {
+		return "", false, false
+	}
+	truncated := len(data) > weblinkTextLimit
+	if truncated {
+		data = data[:weblinkTextLimit]
+		// Drop a multi-byte character cut by the limit.
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	if !utf8.Valid(data) {
+		return "", false, false
+	}
+	return string(data), truncated, true
+}
+
+// previewOf picks how a file page previews the file and the URL the preview loads.
+func previewOf(name, weblinkID, rel, fileURL string) (string, string) {
+	if thumbnail.IsSupportedFormat(name) {
+		thumbURL := "/public/thumb/" + weblinkPreviewPreset + "/" + weblinkID
+		if rel != "" {
+			thumbURL += "/" + escapeRelPath(rel)
+		}
+		return previewImage, thumbURL
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if kind, ok := previewExtensions[ext]; ok {
+		return kind, fileURL
+	}
+	if strings.HasPrefix(mime.TypeByExtension(ext), "image/") {
+		return previewImage, fileURL
+	}
+	return "", ""
+}
+
+// weblinkCrumbs builds the breadcrumbs from the published root down to rel.
+func weblinkCrumbs(base, rootName, rel string) []weblinkCrumb {
+	crumbs := []weblinkCrumb{{Name: rootName, URL: base + "/"}}
+	if rel == "" {
+		crumbs[0].URL = ""
+		return crumbs
+	}
+
+	segments := strings.Split(rel, "/")
+	for i, name := range segments {
+		crumb := weblinkCrumb{Name: name}
+		if i < len(segments)-1 {
+			crumb.URL = base + "/" + escapeRelPath(strings.Join(segments[:i+1], "/"))
+		}
+		crumbs = append(crumbs, crumb)
+	}
+	return crumbs
+}
+
+// escapeRelPath escapes each segment of a slash-separated relative path.
+func escapeRelPath(rel string) string {
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// formatSize renders a byte count in binary units.
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTP"[exp])
+}
+
+// formatModified renders a modification time, or nothing when it is unknown.
+func formatModified(mtime int64) string {
+	if mtime == 0 {
+		return ""
+	}
+	return time.Unix(mtime, 0).UTC().Format("2006-01-02 15:04")
+}
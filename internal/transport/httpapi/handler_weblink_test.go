@@ -0,0 +1,246 @@
+package httpapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+// newTestWeblinkHandler publishes the folder /shared as "ab/cd". It holds
+// "notes <1>.txt" with the given content, "photo.jpg" and the folder "sub".
+func newTestWeblinkHandler(t *testing.T, content string) *WeblinkDownloadHandler {
+	t.Helper()
+
+	contentPath := filepath.Join(t.TempDir(), "content")
+	if err := os.WriteFile(contentPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := mock.NewTestNode(1, "/shared", vo.NodeTypeFolder)
+	root.Weblink = "ab/cd"
+	notes := mock.NewTestFileNode(1, "/shared/notes <1>.txt", mock.ValidHash(), int64(len(content)))
+	photo := mock.NewTestFileNode(1, "/shared/photo.jpg", mock.ValidHash(), 2048)
+	sub := mock.NewTestNode(1, "/shared/sub", vo.NodeTypeFolder)
+	nodes := map[string]*entity.Node{
+		root.Home.String():  root,
+		notes.Home.String(): notes,
+		photo.Home.String(): photo,
+		sub.Home.String():   sub,
+	}
+
+	nodeRepo := &mock.NodeRepositoryMock{
+		GetByWeblinkFunc: func(weblink string) (*entity.Node, error) {
+			if weblink == root.Weblink {
+				return root, nil
+			}
+			return nil, nil
+		},
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			return nodes[path.String()], nil
+		},
+		GetWithDescendantsFunc: func(userID int64, path vo.CloudPath) (*entity.Node, []entity.Node, error) {
+			if path.String() == root.Home.String() {
+				return root, []entity.Node{*sub, *notes, *photo}, nil
+			}
+			return nodes[path.String()], nil, nil
+		},
+		ListChildrenFunc: func(userID int64, path vo.CloudPath, offset, limit int) ([]entity.Node, error) {
+			if path.String() == root.Home.String() {
+				return []entity.Node{*sub, *notes, *photo}, nil
+			}
+			return nil, nil
+		},
+	}
+	storage := &mock.ContentStorageMock{
+		OpenFunc: func(hash vo.ContentHash) (*os.File, error) {
+			return os.Open(contentPath)
+		},
+	}
+
+	return NewWeblinkDownloadHandler(
+		service.NewPublishService(nodeRepo, nil),
+		service.NewDownloadService(nodeRepo, storage),
+		service.NewArchiveService(nodeRepo, storage),
+		service.NewFolderService(nodeRepo),
+		NewPresenter(),
+		"http://localhost:8081",
+	)
+}
+
+func getWeblink(h *WeblinkDownloadHandler, target, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	h.HandleWeblinkDownload(w, r)
+	return w
+}
+
+func TestWeblinkDownloadHandler_FolderPage(t *testing.T) {
+	h := newTestWeblinkHandler(t, "hello")
+
+	t.Run("browser gets HTML listing", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd", browserAccept)
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Fatalf("Content-Type = %q, want text/html", ct)
+		}
+		body := w.Body.String()
+		for _, want := range []string{
+			`href="/public/ab/cd/sub"`,
+			`href="/public/ab/cd/notes%20%3C1%3E.txt"`,
+			`notes &lt;1&gt;.txt`,
+			`src="/public/thumb/xw28/ab/cd/photo.jpg"`,
+			`2.0 KB`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("page does not contain %s", want)
+			}
+		}
+	})
+
+	t.Run("client gets JSON listing", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd", "*/*")
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Fatalf("Content-Type = %q, want application/json", ct)
+		}
+		if !strings.Contains(w.Body.String(), `"weblink_get"`) {
+			t.Error("JSON listing is missing weblink_get")
+		}
+	})
+
+	t.Run("subfolder page has breadcrumbs", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd/sub", browserAccept)
+
+		body := w.Body.String()
+		if !strings.Contains(body, `<a href="/public/ab/cd/">shared</a>`) {
+			t.Error("missing link to the published root")
+		}
+		if !strings.Contains(body, "This folder is empty.") {
+			t.Error("missing empty folder note")
+		}
+	})
+}
+
+func TestWeblinkDownloadHandler_FilePage(t *testing.T) {
+	h := newTestWeblinkHandler(t, "line <one>\nline two\n")
+
+	t.Run("text file is rendered with a download button", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd/notes%20%3C1%3E.txt", browserAccept)
+
+		body := w.Body.String()
+		if !strings.Contains(body, "<pre>line &lt;one&gt;\nline two\n</pre>") {
+			t.Errorf("text is not rendered:\n%s", body)
+		}
+		if !strings.Contains(body, `href="/public/ab/cd/notes%20%3C1%3E.txt?download=1"`) {
+			t.Error("missing download button")
+		}
+	})
+
+	t.Run("image file is previewed through its thumbnail", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd/photo.jpg", browserAccept)
+
+		if !strings.Contains(w.Body.String(), `<img src="/public/thumb/xw2/ab/cd/photo.jpg"`) {
+			t.Error("missing image preview")
+		}
+	})
+
+	t.Run("raw content without HTML accept", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd/notes%20%3C1%3E.txt", "")
+
+		if w.Body.String() != "line <one>\nline two\n" {
+			t.Errorf("body = %q, want file content", w.Body.String())
+		}
+		if w.Header().Get("Content-Disposition") != "" {
+			t.Error("raw content should not be an attachment")
+		}
+	})
+
+	t.Run("download parameter forces attachment", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd/notes%20%3C1%3E.txt?download=1", browserAccept)
+
+		if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment") {
+			t.Errorf("Content-Disposition = %q, want attachment", w.Header().Get("Content-Disposition"))
+		}
+		if w.Body.String() != "line <one>\nline two\n" {
+			t.Errorf("body = %q, want file content", w.Body.String())
+		}
+	})
+}
+
+func TestWeblinkDownloadHandler_FolderArchive(t *testing.T) {
+	h := newTestWeblinkHandler(t, "hello")
+
+	tests := []struct {
+		name   string
+		target string
+		want   []string
+	}{
+		{"whole folder", "/public/ab/cd?download=1", []string{"shared/", "shared/notes <1>.txt", "shared/photo.jpg", "shared/sub/"}},
+		{"selected children", "/public/ab/cd?download=1&name=photo.jpg&name=sub", []string{"photo.jpg", "sub/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getWeblink(h, tt.target, browserAccept)
+
+			if w.Header().Get("Content-Type") != "application/zip" {
+				t.Fatalf("Content-Type = %q, want application/zip", w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("Content-Disposition") != `attachment; filename=shared.zip` {
+				t.Errorf("Content-Disposition = %q", w.Header().Get("Content-Disposition"))
+			}
+			zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+			if err != nil {
+				t.Fatalf("reading archive: %v", err)
+			}
+			if len(zr.File) != len(tt.want) {
+				t.Fatalf("got %d entries, want %v", len(zr.File), tt.want)
+			}
+			for i, f := range zr.File {
+				if f.Name != tt.want[i] {
+					t.Errorf("entry %d = %q, want %q", i, f.Name, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("rejects names leaving the folder", func(t *testing.T) {
+		w := getWeblink(h, "/public/ab/cd?download=1&name=..", "")
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{5 << 20, "5.0 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.bytes); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
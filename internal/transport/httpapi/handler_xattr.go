@@ -0,0 +1,168 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// XAttrHandler handles reading and changing the extended attributes of nodes.
+type XAttrHandler struct {
+	auth   *service.AuthService
+	xattrs *service.XAttrService
+}
+
+// NewXAttrHandler creates a new XAttrHandler.
+func NewXAttrHandler(auth *service.AuthService, xattrs *service.XAttrService) *XAttrHandler {
+	return &XAttrHandler{
+		auth:   auth,
+		xattrs: xattrs,
+	}
+}
+
+// HandleGet handles GET /api/v2/file/xattr - list the attributes of a node,
+// or get the one given by the name parameter.
+func (h *XAttrHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return
+	}
+
+	q := r.URL.Query()
+	homePath := q.Get("home")
+	if homePath == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return
+	}
+	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeRead, path) {
+		return
+	}
+
+	if raw := q.Get("name"); raw != "" {
+		name, err := vo.ParseXAttrName(raw)
+		if err != nil {
+			writeError(w, authed.Email, 400, "name", "invalid")
+			return
+		}
+		attr, err := h.xattrs.Get(authed.UserID, path, name)
+		if err != nil {
+			h.writeError(w, authed, err)
+			return
+		}
+		writeSuccess(w, authed.Email, XAttrItem{Name: attr.Name.String(), Value: attr.Value})
+		return
+	}
+
+	attrs, err := h.xattrs.List(authed.UserID, path)
+	h.writeList(w, authed, attrs, err)
+}
+
+// HandleSet handles POST /api/v2/file/xattr/set - set an attribute of a node.
+// Responds with all attributes of the node.
+func (h *XAttrHandler) HandleSet(w http.ResponseWriter, r *http.Request) {
+	path, name, authed := h.parseForm(w, r)
+	if authed == nil {
+		return
+	}
+
+	value := r.FormValue("value")
+	if !utf8.ValidString(value) {
+		writeError(w, authed.Email, 400, "value", "invalid")
+		return
+	}
+
+	attrs, err := h.xattrs.Set(authed.UserID, path, name, value)
+	h.writeList(w, authed, attrs, err)
+}
+
+// HandleDelete handles POST /api/v2/file/xattr/delete - remove an attribute of a node.
+func (h *XAttrHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	path, name, authed := h.parseForm(w, r)
+	if authed == nil {
+		return
+	}
+
+	if err := h.xattrs.Delete(authed.UserID, path, name); err != nil {
+		h.writeError(w, authed, err)
+		return
+	}
+	writeSuccess(w, authed.Email, path.String())
+}
+
+// parseForm checks an attribute changing request and returns the node path
+// from the home parameter and the attribute name from the name parameter.
+// Returns a nil user if the request was rejected.
+func (h *XAttrHandler) parseForm(w http.ResponseWriter, r *http.Request) (vo.CloudPath, vo.XAttrName, *service.AuthenticatedUser) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return vo.CloudPath{}, "", nil
+	}
+
+	authed := authenticate(w, r, h.auth)
+	if authed == nil {
+		return vo.CloudPath{}, "", nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeHomeError(w, authed.Email, 400, "invalid")
+		return vo.CloudPath{}, "", nil
+	}
+
+	homePath := r.FormValue("home")
+	if homePath == "" {
+		writeHomeError(w, authed.Email, 400, "required")
+		return vo.CloudPath{}, "", nil
+	}
+	raw := r.FormValue("name")
+	if raw == "" {
+		writeError(w, authed.Email, 400, "name", "required")
+		return vo.CloudPath{}, "", nil
+	}
+	name, err := vo.ParseXAttrName(raw)
+	if err != nil {
+		writeError(w, authed.Email, 400, "name", "invalid")
+		return vo.CloudPath{}, "", nil
+	}
+
+	path := vo.NewCloudPath(homePath)
+	if !authorize(w, authed, vo.ScopeWrite, path) {
+		return vo.CloudPath{}, "", nil
+	}
+	return path, name, authed
+}
+
+// writeList writes the attributes of a node, or the error of getting them.
+func (h *XAttrHandler) writeList(w http.ResponseWriter, authed *service.AuthenticatedUser, attrs []entity.XAttr, err error) {
+	if err != nil {
+		h.writeError(w, authed, err)
+		return
+	}
+
+	items := make([]XAttrItem, len(attrs))
+	for i, attr := range attrs {
+		items[i] = XAttrItem{Name: attr.Name.String(), Value: attr.Value}
+	}
+	writeSuccess(w, authed.Email, map[string]interface{}{"list": items})
+}
+
+// writeError writes the error of an attribute operation.
+func (h *XAttrHandler) writeError(w http.ResponseWriter, authed *service.AuthenticatedUser, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		writeHomeError(w, authed.Email, 404, "not_exists")
+	case errors.Is(err, service.ErrTooLarge):
+		writeError(w, authed.Email, 400, "value", "too_large")
+	default:
+		writeHomeError(w, authed.Email, 500, "unknown")
+	}
+}
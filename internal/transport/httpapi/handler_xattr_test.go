@@ -0,0 +1,151 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func newTestXAttrHandler(attrs map[vo.XAttrName]string) *XAttrHandler {
+	auth := setupHandlerAuth()
+	nodes := &mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			if path.String() != "/report.pdf" {
+				return nil, nil
+			}
+			return mock.NewTestFileNode(1, "/report.pdf", mock.ValidHash(), 10), nil
+		},
+	}
+	xattrs := &mock.XAttrRepositoryMock{
+		ListFunc: func(nodeID int64) ([]entity.XAttr, error) {
+			var list []entity.XAttr
+			for _, name := range []vo.XAttrName{"checksum", "source"} {
+				if value, ok := attrs[name]; ok {
+					list = append(list, entity.XAttr{Name: name, Value: value})
+				}
+			}
+			return list, nil
+		},
+		GetFunc: func(nodeID int64, name vo.XAttrName) (*entity.XAttr, error) {
+			if value, ok := attrs[name]; ok {
+				return &entity.XAttr{Name: name, Value: value}, nil
+			}
+			return nil, nil
+		},
+		SetFunc: func(nodeID int64, name vo.XAttrName, value string) error {
+			attrs[name] = value
+			return nil
+		},
+		DeleteFunc: func(nodeID int64, name vo.XAttrName) (bool, error) {
+			_, ok := attrs[name]
+			delete(attrs, name)
+			return ok, nil
+		},
+	}
+	return NewXAttrHandler(auth, service.NewXAttrService(nodes, xattrs))
+}
+
+func TestXAttrHandler_HandleSet(t *testing.T) {
+	attrs := map[vo.XAttrName]string{"checksum": "abc"}
+	h := newTestXAttrHandler(attrs)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v2/file/xattr/set?access_token=access-token-123", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.HandleSet(w, r)
+		return w
+	}
+
+	w := post(url.Values{"home": {"/report.pdf"}, "name": {"source"}, "value": {"crm"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Body struct {
+			List []XAttrItem `json:"list"`
+		} `json:"body"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Body.List) != 2 || resp.Body.List[1] != (XAttrItem{Name: "source", Value: "crm"}) {
+		t.Errorf("list = %+v, want checksum and source", resp.Body.List)
+	}
+
+	for _, tt := range []struct {
+		form url.Values
+		want int
+	}{
+		{url.Values{"name": {"source"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/report.pdf"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/report.pdf"}, "name": {"bad name"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/report.pdf"}, "name": {"source"}, "value": {"\xff"}}, http.StatusBadRequest},
+		{url.Values{"home": {"/report.pdf"}, "name": {"source"}, "value": {strings.Repeat("x", service.MaxXAttrValueSize+1)}}, http.StatusBadRequest},
+		{url.Values{"home": {"/missing.pdf"}, "name": {"source"}}, http.StatusNotFound},
+	} {
+		if w := post(tt.form); w.Code != tt.want {
+			t.Errorf("form %v: status = %d, want %d", tt.form, w.Code, tt.want)
+		}
+	}
+}
+
+func TestXAttrHandler_HandleGetDelete(t *testing.T) {
+	attrs := map[vo.XAttrName]string{"checksum": "abc", "source": "crm"}
+	h := newTestXAttrHandler(attrs)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v2/file/xattr?access_token=access-token-123&"+query, nil)
+		w := httptest.NewRecorder()
+		h.HandleGet(w, r)
+		return w
+	}
+
+	w := get("home=/report.pdf&name=source")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Body XAttrItem `json:"body"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Body != (XAttrItem{Name: "source", Value: "crm"}) {
+		t.Errorf("attribute = %+v, want source=crm", resp.Body)
+	}
+	if w := get("home=/report.pdf"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"checksum"`) {
+		t.Errorf("list: status = %d, body %s", w.Code, w.Body)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v2/file/xattr/delete?access_token=access-token-123", strings.NewReader("home=/report.pdf&name=source"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.HandleDelete(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if _, ok := attrs["source"]; ok {
+		t.Error("attribute not deleted")
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"home=/report.pdf&name=source", http.StatusNotFound},
+		{"home=/missing.pdf", http.StatusNotFound},
+		{"home=/report.pdf&name=a:b", http.StatusBadRequest},
+		{"name=source", http.StatusBadRequest},
+	} {
+		if w := get(tt.query); w.Code != tt.want {
+			t.Errorf("GET %s: status = %d, want %d", tt.query, w.Code, tt.want)
+		}
+	}
+}
@@ -25,6 +25,10 @@ func (p *Presenter) NodeToFolderItem(node *entity.Node, subCount *FolderCount) F
 		Weblink: node.Weblink,
 		Rev:     node.Rev,
 		GRev:    node.GRev,
+		Starred: node.Starred,
+	}
+	for _, tag := range node.Tags {
+		item.Tags = append(item.Tags, tag.String())
 	}
 
 	if node.Type == vo.NodeTypeFile {
@@ -45,6 +45,30 @@ func TestPresenter_NodeToFolderItem_file(t *testing.T) {
 	}
 }
 
+func TestPresenter_NodeToFolderItem_labels(t *testing.T) {
+	p := NewPresenter()
+	node := &entity.Node{
+		Name:    "plan.txt",
+		Home:    vo.NewCloudPath("/plan.txt"),
+		Type:    vo.NodeTypeFile,
+		Hash:    validHash(),
+		Starred: true,
+		Tags:    []vo.Tag{"q3", "work"},
+	}
+
+	item := p.NodeToFolderItem(node, nil)
+
+	if !item.Starred {
+		t.Error("Starred = false, want true")
+	}
+	if len(item.Tags) != 2 || item.Tags[0] != "q3" || item.Tags[1] != "work" {
+		t.Errorf("Tags = %v, want [q3 work]", item.Tags)
+	}
+	if item := p.NodeToFolderItem(&entity.Node{Type: vo.NodeTypeFolder}, nil); item.Tags != nil {
+		t.Errorf("Tags of an untagged node = %v, want nil to omit them", item.Tags)
+	}
+}
+
 func TestPresenter_NodeToFolderItem_folder(t *testing.T) {
 	p := NewPresenter()
 	node := &entity.Node{
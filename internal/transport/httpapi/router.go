@@ -23,6 +23,22 @@ func RegisterRoutes(
 	thumbnailH *ThumbnailHandler,
 	publicThumbH *PublicThumbnailHandler,
 	videoH *VideoHandler,
+	personalTokenH *PersonalTokenHandler,
+	sessionH *SessionHandler,
+	twoFactorH *TwoFactorHandler,
+	impersonationH *ImpersonationHandler,
+	webdavH *WebDAVHandler,
+	s3H *S3Handler,
+	accessKeyH *AccessKeyHandler,
+	sshKeyH *SSHKeyHandler,
+	webH *WebHandler,
+	extractH *ExtractHandler,
+	changeH *ChangeHandler,
+	searchH *SearchHandler,
+	tagH *TagHandler,
+	xattrH *XAttrHandler,
+	mediaH *MediaHandler,
+	v3H *V3Handler,
 ) {
 	// Service discovery (unauthenticated).
 	mux.HandleFunc("/", selfConfigH.HandleSelfConfigure)
@@ -33,14 +49,21 @@ func RegisterRoutes(
 	// CSRF token.
 	mux.HandleFunc("/api/v2/tokens/csrf", csrfH.HandleCSRF)
 
+	// Personal access tokens.
+	mux.HandleFunc("/api/v2/tokens/personal", personalTokenH.HandleList)
+	mux.HandleFunc("/api/v2/tokens/personal/add", personalTokenH.HandleAdd)
+	mux.HandleFunc("/api/v2/tokens/personal/remove", personalTokenH.HandleRemove)
+
 	// Dispatcher.
 	mux.HandleFunc("/api/v2/dispatcher/", dispatchH.HandleDispatcher)
+	mux.HandleFunc("/api/v2/dispatcher/sign", dispatchH.HandleSign)
 	mux.HandleFunc("/d", dispatchH.HandleOAuthDispatcher)
 	mux.HandleFunc("/u", dispatchH.HandleOAuthDispatcher)
 
 	// Folder listing and creation.
 	mux.HandleFunc("/api/v2/folder", folderH.HandleFolder)
 	mux.HandleFunc("/api/v2/folder/add", folderH.HandleFolderAdd)
+	mux.HandleFunc("/api/v2/folder/find", searchH.HandleFind)
 
 	// File metadata and operations.
 	mux.HandleFunc("/api/v2/file", fileH.HandleFile)
@@ -50,11 +73,25 @@ func RegisterRoutes(
 	mux.HandleFunc("/api/v2/file/copy", fileH.HandleFileCopy)
 	mux.HandleFunc("/api/v2/file/add", fileH.HandleFileAdd)
 	mux.HandleFunc("/api/v2/file/history", fileH.HandleFileHistory)
+	mux.HandleFunc("/api/v2/file/history/restore", fileH.HandleFileHistoryRestore)
+	mux.HandleFunc("/api/v2/file/history/download", fileH.HandleFileHistoryDownload)
+	mux.HandleFunc("/api/v2/file/extract", extractH.HandleExtract)
+
+	// Change feed for incremental sync.
+	mux.HandleFunc("/api/v2/changes", changeH.HandleChanges)
+	mux.HandleFunc("/api/v2/changes/events", changeH.HandleEvents)
+
+	// REST API v3 and its OpenAPI document.
+	v3H.Register(mux)
+
+	// Background jobs.
+	mux.HandleFunc("/api/v2/jobs/status", extractH.HandleJobStatus)
 
 	// Upload and download.
 	mux.HandleFunc("/upload/", uploadH.HandleUpload)
 	mux.HandleFunc("/upload", uploadH.HandleUpload)
 	mux.HandleFunc("/get/", downloadH.HandleDownload)
+	mux.HandleFunc("/zip", downloadH.HandleZip)
 
 	// Thumbnails.
 	mux.HandleFunc("/thumb/", thumbnailH.HandleThumbnail)
@@ -62,6 +99,33 @@ func RegisterRoutes(
 	// User space/quota.
 	mux.HandleFunc("/api/v2/user/space", spaceH.HandleSpace)
 
+	// Two-factor authentication and app passwords.
+	mux.HandleFunc("/api/v2/user/2fa", twoFactorH.HandleStatus)
+	mux.HandleFunc("/api/v2/user/2fa/enroll", twoFactorH.HandleEnroll)
+	mux.HandleFunc("/api/v2/user/2fa/confirm", twoFactorH.HandleConfirm)
+	mux.HandleFunc("/api/v2/user/2fa/disable", twoFactorH.HandleDisable)
+	mux.HandleFunc("/api/v2/user/app-passwords", twoFactorH.HandleAppPasswords)
+	mux.HandleFunc("/api/v2/user/app-passwords/add", twoFactorH.HandleAppPasswordAdd)
+	mux.HandleFunc("/api/v2/user/app-passwords/remove", twoFactorH.HandleAppPasswordRemove)
+
+	// S3 access keys.
+	mux.HandleFunc("/api/v2/user/access-keys", accessKeyH.HandleList)
+	mux.HandleFunc("/api/v2/user/access-keys/add", accessKeyH.HandleAdd)
+	mux.HandleFunc("/api/v2/user/access-keys/remove", accessKeyH.HandleRemove)
+
+	// SFTP public keys.
+	mux.HandleFunc("/api/v2/user/ssh-keys", sshKeyH.HandleList)
+	mux.HandleFunc("/api/v2/user/ssh-keys/add", sshKeyH.HandleAdd)
+	mux.HandleFunc("/api/v2/user/ssh-keys/remove", sshKeyH.HandleRemove)
+
+	// End-user web file browser.
+	mux.HandleFunc("/web", webH.HandleWeb)
+	mux.HandleFunc("/web/", webH.HandleWeb)
+	mux.HandleFunc("/web/login", webH.HandleLogin)
+	mux.HandleFunc("/web/logout", webH.HandleLogout)
+	mux.HandleFunc("/web/get/", downloadH.HandleWebDownload)
+	mux.HandleFunc("/web/zip", downloadH.HandleWebZip)
+
 	// Admin panel and authentication.
 	mux.HandleFunc("/admin", adminH.HandleAdmin)
 	mux.HandleFunc("/admin/login", adminH.HandleLogin)
@@ -72,12 +136,36 @@ func RegisterRoutes(
 	mux.HandleFunc("/admin/user/list", userH.HandleUserList)
 	mux.HandleFunc("/admin/user/edit", userH.HandleUserEdit)
 	mux.HandleFunc("/admin/user/remove", userH.HandleUserRemove)
+	mux.HandleFunc("/admin/user/tokens", personalTokenH.HandleAdminList)
+	mux.HandleFunc("/admin/user/tokens/add", personalTokenH.HandleAdminAdd)
+	mux.HandleFunc("/admin/user/tokens/remove", personalTokenH.HandleAdminRemove)
+	mux.HandleFunc("/admin/user/sessions", sessionH.HandleList)
+	mux.HandleFunc("/admin/user/sessions/revoke", sessionH.HandleRevoke)
+	mux.HandleFunc("/admin/user/impersonate", impersonationH.HandleImpersonate)
 
 	// Trashbin.
 	mux.HandleFunc("/api/v2/trashbin", trashH.HandleTrashList)
 	mux.HandleFunc("/api/v2/trashbin/restore", trashH.HandleTrashRestore)
+	mux.HandleFunc("/api/v2/trashbin/delete", trashH.HandleTrashDelete)
 	mux.HandleFunc("/api/v2/trashbin/empty", trashH.HandleTrashEmpty)
 
+	// Tags and stars.
+	mux.HandleFunc("/api/v2/file/tag", tagH.HandleTag)
+	mux.HandleFunc("/api/v2/file/untag", tagH.HandleUntag)
+	mux.HandleFunc("/api/v2/file/star", tagH.HandleStar)
+	mux.HandleFunc("/api/v2/file/unstar", tagH.HandleUnstar)
+	mux.HandleFunc("/api/v2/folder/tagged", tagH.HandleTagged)
+	mux.HandleFunc("/api/v2/folder/starred", tagH.HandleStarred)
+
+	// Extended attributes.
+	mux.HandleFunc("/api/v2/file/xattr", xattrH.HandleGet)
+	mux.HandleFunc("/api/v2/file/xattr/set", xattrH.HandleSet)
+	mux.HandleFunc("/api/v2/file/xattr/delete", xattrH.HandleDelete)
+
+	// Photo metadata and timeline.
+	mux.HandleFunc("/api/v2/file/media", mediaH.HandleInfo)
+	mux.HandleFunc("/api/v2/folder/timeline", mediaH.HandleTimeline)
+
 	// Publishing / weblinks.
 	mux.HandleFunc("/api/v2/file/publish", publishH.HandlePublish)
 	mux.HandleFunc("/api/v2/file/unpublish", publishH.HandleUnpublish)
@@ -89,6 +177,14 @@ func RegisterRoutes(
 	// Video streaming.
 	mux.HandleFunc("/video/", videoH.HandleVideoStream)
 
+	// WebDAV access to the user's tree.
+	mux.HandleFunc("/dav", webdavH.HandleDAV)
+	mux.HandleFunc("/dav/", webdavH.HandleDAV)
+
+	// S3-compatible API (path-style).
+	mux.HandleFunc("/s3", s3H.HandleS3)
+	mux.HandleFunc("/s3/", s3H.HandleS3)
+
 	// Folder sharing / invites.
 	mux.HandleFunc("/api/v2/folder/share", shareH.HandleShare)
 	mux.HandleFunc("/api/v2/folder/unshare", shareH.HandleUnshare)
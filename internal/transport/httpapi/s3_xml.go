@@ -0,0 +1,251 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Namespace is the XML namespace of S3 API responses.
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// s3TimeFormat is the timestamp format used in S3 XML bodies.
+const s3TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// S3Error is the error body of the S3 API.
+type S3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource,omitempty"`
+}
+
+// S3Owner identifies the owner of buckets and objects.
+type S3Owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+// S3Bucket is one entry of ListBuckets.
+type S3Bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+// S3ListAllMyBucketsResult is the ListBuckets response.
+type S3ListAllMyBucketsResult struct {
+	XMLName xml.Name   `xml:"ListAllMyBucketsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Owner   S3Owner    `xml:"Owner"`
+	Buckets []S3Bucket `xml:"Buckets>Bucket"`
+}
+
+// S3Object is one entry of ListObjects.
+type S3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// S3CommonPrefix is a key prefix rolled up by the delimiter in ListObjects.
+type S3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// S3ListBucketResult is the ListObjects (v1) and ListObjectsV2 response.
+// V1-only and V2-only fields are left empty for the other version.
+type S3ListBucketResult struct {
+	XMLName               xml.Name         `xml:"ListBucketResult"`
+	Xmlns                 string           `xml:"xmlns,attr"`
+	Name                  string           `xml:"Name"`
+	Prefix                string           `xml:"Prefix"`
+	Delimiter             string           `xml:"Delimiter,omitempty"`
+	Marker                *string          `xml:"Marker,omitempty"`
+	NextMarker            string           `xml:"NextMarker,omitempty"`
+	StartAfter            string           `xml:"StartAfter,omitempty"`
+	ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+	KeyCount              *int             `xml:"KeyCount,omitempty"`
+	MaxKeys               int              `xml:"MaxKeys"`
+	EncodingType          string           `xml:"EncodingType,omitempty"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	Contents              []S3Object       `xml:"Contents"`
+	CommonPrefixes        []S3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+// S3LocationConstraint is the GetBucketLocation response.
+type S3LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+// S3CopyObjectResult is the CopyObject response.
+type S3CopyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	ETag         string   `xml:"ETag"`
+	LastModified string   `xml:"LastModified"`
+}
+
+// S3InitiateMultipartUploadResult is the CreateMultipartUpload response.
+type S3InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+// S3CompleteMultipartUpload is the CompleteMultipartUpload request body.
+type S3CompleteMultipartUpload struct {
+	Parts []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+// S3CompleteMultipartUploadResult is the CompleteMultipartUpload response.
+type S3CompleteMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// S3Delete is the DeleteObjects request body.
+type S3Delete struct {
+	Quiet   bool `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+// S3DeletedObject is one successfully deleted key in DeleteObjects.
+type S3DeletedObject struct {
+	Key string `xml:"Key"`
+}
+
+// S3DeleteError is one key that could not be deleted in DeleteObjects.
+type S3DeleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// S3DeleteResult is the DeleteObjects response.
+type S3DeleteResult struct {
+	XMLName xml.Name          `xml:"DeleteResult"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Deleted []S3DeletedObject `xml:"Deleted"`
+	Errors  []S3DeleteError   `xml:"Error"`
+}
+
+// writeS3XML writes an XML response body with the given status code.
+func writeS3XML(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(body)
+}
+
+// writeS3Error writes an S3 error response. HEAD responses carry no body.
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	writeS3XML(w, status, S3Error{Code: code, Message: message, Resource: r.URL.Path})
+}
+
+// s3Time formats a Unix timestamp for S3 XML bodies.
+func s3Time(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(s3TimeFormat)
+}
+
+// s3ETag quotes a content hash for use as an ETag.
+func s3ETag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// s3Entry is a candidate for a ListObjects response: a file, or a folder that
+// can only surface as a common prefix.
+type s3Entry struct {
+	Key    string
+	Folder bool
+	Object S3Object
+}
+
+// s3Listing is the page selected by listS3Entries.
+type s3Listing struct {
+	Objects   []S3Object
+	Prefixes  []string
+	Truncated bool
+	Next      string // Last key or prefix returned, for continuing a truncated listing
+}
+
+// listS3Entries applies ListObjects prefix, delimiter and paging rules to the entries.
+// Only keys sorting after "after" are returned; at most maxKeys objects and prefixes in total.
+func listS3Entries(entries []s3Entry, prefix, delimiter, after string, maxKeys int) s3Listing {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	var page s3Listing
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Key, prefix) || e.Key == prefix {
+			continue
+		}
+
+		item, isPrefix := e.Key, false
+		if delimiter != "" {
+			if i := strings.Index(e.Key[len(prefix):], delimiter); i >= 0 {
+				item, isPrefix = e.Key[:len(prefix)+i+len(delimiter)], true
+			}
+		}
+		if e.Folder && !isPrefix {
+			continue
+		}
+		if item <= after || seen[item] {
+			continue
+		}
+
+		if len(page.Objects)+len(page.Prefixes) == maxKeys {
+			page.Truncated = true
+			break
+		}
+		if isPrefix {
+			seen[item] = true
+			page.Prefixes = append(page.Prefixes, item)
+		} else {
+			page.Objects = append(page.Objects, e.Object)
+		}
+		page.Next = item
+	}
+	return page
+}
+
+// encodeS3Token and decodeS3Token make continuation tokens opaque to clients.
+func encodeS3Token(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeS3Token(token string) (string, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	return string(b), err == nil
+}
+
+// s3EncodeKey applies encoding-type=url to a key or prefix.
+// Spaces become %20 rather than "+", which clients would not decode.
+func s3EncodeKey(key string, encode bool) string {
+	if !encode {
+		return key
+	}
+	escaped := strings.ReplaceAll(url.QueryEscape(key), "+", "%20")
+	return strings.ReplaceAll(escaped, "%2F", "/")
+}
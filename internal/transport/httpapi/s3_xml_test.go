@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func s3TestEntries() []s3Entry {
+	entries := []s3Entry{
+		{Key: "docs/", Folder: true},
+		{Key: "docs/a.txt"},
+		{Key: "docs/old/", Folder: true},
+		{Key: "docs/old/b.txt"},
+		{Key: "empty/", Folder: true},
+		{Key: "readme.md"},
+		{Key: "z.bin"},
+	}
+	for i := range entries {
+		entries[i].Object.Key = entries[i].Key
+	}
+	return entries
+}
+
+func s3Keys(objects []S3Object) []string {
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	return keys
+}
+
+func TestListS3Entries(t *testing.T) {
+	tests := []struct {
+		name         string
+		prefix       string
+		delimiter    string
+		after        string
+		maxKeys      int
+		wantKeys     []string
+		wantPrefixes []string
+		wantTrunc    bool
+		wantNext     string
+	}{
+		{
+			name:     "flat listing skips folders",
+			maxKeys:  1000,
+			wantKeys: []string{"docs/a.txt", "docs/old/b.txt", "readme.md", "z.bin"},
+			wantNext: "z.bin",
+		},
+		{
+			name:         "top level with delimiter",
+			delimiter:    "/",
+			maxKeys:      1000,
+			wantKeys:     []string{"readme.md", "z.bin"},
+			wantPrefixes: []string{"docs/", "empty/"},
+			wantNext:     "z.bin",
+		},
+		{
+			name:         "inside a folder",
+			prefix:       "docs/",
+			delimiter:    "/",
+			maxKeys:      1000,
+			wantKeys:     []string{"docs/a.txt"},
+			wantPrefixes: []string{"docs/old/"},
+			wantNext:     "docs/old/",
+		},
+		{
+			name:     "partial name prefix",
+			prefix:   "docs/o",
+			maxKeys:  1000,
+			wantKeys: []string{"docs/old/b.txt"},
+			wantNext: "docs/old/b.txt",
+		},
+		{
+			name:         "truncated page",
+			delimiter:    "/",
+			maxKeys:      2,
+			wantPrefixes: []string{"docs/", "empty/"},
+			wantTrunc:    true,
+			wantNext:     "empty/",
+		},
+		{
+			name:      "continuation after a common prefix",
+			delimiter: "/",
+			after:     "empty/",
+			maxKeys:   2,
+			wantKeys:  []string{"readme.md", "z.bin"},
+			wantNext:  "z.bin",
+		},
+		{
+			name:      "continuation inside a rolled-up prefix",
+			delimiter: "/",
+			after:     "docs/a.txt",
+			maxKeys:   1000,
+			// docs/ sorts before docs/a.txt, so it was already returned.
+			wantKeys:     []string{"readme.md", "z.bin"},
+			wantPrefixes: []string{"empty/"},
+			wantNext:     "z.bin",
+		},
+		{
+			name:      "zero max keys",
+			maxKeys:   0,
+			wantTrunc: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := listS3Entries(s3TestEntries(), tt.prefix, tt.delimiter, tt.after, tt.maxKeys)
+			if got := s3Keys(page.Objects); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", got, tt.wantKeys)
+			}
+			if !reflect.DeepEqual(page.Prefixes, tt.wantPrefixes) {
+				t.Errorf("prefixes = %v, want %v", page.Prefixes, tt.wantPrefixes)
+			}
+			if page.Truncated != tt.wantTrunc || page.Next != tt.wantNext {
+				t.Errorf("truncated, next = %v, %q, want %v, %q", page.Truncated, page.Next, tt.wantTrunc, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+const (
+	// v3Prefix is the path prefix of the REST API v3.
+	v3Prefix = "/api/v3"
+
+	// defaultV3Limit is the page size of v3 collections when none is given.
+	defaultV3Limit = 100
+
+	// maxV3Limit caps the page size of v3 collections.
+	maxV3Limit = 1000
+
+	// maxV3Body caps the size of v3 JSON request bodies.
+	maxV3Body = 1 << 20
+)
+
+// v3 error codes, returned in V3Error.Code.
+const (
+	v3InvalidRequest     = "invalid_request"
+	v3Unauthorized       = "unauthorized"
+	v3Forbidden          = "forbidden"
+	v3NotFound           = "not_found"
+	v3Conflict           = "conflict"
+	v3PreconditionFailed = "precondition_failed"
+	v3OverQuota          = "over_quota"
+	v3Internal           = "internal"
+)
+
+// v3Auth determines who may call a v3 route.
+type v3Auth int
+
+const (
+	// v3User routes require a user's access token.
+	v3User v3Auth = iota
+	// v3Admin routes require an admin panel token in the Authorization header.
+	v3Admin
+	// v3Public routes need no credentials.
+	v3Public
+)
+
+// v3Param describes a query parameter of a v3 route for the OpenAPI document.
+type v3Param struct {
+	Name        string
+	Type        string // OpenAPI type: "string", "integer" or "boolean".
+	Description string
+}
+
+// v3Route is one operation of the REST API v3. The route table drives both
+// request routing and the generated OpenAPI document.
+type v3Route struct {
+	Method   string
+	Pattern  string // Path below v3Prefix, in http.ServeMux syntax.
+	Tag      string
+	Summary  string
+	Auth     v3Auth
+	Query    []v3Param
+	Request  any // Example of the JSON request body, or nil.
+	Response any // Example of the JSON response body, or nil for 204.
+	Status   int // Success status; 200 when zero.
+	Handle   func(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser)
+}
+
+// V3Handler serves the REST API v3: resource-oriented JSON routes over the
+// same application services as the v2 protocol.
+type V3Handler struct {
+	auth        *service.AuthService
+	adminAuth   *service.AdminAuthService
+	folders     *service.FolderService
+	files       *service.FileService
+	trash       *service.TrashService
+	shares      *service.ShareService
+	publish     *service.PublishService
+	users       *service.UserService
+	quota       *service.QuotaService
+	externalURL string
+	routes      []v3Route
+}
+
+// NewV3Handler creates a new V3Handler.
+func NewV3Handler(
+	auth *service.AuthService,
+	adminAuth *service.AdminAuthService,
+	folders *service.FolderService,
+	files *service.FileService,
+	trash *service.TrashService,
+	shares *service.ShareService,
+	publish *service.PublishService,
+	users *service.UserService,
+	quota *service.QuotaService,
+	externalURL string,
+) *V3Handler {
+	h := &V3Handler{
+		auth:        auth,
+		adminAuth:   adminAuth,
+		folders:     folders,
+		files:       files,
+		trash:       trash,
+		shares:      shares,
+		publish:     publish,
+		users:       users,
+		quota:       quota,
+		externalURL: strings.TrimRight(externalURL, "/"),
+	}
+	h.routes = h.buildRoutes()
+	return h
+}
+
+// Register registers the v3 routes and the OpenAPI document on the given mux.
+func (h *V3Handler) Register(mux *http.ServeMux) {
+	for _, rt := range h.routes {
+		mux.HandleFunc(rt.Method+" "+v3Prefix+rt.Pattern, h.serve(rt))
+	}
+	mux.HandleFunc("GET "+v3Prefix+"/openapi.json", h.HandleOpenAPI)
+	mux.HandleFunc(v3Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeV3Error(w, http.StatusNotFound, v3NotFound, "no such route")
+	})
+}
+
+// serve wraps a route handler with authentication.
+func (h *V3Handler) serve(rt v3Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var authed *service.AuthenticatedUser
+		switch rt.Auth {
+		case v3User:
+			u, err := validateRequest(r, h.auth, "access_token")
+			if err != nil || u == nil {
+				writeV3Error(w, http.StatusUnauthorized, v3Unauthorized, "a valid access token is required")
+				return
+			}
+			authed = u
+		case v3Admin:
+			// Only the header is accepted: the admin cookie would let other sites act as the admin.
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !h.adminAuth.Validate(token) {
+				writeV3Error(w, http.StatusUnauthorized, v3Unauthorized, "a valid admin token is required")
+				return
+			}
+		}
+		rt.Handle(w, r, authed)
+	}
+}
+
+// V3Error is the error object of the REST API v3.
+type V3Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// V3ErrorResponse wraps a V3Error.
+type V3ErrorResponse struct {
+	Error V3Error `json:"error"`
+}
+
+// writeV3Error writes a v3 error response.
+func writeV3Error(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, V3ErrorResponse{Error: V3Error{Code: code, Message: message}})
+}
+
+// writeV3ServiceError maps an application service error to a v3 error response.
+func writeV3ServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		writeV3Error(w, http.StatusNotFound, v3NotFound, "not found")
+	case errors.Is(err, service.ErrAlreadyExists):
+		writeV3Error(w, http.StatusConflict, v3Conflict, "the target already exists")
+	case errors.Is(err, service.ErrForbidden):
+		writeV3Error(w, http.StatusForbidden, v3Forbidden, "not allowed")
+	case errors.Is(err, service.ErrOverQuota):
+		writeV3Error(w, http.StatusInsufficientStorage, v3OverQuota, "storage quota exceeded")
+	case errors.Is(err, service.ErrContentNotFound):
+		writeV3Error(w, http.StatusUnprocessableEntity, v3InvalidRequest, "no content with this hash was uploaded")
+	default:
+		writeV3Error(w, http.StatusInternalServerError, v3Internal, "internal error")
+	}
+}
+
+// allowV3 checks that the token grants scope on every given path.
+// If it does not, it writes a 403 error response and returns false.
+func allowV3(w http.ResponseWriter, authed *service.AuthenticatedUser, scope vo.TokenScope, paths ...vo.CloudPath) bool {
+	if authed.Allows(scope, paths...) {
+		return true
+	}
+	writeV3Error(w, http.StatusForbidden, v3Forbidden, "the token does not grant "+scope.String()+" access here")
+	return false
+}
+
+// writeV3 writes a v3 JSON response with an ETag. A GET or HEAD request whose
+// If-None-Match matches the ETag is answered with 304 instead.
+func writeV3(w http.ResponseWriter, r *http.Request, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeV3Error(w, http.StatusInternalServerError, v3Internal, "internal error")
+		return
+	}
+	etag := v3ETag(body)
+	w.Header().Set("ETag", etag)
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+	_, _ = w.Write([]byte("\n"))
+}
+
+// v3ETag derives a strong ETag from a representation.
+func v3ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value lists the ETag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces the request's If-Match precondition against the
+// current representation. If it fails, it writes a 412 error response and returns false.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, current any) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	body, err := json.Marshal(current)
+	if err == nil && etagMatches(header, v3ETag(body)) {
+		return true
+	}
+	writeV3Error(w, http.StatusPreconditionFailed, v3PreconditionFailed, "the resource has changed")
+	return false
+}
+
+// readV3Body decodes the JSON request body into v.
+// If it is malformed, it writes a 400 error response and returns false.
+func readV3Body(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxV3Body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "malformed JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// v3Path returns the cloud path captured by a "{path...}" route wildcard.
+func v3Path(r *http.Request) vo.CloudPath {
+	return vo.NewCloudPath("/" + r.PathValue("path"))
+}
+
+// v3Page is a page of a collection, selected by the cursor and limit query parameters.
+type v3Page struct {
+	Offset int
+	Limit  int
+}
+
+// parseV3Page reads the cursor and limit query parameters.
+// Cursors are opaque to clients; they encode the offset of the next item.
+// If either is invalid, it writes a 400 error response and returns false.
+func parseV3Page(w http.ResponseWriter, r *http.Request) (v3Page, bool) {
+	page := v3Page{Limit: defaultV3Limit}
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "limit must be a positive integer")
+			return page, false
+		}
+		page.Limit = min(n, maxV3Limit)
+	}
+	if v := q.Get("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		offset, ok := strings.CutPrefix(string(raw), "o:")
+		n, convErr := strconv.Atoi(offset)
+		if err != nil || !ok || convErr != nil || n < 0 {
+			writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "invalid cursor")
+			return page, false
+		}
+		page.Offset = n
+	}
+	return page, true
+}
+
+// next returns the cursor of the page after this one, or "" if there is none.
+func (p v3Page) next(hasMore bool) string {
+	if !hasMore {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(p.Offset+p.Limit)))
+}
+
+// paginate returns the page of items and the cursor of the next page.
+func paginate[T any](items []T, page v3Page) ([]T, string) {
+	if page.Offset >= len(items) {
+		return []T{}, ""
+	}
+	end := min(page.Offset+page.Limit, len(items))
+	return items[page.Offset:end], page.next(end < len(items))
+}
@@ -0,0 +1,264 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// newTestV3Mux registers a V3Handler backed by the given node repository.
+// The user token is "access-token-123"; the admin panel login is admin/secret.
+func newTestV3Mux(t *testing.T, nodes *mock.NodeRepositoryMock) (*http.ServeMux, *service.AdminAuthService) {
+	t.Helper()
+	user := mock.NewTestUser(1, "user@example.com")
+	token := mock.NewTestToken(1, time.Now().Add(time.Hour))
+	tokens := &mock.TokenRepositoryMock{
+		LookupAccessFunc: func(accessToken string) (*entity.Token, error) {
+			if accessToken == token.AccessToken {
+				return token, nil
+			}
+			return nil, nil
+		},
+	}
+	users := &mock.UserRepositoryMock{
+		GetByIDFunc: func(id int64) (*entity.User, error) { return user, nil },
+	}
+	contents := &mock.ContentRepositoryMock{}
+	quota := service.NewQuotaService(nodes, users)
+	adminAuth := service.NewAdminAuthService("admin", "secret")
+
+	h := NewV3Handler(
+		service.NewAuthService(tokens, users),
+		adminAuth,
+		service.NewFolderService(nodes),
+		service.NewFileService(nodes, contents, &mock.ContentStorageMock{}, quota, &mock.FileVersionRepositoryMock{}),
+		service.NewTrashService(nodes, &mock.TrashRepositoryMock{}, contents, &mock.ContentStorageMock{}, &mock.ShareRepositoryMock{}),
+		service.NewShareService(&mock.ShareRepositoryMock{}, nodes, contents, users),
+		service.NewPublishService(nodes, contents),
+		service.NewUserService(users, nodes, 1<<30),
+		quota,
+		"https://cloud.example.com",
+	)
+	mux := http.NewServeMux()
+	h.Register(mux)
+	return mux, adminAuth
+}
+
+func serveV3(mux *http.ServeMux, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestV3Handler_Auth(t *testing.T) {
+	mux, adminAuth := newTestV3Mux(t, &mock.NodeRepositoryMock{})
+
+	t.Run("missing token", func(t *testing.T) {
+		w := serveV3(mux, http.MethodGet, "/api/v3/nodes/a.txt", nil)
+
+		var resp V3ErrorResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusUnauthorized || resp.Error.Code != v3Unauthorized {
+			t.Errorf("got %d %+v, want 401 unauthorized", w.Code, resp)
+		}
+	})
+
+	t.Run("user token on admin route", func(t *testing.T) {
+		w := serveV3(mux, http.MethodGet, "/api/v3/users", bearer("access-token-123"))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", w.Code)
+		}
+	})
+
+	t.Run("admin token on admin route", func(t *testing.T) {
+		adminToken, err := adminAuth.Login("admin", "secret", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := serveV3(mux, http.MethodGet, "/api/v3/users/abc", bearer(adminToken))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404 for a malformed user ID", w.Code)
+		}
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		w := serveV3(mux, http.MethodGet, "/api/v3/nothing", bearer("access-token-123"))
+
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), v3NotFound) {
+			t.Errorf("got %d %s, want a 404 JSON error", w.Code, w.Body)
+		}
+	})
+}
+
+func TestV3Handler_GetNode(t *testing.T) {
+	node := mock.NewTestFileNode(1, "/docs/a.txt", mock.ValidHash(), 42)
+	mux, _ := newTestV3Mux(t, &mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			if path.String() == "/docs/a.txt" {
+				return node, nil
+			}
+			return nil, nil
+		},
+	})
+
+	w := serveV3(mux, http.MethodGet, "/api/v3/nodes/docs/a.txt", bearer("access-token-123"))
+	var got V3Node
+	_ = json.NewDecoder(w.Body).Decode(&got)
+	if w.Code != http.StatusOK || got.Path != "/docs/a.txt" || got.Type != "file" || got.Size != 42 {
+		t.Fatalf("got %d %+v, want the file", w.Code, got)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag header is missing")
+	}
+
+	t.Run("not modified", func(t *testing.T) {
+		header := bearer("access-token-123")
+		header.Set("If-None-Match", etag)
+		w := serveV3(mux, http.MethodGet, "/api/v3/nodes/docs/a.txt", header)
+
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("got %d with %d bytes, want an empty 304", w.Code, w.Body.Len())
+		}
+	})
+
+	t.Run("missing node", func(t *testing.T) {
+		w := serveV3(mux, http.MethodGet, "/api/v3/nodes/docs/b.txt", bearer("access-token-123"))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+	})
+
+	t.Run("stale If-Match on delete", func(t *testing.T) {
+		header := bearer("access-token-123")
+		header.Set("If-Match", `"stale"`)
+		w := serveV3(mux, http.MethodDelete, "/api/v3/nodes/docs/a.txt", header)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("status = %d, want 412", w.Code)
+		}
+	})
+}
+
+func TestV3Handler_ListFolder(t *testing.T) {
+	var gotOffset, gotLimit int
+	mux, _ := newTestV3Mux(t, &mock.NodeRepositoryMock{
+		GetFunc: func(userID int64, path vo.CloudPath) (*entity.Node, error) {
+			return mock.NewTestNode(1, path.String(), vo.NodeTypeFolder), nil
+		},
+		ListChildrenFunc: func(userID int64, path vo.CloudPath, offset, limit int) ([]entity.Node, error) {
+			gotOffset, gotLimit = offset, limit
+			return []entity.Node{
+				*mock.NewTestNode(1, "/docs/a", vo.NodeTypeFolder),
+				*mock.NewTestNode(1, "/docs/b", vo.NodeTypeFolder),
+				*mock.NewTestNode(1, "/docs/c", vo.NodeTypeFolder),
+			}, nil
+		},
+	})
+
+	w := serveV3(mux, http.MethodGet, "/api/v3/folders/docs?limit=2", bearer("access-token-123"))
+	var listing V3FolderListing
+	_ = json.NewDecoder(w.Body).Decode(&listing)
+	if w.Code != http.StatusOK || len(listing.Items) != 2 || listing.NextCursor == "" {
+		t.Fatalf("got %d %+v, want two items and a next cursor", w.Code, listing)
+	}
+	if gotOffset != 0 || gotLimit != 3 {
+		t.Errorf("ListChildren(offset %d, limit %d), want 0 and 3", gotOffset, gotLimit)
+	}
+
+	serveV3(mux, http.MethodGet, "/api/v3/folders/docs?limit=2&cursor="+listing.NextCursor, bearer("access-token-123"))
+	if gotOffset != 2 {
+		t.Errorf("offset after the cursor = %d, want 2", gotOffset)
+	}
+}
+
+func TestParseV3Page(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantOK     bool
+		wantOffset int
+		wantLimit  int
+	}{
+		{"", true, 0, defaultV3Limit},
+		{"limit=5", true, 0, 5},
+		{"limit=999999", true, 0, maxV3Limit},
+		{"cursor=" + (v3Page{Offset: 10, Limit: 5}).next(true), true, 15, defaultV3Limit},
+		{"limit=0", false, 0, 0},
+		{"limit=x", false, 0, 0},
+		{"cursor=bogus", false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			page, ok := parseV3Page(w, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", w.Code)
+				}
+				return
+			}
+			if page.Offset != tt.wantOffset || page.Limit != tt.wantLimit {
+				t.Errorf("page = %+v, want offset %d limit %d", page, tt.wantOffset, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	got, next := paginate(items, v3Page{Offset: 0, Limit: 2})
+	if len(got) != 2 || next == "" {
+		t.Errorf("first page = %v %q, want two items and a cursor", got, next)
+	}
+	got, next = paginate(items, v3Page{Offset: 4, Limit: 2})
+	if len(got) != 1 || next != "" {
+		t.Errorf("last page = %v %q, want one item without a cursor", got, next)
+	}
+	got, next = paginate(items, v3Page{Offset: 10, Limit: 2})
+	if got == nil || len(got) != 0 || next != "" {
+		t.Errorf("past the end = %v %q, want an empty non-nil page", got, next)
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`*`, true},
+		{`"x"`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
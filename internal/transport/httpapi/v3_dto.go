@@ -0,0 +1,181 @@
+package httpapi
+
+// REST API v3 request and response bodies. Field names and json tags are also
+// the source of the schemas in the generated OpenAPI document.
+
+// V3Node is a file or folder.
+type V3Node struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size"`
+	Hash    string `json:"hash,omitempty"`
+	MTime   int64  `json:"mtime"`
+	Rev     int64  `json:"rev"`
+	Weblink string `json:"weblink,omitempty"`
+}
+
+// V3FolderListing is a folder with a page of its children.
+type V3FolderListing struct {
+	Folder     V3Node   `json:"folder"`
+	Items      []V3Node `json:"items"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// V3NodeCreate creates a folder, or a file from content uploaded earlier.
+type V3NodeCreate struct {
+	Type string `json:"type"`
+	Hash string `json:"hash,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// V3NodeUpdate renames a node, moves it to another folder, or both.
+type V3NodeUpdate struct {
+	Name   string `json:"name,omitempty"`
+	Parent string `json:"parent,omitempty"`
+}
+
+// V3Version is an entry of a file's version history.
+type V3Version struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash,omitempty"`
+	Rev  int64  `json:"rev,omitempty"`
+	Time int64  `json:"time"`
+}
+
+// V3VersionList is a page of a file's version history.
+type V3VersionList struct {
+	Items      []V3Version `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// V3TrashItem is a node in the trashbin.
+type V3TrashItem struct {
+	ID          int64  `json:"id"`
+	Path        string `json:"path"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Size        int64  `json:"size"`
+	Hash        string `json:"hash,omitempty"`
+	Rev         int64  `json:"rev"`
+	DeletedAt   int64  `json:"deleted_at"`
+	DeletedFrom string `json:"deleted_from"`
+}
+
+// V3TrashList is a page of the trashbin.
+type V3TrashList struct {
+	Items      []V3TrashItem `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// V3Share is an invitation to a folder of the caller.
+type V3Share struct {
+	ID     int64  `json:"id"`
+	Path   string `json:"path"`
+	Email  string `json:"email"`
+	Access string `json:"access"`
+	Status string `json:"status"`
+}
+
+// V3ShareList is a page of the caller's shares.
+type V3ShareList struct {
+	Items      []V3Share `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// V3ShareCreate invites a user to a folder.
+type V3ShareCreate struct {
+	Path   string `json:"path"`
+	Email  string `json:"email"`
+	Access string `json:"access"`
+}
+
+// V3Invite is an invitation to another user's folder.
+type V3Invite struct {
+	Token     string `json:"token"`
+	Owner     string `json:"owner"`
+	Name      string `json:"name"`
+	Access    string `json:"access"`
+	Status    string `json:"status"`
+	MountPath string `json:"mount_path,omitempty"`
+}
+
+// V3InviteList is a page of invitations to the caller.
+type V3InviteList struct {
+	Items      []V3Invite `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// V3InviteAccept mounts a shared folder under the given name in the caller's root.
+type V3InviteAccept struct {
+	Name string `json:"name,omitempty"`
+}
+
+// V3Link is a public weblink.
+type V3Link struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// V3LinkList is a page of the caller's weblinks.
+type V3LinkList struct {
+	Items      []V3Link `json:"items"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// V3LinkCreate publishes a node.
+type V3LinkCreate struct {
+	Path string `json:"path"`
+}
+
+// V3User is a user account.
+type V3User struct {
+	ID             int64  `json:"id"`
+	Email          string `json:"email"`
+	QuotaBytes     int64  `json:"quota_bytes"`
+	BytesUsed      int64  `json:"bytes_used"`
+	BytesVersions  int64  `json:"bytes_versions"`
+	BytesTrash     int64  `json:"bytes_trash"`
+	FileSizeLimit  int64  `json:"file_size_limit"`
+	VersionHistory bool   `json:"version_history"`
+	// VersionRetention is the user's own retention policy, empty for the server default.
+	VersionRetention string `json:"version_retention,omitempty"`
+	// TrashRetentionDays is the user's own trash retention period, omitted for the server default.
+	TrashRetentionDays *int  `json:"trash_retention_days,omitempty"`
+	TwoFactor          bool  `json:"two_factor"`
+	Created            int64 `json:"created,omitempty"`
+}
+
+// V3UserList is a page of user accounts.
+type V3UserList struct {
+	Items      []V3User `json:"items"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// V3UserCreate creates a user account.
+type V3UserCreate struct {
+	Email          string `json:"email"`
+	Password       string `json:"password"`
+	QuotaBytes     int64  `json:"quota_bytes,omitempty"`
+	FileSizeLimit  int64  `json:"file_size_limit,omitempty"`
+	VersionHistory bool   `json:"version_history,omitempty"`
+	// VersionRetention is a policy such as "last=10,days=30"; omitted for the server default.
+	VersionRetention string `json:"version_retention,omitempty"`
+	// TrashRetentionDays is how long trash items are kept, 0 for forever; omitted for the server default.
+	TrashRetentionDays *int `json:"trash_retention_days,omitempty"`
+}
+
+// V3UserUpdate changes a user account. Omitted fields keep their values.
+type V3UserUpdate struct {
+	Email          string `json:"email,omitempty"`
+	Password       string `json:"password,omitempty"`
+	QuotaBytes     *int64 `json:"quota_bytes,omitempty"`
+	FileSizeLimit  *int64 `json:"file_size_limit,omitempty"`
+	VersionHistory *bool  `json:"version_history,omitempty"`
+	// VersionRetention sets the user's retention policy; "" or "default" restores the server default.
+	VersionRetention *string `json:"version_retention,omitempty"`
+	// TrashRetentionDays sets how long trash items are kept, 0 for forever; a negative value restores the server default.
+	TrashRetentionDays *int `json:"trash_retention_days,omitempty"`
+}
@@ -0,0 +1,171 @@
+package httpapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// v3PathParam matches the wildcards of a route pattern, such as "{id}" or "{path...}".
+var v3PathParam = regexp.MustCompile(`\{(\w+)(\.\.\.)?\}`)
+
+// HandleOpenAPI handles GET /api/v3/openapi.json - the OpenAPI 3 document of
+// the REST API v3, generated from the route table and the request and response types.
+func (h *V3Handler) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeV3(w, r, http.StatusOK, h.openAPI())
+}
+
+// openAPI builds the OpenAPI document.
+func (h *V3Handler) openAPI() map[string]any {
+	schemas := map[string]any{}
+	errorRef := schemaRef(reflect.TypeOf(V3ErrorResponse{}), schemas)
+
+	paths := map[string]any{}
+	for _, rt := range h.routes {
+		path := v3PathParam.ReplaceAllString(rt.Pattern, "{$1}")
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		if rt.Response != nil {
+			success["content"] = jsonContent(schemaRef(reflect.TypeOf(rt.Response), schemas))
+		} else {
+			status = http.StatusNoContent
+			success["description"] = http.StatusText(status)
+		}
+
+		op := map[string]any{
+			"tags":        []string{rt.Tag},
+			"summary":     rt.Summary,
+			"operationId": operationID(rt),
+			"responses": map[string]any{
+				strconv.Itoa(status): success,
+				"default":            map[string]any{"description": "Error", "content": jsonContent(errorRef)},
+			},
+		}
+
+		var params []any
+		for _, m := range v3PathParam.FindAllStringSubmatch(rt.Pattern, -1) {
+			p := map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}}
+			if m[1] == "path" {
+				p["description"] = "Cloud path without the leading slash."
+			}
+			params = append(params, p)
+		}
+		for _, q := range rt.Query {
+			params = append(params, map[string]any{"name": q.Name, "in": "query", "description": q.Description, "schema": map[string]any{"type": q.Type}})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if rt.Request != nil {
+			op["requestBody"] = map[string]any{"required": true, "content": jsonContent(schemaRef(reflect.TypeOf(rt.Request), schemas))}
+		}
+		switch rt.Auth {
+		case v3User:
+			op["security"] = []any{map[string]any{"accessToken": []string{}}}
+		case v3Admin:
+			op["security"] = []any{map[string]any{"adminToken": []string{}}}
+		}
+
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Tucha REST API",
+			"version": "3",
+		},
+		"servers": []any{map[string]any{"url": h.externalURL + v3Prefix}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"accessToken": map[string]any{"type": "http", "scheme": "bearer", "description": "User access token from /token."},
+				"adminToken":  map[string]any{"type": "http", "scheme": "bearer", "description": "Admin panel token from /admin/login."},
+			},
+		},
+	}
+}
+
+// operationID derives a unique operation ID from a route, such as "getNodes".
+func operationID(rt v3Route) string {
+	id := strings.ToLower(rt.Method)
+	for _, segment := range strings.Split(rt.Pattern, "/") {
+		if segment == "" || strings.HasPrefix(segment, "{") {
+			continue
+		}
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	if strings.HasSuffix(rt.Pattern, "}") && !strings.HasSuffix(rt.Pattern, "{path...}") {
+		id += "ByID"
+	}
+	return id
+}
+
+// jsonContent wraps a schema as JSON media type content.
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// schemaRef returns the schema of a Go type. Structs are added to schemas
+// under their type name and referenced; fields without omitempty are required.
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaRef(t.Elem(), schemas)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		schemas[t.Name()] = nil // Placeholder against recursion.
+
+		props := map[string]any{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = schemaRef(f.Type, schemas)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if required != nil {
+			schema["required"] = required
+		}
+		schemas[t.Name()] = schema
+		return ref
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+func TestV3Handler_HandleOpenAPI(t *testing.T) {
+	mux, _ := newTestV3Mux(t, &mock.NodeRepositoryMock{})
+
+	w := serveV3(mux, http.MethodGet, "/api/v3/openapi.json", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 without authentication", w.Code)
+	}
+	raw := w.Body.String()
+
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Servers []struct{ URL string }                `json:"servers"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+		Comps   struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" || len(doc.Servers) != 1 || doc.Servers[0].URL != "https://cloud.example.com/api/v3" {
+		t.Errorf("openapi %q servers %+v, want 3.0.3 at the external URL", doc.OpenAPI, doc.Servers)
+	}
+
+	t.Run("every route is documented once", func(t *testing.T) {
+		ids := map[string]bool{}
+		for _, rt := range (&V3Handler{}).buildRoutes() {
+			path := v3PathParam.ReplaceAllString(rt.Pattern, "{$1}")
+			var op struct {
+				OperationID string `json:"operationId"`
+			}
+			if err := json.Unmarshal(doc.Paths[path][strings.ToLower(rt.Method)], &op); err != nil {
+				t.Errorf("%s %s is missing", rt.Method, path)
+				continue
+			}
+			if ids[op.OperationID] {
+				t.Errorf("duplicate operationId %q", op.OperationID)
+			}
+			ids[op.OperationID] = true
+		}
+	})
+
+	t.Run("every schema reference resolves", func(t *testing.T) {
+		for _, ref := range strings.Split(raw, `"$ref":"#/components/schemas/`)[1:] {
+			name, _, _ := strings.Cut(ref, `"`)
+			if _, ok := doc.Comps.Schemas[name]; !ok {
+				t.Errorf("schema %q is referenced but not defined", name)
+			}
+		}
+	})
+}
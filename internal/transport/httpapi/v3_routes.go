@@ -0,0 +1,462 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// Query parameters shared by v3 routes.
+var (
+	v3PageParams = []v3Param{
+		{Name: "cursor", Type: "string", Description: "Cursor from next_cursor of the previous page."},
+		{Name: "limit", Type: "integer", Description: "Page size, 100 by default and at most 1000."},
+	}
+	v3ConflictParam = v3Param{Name: "conflict", Type: "string", Description: `"strict" (default) fails if the target exists, "rename" adds a number to the new name, "replace" replaces it.`}
+)
+
+// buildRoutes returns the route table of the REST API v3.
+func (h *V3Handler) buildRoutes() []v3Route {
+	return []v3Route{
+		{Method: "GET", Pattern: "/nodes/{path...}", Tag: "nodes", Summary: "Get a file or folder", Response: V3Node{}, Handle: h.getNode},
+		{Method: "POST", Pattern: "/nodes/{path...}", Tag: "nodes", Summary: "Create a folder, or a file from uploaded content", Query: []v3Param{v3ConflictParam}, Request: V3NodeCreate{}, Response: V3Node{}, Status: http.StatusCreated, Handle: h.createNode},
+		{Method: "PATCH", Pattern: "/nodes/{path...}", Tag: "nodes", Summary: "Rename or move a file or folder", Request: V3NodeUpdate{}, Response: V3Node{}, Handle: h.updateNode},
+		{Method: "DELETE", Pattern: "/nodes/{path...}", Tag: "nodes", Summary: "Move a file or folder to the trashbin", Handle: h.deleteNode},
+		{Method: "GET", Pattern: "/folders/{path...}", Tag: "nodes", Summary: "List a folder", Query: v3PageParams, Response: V3FolderListing{}, Handle: h.listFolder},
+		{Method: "GET", Pattern: "/versions/{path...}", Tag: "versions", Summary: "List the versions of a file", Query: v3PageParams, Response: V3VersionList{}, Handle: h.listVersions},
+
+		{Method: "GET", Pattern: "/trash", Tag: "trash", Summary: "List the trashbin", Query: v3PageParams, Response: V3TrashList{}, Handle: h.listTrash},
+		{Method: "POST", Pattern: "/trash/{id}/restore", Tag: "trash", Summary: "Restore an item from the trashbin, with the items trashed inside it", Query: []v3Param{v3ConflictParam, {Name: "folder", Type: "string", Description: "Folder to restore into instead of the original one."}}, Response: V3Node{}, Handle: h.restoreTrash},
+		{Method: "DELETE", Pattern: "/trash/{id}", Tag: "trash", Summary: "Permanently delete an item from the trashbin", Handle: h.deleteTrash},
+		{Method: "DELETE", Pattern: "/trash", Tag: "trash", Summary: "Empty the trashbin", Handle: h.emptyTrash},
+
+		{Method: "GET", Pattern: "/shares", Tag: "shares", Summary: "List invitations to the caller's folders", Query: v3PageParams, Response: V3ShareList{}, Handle: h.listShares},
+		{Method: "POST", Pattern: "/shares", Tag: "shares", Summary: "Invite a user to a folder", Request: V3ShareCreate{}, Response: V3Share{}, Status: http.StatusCreated, Handle: h.createShare},
+		{Method: "DELETE", Pattern: "/shares/{id}", Tag: "shares", Summary: "Withdraw an invitation", Handle: h.deleteShare},
+		{Method: "GET", Pattern: "/invites", Tag: "shares", Summary: "List invitations to other users' folders", Query: v3PageParams, Response: V3InviteList{}, Handle: h.listInvites},
+		{Method: "POST", Pattern: "/invites/{token}/accept", Tag: "shares", Summary: "Accept an invitation and mount the folder", Query: []v3Param{{Name: "conflict", Type: "string", Description: `"strict" (default) fails if the mount name is taken, "rename" adds a number to it.`}}, Request: V3InviteAccept{}, Response: V3Invite{}, Handle: h.acceptInvite},
+		{Method: "POST", Pattern: "/invites/{token}/reject", Tag: "shares", Summary: "Reject an invitation", Handle: h.rejectInvite},
+		{Method: "DELETE", Pattern: "/mounts/{path...}", Tag: "shares", Summary: "Unmount a shared folder", Query: []v3Param{{Name: "clone", Type: "boolean", Description: "Keep a copy of the folder's content in its place."}}, Handle: h.deleteMount},
+
+		{Method: "GET", Pattern: "/links", Tag: "links", Summary: "List public weblinks", Query: v3PageParams, Response: V3LinkList{}, Handle: h.listLinks},
+		{Method: "POST", Pattern: "/links", Tag: "links", Summary: "Publish a file or folder", Request: V3LinkCreate{}, Response: V3Link{}, Status: http.StatusCreated, Handle: h.createLink},
+		{Method: "DELETE", Pattern: "/links/{id...}", Tag: "links", Summary: "Remove a public weblink", Handle: h.deleteLink},
+
+		{Method: "GET", Pattern: "/users/me", Tag: "users", Summary: "Get the caller's account", Response: V3User{}, Handle: h.getMe},
+		{Method: "GET", Pattern: "/users", Tag: "users", Summary: "List user accounts", Auth: v3Admin, Query: v3PageParams, Response: V3UserList{}, Handle: h.listUsers},
+		{Method: "POST", Pattern: "/users", Tag: "users", Summary: "Create a user account", Auth: v3Admin, Request: V3UserCreate{}, Response: V3User{}, Status: http.StatusCreated, Handle: h.createUser},
+		{Method: "GET", Pattern: "/users/{id}", Tag: "users", Summary: "Get a user account", Auth: v3Admin, Response: V3User{}, Handle: h.getUser},
+		{Method: "PATCH", Pattern: "/users/{id}", Tag: "users", Summary: "Change a user account", Auth: v3Admin, Request: V3UserUpdate{}, Response: V3User{}, Handle: h.updateUser},
+		{Method: "DELETE", Pattern: "/users/{id}", Tag: "users", Summary: "Delete a user account", Auth: v3Admin, Handle: h.deleteUser},
+	}
+}
+
+// toV3Node converts a Node entity to its v3 representation.
+func toV3Node(node *entity.Node) V3Node {
+	n := V3Node{
+		Path:    node.Home.String(),
+		Name:    node.Name,
+		Type:    node.Type.String(),
+		Size:    node.Size,
+		MTime:   node.MTime,
+		Rev:     node.Rev,
+		Weblink: node.Weblink,
+	}
+	if node.IsFile() && !node.Hash.IsZero() {
+		n.Hash = node.Hash.String()
+	}
+	return n
+}
+
+// parseV3Conflict reads the conflict query parameter: strict (the default), rename or replace.
+// If it is invalid, it writes a 400 error response and returns false.
+func parseV3Conflict(w http.ResponseWriter, r *http.Request) (vo.ConflictMode, bool) {
+	switch r.URL.Query().Get("conflict") {
+	case "", "strict":
+		return vo.ConflictStrict, true
+	case "rename":
+		return vo.ConflictRename, true
+	case "replace":
+		return vo.ConflictReplace, true
+	default:
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "conflict must be strict, rename or replace")
+		return "", false
+	}
+}
+
+// getV3Node looks up the node at path in the caller's tree.
+// If it does not exist, it writes a 404 error response and returns nil.
+func (h *V3Handler) getV3Node(w http.ResponseWriter, authed *service.AuthenticatedUser, path vo.CloudPath) *entity.Node {
+	node, err := h.files.Get(authed.UserID, path)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return nil
+	}
+	if node == nil {
+		writeV3Error(w, http.StatusNotFound, v3NotFound, "no such file or folder: "+path.String())
+		return nil
+	}
+	return node
+}
+
+// getNode handles GET /api/v3/nodes/{path...}.
+func (h *V3Handler) getNode(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	if !allowV3(w, authed, vo.ScopeRead, path) {
+		return
+	}
+	node := h.getV3Node(w, authed, path)
+	if node == nil {
+		return
+	}
+	writeV3(w, r, http.StatusOK, toV3Node(node))
+}
+
+// createNode handles POST /api/v3/nodes/{path...}. Files are created from
+// content already uploaded to /upload, identified by its hash.
+func (h *V3Handler) createNode(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	if !allowV3(w, authed, vo.ScopeWrite, path) {
+		return
+	}
+	if path.IsRoot() {
+		writeV3Error(w, http.StatusConflict, v3Conflict, "the root folder already exists")
+		return
+	}
+	conflict, ok := parseV3Conflict(w, r)
+	if !ok {
+		return
+	}
+	var req V3NodeCreate
+	if !readV3Body(w, r, &req) {
+		return
+	}
+
+	var (
+		node *entity.Node
+		err  error
+	)
+	switch req.Type {
+	case vo.NodeTypeFolder.String():
+		node, err = h.folders.CreateFolder(authed.UserID, path)
+	case vo.NodeTypeFile.String():
+		hash, hashErr := vo.NewContentHash(req.Hash)
+		if hashErr != nil || req.Size < 0 {
+			writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "a file needs the hash and size of uploaded content")
+			return
+		}
+		// A replaced file must still match the version the client has seen.
+		if existing, getErr := h.files.Get(authed.UserID, path); conflict == vo.ConflictReplace && getErr == nil && existing != nil && !checkIfMatch(w, r, toV3Node(existing)) {
+			return
+		}
+		node, err = h.files.AddByHash(authed.UserID, path, hash, req.Size, conflict)
+	default:
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, `type must be "file" or "folder"`)
+		return
+	}
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", v3Prefix+"/nodes"+node.Home.String())
+	writeV3(w, r, http.StatusCreated, toV3Node(node))
+}
+
+// updateNode handles PATCH /api/v3/nodes/{path...}. A new parent moves the
+// node, a new name renames it; with both, the node is moved first.
+func (h *V3Handler) updateNode(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	var req V3NodeUpdate
+	if !readV3Body(w, r, &req) {
+		return
+	}
+	if req.Name == "" && req.Parent == "" {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "name or parent is required")
+		return
+	}
+	if strings.Contains(req.Name, "/") {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "name must not contain slashes")
+		return
+	}
+
+	parent := path.Parent()
+	if req.Parent != "" {
+		parent = vo.NewCloudPath(req.Parent)
+	}
+	name := path.Name()
+	if req.Name != "" {
+		name = req.Name
+	}
+	target := parent.Join(name)
+	if !allowV3(w, authed, vo.ScopeWrite, path, target) {
+		return
+	}
+	if path.IsRoot() {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "the root folder cannot be renamed or moved")
+		return
+	}
+
+	node := h.getV3Node(w, authed, path)
+	if node == nil || !checkIfMatch(w, r, toV3Node(node)) {
+		return
+	}
+	if target.String() == path.String() {
+		writeV3(w, r, http.StatusOK, toV3Node(node))
+		return
+	}
+	if parent.HasPrefix(path) {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "a folder cannot be moved into itself")
+		return
+	}
+	if exists, _ := h.files.Get(authed.UserID, target); exists != nil {
+		writeV3Error(w, http.StatusConflict, v3Conflict, "the target already exists: "+target.String())
+		return
+	}
+
+	var err error
+	if parent.String() != path.Parent().String() {
+		if node, err = h.files.Move(authed.UserID, path, parent, vo.ConflictStrict); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+	if name != node.Name {
+		if node, err = h.files.Rename(authed.UserID, node.Home, name); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+	writeV3(w, r, http.StatusOK, toV3Node(node))
+}
+
+// deleteNode handles DELETE /api/v3/nodes/{path...}.
+func (h *V3Handler) deleteNode(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	if !allowV3(w, authed, vo.ScopeTrash, path) {
+		return
+	}
+	if path.IsRoot() {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "the root folder cannot be deleted")
+		return
+	}
+	node := h.getV3Node(w, authed, path)
+	if node == nil || !checkIfMatch(w, r, toV3Node(node)) {
+		return
+	}
+	if err := h.trash.Trash(authed.UserID, path, authed.UserID); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listFolder handles GET /api/v3/folders/{path...}.
+func (h *V3Handler) listFolder(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	if !allowV3(w, authed, vo.ScopeRead, path) {
+		return
+	}
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	folder := h.getV3Node(w, authed, path)
+	if folder == nil {
+		return
+	}
+	if !folder.IsFolder() {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "not a folder: "+path.String())
+		return
+	}
+
+	children, err := h.folders.ListChildren(authed.UserID, path, page.Offset, page.Limit+1)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	hasMore := len(children) > page.Limit
+	if hasMore {
+		children = children[:page.Limit]
+	}
+
+	listing := V3FolderListing{
+		Folder:     toV3Node(folder),
+		Items:      make([]V3Node, 0, len(children)),
+		NextCursor: page.next(hasMore),
+	}
+	for i := range children {
+		listing.Items = append(listing.Items, toV3Node(&children[i]))
+	}
+	writeV3(w, r, http.StatusOK, listing)
+}
+
+// listVersions handles GET /api/v3/versions/{path...}.
+// Hashes and revisions are only shown to users with version history.
+func (h *V3Handler) listVersions(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	if !allowV3(w, authed, vo.ScopeRead, path) {
+		return
+	}
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	node := h.getV3Node(w, authed, path)
+	if node == nil {
+		return
+	}
+	if !node.IsFile() {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "not a file: "+path.String())
+		return
+	}
+
+	versions, err := h.files.History(authed.UserID, path, authed.VersionHistory)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	versions, next := paginate(versions, page)
+
+	list := V3VersionList{Items: make([]V3Version, 0, len(versions)), NextCursor: next}
+	for _, v := range versions {
+		item := V3Version{Name: v.Name, Size: v.Size, Rev: v.Rev, Time: v.Time}
+		if !v.Hash.IsZero() {
+			item.Hash = v.Hash.String()
+		}
+		list.Items = append(list.Items, item)
+	}
+	writeV3(w, r, http.StatusOK, list)
+}
+
+// listTrash handles GET /api/v3/trash.
+func (h *V3Handler) listTrash(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeTrash) {
+		return
+	}
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	items, err := h.trash.List(authed.UserID)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	items, next := paginate(items, page)
+
+	list := V3TrashList{Items: make([]V3TrashItem, 0, len(items)), NextCursor: next}
+	for i := range items {
+		list.Items = append(list.Items, toV3TrashItem(&items[i]))
+	}
+	writeV3(w, r, http.StatusOK, list)
+}
+
+// toV3TrashItem converts a TrashItem entity to its v3 representation.
+func toV3TrashItem(item *entity.TrashItem) V3TrashItem {
+	t := V3TrashItem{
+		ID:          item.ID,
+		Path:        item.Home.String(),
+		Name:        item.Name,
+		Type:        item.Type.String(),
+		Size:        item.Size,
+		Rev:         item.Rev,
+		DeletedAt:   item.DeletedAt,
+		DeletedFrom: item.DeletedFrom,
+	}
+	if item.HasContent() {
+		t.Hash = item.Hash.String()
+	}
+	return t
+}
+
+// v3TrashItem looks up the trash item in the route.
+// If there is no such item, or the caller may not access it, it writes an
+// error response and returns nil.
+func (h *V3Handler) v3TrashItem(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) *entity.TrashItem {
+	items, err := h.trash.List(authed.UserID)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return nil
+	}
+	var item *entity.TrashItem
+	for i := range items {
+		if r.PathValue("id") == strconv.FormatInt(items[i].ID, 10) {
+			item = &items[i]
+			break
+		}
+	}
+	if item == nil {
+		writeV3Error(w, http.StatusNotFound, v3NotFound, "no such item in the trashbin")
+		return nil
+	}
+	if !allowV3(w, authed, vo.ScopeTrash, item.Home) {
+		return nil
+	}
+	return item
+}
+
+// restoreTrash handles POST /api/v3/trash/{id}/restore.
+func (h *V3Handler) restoreTrash(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeTrash) {
+		return
+	}
+	conflict, ok := parseV3Conflict(w, r)
+	if !ok {
+		return
+	}
+	item := h.v3TrashItem(w, r, authed)
+	if item == nil {
+		return
+	}
+	folder := item.Home.Parent()
+	if f := r.URL.Query().Get("folder"); f != "" {
+		folder = vo.NewCloudPath(f)
+		if !allowV3(w, authed, vo.ScopeTrash, folder) {
+			return
+		}
+	}
+
+	restored, err := h.trash.RestoreTo(authed.UserID, item.Home, item.Rev, folder, conflict)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	node := h.getV3Node(w, authed, restored.Home)
+	if node == nil {
+		return
+	}
+	writeV3(w, r, http.StatusOK, toV3Node(node))
+}
+
+// deleteTrash handles DELETE /api/v3/trash/{id}.
+func (h *V3Handler) deleteTrash(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeTrash) {
+		return
+	}
+	item := h.v3TrashItem(w, r, authed)
+	if item == nil {
+		return
+	}
+	if err := h.trash.Remove(authed.UserID, item.Home, item.Rev); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// emptyTrash handles DELETE /api/v3/trash.
+func (h *V3Handler) emptyTrash(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeTrash) {
+		return
+	}
+	if err := h.trash.Empty(authed.UserID); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
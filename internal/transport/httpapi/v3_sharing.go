@@ -0,0 +1,299 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// toV3Share converts a Share entity to its v3 representation for the owner.
+func toV3Share(share *entity.Share) V3Share {
+	return V3Share{
+		ID:     share.ID,
+		Path:   share.Home.String(),
+		Email:  share.InvitedEmail,
+		Access: share.Access.APIString(),
+		Status: share.Status.String(),
+	}
+}
+
+// toV3Invite converts a Share entity to its v3 representation for the invited user.
+func (h *V3Handler) toV3Invite(share *entity.Share) V3Invite {
+	inv := V3Invite{
+		Token:  share.InviteToken,
+		Name:   share.Home.Name(),
+		Access: share.Access.APIString(),
+		Status: share.Status.String(),
+	}
+	if owner, _ := h.auth.ResolveUser(share.OwnerID); owner != nil {
+		inv.Owner = owner.Email
+	}
+	if share.IsAccepted() {
+		inv.MountPath = vo.NewCloudPath(share.MountHome).String()
+	}
+	return inv
+}
+
+// listShares handles GET /api/v3/shares.
+func (h *V3Handler) listShares(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeShare) {
+		return
+	}
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	shares, err := h.shares.ListOutgoing(authed.UserID)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	shares, next := paginate(shares, page)
+
+	list := V3ShareList{Items: make([]V3Share, 0, len(shares)), NextCursor: next}
+	for i := range shares {
+		list.Items = append(list.Items, toV3Share(&shares[i]))
+	}
+	writeV3(w, r, http.StatusOK, list)
+}
+
+// createShare handles POST /api/v3/shares. Inviting a user again changes
+// the access level of the existing invitation.
+func (h *V3Handler) createShare(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	var req V3ShareCreate
+	if !readV3Body(w, r, &req) {
+		return
+	}
+	if req.Path == "" || req.Email == "" {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "path and email are required")
+		return
+	}
+	access, err := vo.ParseAccessLevel(req.Access)
+	if err != nil {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "access must be read_only or read_write")
+		return
+	}
+	path := vo.NewCloudPath(req.Path)
+	if !allowV3(w, authed, vo.ScopeShare, path) {
+		return
+	}
+
+	share, err := h.shares.Share(authed.UserID, path, req.Email, access)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	writeV3(w, r, http.StatusCreated, toV3Share(share))
+}
+
+// deleteShare handles DELETE /api/v3/shares/{id}.
+func (h *V3Handler) deleteShare(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeShare) {
+		return
+	}
+	shares, err := h.shares.ListOutgoing(authed.UserID)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	for i := range shares {
+		share := &shares[i]
+		if strconv.FormatInt(share.ID, 10) != r.PathValue("id") {
+			continue
+		}
+		if !allowV3(w, authed, vo.ScopeShare, share.Home) {
+			return
+		}
+		if err := h.shares.Unshare(authed.UserID, share.Home, share.InvitedEmail); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeV3Error(w, http.StatusNotFound, v3NotFound, "no such share")
+}
+
+// listInvites handles GET /api/v3/invites.
+func (h *V3Handler) listInvites(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeShare) {
+		return
+	}
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	shares, err := h.shares.ListIncoming(authed.Email)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	shares, next := paginate(shares, page)
+
+	list := V3InviteList{Items: make([]V3Invite, 0, len(shares)), NextCursor: next}
+	for i := range shares {
+		list.Items = append(list.Items, h.toV3Invite(&shares[i]))
+	}
+	writeV3(w, r, http.StatusOK, list)
+}
+
+// findInvite returns the invitation to the caller with the token in the route.
+// If there is none, it writes a 404 error response and returns nil.
+func (h *V3Handler) findInvite(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) *entity.Share {
+	shares, err := h.shares.ListIncoming(authed.Email)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return nil
+	}
+	for i := range shares {
+		if shares[i].InviteToken == r.PathValue("token") {
+			return &shares[i]
+		}
+	}
+	writeV3Error(w, http.StatusNotFound, v3NotFound, "no such invitation")
+	return nil
+}
+
+// acceptInvite handles POST /api/v3/invites/{token}/accept. The folder is
+// mounted in the caller's root, under its own name unless another is given.
+func (h *V3Handler) acceptInvite(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeShare) {
+		return
+	}
+	var conflict vo.ConflictMode
+	switch r.URL.Query().Get("conflict") {
+	case "", "strict":
+		conflict = vo.ConflictStrict
+	case "rename":
+		conflict = vo.ConflictRename
+	default:
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "conflict must be strict or rename")
+		return
+	}
+	var req V3InviteAccept
+	if !readV3Body(w, r, &req) {
+		return
+	}
+
+	share := h.findInvite(w, r, authed)
+	if share == nil {
+		return
+	}
+	if share.IsAccepted() {
+		writeV3Error(w, http.StatusConflict, v3Conflict, "the invitation is already accepted")
+		return
+	}
+	name := req.Name
+	if name == "" {
+		name = share.Home.Name()
+	}
+	if !allowV3(w, authed, vo.ScopeShare, vo.NewCloudPath(name)) {
+		return
+	}
+
+	if err := h.shares.Mount(authed.UserID, name, share.InviteToken, conflict); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	if share = h.findInvite(w, r, authed); share == nil {
+		return
+	}
+	writeV3(w, r, http.StatusOK, h.toV3Invite(share))
+}
+
+// rejectInvite handles POST /api/v3/invites/{token}/reject.
+func (h *V3Handler) rejectInvite(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopeShare) {
+		return
+	}
+	if err := h.shares.Reject(authed.UserID, r.PathValue("token")); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteMount handles DELETE /api/v3/mounts/{path...}.
+func (h *V3Handler) deleteMount(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	path := v3Path(r)
+	if !allowV3(w, authed, vo.ScopeShare, path) {
+		return
+	}
+	clone, _ := strconv.ParseBool(r.URL.Query().Get("clone"))
+	if err := h.shares.Unmount(authed.UserID, path.String(), clone); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toV3Link converts a published node to its v3 representation.
+func (h *V3Handler) toV3Link(node *entity.Node) V3Link {
+	return V3Link{
+		ID:   node.Weblink,
+		Path: node.Home.String(),
+		URL:  h.externalURL + "/public/" + node.Weblink,
+	}
+}
+
+// listLinks handles GET /api/v3/links.
+func (h *V3Handler) listLinks(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopePublish) {
+		return
+	}
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	nodes, err := h.publish.ListPublished(authed.UserID)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	nodes, next := paginate(nodes, page)
+
+	list := V3LinkList{Items: make([]V3Link, 0, len(nodes)), NextCursor: next}
+	for i := range nodes {
+		list.Items = append(list.Items, h.toV3Link(&nodes[i]))
+	}
+	writeV3(w, r, http.StatusOK, list)
+}
+
+// createLink handles POST /api/v3/links. Publishing a node that already has
+// a weblink returns the existing one.
+func (h *V3Handler) createLink(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	var req V3LinkCreate
+	if !readV3Body(w, r, &req) {
+		return
+	}
+	if req.Path == "" {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "path is required")
+		return
+	}
+	path := vo.NewCloudPath(req.Path)
+	if !allowV3(w, authed, vo.ScopePublish, path) {
+		return
+	}
+
+	weblink, err := h.publish.Publish(authed.UserID, path)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	writeV3(w, r, http.StatusCreated, h.toV3Link(&entity.Node{Home: path, Weblink: weblink}))
+}
+
+// deleteLink handles DELETE /api/v3/links/{id}.
+func (h *V3Handler) deleteLink(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if !allowV3(w, authed, vo.ScopePublish) {
+		return
+	}
+	if err := h.publish.Unpublish(authed.UserID, r.PathValue("id")); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
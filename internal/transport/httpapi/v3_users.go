@@ -0,0 +1,230 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// toV3User converts a User entity and its disk usage to its v3 representation.
+func toV3User(user *entity.User, bytesUsed int64) V3User {
+	return V3User{
+		ID:                 user.ID,
+		Email:              user.Email,
+		QuotaBytes:         user.QuotaBytes,
+		BytesUsed:          bytesUsed,
+		FileSizeLimit:      user.FileSizeLimit,
+		VersionHistory:     user.VersionHistory,
+		VersionRetention:   retentionToString(user.VersionRetention),
+		TrashRetentionDays: user.TrashRetentionDays,
+		TwoFactor:          user.TOTPEnabled,
+		Created:            user.Created,
+	}
+}
+
+// v3User looks up a user and their disk usage.
+// If there is no such user, it writes a 404 error response and returns false.
+func (h *V3Handler) v3User(w http.ResponseWriter, id int64) (V3User, bool) {
+	user, err := h.users.Get(id)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return V3User{}, false
+	}
+	usage, err := h.quota.GetUsage(id)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return V3User{}, false
+	}
+	v3 := toV3User(user, usage.BytesUsed)
+	v3.BytesVersions = usage.BytesVersions
+	v3.BytesTrash = usage.BytesTrash
+	return v3, true
+}
+
+// userID parses the user ID in the route.
+// If it is invalid, it writes a 404 error response and returns false.
+func userID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeV3Error(w, http.StatusNotFound, v3NotFound, "no such user")
+		return 0, false
+	}
+	return id, true
+}
+
+// getMe handles GET /api/v3/users/me.
+func (h *V3Handler) getMe(w http.ResponseWriter, r *http.Request, authed *service.AuthenticatedUser) {
+	if user, ok := h.v3User(w, authed.UserID); ok {
+		writeV3(w, r, http.StatusOK, user)
+	}
+}
+
+// listUsers handles GET /api/v3/users.
+func (h *V3Handler) listUsers(w http.ResponseWriter, r *http.Request, _ *service.AuthenticatedUser) {
+	page, ok := parseV3Page(w, r)
+	if !ok {
+		return
+	}
+	users, err := h.users.ListWithUsage()
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	users, next := paginate(users, page)
+
+	list := V3UserList{Items: make([]V3User, 0, len(users)), NextCursor: next}
+	for i := range users {
+		list.Items = append(list.Items, toV3User(&users[i].User, users[i].BytesUsed))
+	}
+	writeV3(w, r, http.StatusOK, list)
+}
+
+// createUser handles POST /api/v3/users.
+func (h *V3Handler) createUser(w http.ResponseWriter, r *http.Request, _ *service.AuthenticatedUser) {
+	var req V3UserCreate
+	if !readV3Body(w, r, &req) {
+		return
+	}
+	if req.Email == "" || req.Password == "" {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "email and password are required")
+		return
+	}
+	if req.QuotaBytes < 0 || req.FileSizeLimit < 0 || (req.TrashRetentionDays != nil && *req.TrashRetentionDays < 0) {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "sizes and trash_retention_days must not be negative")
+		return
+	}
+	retention, err := parseVersionRetention(req.VersionRetention)
+	if err != nil {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, err.Error())
+		return
+	}
+
+	user, err := h.users.Create(req.Email, req.Password, false, req.QuotaBytes)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	if req.FileSizeLimit != 0 || req.VersionHistory {
+		user.FileSizeLimit = req.FileSizeLimit
+		user.VersionHistory = req.VersionHistory
+		if err := h.users.Update(user); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+	if retention != nil {
+		if err := h.users.SetVersionRetention(user.ID, retention); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+	if req.TrashRetentionDays != nil {
+		if err := h.users.SetTrashRetention(user.ID, req.TrashRetentionDays); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Location", v3Prefix+"/users/"+strconv.FormatInt(user.ID, 10))
+	if created, ok := h.v3User(w, user.ID); ok {
+		writeV3(w, r, http.StatusCreated, created)
+	}
+}
+
+// getUser handles GET /api/v3/users/{id}.
+func (h *V3Handler) getUser(w http.ResponseWriter, r *http.Request, _ *service.AuthenticatedUser) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	if user, ok := h.v3User(w, id); ok {
+		writeV3(w, r, http.StatusOK, user)
+	}
+}
+
+// updateUser handles PATCH /api/v3/users/{id}.
+func (h *V3Handler) updateUser(w http.ResponseWriter, r *http.Request, _ *service.AuthenticatedUser) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var req V3UserUpdate
+	if !readV3Body(w, r, &req) {
+		return
+	}
+	if (req.QuotaBytes != nil && *req.QuotaBytes <= 0) || (req.FileSizeLimit != nil && *req.FileSizeLimit < 0) {
+		writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, "quota_bytes must be positive and file_size_limit not negative")
+		return
+	}
+	var retention *vo.RetentionPolicy
+	if req.VersionRetention != nil {
+		var err error
+		if retention, err = parseVersionRetention(*req.VersionRetention); err != nil {
+			writeV3Error(w, http.StatusBadRequest, v3InvalidRequest, err.Error())
+			return
+		}
+	}
+
+	user, err := h.users.Get(id)
+	if err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	// UserService.Update always applies these fields, so unchanged ones keep their values.
+	update := &entity.User{
+		ID:             id,
+		Email:          req.Email,
+		Password:       req.Password,
+		IsAdmin:        user.IsAdmin,
+		FileSizeLimit:  user.FileSizeLimit,
+		VersionHistory: user.VersionHistory,
+	}
+	if req.QuotaBytes != nil {
+		update.QuotaBytes = *req.QuotaBytes
+	}
+	if req.FileSizeLimit != nil {
+		update.FileSizeLimit = *req.FileSizeLimit
+	}
+	if req.VersionHistory != nil {
+		update.VersionHistory = *req.VersionHistory
+	}
+	if err := h.users.Update(update); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	if req.VersionRetention != nil {
+		if err := h.users.SetVersionRetention(id, retention); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+	if days := req.TrashRetentionDays; days != nil {
+		if *days < 0 {
+			days = nil
+		}
+		if err := h.users.SetTrashRetention(id, days); err != nil {
+			writeV3ServiceError(w, err)
+			return
+		}
+	}
+
+	if updated, ok := h.v3User(w, id); ok {
+		writeV3(w, r, http.StatusOK, updated)
+	}
+}
+
+// deleteUser handles DELETE /api/v3/users/{id}.
+func (h *V3Handler) deleteUser(w http.ResponseWriter, r *http.Request, _ *service.AuthenticatedUser) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.users.Delete(id); err != nil {
+		writeV3ServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
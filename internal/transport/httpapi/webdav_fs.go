@@ -0,0 +1,421 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"time"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// davListLimit caps the number of children returned for one collection.
+const davListLimit = 65535
+
+// davMaxFileSize caps uploads for users without a file size limit.
+// Bodies are spooled to disk, so this bounds the temporary space one PUT can take.
+const davMaxFileSize = 64 << 30
+
+// errFileTooLarge is returned when a WebDAV upload exceeds the user's file size limit.
+var errFileTooLarge = errors.New("file too large")
+
+// davFileSystem exposes one user's node tree as a webdav.FileSystem.
+// Paths under a mounted share are resolved to the owner's tree, like in the v2 API;
+// read-only mounts reject every modification with os.ErrPermission.
+type davFileSystem struct {
+	authed    *service.AuthenticatedUser
+	folders   *service.FolderService
+	files     *service.FileService
+	uploads   *service.UploadService
+	downloads *service.DownloadService
+	trash     *service.TrashService
+	shares    *service.ShareService
+}
+
+// davTarget is a WebDAV path resolved to the tree it lives in.
+type davTarget struct {
+	userID    int64
+	path      vo.CloudPath
+	readOnly  bool
+	mountRoot bool
+}
+
+// resolve maps a WebDAV path to the owning user's tree, following mounted shares.
+func (fs *davFileSystem) resolve(name string) (davTarget, error) {
+	p := vo.NewCloudPath(name)
+	resolution, err := fs.shares.ResolveMount(fs.authed.UserID, p)
+	if err != nil {
+		return davTarget{}, err
+	}
+	if resolution == nil {
+		return davTarget{userID: fs.authed.UserID, path: p}, nil
+	}
+	return davTarget{
+		userID:    resolution.Share.OwnerID,
+		path:      resolution.OwnerPath,
+		readOnly:  resolution.Share.Access == vo.AccessReadOnly,
+		mountRoot: resolution.OwnerPath.String() == resolution.Share.Home.String(),
+	}, nil
+}
+
+// lookup resolves a WebDAV path and loads its node.
+// Returns os.ErrNotExist if there is no node at the path.
+func (fs *davFileSystem) lookup(name string) (davTarget, *entity.Node, error) {
+	target, err := fs.resolve(name)
+	if err != nil {
+		return target, nil, err
+	}
+	node, err := fs.files.Get(target.userID, target.path)
+	if err != nil {
+		return target, nil, err
+	}
+	if node == nil {
+		return target, nil, os.ErrNotExist
+	}
+	return target, node, nil
+}
+
+// requireFolder returns os.ErrNotExist unless the path is an existing folder.
+func (fs *davFileSystem) requireFolder(name string) error {
+	_, node, err := fs.lookup(name)
+	if err != nil {
+		return err
+	}
+	if !node.IsFolder() {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// Mkdir creates a collection. The parent must already exist.
+func (fs *davFileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	target, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if target.readOnly {
+		return os.ErrPermission
+	}
+	if err := fs.requireFolder(path.Dir(name)); err != nil {
+		return err
+	}
+
+	if _, err := fs.folders.CreateFolder(target.userID, target.path); err != nil {
+		if errors.Is(err, service.ErrAlreadyExists) {
+			return os.ErrExist
+		}
+		return err
+	}
+	return nil
+}
+
+// OpenFile opens a node for reading, or starts an upload when any write flag is set.
+func (fs *davFileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return fs.create(name)
+	}
+
+	_, node, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &davFile{fs: fs, name: name, node: node}
+	if node.IsFile() {
+		result, err := fs.downloads.ResolveByNode(node)
+		if err != nil {
+			return nil, err
+		}
+		f.content = result.File
+	}
+	return f, nil
+}
+
+// create starts an upload to the given path. The content is stored when the file is closed.
+func (fs *davFileSystem) create(name string) (webdav.File, error) {
+	target, node, err := fs.lookup(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if target.readOnly {
+		return nil, os.ErrPermission
+	}
+	if node != nil && node.IsFolder() {
+		return nil, os.ErrExist
+	}
+	if err := fs.requireFolder(path.Dir(name)); err != nil {
+		return nil, err
+	}
+	spool, err := os.CreateTemp("", "tucha-dav-*")
+	if err != nil {
+		return nil, err
+	}
+	return &davUpload{fs: fs, name: name, target: target, spool: spool}, nil
+}
+
+// RemoveAll moves the node and its descendants to the trash.
+// Removing a mount point unmounts the share instead of trashing the owner's folder.
+func (fs *davFileSystem) RemoveAll(ctx context.Context, name string) error {
+	target, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if target.mountRoot {
+		return fs.shares.Unmount(fs.authed.UserID, vo.NewCloudPath(name).String(), false)
+	}
+	if target.readOnly {
+		return os.ErrPermission
+	}
+	return fs.trash.Trash(target.userID, target.path, fs.authed.UserID)
+}
+
+// Rename moves and/or renames a node within one tree.
+// Moving across share boundaries and moving mount points are not supported.
+func (fs *davFileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	src, _, err := fs.lookup(oldName)
+	if err != nil {
+		return err
+	}
+	dst, err := fs.resolve(newName)
+	if err != nil {
+		return err
+	}
+	if src.readOnly || dst.readOnly || src.mountRoot || dst.mountRoot || src.userID != dst.userID {
+		return os.ErrPermission
+	}
+	if err := fs.requireFolder(path.Dir(newName)); err != nil {
+		return err
+	}
+
+	current := src.path
+	if current.Parent().String() != dst.path.Parent().String() {
+		node, err := fs.files.Move(src.userID, current, dst.path.Parent(), vo.ConflictStrict)
+		if err != nil {
+			return err
+		}
+		current = node.Home
+	}
+	if current.Name() != dst.path.Name() {
+		if _, err := fs.files.Rename(src.userID, current, dst.path.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stat returns the file info of the node at the path.
+func (fs *davFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	_, node, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return newDavFileInfo(path.Base(name), node), nil
+}
+
+// readdir lists a collection, including shares mounted directly inside it.
+func (fs *davFileSystem) readdir(name string) ([]os.FileInfo, error) {
+	target, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := fs.folders.ListChildren(target.userID, target.path, 0, davListLimit)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(children))
+	for i := range children {
+		infos = append(infos, newDavFileInfo(children[i].Name, &children[i]))
+	}
+
+	if target.userID != fs.authed.UserID {
+		return infos, nil
+	}
+	mounted, err := fs.shares.ListMountedIn(fs.authed.UserID, target.path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mounted {
+		ms := &mounted[i]
+		ownerFolder, err := fs.folders.Get(ms.OwnerID, ms.Home)
+		if err != nil {
+			return nil, err
+		}
+		if ownerFolder != nil {
+			infos = append(infos, newDavFileInfo(vo.NewCloudPath(ms.MountHome).Name(), ownerFolder))
+		}
+	}
+	return infos, nil
+}
+
+// davFile is a node opened for reading. Files are backed by their content on disk.
+type davFile struct {
+	fs      *davFileSystem
+	name    string
+	node    *entity.Node
+	content *os.File
+	listed  bool
+}
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if f.content == nil {
+		return 0, os.ErrInvalid
+	}
+	return f.content.Read(p)
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	if f.content == nil {
+		return 0, os.ErrInvalid
+	}
+	return f.content.Seek(offset, whence)
+}
+
+func (f *davFile) Write(p []byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+func (f *davFile) Close() error {
+	if f.content == nil {
+		return nil
+	}
+	return f.content.Close()
+}
+
+// Readdir returns all children on the first call and io.EOF afterwards when count > 0.
+func (f *davFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.node.IsFolder() {
+		return nil, os.ErrInvalid
+	}
+	if f.listed {
+		if count > 0 {
+			return nil, io.EOF
+		}
+		return nil, nil
+	}
+	f.listed = true
+	return f.fs.readdir(f.name)
+}
+
+func (f *davFile) Stat() (os.FileInfo, error) {
+	return newDavFileInfo(path.Base(f.name), f.node), nil
+}
+
+// davUpload spools a PUT body to a temporary file and registers it as a file node
+// on Close, replacing any existing file and recording a version like /upload does.
+// Nothing is stored if a write failed.
+type davUpload struct {
+	fs     *davFileSystem
+	name   string
+	target davTarget
+	spool  *os.File
+	size   int64
+	failed bool
+}
+
+func (u *davUpload) Write(p []byte) (int, error) {
+	limit := int64(davMaxFileSize)
+	if l := u.fs.authed.FileSizeLimit; l > 0 && l < limit {
+		limit = l
+	}
+	if u.size+int64(len(p)) > limit {
+		u.failed = true
+		return 0, errFileTooLarge
+	}
+	n, err := u.spool.Write(p)
+	u.size += int64(n)
+	if err != nil {
+		u.failed = true
+	}
+	return n, err
+}
+
+func (u *davUpload) Close() error {
+	defer os.Remove(u.spool.Name())
+	defer u.spool.Close()
+
+	if u.failed {
+		return nil
+	}
+	hash, err := u.fs.uploads.UploadFile(u.spool, u.size)
+	if err != nil {
+		return err
+	}
+	_, err = u.fs.files.AddByHash(u.target.userID, u.target.path, hash, u.size, vo.ConflictReplace)
+	return err
+}
+
+func (u *davUpload) Read(p []byte) (int, error) {
+	return 0, os.ErrInvalid
+}
+
+func (u *davUpload) Seek(offset int64, whence int) (int64, error) {
+	return 0, os.ErrInvalid
+}
+
+func (u *davUpload) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+func (u *davUpload) Stat() (os.FileInfo, error) {
+	return &davFileInfo{name: path.Base(u.name), size: u.size, modTime: time.Now()}, nil
+}
+
+// davFileInfo describes a node. It supplies content types and ETags
+// directly, so listings never have to open file content.
+type davFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	hash    string
+}
+
+func newDavFileInfo(name string, node *entity.Node) *davFileInfo {
+	info := &davFileInfo{
+		name:    name,
+		size:    node.Size,
+		modTime: time.Unix(node.MTime, 0),
+		dir:     node.IsFolder(),
+	}
+	if node.HasContent() {
+		info.hash = node.Hash.String()
+	}
+	return info
+}
+
+func (i *davFileInfo) Name() string       { return i.name }
+func (i *davFileInfo) Size() int64        { return i.size }
+func (i *davFileInfo) ModTime() time.Time { return i.modTime }
+func (i *davFileInfo) IsDir() bool        { return i.dir }
+func (i *davFileInfo) Sys() any           { return nil }
+
+func (i *davFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+// ContentType implements webdav.ContentTyper using the file extension.
+func (i *davFileInfo) ContentType(ctx context.Context) (string, error) {
+	if ct := mime.TypeByExtension(path.Ext(i.name)); ct != "" {
+		return ct, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// ETag implements webdav.ETager using the content hash.
+func (i *davFileInfo) ETag(ctx context.Context) (string, error) {
+	if i.hash == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + i.hash + `"`, nil
+}
@@ -0,0 +1,396 @@
+package sftpd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/domain/vo"
+)
+
+// maxFileSize caps uploads for users without a file size limit. Uploads are
+// spooled to disk, so this bounds the temporary space one handle can take.
+const maxFileSize = 64 << 30
+
+var (
+	// errFileTooLarge is returned when an upload exceeds the user's file size limit.
+	errFileTooLarge = errors.New("file too large")
+
+	// errNotEmpty is returned when removing a folder that still has children.
+	errNotEmpty = errors.New("directory not empty")
+)
+
+// fileSystem exposes one user's own node tree to an SFTP session.
+// Shares mounted into the tree are not followed.
+type fileSystem struct {
+	authed    *service.AuthenticatedUser
+	folders   *service.FolderService
+	files     *service.FileService
+	uploads   *service.UploadService
+	downloads *service.DownloadService
+	trash     *service.TrashService
+}
+
+// lookup loads the node at the path.
+// Returns os.ErrNotExist if there is no node at the path.
+func (fs *fileSystem) lookup(p string) (*entity.Node, error) {
+	node, err := fs.files.Get(fs.authed.UserID, vo.NewCloudPath(p))
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, os.ErrNotExist
+	}
+	return node, nil
+}
+
+// requireFolder returns os.ErrNotExist unless the path is an existing folder.
+func (fs *fileSystem) requireFolder(p vo.CloudPath) error {
+	node, err := fs.lookup(p.String())
+	if err != nil {
+		return err
+	}
+	if !node.IsFolder() {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// Fileread opens a file for reading. The content file is closed by the SFTP server.
+func (fs *fileSystem) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	node, err := fs.lookup(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	if !node.IsFile() {
+		return nil, os.ErrInvalid
+	}
+	result, err := fs.downloads.ResolveByNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return result.File, nil
+}
+
+// Filewrite starts an upload to the path. The content is stored when the handle is closed.
+// Files opened without truncation start from their current content.
+func (fs *fileSystem) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	p := vo.NewCloudPath(r.Filepath)
+	flags := r.Pflags()
+
+	node, err := fs.lookup(r.Filepath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if node != nil && (node.IsFolder() || flags.Excl) {
+		return nil, os.ErrExist
+	}
+	if node == nil && !flags.Creat {
+		return nil, os.ErrNotExist
+	}
+	if err := fs.requireFolder(p.Parent()); err != nil {
+		return nil, err
+	}
+
+	spool, err := os.CreateTemp("", "tucha-sftp-*")
+	if err != nil {
+		return nil, err
+	}
+	u := &upload{fs: fs, path: p, spool: spool, dirty: node == nil || flags.Trunc}
+	if node != nil && !flags.Trunc && node.Size > 0 {
+		if err := u.preload(node); err != nil {
+			u.discard()
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// Filecmd handles modifications that do not transfer content.
+func (fs *fileSystem) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		// Permissions, ownership and times are not stored.
+		return nil
+	case "Rename":
+		return fs.rename(r.Filepath, r.Target, false)
+	case "Mkdir":
+		return fs.mkdir(r.Filepath)
+	case "Rmdir":
+		return fs.remove(r.Filepath, true)
+	case "Remove":
+		return fs.remove(r.Filepath, false)
+	default:
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+// PosixRename renames a node, replacing an existing file at the target.
+func (fs *fileSystem) PosixRename(r *sftp.Request) error {
+	return fs.rename(r.Filepath, r.Target, true)
+}
+
+// Filelist lists a folder or stats a single node.
+func (fs *fileSystem) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	node, err := fs.lookup(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		if !node.IsFolder() {
+			return nil, os.ErrInvalid
+		}
+		return &folderLister{fs: fs, path: vo.NewCloudPath(r.Filepath)}, nil
+	case "Stat":
+		return statLister{newFileInfo(node)}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+// mkdir creates a folder. The parent must already exist.
+func (fs *fileSystem) mkdir(name string) error {
+	p := vo.NewCloudPath(name)
+	if err := fs.requireFolder(p.Parent()); err != nil {
+		return err
+	}
+	if _, err := fs.folders.CreateFolder(fs.authed.UserID, p); err != nil {
+		if errors.Is(err, service.ErrAlreadyExists) {
+			return os.ErrExist
+		}
+		return err
+	}
+	return nil
+}
+
+// remove moves a file (or, with folder set, an empty folder) to the trash.
+func (fs *fileSystem) remove(name string, folder bool) error {
+	p := vo.NewCloudPath(name)
+	if p.IsRoot() {
+		return os.ErrPermission
+	}
+	node, err := fs.lookup(name)
+	if err != nil {
+		return err
+	}
+	if node.IsFolder() != folder {
+		return os.ErrInvalid
+	}
+	if folder {
+		folders, files, err := fs.folders.CountChildren(fs.authed.UserID, p)
+		if err != nil {
+			return err
+		}
+		if folders+files > 0 {
+			return errNotEmpty
+		}
+	}
+	return fs.trash.Trash(fs.authed.UserID, p, fs.authed.UserID)
+}
+
+// rename moves and/or renames a node. An existing target fails the rename unless
+// replace is set and the target is a file, which is then moved to the trash.
+func (fs *fileSystem) rename(oldName, newName string, replace bool) error {
+	src, dst := vo.NewCloudPath(oldName), vo.NewCloudPath(newName)
+	if src.IsRoot() || dst.IsRoot() {
+		return os.ErrPermission
+	}
+	if _, err := fs.lookup(oldName); err != nil {
+		return err
+	}
+	if src.String() == dst.String() {
+		return nil
+	}
+	if err := fs.requireFolder(dst.Parent()); err != nil {
+		return err
+	}
+
+	existing, err := fs.lookup(newName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if existing != nil {
+		if !replace || existing.IsFolder() {
+			return os.ErrExist
+		}
+		if err := fs.trash.Trash(fs.authed.UserID, dst, fs.authed.UserID); err != nil {
+			return err
+		}
+	}
+
+	current := src
+	if current.Parent().String() != dst.Parent().String() {
+		node, err := fs.files.Move(fs.authed.UserID, current, dst.Parent(), vo.ConflictStrict)
+		if err != nil {
+			return err
+		}
+		current = node.Home
+	}
+	if current.Name() != dst.Name() {
+		if _, err := fs.files.Rename(fs.authed.UserID, current, dst.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upload spools the content written to a handle to a temporary file and registers
+// it as a file node when the handle is closed, replacing the previous content and
+// recording a version like /upload does. Nothing is stored if a transfer failed.
+type upload struct {
+	fs    *fileSystem
+	path  vo.CloudPath
+	spool *os.File
+
+	mu     sync.Mutex
+	size   int64
+	dirty  bool
+	failed bool
+}
+
+// preload copies the current content of the node to the spool file.
+func (u *upload) preload(node *entity.Node) error {
+	result, err := u.fs.downloads.ResolveByNode(node)
+	if err != nil {
+		return err
+	}
+	defer result.File.Close()
+
+	u.size, err = io.Copy(u.spool, result.File)
+	return err
+}
+
+// discard removes the spool file.
+func (u *upload) discard() {
+	u.spool.Close()
+	os.Remove(u.spool.Name())
+}
+
+func (u *upload) WriteAt(p []byte, off int64) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	limit := int64(maxFileSize)
+	if l := u.fs.authed.FileSizeLimit; l > 0 && l < limit {
+		limit = l
+	}
+	end := off + int64(len(p))
+	if off < 0 || end > limit {
+		return 0, errFileTooLarge
+	}
+	n, err := u.spool.WriteAt(p, off)
+	if written := off + int64(n); written > u.size {
+		u.size = written
+	}
+	u.dirty = true
+	return n, err
+}
+
+// TransferError implements sftp.TransferError.
+func (u *upload) TransferError(err error) {
+	u.mu.Lock()
+	u.failed = true
+	u.mu.Unlock()
+}
+
+func (u *upload) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	defer u.discard()
+
+	if u.failed || !u.dirty {
+		return nil
+	}
+
+	// A folder may have been created at the path while the handle was open.
+	node, err := u.fs.files.Get(u.fs.authed.UserID, u.path)
+	if err != nil {
+		return err
+	}
+	if node != nil && node.IsFolder() {
+		return os.ErrExist
+	}
+
+	hash, err := u.fs.uploads.UploadFile(u.spool, u.size)
+	if err != nil {
+		return err
+	}
+	_, err = u.fs.files.AddByHash(u.fs.authed.UserID, u.path, hash, u.size, vo.ConflictReplace)
+	return err
+}
+
+// folderLister pages through a folder's children as the client reads the listing.
+type folderLister struct {
+	fs   *fileSystem
+	path vo.CloudPath
+}
+
+func (l *folderLister) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	children, err := l.fs.folders.ListChildren(l.fs.authed.UserID, l.path, int(offset), len(ls))
+	if err != nil {
+		return 0, err
+	}
+	for i := range children {
+		ls[i] = newFileInfo(&children[i])
+	}
+	if len(children) < len(ls) {
+		return len(children), io.EOF
+	}
+	return len(children), nil
+}
+
+// statLister returns a single file info.
+type statLister []os.FileInfo
+
+func (l statLister) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fileInfo describes a node.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func newFileInfo(node *entity.Node) *fileInfo {
+	name := node.Name
+	if node.Home.IsRoot() {
+		name = "/"
+	}
+	return &fileInfo{
+		name:    name,
+		size:    node.Size,
+		modTime: time.Unix(node.MTime, 0),
+		dir:     node.IsFolder(),
+	}
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
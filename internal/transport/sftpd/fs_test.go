@@ -0,0 +1,63 @@
+package sftpd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+)
+
+func TestUpload_WriteAt(t *testing.T) {
+	spool, err := os.Create(filepath.Join(t.TempDir(), "spool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+	if _, err := spool.WriteString("abc"); err != nil {
+		t.Fatal(err)
+	}
+	u := &upload{fs: &fileSystem{authed: &service.AuthenticatedUser{FileSizeLimit: 8}}, spool: spool, size: 3}
+
+	if _, err := u.WriteAt([]byte("XY"), 1); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if _, err := u.WriteAt([]byte("Z"), 5); err != nil {
+		t.Fatalf("write past the end: %v", err)
+	}
+	content, err := os.ReadFile(spool.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(content); got != "aXY\x00\x00Z" {
+		t.Errorf("content = %q, want %q", got, "aXY\x00\x00Z")
+	}
+	if !u.dirty {
+		t.Error("upload not marked as modified")
+	}
+
+	if _, err := u.WriteAt([]byte("123"), 6); !errors.Is(err, errFileTooLarge) {
+		t.Errorf("error = %v, want errFileTooLarge", err)
+	}
+	if u.size != 6 {
+		t.Errorf("size = %d after a rejected write, want 6", u.size)
+	}
+}
+
+func TestUpload_WriteAt_serverLimit(t *testing.T) {
+	spool, err := os.Create(filepath.Join(t.TempDir(), "spool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+	u := &upload{fs: &fileSystem{authed: &service.AuthenticatedUser{}}, spool: spool}
+
+	// Users without a file size limit are still held to maxFileSize.
+	if _, err := u.WriteAt([]byte("x"), 1<<62); !errors.Is(err, errFileTooLarge) {
+		t.Errorf("error = %v, want errFileTooLarge", err)
+	}
+	if u.size != 0 {
+		t.Errorf("size = %d after a rejected write, want 0", u.size)
+	}
+}
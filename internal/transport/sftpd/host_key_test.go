@@ -0,0 +1,43 @@
+package sftpd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateHostKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host_key")
+
+	created, err := LoadOrCreateHostKey(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("host key not saved: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	loaded, err := LoadOrCreateHostKey(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !bytes.Equal(created.PublicKey().Marshal(), loaded.PublicKey().Marshal()) {
+		t.Error("reloaded host key differs from the generated one")
+	}
+}
+
+func TestLoadOrCreateHostKey_invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host_key")
+	if err := os.WriteFile(path, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadOrCreateHostKey(path); err == nil {
+		t.Error("expected an error for an invalid host key file")
+	}
+}
@@ -0,0 +1,272 @@
+// Package sftpd serves users' cloud trees over SFTP.
+package sftpd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pozitronik/tucha/internal/application/port"
+	"github.com/pozitronik/tucha/internal/application/service"
+)
+
+// handshakeTimeout limits how long a client may take to authenticate.
+const handshakeTimeout = 30 * time.Second
+
+// userIDExtension is the ssh.Permissions extension carrying the authenticated user ID.
+const userIDExtension = "tucha-user-id"
+
+// Server accepts SSH connections and serves the "sftp" subsystem.
+// Clients log in with their email as the user name and either their password
+// (an app password for two-factor accounts) or an authorized public key.
+type Server struct {
+	config    *ssh.ServerConfig
+	tokens    *service.TokenService
+	sshKeys   *service.SSHKeyService
+	auth      *service.AuthService
+	folders   *service.FolderService
+	files     *service.FileService
+	uploads   *service.UploadService
+	downloads *service.DownloadService
+	trash     *service.TrashService
+	logger    port.Logger
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a new Server presenting the given host key.
+func NewServer(
+	hostKey ssh.Signer,
+	tokens *service.TokenService,
+	sshKeys *service.SSHKeyService,
+	auth *service.AuthService,
+	folders *service.FolderService,
+	files *service.FileService,
+	uploads *service.UploadService,
+	downloads *service.DownloadService,
+	trash *service.TrashService,
+	logger port.Logger,
+) *Server {
+	s := &Server{
+		tokens:    tokens,
+		sshKeys:   sshKeys,
+		auth:      auth,
+		folders:   folders,
+		files:     files,
+		uploads:   uploads,
+		downloads: downloads,
+		trash:     trash,
+		logger:    logger,
+		conns:     make(map[net.Conn]struct{}),
+	}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback:  s.checkPassword,
+		PublicKeyCallback: s.checkPublicKey,
+	}
+	s.config.AddHostKey(hostKey)
+	return s
+}
+
+// checkPassword authenticates a login with the user's password or app password.
+func (s *Server) checkPassword(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	user, err := s.tokens.Verify(conn.User(), string(password))
+	if err != nil {
+		s.logger.Warn("SFTP password login failed: user=%q remote=%s", conn.User(), conn.RemoteAddr())
+		return nil, err
+	}
+	return userPermissions(user.ID), nil
+}
+
+// checkPublicKey authenticates a login with one of the user's authorized keys.
+// The SSH library verifies that the client holds the private key before
+// the login is accepted.
+func (s *Server) checkPublicKey(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	user, err := s.sshKeys.Authenticate(conn.User(), ssh.FingerprintSHA256(key))
+	if err != nil {
+		return nil, err
+	}
+	return userPermissions(user.ID), nil
+}
+
+func userPermissions(userID int64) *ssh.Permissions {
+	return &ssh.Permissions{Extensions: map[string]string{userIDExtension: strconv.FormatInt(userID, 10)}}
+}
+
+// Serve accepts connections on the listener until Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return nil
+	}
+	s.listener = ln
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer s.untrack(conn)
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections, disconnects all clients and waits for
+// their sessions to end.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	conn.Close()
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+// handleConn performs the SSH handshake and serves the connection's session channels.
+func (s *Server) handleConn(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		s.logger.Debug("SFTP handshake failed: remote=%s err=%v", conn.RemoteAddr(), err)
+		return
+	}
+	defer sshConn.Close()
+	conn.SetDeadline(time.Time{})
+	go ssh.DiscardRequests(reqs)
+
+	userID, err := strconv.ParseInt(sshConn.Permissions.Extensions[userIDExtension], 10, 64)
+	if err != nil {
+		return
+	}
+	authed, err := s.auth.ResolveUser(userID)
+	if err != nil || authed == nil {
+		return
+	}
+	s.logger.Info("SFTP login: user=%q remote=%s", authed.Email, conn.RemoteAddr())
+
+	var wg sync.WaitGroup
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handleSession(channel, requests, authed)
+		}()
+	}
+	wg.Wait()
+}
+
+// handleSession waits for the "sftp" subsystem request and serves it on the channel.
+// Shells, commands and other subsystems are refused.
+func (s *Server) handleSession(channel ssh.Channel, requests <-chan *ssh.Request, authed *service.AuthenticatedUser) {
+	defer channel.Close()
+
+	started := false
+	for req := range requests {
+		ok := !started && req.Type == "subsystem" && subsystemName(req.Payload) == "sftp"
+		if req.WantReply {
+			req.Reply(ok, nil)
+		}
+		if !ok {
+			continue
+		}
+		started = true
+
+		go func() {
+			defer channel.Close()
+			server := sftp.NewRequestServer(channel, s.handlers(authed))
+			if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+				s.logger.Warn("SFTP session failed: user=%q err=%v", authed.Email, err)
+			}
+			server.Close()
+		}()
+	}
+}
+
+// subsystemName decodes the SSH string payload of a "subsystem" request.
+func subsystemName(payload []byte) string {
+	if len(payload) < 4 {
+		return ""
+	}
+	n := binary.BigEndian.Uint32(payload)
+	if uint64(len(payload)-4) < uint64(n) {
+		return ""
+	}
+	return string(payload[4 : 4+n])
+}
+
+// handlers returns the SFTP request handlers for the user's tree.
+func (s *Server) handlers(authed *service.AuthenticatedUser) sftp.Handlers {
+	fs := &fileSystem{
+		authed:    authed,
+		folders:   s.folders,
+		files:     s.files,
+		uploads:   s.uploads,
+		downloads: s.downloads,
+		trash:     s.trash,
+	}
+	return sftp.Handlers{FileGet: fs, FilePut: fs, FileCmd: fs, FileList: fs}
+}
@@ -0,0 +1,137 @@
+package sftpd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/pozitronik/tucha/internal/application/service"
+	"github.com/pozitronik/tucha/internal/domain/entity"
+	"github.com/pozitronik/tucha/internal/testutil/mock"
+)
+
+// connMetadata is a minimal ssh.ConnMetadata for calling the auth callbacks.
+type connMetadata struct{ user string }
+
+func (c connMetadata) User() string          { return c.user }
+func (c connMetadata) SessionID() []byte     { return nil }
+func (c connMetadata) ClientVersion() []byte { return nil }
+func (c connMetadata) ServerVersion() []byte { return nil }
+func (c connMetadata) RemoteAddr() net.Addr  { return &net.TCPAddr{} }
+func (c connMetadata) LocalAddr() net.Addr   { return &net.TCPAddr{} }
+
+func newTestKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestServer(t *testing.T, authorized ssh.PublicKey) *Server {
+	t.Helper()
+	user := mock.NewTestUser(1, "user@example.com")
+	users := &mock.UserRepositoryMock{
+		GetByEmailFunc: func(email string) (*entity.User, error) {
+			if email == user.Email {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	keys := &mock.SSHKeyRepositoryMock{
+		GetByFingerprintFunc: func(userID int64, fingerprint string) (*entity.SSHKey, error) {
+			if userID == 1 && fingerprint == ssh.FingerprintSHA256(authorized) {
+				return &entity.SSHKey{ID: 1, UserID: 1}, nil
+			}
+			return nil, nil
+		},
+	}
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewServer(
+		signer,
+		service.NewTokenService(&mock.TokenRepositoryMock{}, users, &mock.AppPasswordRepositoryMock{}),
+		service.NewSSHKeyService(keys, users, &mock.PublicKeyParserMock{}),
+		nil, nil, nil, nil, nil, nil,
+		&mock.LoggerMock{},
+	)
+}
+
+func TestServer_checkPassword(t *testing.T) {
+	s := newTestServer(t, newTestKey(t))
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		wantOK   bool
+	}{
+		{"valid", "user@example.com", "password", true},
+		{"wrong password", "user@example.com", "nope", false},
+		{"unknown user", "other@example.com", "password", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perms, err := s.checkPassword(connMetadata{tt.user}, []byte(tt.password))
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("error = %v, want ok=%v", err, tt.wantOK)
+			}
+			if tt.wantOK && perms.Extensions[userIDExtension] != "1" {
+				t.Errorf("user ID extension = %q, want %q", perms.Extensions[userIDExtension], "1")
+			}
+		})
+	}
+}
+
+func TestServer_checkPublicKey(t *testing.T) {
+	authorized := newTestKey(t)
+	s := newTestServer(t, authorized)
+
+	perms, err := s.checkPublicKey(connMetadata{"user@example.com"}, authorized)
+	if err != nil {
+		t.Fatalf("authorized key: %v", err)
+	}
+	if perms.Extensions[userIDExtension] != "1" {
+		t.Errorf("user ID extension = %q, want %q", perms.Extensions[userIDExtension], "1")
+	}
+
+	if _, err := s.checkPublicKey(connMetadata{"user@example.com"}, newTestKey(t)); err == nil {
+		t.Error("unknown key was accepted")
+	}
+	if _, err := s.checkPublicKey(connMetadata{"other@example.com"}, authorized); err == nil {
+		t.Error("key was accepted for another user")
+	}
+}
+
+func TestSubsystemName(t *testing.T) {
+	tests := []struct {
+		payload []byte
+		want    string
+	}{
+		{[]byte{0, 0, 0, 4, 's', 'f', 't', 'p'}, "sftp"},
+		{[]byte{0, 0, 0, 9, 's', 'f', 't', 'p'}, ""},
+		{[]byte{0, 0}, ""},
+	}
+	for _, tt := range tests {
+		if got := subsystemName(tt.payload); got != tt.want {
+			t.Errorf("subsystemName(%v) = %q, want %q", tt.payload, got, tt.want)
+		}
+	}
+}